@@ -39,11 +39,145 @@ func main() {
 		"version": "1.0.0",
 	})
 
-	// Create matching engine with config
-	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
+	// Build the quote conventions for each symbol
+	instruments := make([]matching.Instrument, 0, len(cfg.Engine.Symbols))
+	for _, symbol := range cfg.Engine.Symbols {
+		precision, tickSize := cfg.Engine.Instrument(symbol)
+		inst, err := matching.NewInstrument(symbol, precision, tickSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid instrument configuration: %v\n", err)
+			os.Exit(1)
+		}
+		instruments = append(instruments, inst)
+	}
+
+	// Build the matching policy for each symbol
+	policyParams := matching.ProRata{
+		MinAllocation:    cfg.Engine.ProRataMinAllocation,
+		LeadMarketMakers: cfg.Engine.LeadMarketMakers,
+		LMMPercent:       cfg.Engine.LMMAllocationPercent,
+	}
+	policies := make(map[string]matching.MatchingPolicy, len(cfg.Engine.Symbols))
+	for _, symbol := range cfg.Engine.Symbols {
+		policy, err := matching.NewMatchingPolicy(cfg.Engine.MatchingPolicyFor(symbol), policyParams)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid matching policy for %s: %v\n", symbol, err)
+			os.Exit(1)
+		}
+		policies[symbol] = policy
+	}
+
+	// Config validation already checked the policy, mode and phase names
+	journalSync, _ := matching.ParseSyncPolicy(cfg.Engine.JournalSync)
+	selfTradeMode, _ := matching.ParseSelfTradeMode(cfg.Engine.SelfTradePrevention)
+
+	// Build the daily trading phases, if scheduled
+	var schedule *matching.Schedule
+	if session, _ := cfg.Engine.Schedule(); session != nil {
+		schedule = &matching.Schedule{
+			Days:     session.Days,
+			Holidays: session.Holidays,
+			Location: session.Location,
+		}
+		for _, scheduled := range session.Phases {
+			phase, _ := matching.ParseTradingPhase(scheduled.Phase)
+			schedule.Phases = append(schedule.Phases, matching.ScheduledPhase{Start: scheduled.Start, Phase: phase})
+		}
+	}
+
+	// Every symbol shares the configured price bands and circuit breaker
+	priceBands := make(map[string]matching.PriceBands, len(cfg.Engine.Symbols))
+	for _, symbol := range cfg.Engine.Symbols {
+		priceBands[symbol] = matching.PriceBands{
+			StaticBps:     cfg.Engine.StaticBandBps,
+			DynamicBps:    cfg.Engine.DynamicBandBps,
+			HaltMoveBps:   cfg.Engine.HaltMoveBps,
+			HaltWindow:    cfg.Engine.HaltWindow,
+			HaltDuration:  cfg.Engine.HaltDuration,
+			ReopenAuction: cfg.Engine.ReopenAuction,
+		}
+	}
+
+	// Build the fee tiers of each symbol that charges fees; users are assigned a tier in every
+	// symbol that has a tier of that name
+	userTiers, _ := cfg.Engine.UserFeeTiers()
+	fees := make(map[string]matching.FeeSchedule)
+	for _, symbol := range cfg.Engine.Symbols {
+		tiers, _ := cfg.Engine.FeeTiersFor(symbol)
+		if len(tiers) == 0 {
+			continue
+		}
+		schedule := matching.FeeSchedule{UserTiers: make(map[string]string)}
+		for _, tier := range tiers {
+			schedule.Tiers = append(schedule.Tiers, matching.FeeTier(tier))
+			for userID, name := range userTiers {
+				if name == tier.Name {
+					schedule.UserTiers[userID] = name
+				}
+			}
+		}
+		if err := schedule.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid fee tiers for %s: %v\n", symbol, err)
+			os.Exit(1)
+		}
+		fees[symbol] = schedule
+	}
+
+	// Build the pre-trade risk limits of each symbol, converting notionals with its instrument
+	risk := make(map[string]matching.RiskRules)
+	for i, symbol := range cfg.Engine.Symbols {
+		defaults, users, _ := cfg.Engine.RiskLimitsFor(symbol)
+		rules := matching.RiskRules{Users: make(map[string]matching.RiskLimits)}
+		var err error
+		if rules.Default, err = riskLimits(instruments[i], defaults); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid risk limits for %s: %v\n", symbol, err)
+			os.Exit(1)
+		}
+		for userID, limits := range users {
+			if rules.Users[userID], err = riskLimits(instruments[i], limits); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid risk limits for %s in %s: %v\n", userID, symbol, err)
+				os.Exit(1)
+			}
+		}
+		risk[symbol] = rules
+	}
+
+	// Create matching engine with config, recovering resting orders from the journal
+	engine, err := matching.OpenEngine(&matching.EngineConfig{
 		TradeHistorySize: cfg.Engine.TradeHistorySize,
 		TradeLogPath:     cfg.Engine.TradeLogPath,
+		Instruments:      instruments,
+
+		ExpiryCheckInterval: cfg.Engine.OrderExpiryInterval,
+		DaySessionEnd:       cfg.Engine.DaySessionEnd,
+		OrderRetention:      cfg.Engine.OrderRetention,
+
+		JournalPath:         cfg.Engine.JournalPath,
+		JournalSync:         journalSync,
+		JournalSyncInterval: cfg.Engine.JournalSyncInterval,
+
+		SnapshotDir:      cfg.Engine.SnapshotDir,
+		SnapshotInterval: cfg.Engine.SnapshotInterval,
+		SnapshotRetain:   cfg.Engine.SnapshotRetain,
+
+		SelfTradePrevention: selfTradeMode,
+		MatchingPolicies:    policies,
+		Schedule:            schedule,
+		PriceBands:          priceBands,
+		Fees:                fees,
+		Risk:                risk,
 	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to recover matching engine: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Engine.JournalPath != "" {
+		logger.Info("Engine recovered from journal", map[string]interface{}{
+			"journal":   cfg.Engine.JournalPath,
+			"snapshots": cfg.Engine.SnapshotDir,
+			"orders":    len(engine.GetAllOrders()),
+		})
+	}
 	defer func() {
 		if err := engine.Close(); err != nil {
 			logger.Error("Failed to close engine", map[string]interface{}{
@@ -53,7 +187,11 @@ func main() {
 	}()
 
 	// Create engine holder for dependency injection
-	engineHolder := handlers.NewEngineHolder(engine)
+	engineHolder := handlers.NewEngineHolderWithStream(engine, handlers.StreamConfig{
+		SendBuffer:   cfg.API.StreamSendBuffer,
+		WriteTimeout: cfg.API.StreamWriteTimeout,
+		PingInterval: cfg.API.StreamPingInterval,
+	})
 
 	// Setup routes with middleware
 	handler := routes.SetupRoutes(engineHolder)
@@ -67,6 +205,9 @@ func main() {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// Shutdown does not track hijacked WebSocket connections, so close them with the server
+	server.RegisterOnShutdown(engineHolder.Close)
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("Server starting", map[string]interface{}{
@@ -106,3 +247,29 @@ func main() {
 
 	logger.Info("Server exited successfully", nil)
 }
+
+// riskLimits converts configured risk limits to the engine's, parsing notionals at the symbol's
+// precision whatever its tick size; unset notionals stay 0
+func riskLimits(inst matching.Instrument, limits config.RiskLimits) (matching.RiskLimits, error) {
+	parseNotional := func(text string) (matching.Price, error) {
+		if text == "" {
+			return 0, nil
+		}
+		return inst.ParseAmount(text)
+	}
+	orderNotional, err := parseNotional(limits.MaxOrderNotional)
+	if err != nil {
+		return matching.RiskLimits{}, err
+	}
+	openNotional, err := parseNotional(limits.MaxOpenNotional)
+	if err != nil {
+		return matching.RiskLimits{}, err
+	}
+	return matching.RiskLimits{
+		MaxOrderQuantity:     limits.MaxOrderQuantity,
+		MaxOrderNotional:     orderNotional,
+		MaxPriceDeviationBps: limits.MaxPriceDeviationBps,
+		MaxOpenOrders:        limits.MaxOpenOrders,
+		MaxOpenNotional:      openNotional,
+	}, nil
+}
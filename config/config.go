@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -28,10 +29,60 @@ type ServerConfig struct {
 
 // EngineConfig holds matching engine configuration
 type EngineConfig struct {
-	TradeHistorySize int
-	TradeLogPath     string
-	OrderCleanupEnabled bool
-	OrderCleanupInterval time.Duration
+	TradeHistorySize       int
+	TradeLogPath           string
+	Symbols                []string
+	PricePrecision         int               // Default decimal places in a price
+	TickSize               string            // Default minimum price increment, e.g. "0.01"
+	SymbolPrecisions       map[string]int    // Per-symbol overrides of PricePrecision
+	SymbolTickSizes        map[string]string // Per-symbol overrides of TickSize
+	OrderExpiryInterval    time.Duration     // How often GTD/DAY expiry runs, 0 disables it
+	DaySessionEnd          time.Duration     // Time of day DAY orders expire (offset from midnight)
+	OrderRetention         time.Duration     // How long filled/cancelled/expired orders stay queryable
+	JournalPath            string            // Command journal replayed on startup, empty disables it
+	JournalSync            string            // "always", "interval" or "never"
+	JournalSyncInterval    time.Duration     // fsync period when JournalSync is "interval"
+	SnapshotDir            string            // Directory for recovery snapshots, empty disables them
+	SnapshotInterval       time.Duration     // How often snapshots are written, 0 disables the worker
+	SnapshotRetain         int               // Snapshots kept on disk
+	SelfTradePrevention    string            // Mode for orders that do not set one: allow, cancel_newest, cancel_oldest, cancel_both or decrement
+	MatchingPolicy         string            // fifo, pro_rata, pro_rata_top_order or pro_rata_lmm
+	SymbolMatchingPolicies map[string]string // Per-symbol overrides of MatchingPolicy
+	ProRataMinAllocation   int               // Smallest pro-rata share given to an order
+	LeadMarketMakers       []string          // Users given priority under pro_rata_lmm
+	LMMAllocationPercent   int               // Percent of each match reserved for lead market makers
+	SessionSchedule        string            // "HH:MM=phase,..." phase changes of each trading day, empty keeps symbols in continuous trading
+	SessionDays            []string          // Weekdays the schedule runs (SUN..SAT); other days stay closed
+	SessionHolidays        []string          // Dates (YYYY-MM-DD) that stay closed
+	SessionTimezone        string            // Time zone of the schedule, e.g. America/New_York
+	StaticBandBps          int               // Band around the reference price in basis points, 0 disables it
+	DynamicBandBps         int               // Band around the last trade in basis points, 0 disables it
+	HaltMoveBps            int               // Price range within HaltWindow that halts a symbol, 0 disables halts
+	HaltWindow             time.Duration     // Period the halt range is measured over
+	HaltDuration           time.Duration     // How long a volatility halt lasts, 0 waits for an admin to resume
+	ReopenAuction          time.Duration     // How long the reopening auction after a halt collects orders
+	FeeTiers               string            // "name:min_volume:maker_bps:taker_bps;..." fee tiers by rising 30-day volume, empty charges no fees
+	SymbolFeeTiers         map[string]string // Per-symbol overrides of FeeTiers
+	FeeUserTiers           []string          // "user:tier" tiers assigned to users whatever their volume
+	RiskLimits             string            // "max_quantity:max_notional:max_open_orders:max_open_notional:max_deviation_bps" pre-trade limits of every user, empty checks nothing
+	SymbolRiskLimits       map[string]string // Per-symbol overrides of RiskLimits
+	UserRiskLimits         []string          // "user:limits" or "user@SYMBOL:limits" overrides for particular users
+	OrderCleanupEnabled    bool
+	OrderCleanupInterval   time.Duration
+}
+
+// SessionSchedule is the parsed trading day shared by every symbol
+type SessionSchedule struct {
+	Phases   []SessionPhase
+	Days     []time.Weekday
+	Holidays []time.Time
+	Location *time.Location
+}
+
+// SessionPhase starts a trading phase at a time of day
+type SessionPhase struct {
+	Start time.Duration // Offset from midnight
+	Phase string
 }
 
 // APIConfig holds API-specific configuration
@@ -42,6 +93,9 @@ type APIConfig struct {
 	MaxTradeLimit         int
 	DefaultOrderBookDepth int
 	MaxOrderBookDepth     int
+	StreamSendBuffer      int           // Messages queued per stream client before it is dropped
+	StreamWriteTimeout    time.Duration // Longest a write to a stream client may block
+	StreamPingInterval    time.Duration // Keepalive ping period for stream clients
 }
 
 // LoggerConfig holds logger configuration
@@ -65,10 +119,46 @@ func Load() (*Config, error) {
 			ShutdownTimeout: getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second),
 		},
 		Engine: EngineConfig{
-			TradeHistorySize: getEnvInt("TRADE_HISTORY_SIZE", 1000),
-			TradeLogPath:     getEnv("TRADE_LOG_PATH", "trades.log"),
-			OrderCleanupEnabled: getEnvBool("ORDER_CLEANUP_ENABLED", false),
-			OrderCleanupInterval: getEnvDuration("ORDER_CLEANUP_INTERVAL", 5*time.Minute),
+			TradeHistorySize:       getEnvInt("TRADE_HISTORY_SIZE", 1000),
+			TradeLogPath:           getEnv("TRADE_LOG_PATH", "trades.log"),
+			Symbols:                getEnvList("SYMBOLS", []string{"COOTX"}),
+			PricePrecision:         getEnvInt("PRICE_PRECISION", 2),
+			TickSize:               getEnv("TICK_SIZE", "0.01"),
+			SymbolPrecisions:       getEnvIntMap("SYMBOL_PRICE_PRECISIONS"),
+			SymbolTickSizes:        getEnvMap("SYMBOL_TICK_SIZES"),
+			OrderExpiryInterval:    getEnvDuration("ORDER_EXPIRY_INTERVAL", 1*time.Second),
+			DaySessionEnd:          getEnvTimeOfDay("DAY_SESSION_END", 16*time.Hour),
+			OrderRetention:         getEnvDuration("ORDER_RETENTION", 1*time.Hour),
+			JournalPath:            os.Getenv("JOURNAL_PATH"),
+			JournalSync:            getEnv("JOURNAL_SYNC", "always"),
+			JournalSyncInterval:    getEnvDuration("JOURNAL_SYNC_INTERVAL", 100*time.Millisecond),
+			SnapshotDir:            os.Getenv("SNAPSHOT_DIR"),
+			SnapshotInterval:       getEnvDuration("SNAPSHOT_INTERVAL", 5*time.Minute),
+			SnapshotRetain:         getEnvInt("SNAPSHOT_RETAIN", 2),
+			SelfTradePrevention:    getEnv("SELF_TRADE_PREVENTION", "allow"),
+			MatchingPolicy:         getEnv("MATCHING_POLICY", "fifo"),
+			SymbolMatchingPolicies: getEnvMap("SYMBOL_MATCHING_POLICIES"),
+			ProRataMinAllocation:   getEnvInt("PRO_RATA_MIN_ALLOCATION", 1),
+			LeadMarketMakers:       getEnvNames("LEAD_MARKET_MAKERS"),
+			LMMAllocationPercent:   getEnvInt("LMM_ALLOCATION_PERCENT", 0),
+			SessionSchedule:        os.Getenv("SESSION_SCHEDULE"),
+			SessionDays:            getEnvList("SESSION_DAYS", []string{"MON", "TUE", "WED", "THU", "FRI"}),
+			SessionHolidays:        getEnvNames("SESSION_HOLIDAYS"),
+			SessionTimezone:        getEnv("SESSION_TIMEZONE", "Local"),
+			StaticBandBps:          getEnvInt("STATIC_BAND_BPS", 0),
+			DynamicBandBps:         getEnvInt("DYNAMIC_BAND_BPS", 0),
+			HaltMoveBps:            getEnvInt("HALT_MOVE_BPS", 0),
+			HaltWindow:             getEnvDuration("HALT_WINDOW", 5*time.Minute),
+			HaltDuration:           getEnvDuration("HALT_DURATION", 5*time.Minute),
+			ReopenAuction:          getEnvDuration("REOPEN_AUCTION_DURATION", 1*time.Minute),
+			FeeTiers:               os.Getenv("FEE_TIERS"),
+			SymbolFeeTiers:         getEnvMap("SYMBOL_FEE_TIERS"),
+			FeeUserTiers:           getEnvNames("FEE_USER_TIERS"),
+			RiskLimits:             os.Getenv("RISK_LIMITS"),
+			SymbolRiskLimits:       getEnvMap("SYMBOL_RISK_LIMITS"),
+			UserRiskLimits:         getEnvNames("USER_RISK_LIMITS"),
+			OrderCleanupEnabled:    getEnvBool("ORDER_CLEANUP_ENABLED", false),
+			OrderCleanupInterval:   getEnvDuration("ORDER_CLEANUP_INTERVAL", 5*time.Minute),
 		},
 		API: APIConfig{
 			DefaultOrderLimit:     getEnvInt("DEFAULT_ORDER_LIMIT", 100),
@@ -77,6 +167,9 @@ func Load() (*Config, error) {
 			MaxTradeLimit:         getEnvInt("MAX_TRADE_LIMIT", 1000),
 			DefaultOrderBookDepth: getEnvInt("DEFAULT_ORDERBOOK_DEPTH", 10),
 			MaxOrderBookDepth:     getEnvInt("MAX_ORDERBOOK_DEPTH", 10),
+			StreamSendBuffer:      getEnvInt("STREAM_SEND_BUFFER", 256),
+			StreamWriteTimeout:    getEnvDuration("STREAM_WRITE_TIMEOUT", 5*time.Second),
+			StreamPingInterval:    getEnvDuration("STREAM_PING_INTERVAL", 30*time.Second),
 		},
 		Logger: LoggerConfig{
 			Level: getEnv("LOG_LEVEL", "INFO"),
@@ -100,6 +193,207 @@ func Get() *Config {
 	return instance
 }
 
+// Instrument returns the price precision and tick size configured for a symbol
+func (c *EngineConfig) Instrument(symbol string) (int, string) {
+	precision, tickSize := c.PricePrecision, c.TickSize
+	if value, ok := c.SymbolPrecisions[symbol]; ok {
+		precision = value
+	}
+	if value, ok := c.SymbolTickSizes[symbol]; ok {
+		tickSize = value
+	}
+	return precision, tickSize
+}
+
+// MatchingPolicyFor returns the matching policy configured for a symbol
+func (c *EngineConfig) MatchingPolicyFor(symbol string) string {
+	if value, ok := c.SymbolMatchingPolicies[symbol]; ok {
+		return value
+	}
+	return c.MatchingPolicy
+}
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday, "MON": time.Monday, "TUE": time.Tuesday, "WED": time.Wednesday,
+	"THU": time.Thursday, "FRI": time.Friday, "SAT": time.Saturday,
+}
+
+// Schedule parses the trading session settings, returning nil if no schedule is configured
+func (c *EngineConfig) Schedule() (*SessionSchedule, error) {
+	if strings.TrimSpace(c.SessionSchedule) == "" {
+		return nil, nil
+	}
+
+	schedule := &SessionSchedule{}
+	validPhases := map[string]bool{"pre_open": true, "opening_auction": true, "continuous": true, "closing_auction": true, "halted": true, "reopening_auction": true, "closed": true}
+	for _, item := range strings.Split(c.SessionSchedule, ",") {
+		start, phase, ok := strings.Cut(strings.TrimSpace(item), "=")
+		t, err := time.Parse("15:04", start)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("SESSION_SCHEDULE entry %q must be HH:MM=phase", item)
+		}
+		if !validPhases[phase] {
+			return nil, fmt.Errorf("SESSION_SCHEDULE has unknown phase %q", phase)
+		}
+		offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		if n := len(schedule.Phases); n > 0 && offset <= schedule.Phases[n-1].Start {
+			return nil, fmt.Errorf("SESSION_SCHEDULE times must increase")
+		}
+		schedule.Phases = append(schedule.Phases, SessionPhase{Start: offset, Phase: phase})
+	}
+
+	for _, day := range c.SessionDays {
+		weekday, ok := weekdays[day]
+		if !ok {
+			return nil, fmt.Errorf("SESSION_DAYS has unknown day %q, use SUN, MON, TUE, WED, THU, FRI or SAT", day)
+		}
+		schedule.Days = append(schedule.Days, weekday)
+	}
+
+	for _, holiday := range c.SessionHolidays {
+		date, err := time.Parse("2006-01-02", holiday)
+		if err != nil {
+			return nil, fmt.Errorf("SESSION_HOLIDAYS date %q must be YYYY-MM-DD", holiday)
+		}
+		schedule.Holidays = append(schedule.Holidays, date)
+	}
+
+	location, err := time.LoadLocation(c.SessionTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("SESSION_TIMEZONE: %w", err)
+	}
+	schedule.Location = location
+	return schedule, nil
+}
+
+// FeeTier is a parsed fee tier
+type FeeTier struct {
+	Name      string
+	MinVolume int64
+	MakerBps  int
+	TakerBps  int
+}
+
+// FeeTiersFor parses the fee tiers configured for a symbol, returning nil if it has none
+func (c *EngineConfig) FeeTiersFor(symbol string) ([]FeeTier, error) {
+	value := c.FeeTiers
+	if override, ok := c.SymbolFeeTiers[symbol]; ok {
+		value = override
+	}
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var tiers []FeeTier
+	for _, item := range strings.Split(value, ";") {
+		fields := strings.Split(strings.TrimSpace(item), ":")
+		if len(fields) != 4 || fields[0] == "" {
+			return nil, fmt.Errorf("fee tier %q for %s must be name:min_volume:maker_bps:taker_bps", item, symbol)
+		}
+		minVolume, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || minVolume < 0 {
+			return nil, fmt.Errorf("fee tier %q for %s has an invalid minimum volume", item, symbol)
+		}
+		makerBps, makerErr := strconv.Atoi(fields[2])
+		takerBps, takerErr := strconv.Atoi(fields[3])
+		if makerErr != nil || takerErr != nil {
+			return nil, fmt.Errorf("fee tier %q for %s has an invalid rate", item, symbol)
+		}
+		tiers = append(tiers, FeeTier{Name: fields[0], MinVolume: minVolume, MakerBps: makerBps, TakerBps: takerBps})
+	}
+	return tiers, nil
+}
+
+// UserFeeTiers parses the fee tiers assigned to users, by user ID
+func (c *EngineConfig) UserFeeTiers() (map[string]string, error) {
+	assigned := make(map[string]string)
+	for _, item := range c.FeeUserTiers {
+		userID, tier, ok := strings.Cut(item, ":")
+		if userID, tier = strings.TrimSpace(userID), strings.TrimSpace(tier); !ok || userID == "" || tier == "" {
+			return nil, fmt.Errorf("FEE_USER_TIERS entry %q must be user:tier", item)
+		}
+		assigned[userID] = tier
+	}
+	return assigned, nil
+}
+
+// RiskLimits is a parsed set of pre-trade risk limits; notionals are decimal prices and zero
+// values disable each limit
+type RiskLimits struct {
+	MaxOrderQuantity     int
+	MaxOrderNotional     string
+	MaxOpenOrders        int
+	MaxOpenNotional      string
+	MaxPriceDeviationBps int
+}
+
+// parseRiskLimits parses "max_quantity:max_notional:max_open_orders:max_open_notional:max_deviation_bps"
+func parseRiskLimits(value string, scope string) (RiskLimits, error) {
+	fields := strings.Split(strings.TrimSpace(value), ":")
+	if len(fields) != 5 {
+		return RiskLimits{}, fmt.Errorf("risk limits %q for %s must be max_quantity:max_notional:max_open_orders:max_open_notional:max_deviation_bps", value, scope)
+	}
+	var counts [3]int
+	for i, field := range []string{fields[0], fields[2], fields[4]} {
+		count, err := strconv.Atoi(field)
+		if err != nil || count < 0 {
+			return RiskLimits{}, fmt.Errorf("risk limits %q for %s have an invalid count", value, scope)
+		}
+		counts[i] = count
+	}
+	for _, notional := range []string{fields[1], fields[3]} {
+		if amount, err := strconv.ParseFloat(notional, 64); err != nil || amount < 0 {
+			return RiskLimits{}, fmt.Errorf("risk limits %q for %s have an invalid notional", value, scope)
+		}
+	}
+	return RiskLimits{
+		MaxOrderQuantity:     counts[0],
+		MaxOrderNotional:     fields[1],
+		MaxOpenOrders:        counts[1],
+		MaxOpenNotional:      fields[3],
+		MaxPriceDeviationBps: counts[2],
+	}, nil
+}
+
+// RiskLimitsFor parses the risk limits of a symbol: those of users without their own, and those
+// of users with their own, by user ID. A user's limits for the symbol win over their limits for
+// every symbol, and either replaces the symbol's limits as a whole.
+func (c *EngineConfig) RiskLimitsFor(symbol string) (RiskLimits, map[string]RiskLimits, error) {
+	var limits RiskLimits
+	value := c.RiskLimits
+	if override, ok := c.SymbolRiskLimits[symbol]; ok {
+		value = override
+	}
+	if strings.TrimSpace(value) != "" {
+		var err error
+		if limits, err = parseRiskLimits(value, symbol); err != nil {
+			return RiskLimits{}, nil, err
+		}
+	}
+
+	users := make(map[string]RiskLimits)
+	scoped := make(map[string]bool)
+	for _, item := range c.UserRiskLimits {
+		scope, value, ok := strings.Cut(item, ":")
+		userID, userSymbol, forSymbol := strings.Cut(strings.TrimSpace(scope), "@")
+		if !ok || userID == "" || (forSymbol && userSymbol == "") {
+			return RiskLimits{}, nil, fmt.Errorf("USER_RISK_LIMITS entry %q must be user:limits or user@SYMBOL:limits", item)
+		}
+		userLimits, err := parseRiskLimits(value, scope)
+		if err != nil {
+			return RiskLimits{}, nil, err
+		}
+		switch {
+		case forSymbol && strings.ToUpper(userSymbol) == symbol:
+			users[userID] = userLimits
+			scoped[userID] = true
+		case !forSymbol && !scoped[userID]:
+			users[userID] = userLimits
+		}
+	}
+	return limits, users, nil
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	// Validate server config
@@ -114,6 +408,99 @@ func (c *Config) Validate() error {
 	if c.Engine.TradeLogPath == "" {
 		return fmt.Errorf("TRADE_LOG_PATH cannot be empty")
 	}
+	if len(c.Engine.Symbols) == 0 {
+		return fmt.Errorf("SYMBOLS must list at least one symbol")
+	}
+	if c.Engine.PricePrecision < 0 {
+		return fmt.Errorf("PRICE_PRECISION must be >= 0")
+	}
+	if c.Engine.OrderExpiryInterval < 0 {
+		return fmt.Errorf("ORDER_EXPIRY_INTERVAL must be >= 0")
+	}
+	if c.Engine.OrderRetention < 0 {
+		return fmt.Errorf("ORDER_RETENTION must be >= 0")
+	}
+	validSyncPolicies := map[string]bool{"always": true, "interval": true, "never": true}
+	if !validSyncPolicies[c.Engine.JournalSync] {
+		return fmt.Errorf("JOURNAL_SYNC must be one of: always, interval, never")
+	}
+	if c.Engine.JournalSync == "interval" && c.Engine.JournalSyncInterval <= 0 {
+		return fmt.Errorf("JOURNAL_SYNC_INTERVAL must be > 0")
+	}
+	if c.Engine.SnapshotDir != "" && c.Engine.JournalPath == "" {
+		return fmt.Errorf("SNAPSHOT_DIR requires JOURNAL_PATH")
+	}
+	if c.Engine.SnapshotInterval < 0 {
+		return fmt.Errorf("SNAPSHOT_INTERVAL must be >= 0")
+	}
+	if c.Engine.SnapshotRetain < 1 {
+		return fmt.Errorf("SNAPSHOT_RETAIN must be > 0")
+	}
+	validSelfTradeModes := map[string]bool{"allow": true, "cancel_newest": true, "cancel_oldest": true, "cancel_both": true, "decrement": true}
+	if !validSelfTradeModes[c.Engine.SelfTradePrevention] {
+		return fmt.Errorf("SELF_TRADE_PREVENTION must be one of: allow, cancel_newest, cancel_oldest, cancel_both, decrement")
+	}
+	validMatchingPolicies := map[string]bool{"fifo": true, "pro_rata": true, "pro_rata_top_order": true, "pro_rata_lmm": true}
+	if !validMatchingPolicies[c.Engine.MatchingPolicy] {
+		return fmt.Errorf("MATCHING_POLICY must be one of: fifo, pro_rata, pro_rata_top_order, pro_rata_lmm")
+	}
+	for symbol, policy := range c.Engine.SymbolMatchingPolicies {
+		if !validMatchingPolicies[policy] {
+			return fmt.Errorf("SYMBOL_MATCHING_POLICIES has unknown policy %q for %s", policy, symbol)
+		}
+	}
+	if c.Engine.ProRataMinAllocation < 1 {
+		return fmt.Errorf("PRO_RATA_MIN_ALLOCATION must be > 0")
+	}
+	for _, symbol := range c.Engine.Symbols {
+		if c.Engine.MatchingPolicyFor(symbol) != "pro_rata_lmm" {
+			continue
+		}
+		if len(c.Engine.LeadMarketMakers) == 0 {
+			return fmt.Errorf("LEAD_MARKET_MAKERS must list at least one user for pro_rata_lmm")
+		}
+		if c.Engine.LMMAllocationPercent < 1 || c.Engine.LMMAllocationPercent > 100 {
+			return fmt.Errorf("LMM_ALLOCATION_PERCENT must be between 1 and 100 for pro_rata_lmm")
+		}
+	}
+
+	if _, err := c.Engine.Schedule(); err != nil {
+		return err
+	}
+	if c.Engine.StaticBandBps < 0 || c.Engine.DynamicBandBps < 0 || c.Engine.HaltMoveBps < 0 {
+		return fmt.Errorf("STATIC_BAND_BPS, DYNAMIC_BAND_BPS and HALT_MOVE_BPS must be >= 0")
+	}
+	if c.Engine.HaltMoveBps > 0 && c.Engine.HaltWindow <= 0 {
+		return fmt.Errorf("HALT_WINDOW must be > 0")
+	}
+	if c.Engine.HaltDuration < 0 || c.Engine.ReopenAuction < 0 {
+		return fmt.Errorf("HALT_DURATION and REOPEN_AUCTION_DURATION must be >= 0")
+	}
+	feeTierNames := make(map[string]bool)
+	for _, symbol := range c.Engine.Symbols {
+		tiers, err := c.Engine.FeeTiersFor(symbol)
+		if err != nil {
+			return fmt.Errorf("FEE_TIERS: %w", err)
+		}
+		for _, tier := range tiers {
+			feeTierNames[tier.Name] = true
+		}
+	}
+	userTiers, err := c.Engine.UserFeeTiers()
+	if err != nil {
+		return err
+	}
+	for userID, tier := range userTiers {
+		if !feeTierNames[tier] {
+			return fmt.Errorf("FEE_USER_TIERS assigns %s unknown fee tier %q", userID, tier)
+		}
+	}
+
+	for _, symbol := range c.Engine.Symbols {
+		if _, _, err := c.Engine.RiskLimitsFor(symbol); err != nil {
+			return fmt.Errorf("RISK_LIMITS: %w", err)
+		}
+	}
 
 	// Validate API config
 	if c.API.DefaultOrderLimit < 1 {
@@ -134,6 +521,15 @@ func (c *Config) Validate() error {
 	if c.API.MaxOrderBookDepth < c.API.DefaultOrderBookDepth {
 		return fmt.Errorf("MAX_ORDERBOOK_DEPTH must be >= DEFAULT_ORDERBOOK_DEPTH")
 	}
+	if c.API.StreamSendBuffer < 1 {
+		return fmt.Errorf("STREAM_SEND_BUFFER must be > 0")
+	}
+	if c.API.StreamWriteTimeout <= 0 {
+		return fmt.Errorf("STREAM_WRITE_TIMEOUT must be > 0")
+	}
+	if c.API.StreamPingInterval <= 0 {
+		return fmt.Errorf("STREAM_PING_INTERVAL must be > 0")
+	}
 
 	// Validate logger config
 	validLevels := map[string]bool{"DEBUG": true, "INFO": true, "WARN": true, "ERROR": true}
@@ -171,6 +567,63 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvList(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		list := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
+
+// getEnvNames parses a comma-separated list, keeping the case of each item
+func getEnvNames(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvMap parses "KEY:value,KEY:value" pairs, upper-casing keys
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(item, ":")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if ok && name != "" {
+			result[name] = strings.TrimSpace(value)
+		}
+	}
+	return result
+}
+
+func getEnvIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for name, value := range getEnvMap(key) {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			result[name] = intVal
+		}
+	}
+	return result
+}
+
+// getEnvTimeOfDay parses an "HH:MM" value into an offset from midnight
+func getEnvTimeOfDay(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if t, err := time.Parse("15:04", value); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -3,6 +3,7 @@ module github.com/PxPatel/trading-system
 go 1.23
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	github.com/joho/godotenv v1.5.1
 	github.com/stretchr/testify v1.11.1
 )
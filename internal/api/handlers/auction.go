@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/logger"
+	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// convertAuctionToDTO converts an auction's state, leaving the price out when nothing crosses
+func (eh *EngineHolder) convertAuctionToDTO(info matching.AuctionInfo) models.AuctionDTO {
+	dto := models.AuctionDTO{
+		Symbol:    info.Symbol,
+		Running:   info.Running,
+		Volume:    info.Volume,
+		Imbalance: info.Imbalance,
+	}
+	if info.Volume > 0 {
+		dto.Price = formatPrice(eh.instrumentFor(info.Symbol), info.Price)
+	}
+	return dto
+}
+
+// GetAuctionHandler handles requests for a symbol's auction state and indicative uncross
+func (eh *EngineHolder) GetAuctionHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	info, _ := eh.Engine.GetAuction(symbol)
+
+	response := models.AuctionResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+		},
+		Auction: eh.convertAuctionToDTO(info),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartAuctionHandler handles moving a symbol into a call auction
+func (eh *EngineHolder) StartAuctionHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	if err := eh.Engine.StartAuction(symbol); err != nil {
+		if errors.Is(err, matching.ErrAuctionRunning) {
+			writeErrorResponse(w, models.ErrAuctionRunningError(symbol))
+			return
+		}
+		if errors.Is(err, matching.ErrNotContinuous) {
+			writeErrorResponse(w, eh.notContinuousError(symbol))
+			return
+		}
+		logger.Error("Auction start could not be recorded", map[string]interface{}{
+			"symbol": symbol,
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Auction start could not be recorded"))
+		return
+	}
+
+	logger.Info("Auction started", map[string]interface{}{
+		"symbol": symbol,
+	})
+
+	info, _ := eh.Engine.GetAuction(symbol)
+	response := models.AuctionResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+			Message:   "Auction started",
+		},
+		Auction: eh.convertAuctionToDTO(info),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UncrossHandler handles ending a symbol's auction at its equilibrium price
+func (eh *EngineHolder) UncrossHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	info, trades, err := eh.Engine.Uncross(symbol)
+	if err != nil {
+		if errors.Is(err, matching.ErrNoAuction) {
+			writeErrorResponse(w, models.ErrNoAuctionError(symbol))
+			return
+		}
+		if errors.Is(err, matching.ErrNotContinuous) {
+			writeErrorResponse(w, eh.notContinuousError(symbol))
+			return
+		}
+		logger.Error("Uncross could not be recorded", map[string]interface{}{
+			"symbol": symbol,
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Uncross could not be recorded"))
+		return
+	}
+
+	inst := eh.instrumentFor(symbol)
+	logger.Info("Auction uncrossed", map[string]interface{}{
+		"symbol": symbol,
+		"price":  inst.FormatPrice(info.Price),
+		"volume": info.Volume,
+		"trades": len(trades),
+	})
+
+	response := models.UncrossResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+			Message:   "Auction uncrossed",
+		},
+		Auction: eh.convertAuctionToDTO(info),
+		Trades:  eh.convertTradesToDTO(trades),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// notContinuousError reports an auction command for a symbol whose phase runs its auctions
+func (eh *EngineHolder) notContinuousError(symbol string) *models.HTTPError {
+	phase, _ := eh.Engine.GetPhase(symbol)
+	return models.ErrNotAllowedInPhaseError("Auctions can only be started and uncrossed in continuous trading", symbol, phase.String())
+}
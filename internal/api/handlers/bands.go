@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// convertPriceBandsToDTO converts a symbol's price bands, leaving out bounds that are not set
+func (eh *EngineHolder) convertPriceBandsToDTO(info matching.PriceBandInfo) models.PriceBandsDTO {
+	inst := eh.instrumentFor(info.Symbol)
+	format := func(price matching.Price) models.Decimal {
+		if price == 0 {
+			return ""
+		}
+		return formatPrice(inst, price)
+	}
+
+	dto := models.PriceBandsDTO{
+		Symbol:      info.Symbol,
+		Reference:   format(info.Reference),
+		StaticLow:   format(info.StaticLow),
+		StaticHigh:  format(info.StaticHigh),
+		DynamicLow:  format(info.DynamicLow),
+		DynamicHigh: format(info.DynamicHigh),
+	}
+	if !info.ReopenAt.IsZero() {
+		reopenAt := info.ReopenAt.UTC()
+		dto.ReopenAt = &reopenAt
+	}
+	return dto
+}
+
+// GetPriceBandsHandler handles requests for a symbol's price bands
+func (eh *EngineHolder) GetPriceBandsHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	info, _ := eh.Engine.GetPriceBands(symbol)
+
+	response := models.PriceBandsResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+		},
+		Bands: eh.convertPriceBandsToDTO(info),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// priceBandError reports an order or amend priced outside its symbol's bands
+func (eh *EngineHolder) priceBandError(orderID uint64, symbol string, price matching.Price) *models.HTTPError {
+	info, _ := eh.Engine.GetPriceBands(symbol)
+	return models.ErrOutsidePriceBandError(orderID, formatPrice(eh.instrumentFor(symbol), price), eh.convertPriceBandsToDTO(info))
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/models"
+)
+
+// GetFeesHandler handles requests for a user's fee tier and 30-day volume in a symbol
+func (eh *EngineHolder) GetFeesHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeErrorResponse(w, models.ErrBadRequest("user_id is required", map[string]interface{}{"field": "user_id"}))
+		return
+	}
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	info, _ := eh.Engine.GetFeeInfo(userID, symbol)
+
+	response := models.FeesResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+		},
+		Fees: models.FeesDTO{
+			UserID:    info.UserID,
+			Symbol:    info.Symbol,
+			Tier:      info.Tier.Name,
+			Assigned:  info.Assigned,
+			MakerBps:  info.Tier.MakerBps,
+			TakerBps:  info.Tier.TakerBps,
+			Volume30d: info.Volume,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,81 +11,45 @@ import (
 	"github.com/PxPatel/trading-system/internal/matching"
 )
 
-// aggregatePriceLevels aggregates orders by tick size
-func aggregatePriceLevels(prices []float64, getOrders func(float64) []*matching.Order, tickSize float64, maxDepth int) []models.PriceLevel {
-	if len(prices) == 0 {
-		return []models.PriceLevel{}
-	}
-
-	// If no tick size aggregation, return raw levels
-	if tickSize <= 0 {
-		levels := make([]models.PriceLevel, 0)
-		for i, price := range prices {
-			if i >= maxDepth {
-				break
-			}
-
-			orders := getOrders(price)
-			totalQty := 0
-			for _, order := range orders {
-				totalQty += order.Size
-			}
-
-			levels = append(levels, models.PriceLevel{
-				Price:      price,
-				Quantity:   totalQty,
-				OrderCount: len(orders),
-			})
+// aggregatePriceLevels aggregates book levels into buckets of the given size, rounding each price to
+// the nearest bucket. Levels arrive best-first and rounding keeps that order, so buckets do too.
+func aggregatePriceLevels(bookLevels []matching.BookLevel, inst matching.Instrument, bucket matching.Price, maxDepth int) []models.PriceLevel {
+	levels := make([]models.PriceLevel, 0)
+	var current *models.PriceLevel
+	var currentPrice matching.Price
+
+	for _, level := range bookLevels {
+		// Round to nearest bucket, if aggregating
+		levelPrice := level.Price
+		if bucket > 0 {
+			levelPrice = (level.Price + bucket/2) / bucket * bucket
 		}
-		return levels
-	}
 
-	// Aggregate by tick size
-	aggregated := make(map[float64]*models.PriceLevel)
-	for _, price := range prices {
-		// Round to nearest tick
-		tickPrice := math.Round(price/tickSize) * tickSize
-
-		if aggregated[tickPrice] == nil {
-			aggregated[tickPrice] = &models.PriceLevel{
-				Price:      tickPrice,
-				Quantity:   0,
-				OrderCount: 0,
+		if current == nil || levelPrice != currentPrice {
+			if len(levels) >= maxDepth {
+				break
 			}
+			levels = append(levels, models.PriceLevel{Price: formatPrice(inst, levelPrice)})
+			current = &levels[len(levels)-1]
+			currentPrice = levelPrice
 		}
 
-		orders := getOrders(price)
-		for _, order := range orders {
-			aggregated[tickPrice].Quantity += order.Size
-			aggregated[tickPrice].OrderCount++
-		}
-	}
-
-	// Convert to sorted slice
-	sortedPrices := make([]float64, 0, len(aggregated))
-	for price := range aggregated {
-		sortedPrices = append(sortedPrices, price)
+		current.Quantity += level.Quantity
+		current.OrderCount += level.OrderCount
 	}
 
-	// Sort based on side (bids descending, asks ascending)
-	for i := 0; i < len(sortedPrices); i++ {
-		for j := i + 1; j < len(sortedPrices); j++ {
-			if sortedPrices[i] < sortedPrices[j] {
-				sortedPrices[i], sortedPrices[j] = sortedPrices[j], sortedPrices[i]
-			}
-		}
-	}
+	return levels
+}
 
-	// Build result
-	levels := make([]models.PriceLevel, 0)
-	for i, price := range sortedPrices {
-		if i >= maxDepth {
-			break
-		}
-		levels = append(levels, *aggregated[price])
+// formatMidPrice formats the midpoint of two prices, adding a decimal place when it falls
+// between price units
+func formatMidPrice(inst matching.Instrument, bid, ask matching.Price) models.Decimal {
+	sum := bid + ask
+	if sum%2 == 0 {
+		return formatPrice(inst, sum/2)
 	}
-
-	return levels
+	halves := matching.Instrument{Symbol: inst.Symbol, Precision: inst.Precision + 1, TickSize: 1}
+	return formatPrice(halves, sum*5)
 }
 
 // GetOrderBookHandler handles full order book snapshot requests
@@ -95,6 +58,12 @@ func (eh *EngineHolder) GetOrderBookHandler(w http.ResponseWriter, r *http.Reque
 	depthStr := r.URL.Query().Get("depth")
 	aggregateStr := r.URL.Query().Get("aggregate")
 
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
 	// Default depth: 10, max: 10
 	depth := 10
 	if depthStr != "" {
@@ -107,44 +76,40 @@ func (eh *EngineHolder) GetOrderBookHandler(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	// Parse tick size for aggregation
-	tickSize := 0.0
+	// Parse bucket size for aggregation, ignoring sizes off the tick grid
+	inst := eh.instrumentFor(symbol)
+	var bucket matching.Price
 	if aggregateStr != "" {
-		parsedTick, err := strconv.ParseFloat(aggregateStr, 64)
-		if err == nil && parsedTick > 0 {
-			tickSize = parsedTick
+		parsedBucket, err := inst.ParsePrice(aggregateStr)
+		if err == nil && parsedBucket > 0 {
+			bucket = parsedBucket
 		}
 	}
 
-	// Get order book from engine
-	bidPrices := eh.Engine.GetOrderBook().GetAllBids()
-	askPrices := eh.Engine.GetOrderBook().GetAllAsks()
-
-	// Build bid levels (descending)
-	bids := aggregatePriceLevels(bidPrices, eh.Engine.GetOrderBook().GetBidsAtPrice, tickSize, depth)
-
-	// Build ask levels (ascending) - need to reverse sort
-	asks := aggregatePriceLevels(askPrices, eh.Engine.GetOrderBook().GetAsksAtPrice, tickSize, depth)
-	// Re-sort asks in ascending order
-	for i := 0; i < len(asks); i++ {
-		for j := i + 1; j < len(asks); j++ {
-			if asks[i].Price > asks[j].Price {
-				asks[i], asks[j] = asks[j], asks[i]
-			}
-		}
+	// Aggregated buckets can span many raw levels, so take the whole book when aggregating
+	snapshotDepth := depth
+	if bucket > 0 {
+		snapshotDepth = 0
 	}
-
-	// Calculate spread and mid price
-	var spread, midPrice float64
-	if len(bids) > 0 && len(asks) > 0 {
-		spread = asks[0].Price - bids[0].Price
-		midPrice = (bids[0].Price + asks[0].Price) / 2.0
+	snapshot, _ := eh.Engine.GetBookSnapshot(symbol, snapshotDepth)
+
+	// Build bid levels (descending) and ask levels (ascending)
+	bids := aggregatePriceLevels(snapshot.Bids, inst, bucket, depth)
+	asks := aggregatePriceLevels(snapshot.Asks, inst, bucket, depth)
+
+	// Calculate spread and mid price from the raw touch
+	var spread, midPrice models.Decimal
+	if len(snapshot.Bids) > 0 && len(snapshot.Asks) > 0 {
+		bestBid, bestAsk := snapshot.Bids[0].Price, snapshot.Asks[0].Price
+		spread = formatPrice(inst, bestAsk-bestBid)
+		midPrice = formatMidPrice(inst, bestBid, bestAsk)
 	}
 
 	logger.Info("Order book snapshot retrieved", map[string]interface{}{
+		"symbol":     symbol,
 		"bid_levels": len(bids),
 		"ask_levels": len(asks),
-		"tick_size":  tickSize,
+		"tick_size":  inst.FormatPrice(bucket),
 	})
 
 	// Return response
@@ -153,7 +118,7 @@ func (eh *EngineHolder) GetOrderBookHandler(w http.ResponseWriter, r *http.Reque
 			Success:   true,
 			Timestamp: time.Now().UTC(),
 		},
-		Symbol:   "COOTX",
+		Symbol:   symbol,
 		Bids:     bids,
 		Asks:     asks,
 		Spread:   spread,
@@ -167,46 +132,48 @@ func (eh *EngineHolder) GetOrderBookHandler(w http.ResponseWriter, r *http.Reque
 
 // GetTopOfBookHandler handles best bid/ask requests
 func (eh *EngineHolder) GetTopOfBookHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
 	// Get best bid and ask
-	bestBidPrice, bestBidOrders := eh.Engine.GetOrderBook().GetBestBid()
-	bestAskPrice, bestAskOrders := eh.Engine.GetOrderBook().GetBestAsk()
+	inst := eh.instrumentFor(symbol)
+	snapshot, _ := eh.Engine.GetBookSnapshot(symbol, 1)
 
 	var bestBid, bestAsk *models.BestQuote
-	var spread, midPrice float64
+	var bestBidPrice, bestAskPrice matching.Price
+	var spread, midPrice models.Decimal
 
 	// Build best bid
-	if len(bestBidOrders) > 0 {
-		totalQty := 0
-		for _, order := range bestBidOrders {
-			totalQty += order.Size
-		}
+	if len(snapshot.Bids) > 0 {
+		bestBidPrice = snapshot.Bids[0].Price
 		bestBid = &models.BestQuote{
-			Price:    bestBidPrice,
-			Quantity: totalQty,
+			Price:    formatPrice(inst, bestBidPrice),
+			Quantity: snapshot.Bids[0].Quantity,
 		}
 	}
 
 	// Build best ask
-	if len(bestAskOrders) > 0 {
-		totalQty := 0
-		for _, order := range bestAskOrders {
-			totalQty += order.Size
-		}
+	if len(snapshot.Asks) > 0 {
+		bestAskPrice = snapshot.Asks[0].Price
 		bestAsk = &models.BestQuote{
-			Price:    bestAskPrice,
-			Quantity: totalQty,
+			Price:    formatPrice(inst, bestAskPrice),
+			Quantity: snapshot.Asks[0].Quantity,
 		}
 	}
 
 	// Calculate spread and mid price
 	if bestBid != nil && bestAsk != nil {
-		spread = bestAsk.Price - bestBid.Price
-		midPrice = (bestBid.Price + bestAsk.Price) / 2.0
+		spread = formatPrice(inst, bestAskPrice-bestBidPrice)
+		midPrice = formatMidPrice(inst, bestBidPrice, bestAskPrice)
 	}
 
 	logger.Info("Top of book retrieved", map[string]interface{}{
-		"best_bid": bestBidPrice,
-		"best_ask": bestAskPrice,
+		"symbol":   symbol,
+		"best_bid": inst.FormatPrice(bestBidPrice),
+		"best_ask": inst.FormatPrice(bestAskPrice),
 	})
 
 	// Return response
@@ -215,7 +182,7 @@ func (eh *EngineHolder) GetTopOfBookHandler(w http.ResponseWriter, r *http.Reque
 			Success:   true,
 			Timestamp: time.Now().UTC(),
 		},
-		Symbol:   "COOTX",
+		Symbol:   symbol,
 		BestBid:  bestBid,
 		BestAsk:  bestAsk,
 		Spread:   spread,
@@ -227,7 +194,8 @@ func (eh *EngineHolder) GetTopOfBookHandler(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetOrderBook is a helper to access the order book
+// GetOrderBook is a helper to access the order book. Handlers should read through
+// Engine.GetBookSnapshot instead, since the book changes while orders are matched.
 func (eh *EngineHolder) GetOrderBook() *matching.OrderBook {
 	return eh.Engine.GetOrderBook()
 }
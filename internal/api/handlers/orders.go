@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,11 +16,24 @@ import (
 // EngineHolder wraps the matching engine for dependency injection
 type EngineHolder struct {
 	Engine *matching.Engine
+	Stream *StreamHub // WebSocket feed of engine events
 }
 
-// NewEngineHolder creates a new engine holder
+// NewEngineHolder creates a new engine holder with the default stream settings
 func NewEngineHolder(engine *matching.Engine) *EngineHolder {
-	return &EngineHolder{Engine: engine}
+	return NewEngineHolderWithStream(engine, DefaultStreamConfig())
+}
+
+// NewEngineHolderWithStream creates a new engine holder with custom stream settings
+func NewEngineHolderWithStream(engine *matching.Engine, streamCfg StreamConfig) *EngineHolder {
+	eh := &EngineHolder{Engine: engine}
+	eh.Stream = newStreamHub(eh, streamCfg)
+	return eh
+}
+
+// Close disconnects stream clients. The engine is closed by its owner.
+func (eh *EngineHolder) Close() {
+	eh.Stream.Close()
 }
 
 // writeErrorResponse writes an error response
@@ -42,6 +56,19 @@ func writeErrorResponse(w http.ResponseWriter, httpErr *models.HTTPError) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// resolveSymbol normalizes a requested symbol, falling back to the default symbol,
+// and checks that the engine has a book for it
+func (eh *EngineHolder) resolveSymbol(symbol string) (string, *models.HTTPError) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		symbol = matching.DefaultSymbol
+	}
+	if !eh.Engine.HasSymbol(symbol) {
+		return symbol, models.ErrUnknownSymbolError(symbol)
+	}
+	return symbol, nil
+}
+
 // convertOrderType converts string to OrderType
 func convertOrderType(orderType string) matching.OrderType {
 	switch strings.ToLower(strings.TrimSpace(orderType)) {
@@ -49,11 +76,31 @@ func convertOrderType(orderType string) matching.OrderType {
 		return matching.MarketOrder
 	case "limit":
 		return matching.LimitOrder
+	case "stop_market":
+		return matching.StopMarketOrder
+	case "stop_limit":
+		return matching.StopLimitOrder
 	default:
 		return matching.NoActionOrder
 	}
 }
 
+// orderTypeToString converts OrderType to its API name
+func orderTypeToString(orderType matching.OrderType) string {
+	switch orderType {
+	case matching.MarketOrder:
+		return "market"
+	case matching.LimitOrder:
+		return "limit"
+	case matching.StopMarketOrder:
+		return "stop_market"
+	case matching.StopLimitOrder:
+		return "stop_limit"
+	default:
+		return "unknown"
+	}
+}
+
 // convertSide converts string to SideType
 func convertSide(side string) matching.SideType {
 	switch strings.ToLower(strings.TrimSpace(side)) {
@@ -66,16 +113,246 @@ func convertSide(side string) matching.SideType {
 	}
 }
 
+// aggressorSideToString converts a trade's aggressor side, empty for an auction uncross
+func aggressorSideToString(side matching.SideType) string {
+	switch side {
+	case matching.Buy:
+		return "buy"
+	case matching.Sell:
+		return "sell"
+	default:
+		return ""
+	}
+}
+
+// convertTimeInForce converts string to TimeInForce, defaulting to good-till-cancel
+func convertTimeInForce(timeInForce string) matching.TimeInForce {
+	switch strings.ToLower(strings.TrimSpace(timeInForce)) {
+	case "ioc":
+		return matching.ImmediateOrCancel
+	case "fok":
+		return matching.FillOrKill
+	case "gtd":
+		return matching.GoodTillDate
+	case "day":
+		return matching.Day
+	default:
+		return matching.GoodTillCancel
+	}
+}
+
+// timeInForceToString converts TimeInForce to its API name
+func timeInForceToString(timeInForce matching.TimeInForce) string {
+	switch timeInForce {
+	case matching.ImmediateOrCancel:
+		return "ioc"
+	case matching.FillOrKill:
+		return "fok"
+	case matching.GoodTillDate:
+		return "gtd"
+	case matching.Day:
+		return "day"
+	default:
+		return "gtc"
+	}
+}
+
+// instrumentFor returns the quote conventions for a symbol, falling back to the defaults
+func (eh *EngineHolder) instrumentFor(symbol string) matching.Instrument {
+	if inst, ok := eh.Engine.GetInstrument(symbol); ok {
+		return inst
+	}
+	return matching.DefaultInstrument(symbol)
+}
+
+// convertPrice converts a decimal request price to the instrument's fixed-point price
+func convertPrice(inst matching.Instrument, field string, price models.Decimal) (matching.Price, *models.HTTPError) {
+	if price == "" {
+		return 0, nil
+	}
+
+	converted, err := inst.ParsePrice(price.String())
+	if errors.Is(err, matching.ErrOffTick) {
+		return 0, models.ErrPriceOffTickError(field, price, inst.FormatPrice(inst.TickSize))
+	}
+	if err != nil {
+		return 0, models.ErrBadRequest("Invalid price", map[string]interface{}{"field": field, "provided_value": price})
+	}
+	return converted, nil
+}
+
+// formatPrice converts a fixed-point price to decimal text at the instrument's precision
+func formatPrice(inst matching.Instrument, price matching.Price) models.Decimal {
+	return models.Decimal(inst.FormatPrice(price))
+}
+
+// convertRequestToOrder converts a validated request to a matching order
+func convertRequestToOrder(orderID uint64, inst matching.Instrument, req *models.SubmitOrderRequest) (*matching.Order, *models.HTTPError) {
+	price, httpErr := convertPrice(inst, "price", req.Price)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	stopPrice, httpErr := convertPrice(inst, "stop_price", req.StopPrice)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	protectionPrice, httpErr := convertPrice(inst, "protection_price", req.ProtectionPrice)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	pegOffset, httpErr := convertPrice(inst, "peg_offset", req.PegOffset)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	pegCap, httpErr := convertPrice(inst, "peg_cap", req.PegCap)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	trailAmount, httpErr := convertPrice(inst, "trail_amount", req.TrailAmount)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	order := matching.NewOrderWithSymbol(
+		orderID,
+		req.UserID,
+		inst.Symbol,
+		convertOrderType(req.OrderType),
+		convertSide(req.Side),
+		price,
+		req.Quantity,
+	)
+	order.StopPrice = stopPrice
+	order.TimeInForce = convertTimeInForce(req.TimeInForce)
+	order.DisplaySize = req.DisplayQuantity
+	order.ReduceOnly = req.ReduceOnly
+	order.MinQuantity = req.MinQuantity
+	order.ProtectionPrice = protectionPrice
+	order.MaxSlippageTicks = req.MaxSlippageTicks
+	order.MaxSlippageBps = req.MaxSlippageBps
+	if pegType := strings.ToLower(strings.TrimSpace(req.PegType)); pegType != "" {
+		order.Peg, _ = matching.ParsePegType(pegType) // Validated with the request
+		order.PegOffset = pegOffset
+		order.PegCap = pegCap
+		order.Price = 0
+	}
+	order.TrailAmount = trailAmount
+	order.TrailBps = req.TrailBps
+	if mode := strings.ToLower(strings.TrimSpace(req.SelfTradePrevention)); mode != "" {
+		order.SelfTradePrevention, _ = matching.ParseSelfTradeMode(mode) // Validated with the request
+	}
+	if req.PostOnlySlide {
+		order.PostOnly = matching.PostOnlySlide
+	} else if req.PostOnly {
+		order.PostOnly = matching.PostOnlyReject
+	}
+	if req.ExpireTime != nil {
+		order.ExpireTime = *req.ExpireTime
+	}
+
+	// Market orders cannot rest, so they behave as immediate-or-cancel unless fill-or-kill
+	if (order.OrderType == matching.MarketOrder || order.OrderType == matching.StopMarketOrder) &&
+		order.TimeInForce != matching.FillOrKill {
+		order.TimeInForce = matching.ImmediateOrCancel
+	}
+	return order, nil
+}
+
+// rejectionError converts an engine rejection of an order to the error reported to the client
+func (eh *EngineHolder) rejectionError(order *matching.Order, rejectErr *matching.RejectError) *models.HTTPError {
+	switch rejectErr.Reason {
+	case matching.RejectPostOnly:
+		return models.ErrPostOnlyWouldCrossError(order.ID, formatPrice(eh.instrumentFor(order.Symbol), order.Price))
+	case matching.RejectReduceOnly:
+		return models.ErrReduceOnlyError(order.ID)
+	case matching.RejectMinQuantity:
+		return models.ErrMinQuantityNotMetError(order.ID, order.MinQuantity)
+	case matching.RejectUnknownSymbol:
+		return models.ErrUnknownSymbolError(order.Symbol)
+	case matching.RejectAuction:
+		return models.ErrAuctionInProgressError(order.ID)
+	case matching.RejectPhase:
+		phase, _ := eh.Engine.GetPhase(order.Symbol)
+		return models.ErrOrderNotAllowedInPhaseError(order.ID, order.Symbol, phase.String())
+	case matching.RejectPriceBand:
+		return eh.priceBandError(order.ID, order.Symbol, order.Price)
+	case matching.RejectPegReference:
+		return models.ErrNoPegReferenceError(order.ID, order.Peg.String())
+	case matching.RejectTrailReference:
+		return models.ErrNoTrailReferenceError(order.ID)
+	case matching.RejectMaxOrderQuantity, matching.RejectMaxOrderNotional, matching.RejectMaxOpenOrders,
+		matching.RejectMaxOpenNotional, matching.RejectPriceDeviation:
+		return eh.riskError(order, rejectErr.Reason)
+	default:
+		return models.ErrOrderRejectedError(order.ID, rejectErr.Reason.String())
+	}
+}
+
+// riskError reports the risk limit of an order's user that the order, or its amend, would break
+func (eh *EngineHolder) riskError(order *matching.Order, reason matching.RejectReason) *models.HTTPError {
+	limits := eh.Engine.GetRiskLimits(order.UserID, order.Symbol)
+	inst := eh.instrumentFor(order.Symbol)
+	report := func(code models.ErrorCode, message string, limit interface{}) *models.HTTPError {
+		return models.ErrRiskLimitError(code, message, order.ID, order.UserID, order.Symbol, limit)
+	}
+	switch reason {
+	case matching.RejectMaxOrderQuantity:
+		return report(models.ErrRiskMaxOrderQuantity, "Order quantity is above the user's limit", limits.MaxOrderQuantity)
+	case matching.RejectMaxOrderNotional:
+		return report(models.ErrRiskMaxOrderNotional, "Order notional is above the user's limit", formatPrice(inst, limits.MaxOrderNotional))
+	case matching.RejectMaxOpenOrders:
+		return report(models.ErrRiskMaxOpenOrders, "User already has the most working orders allowed in the symbol", limits.MaxOpenOrders)
+	case matching.RejectMaxOpenNotional:
+		return report(models.ErrRiskMaxOpenNotional, "User's working notional on the side would exceed the limit", formatPrice(inst, limits.MaxOpenNotional))
+	default:
+		return report(models.ErrRiskPriceDeviation, "Limit price is too far through the touch", limits.MaxPriceDeviationBps)
+	}
+}
+
+// orderOutcome reports where a submitted order stands. A working order is read back from the
+// engine, which may still be changing it; one the engine no longer holds is done and unchanging.
+func (eh *EngineHolder) orderOutcome(submitted *matching.Order) models.OrderOutcome {
+	order := eh.Engine.GetOrder(submitted.ID)
+	if order == nil {
+		order = submitted
+	}
+	outcome := models.OrderOutcome{Status: order.Status.String(), CancelReason: order.CancelReason.String()}
+	if order.Status == matching.StatusCancelled {
+		outcome.CancelledQuantity = order.Size
+	}
+	return outcome
+}
+
+// phaseError reports a cancel or amend of an order that its symbol's trading phase refuses
+func (eh *EngineHolder) phaseError(message string, orderID uint64) *models.HTTPError {
+	var symbol string
+	if order := eh.Engine.GetOrder(orderID); order != nil {
+		symbol = order.Symbol
+	}
+	phase, _ := eh.Engine.GetPhase(symbol)
+	return models.ErrNotAllowedInPhaseError(message, symbol, phase.String())
+}
+
 // convertTradesToDTO converts matching trades to DTO trades
-func convertTradesToDTO(trades []*matching.Trade) []models.TradeDTO {
+func (eh *EngineHolder) convertTradesToDTO(trades []*matching.Trade) []models.TradeDTO {
 	dtos := make([]models.TradeDTO, len(trades))
 	for i, trade := range trades {
+		inst := eh.instrumentFor(trade.Symbol)
 		dtos[i] = models.TradeDTO{
-			BuyOrderID:  trade.BuyOrderID,
-			SellOrderID: trade.SellOrderID,
-			Price:       trade.Price,
-			Quantity:    trade.Size,
-			Timestamp:   trade.Timestamp,
+			TradeID:       trade.ID,
+			Symbol:        trade.Symbol,
+			BuyOrderID:    trade.BuyOrderID,
+			SellOrderID:   trade.SellOrderID,
+			Price:         formatPrice(inst, trade.Price),
+			Quantity:      trade.Size,
+			Timestamp:     trade.Timestamp,
+			AggressorSide: aggressorSideToString(trade.AggressorSide),
+			MakerOrderID:  trade.MakerOrderID,
+			TakerOrderID:  trade.TakerOrderID,
+			MakerUserID:   trade.MakerUserID,
+			TakerUserID:   trade.TakerUserID,
+			MakerFee:      formatPrice(inst, trade.MakerFee),
+			TakerFee:      formatPrice(inst, trade.TakerFee),
 		}
 	}
 	return dtos
@@ -97,25 +374,47 @@ func (eh *EngineHolder) SubmitOrderHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	symbol, httpErr := eh.resolveSymbol(req.Symbol)
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
 	// Generate order ID
 	orderID := eh.Engine.GenerateOrderID()
 
 	// Convert to matching order
-	order := matching.NewOrder(
-		orderID,
-		req.UserID,
-		convertOrderType(req.OrderType),
-		convertSide(req.Side),
-		req.Price,
-		req.Quantity,
-	)
+	order, httpErr := convertRequestToOrder(orderID, eh.instrumentFor(symbol), &req)
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
 
 	// Submit order to engine
-	trades := eh.Engine.PlaceOrder(order)
+	trades, err := eh.Engine.SubmitOrder(order)
+	var rejectErr *matching.RejectError
+	if errors.As(err, &rejectErr) {
+		logger.Info("Order rejected", map[string]interface{}{
+			"order_id": orderID,
+			"user_id":  req.UserID,
+			"reason":   rejectErr.Reason.String(),
+		})
+		writeErrorResponse(w, eh.rejectionError(order, rejectErr))
+		return
+	}
+	if err != nil {
+		logger.Error("Order could not be recorded", map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Order could not be recorded"))
+		return
+	}
 
 	logger.Info("Order submitted successfully", map[string]interface{}{
 		"order_id": orderID,
 		"user_id":  req.UserID,
+		"symbol":   symbol,
 		"type":     req.OrderType,
 		"side":     req.Side,
 		"trades":   len(trades),
@@ -129,7 +428,10 @@ func (eh *EngineHolder) SubmitOrderHandler(w http.ResponseWriter, r *http.Reques
 			Message:   "Order submitted successfully",
 		},
 		OrderID: orderID,
-		Trades:  convertTradesToDTO(trades),
+		Trades:  eh.convertTradesToDTO(trades),
+
+		SelfTradeCancelled: order.SelfTradeCancelled,
+		OrderOutcome:       eh.orderOutcome(order),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -164,30 +466,46 @@ func (eh *EngineHolder) BatchOrderHandler(w http.ResponseWriter, r *http.Request
 		}
 
 		// Validate individual order
-		if httpErr := orderReq.Validate(); httpErr != nil {
+		httpErr := orderReq.Validate()
+		symbol := ""
+		if httpErr == nil {
+			symbol, httpErr = eh.resolveSymbol(orderReq.Symbol)
+		}
+
+		// Convert to matching order
+		var order *matching.Order
+		if httpErr == nil {
+			order, httpErr = convertRequestToOrder(eh.Engine.GenerateOrderID(), eh.instrumentFor(symbol), &orderReq)
+		}
+
+		var trades []*matching.Trade
+		var err error
+		var rejectErr *matching.RejectError
+		if httpErr == nil {
+			trades, err = eh.Engine.SubmitOrder(order)
+			if errors.As(err, &rejectErr) {
+				httpErr = eh.rejectionError(order, rejectErr)
+			}
+		}
+
+		if httpErr != nil {
 			result.Success = false
 			result.Error = &httpErr.Error
 			failed++
+		} else if err != nil {
+			logger.Error("Order could not be recorded", map[string]interface{}{
+				"order_id": order.ID,
+				"error":    err.Error(),
+			})
+			result.Success = false
+			result.Error = &models.ErrInternal("Order could not be recorded").Error
+			failed++
 		} else {
-			// Generate order ID
-			orderID := eh.Engine.GenerateOrderID()
-
-			// Convert to matching order
-			order := matching.NewOrder(
-				orderID,
-				orderReq.UserID,
-				convertOrderType(orderReq.OrderType),
-				convertSide(orderReq.Side),
-				orderReq.Price,
-				orderReq.Quantity,
-			)
-
-			// Submit order to engine
-			trades := eh.Engine.PlaceOrder(order)
-
 			result.Success = true
-			result.OrderID = orderID
-			result.Trades = convertTradesToDTO(trades)
+			result.OrderID = order.ID
+			result.Trades = eh.convertTradesToDTO(trades)
+			result.SelfTradeCancelled = order.SelfTradeCancelled
+			result.OrderOutcome = eh.orderOutcome(order)
 			successful++
 		}
 
@@ -219,6 +537,99 @@ func (eh *EngineHolder) BatchOrderHandler(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(response)
 }
 
+// OrderGroupHandler handles submission of an OCO pair or a bracket
+func (eh *EngineHolder) OrderGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.OrderGroupRequest
+
+	// Parse request body
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, models.ErrBadRequest("Invalid JSON format", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	// Validate the group and each of its orders
+	if httpErr := req.Validate(); httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	symbol, httpErr := eh.resolveSymbol(req.Orders[0].Symbol)
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	// Convert to matching orders
+	orders := make([]*matching.Order, len(req.Orders))
+	for i := range req.Orders {
+		orders[i], httpErr = convertRequestToOrder(eh.Engine.GenerateOrderID(), eh.instrumentFor(symbol), &req.Orders[i])
+		if httpErr != nil {
+			writeErrorResponse(w, httpErr)
+			return
+		}
+	}
+	groupType, _ := matching.ParseGroupType(strings.ToLower(strings.TrimSpace(req.Type))) // Validated with the request
+
+	// Submit the group to the engine
+	trades, err := eh.Engine.SubmitGroup(groupType, orders)
+	if errors.Is(err, matching.ErrInvalidGroup) {
+		writeErrorResponse(w, models.ErrInvalidGroupError("Orders cannot form the order group", nil))
+		return
+	}
+	var rejectErr *matching.RejectError
+	if errors.As(err, &rejectErr) {
+		logger.Info("Order group rejected", map[string]interface{}{
+			"group_id": orders[0].ID,
+			"user_id":  req.Orders[0].UserID,
+			"reason":   rejectErr.Reason.String(),
+		})
+		writeErrorResponse(w, eh.rejectionError(orders[0], rejectErr))
+		return
+	}
+	if err != nil {
+		logger.Error("Order group could not be recorded", map[string]interface{}{
+			"group_id": orders[0].ID,
+			"error":    err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Order group could not be recorded"))
+		return
+	}
+
+	logger.Info("Order group submitted successfully", map[string]interface{}{
+		"group_id": orders[0].ID,
+		"type":     groupType.String(),
+		"user_id":  req.Orders[0].UserID,
+		"symbol":   symbol,
+		"trades":   len(trades),
+	})
+
+	// Working orders are read back from the engine; those it no longer holds are done
+	dtos := make([]models.OrderDTO, len(orders))
+	for i, order := range orders {
+		if working := eh.Engine.GetOrder(order.ID); working != nil {
+			order = working
+		}
+		dtos[i] = *eh.convertOrderToDTO(order)
+	}
+
+	// Return response
+	response := models.OrderGroupResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+			Message:   "Order group submitted successfully",
+		},
+		GroupID:   orders[0].ID,
+		GroupType: groupType.String(),
+		Orders:    dtos,
+		Trades:    eh.convertTradesToDTO(trades),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // CancelOrderHandler handles order cancellation
 func (eh *EngineHolder) CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract order ID from path
@@ -236,7 +647,19 @@ func (eh *EngineHolder) CancelOrderHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Cancel order
-	cancelled := eh.Engine.CancelOrder(orderID)
+	cancelled, err := eh.Engine.SubmitCancel(orderID)
+	if errors.Is(err, matching.ErrCancelNotAllowed) {
+		writeErrorResponse(w, eh.phaseError("Cancels are not accepted in the symbol's trading phase", orderID))
+		return
+	}
+	if err != nil {
+		logger.Error("Cancel could not be recorded", map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Cancel could not be recorded"))
+		return
+	}
 
 	if !cancelled {
 		writeErrorResponse(w, models.ErrOrderNotFoundError(orderID))
@@ -262,6 +685,111 @@ func (eh *EngineHolder) CancelOrderHandler(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
+// AmendOrderHandler handles changing the price or quantity of a working order
+func (eh *EngineHolder) AmendOrderHandler(w http.ResponseWriter, r *http.Request) {
+	// Extract order ID from path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 5 {
+		writeErrorResponse(w, models.ErrBadRequest("Invalid order ID", nil))
+		return
+	}
+
+	orderIDStr := pathParts[len(pathParts)-1]
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 64)
+	if err != nil {
+		writeErrorResponse(w, models.ErrBadRequest("Invalid order ID format", map[string]interface{}{"provided_value": orderIDStr}))
+		return
+	}
+
+	var req models.AmendOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, models.ErrBadRequest("Invalid JSON format", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	if httpErr := req.Validate(); httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	// The price is parsed with the order's instrument
+	order := eh.Engine.GetOrder(orderID)
+	if order == nil || order.Status.IsTerminal() {
+		writeErrorResponse(w, models.ErrOrderNotFoundError(orderID))
+		return
+	}
+	price, httpErr := convertPrice(eh.instrumentFor(order.Symbol), "price", req.Price)
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	// Amend order
+	trades, err := eh.Engine.AmendOrder(orderID, price, req.Quantity)
+	var rejectErr *matching.RejectError
+	switch {
+	case errors.As(err, &rejectErr) && rejectErr.Reason == matching.RejectPostOnly:
+		writeErrorResponse(w, models.ErrPostOnlyWouldCrossError(orderID, req.Price))
+		return
+	case errors.As(err, &rejectErr) && rejectErr.Reason == matching.RejectPhase:
+		writeErrorResponse(w, eh.phaseError("Amends are not accepted in the symbol's trading phase", orderID))
+		return
+	case errors.As(err, &rejectErr) && rejectErr.Reason == matching.RejectPriceBand:
+		writeErrorResponse(w, eh.priceBandError(orderID, order.Symbol, price))
+		return
+	case errors.As(err, &rejectErr):
+		writeErrorResponse(w, eh.rejectionError(order, rejectErr))
+		return
+	case errors.Is(err, matching.ErrOrderNotWorking):
+		writeErrorResponse(w, models.ErrOrderNotFoundError(orderID))
+		return
+	case errors.Is(err, matching.ErrAmendQuantity):
+		writeErrorResponse(w, models.ErrAmendQuantityError(req.Quantity, order.FilledSize))
+		return
+	case errors.Is(err, matching.ErrAmendGroup):
+		writeErrorResponse(w, models.ErrAmendGroupError(req.Quantity))
+		return
+	case errors.Is(err, matching.ErrAmendPrice):
+		writeErrorResponse(w, models.ErrAmendPriceError(req.Price))
+		return
+	case err != nil:
+		logger.Error("Amend could not be recorded", map[string]interface{}{
+			"order_id": orderID,
+			"error":    err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Amend could not be recorded"))
+		return
+	}
+
+	logger.Info("Order amended", map[string]interface{}{
+		"order_id": orderID,
+		"price":    req.Price,
+		"quantity": req.Quantity,
+		"trades":   len(trades),
+	})
+
+	// A fully filled order may already be gone if terminal orders are not retained
+	var orderDTO *models.OrderDTO
+	if amended := eh.Engine.GetOrder(orderID); amended != nil {
+		orderDTO = eh.convertOrderToDTO(amended)
+	}
+
+	// Return response
+	response := models.AmendOrderResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+			Message:   "Order amended successfully",
+		},
+		Order:  orderDTO,
+		Trades: eh.convertTradesToDTO(trades),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // GetOrderHandler handles retrieving a single order
 func (eh *EngineHolder) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract order ID from path
@@ -287,7 +815,7 @@ func (eh *EngineHolder) GetOrderHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Convert to DTO
-	orderDTO := convertOrderToDTO(order)
+	orderDTO := eh.convertOrderToDTO(order)
 
 	// Return response
 	response := models.GetOrderResponse{
@@ -303,11 +831,12 @@ func (eh *EngineHolder) GetOrderHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetAllOrdersHandler handles retrieving all open orders
+// GetAllOrdersHandler handles retrieving working and recently completed orders
 func (eh *EngineHolder) GetAllOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	userID := r.URL.Query().Get("user_id")
 	sideStr := r.URL.Query().Get("side")
+	statusStr := r.URL.Query().Get("status")
 	limitStr := r.URL.Query().Get("limit")
 
 	// Default limit
@@ -338,6 +867,17 @@ func (eh *EngineHolder) GetAllOrdersHandler(w http.ResponseWriter, r *http.Reque
 		orders = eh.Engine.GetAllOrders()
 	}
 
+	// Filter by lifecycle status, if requested
+	if statusStr != "" {
+		filtered := orders[:0]
+		for _, order := range orders {
+			if order.Status.String() == statusStr {
+				filtered = append(filtered, order)
+			}
+		}
+		orders = filtered
+	}
+
 	// Apply limit
 	if len(orders) > limit {
 		orders = orders[:limit]
@@ -346,7 +886,7 @@ func (eh *EngineHolder) GetAllOrdersHandler(w http.ResponseWriter, r *http.Reque
 	// Convert to DTOs
 	orderDTOs := make([]models.OrderDTO, len(orders))
 	for i, order := range orders {
-		orderDTOs[i] = *convertOrderToDTO(order)
+		orderDTOs[i] = *eh.convertOrderToDTO(order)
 	}
 
 	logger.Info("Retrieved orders", map[string]interface{}{
@@ -369,18 +909,10 @@ func (eh *EngineHolder) GetAllOrdersHandler(w http.ResponseWriter, r *http.Reque
 }
 
 // convertOrderToDTO converts a matching order to DTO
-func convertOrderToDTO(order *matching.Order) *models.OrderDTO {
-	var orderType, side string
-
-	switch order.OrderType {
-	case matching.MarketOrder:
-		orderType = "market"
-	case matching.LimitOrder:
-		orderType = "limit"
-	default:
-		orderType = "unknown"
-	}
+func (eh *EngineHolder) convertOrderToDTO(order *matching.Order) *models.OrderDTO {
+	orderType := orderTypeToString(order.OrderType)
 
+	var side string
 	switch order.Side {
 	case matching.Buy:
 		side = "buy"
@@ -390,15 +922,77 @@ func convertOrderToDTO(order *matching.Order) *models.OrderDTO {
 		side = "unknown"
 	}
 
+	var expireTime *time.Time
+	if order.TimeInForce == matching.GoodTillDate {
+		expireTime = &order.ExpireTime
+	}
+
+	inst := eh.instrumentFor(order.Symbol)
+	var stopPrice models.Decimal
+	if order.OrderType == matching.StopMarketOrder || order.OrderType == matching.StopLimitOrder {
+		stopPrice = formatPrice(inst, order.StopPrice)
+	}
+
+	// Terminal orders have nothing left working
+	remaining := order.Size
+	if order.Status.IsTerminal() {
+		remaining = 0
+	}
+	var avgFillPrice models.Decimal
+	if order.FilledSize > 0 {
+		avgFillPrice = formatPrice(inst, order.AvgFillPrice())
+	}
+	var protectionPrice models.Decimal
+	if order.ProtectionPrice > 0 {
+		protectionPrice = formatPrice(inst, order.ProtectionPrice)
+	}
+	var pegOffset, pegCap models.Decimal
+	if order.Peg != matching.PegNone {
+		pegOffset = formatPrice(inst, order.PegOffset)
+	}
+	if order.PegCap > 0 {
+		pegCap = formatPrice(inst, order.PegCap)
+	}
+	var trailAmount models.Decimal
+	if order.TrailAmount > 0 {
+		trailAmount = formatPrice(inst, order.TrailAmount)
+	}
+
 	return &models.OrderDTO{
 		OrderID:   order.ID,
 		UserID:    order.UserID,
 		Symbol:    order.Symbol,
 		OrderType: orderType,
 		Side:      side,
-		Price:     order.Price,
-		Quantity:  order.Size,
-		Status:    "open",
+		Price:     formatPrice(inst, order.Price),
+		StopPrice: stopPrice,
+		Quantity:  order.OriginalSize,
+		Status:    order.Status.String(),
 		Timestamp: order.TimeStamp,
+
+		FilledQuantity:      order.FilledSize,
+		RemainingQuantity:   remaining,
+		AvgFillPrice:        avgFillPrice,
+		DisplayQuantity:     order.DisplaySize,
+		PostOnly:            order.PostOnly != matching.PostOnlyOff,
+		PostOnlySlide:       order.PostOnly == matching.PostOnlySlide,
+		ReduceOnly:          order.ReduceOnly,
+		MinQuantity:         order.MinQuantity,
+		SelfTradePrevention: order.SelfTradePrevention.String(),
+		ProtectionPrice:     protectionPrice,
+		MaxSlippageTicks:    order.MaxSlippageTicks,
+		MaxSlippageBps:      order.MaxSlippageBps,
+		PegType:             order.Peg.String(),
+		PegOffset:           pegOffset,
+		PegCap:              pegCap,
+		TrailAmount:         trailAmount,
+		TrailBps:            order.TrailBps,
+		GroupType:           order.Group.String(),
+		GroupID:             order.GroupID,
+		RejectReason:        order.RejectReason.String(),
+		CancelReason:        order.CancelReason.String(),
+
+		TimeInForce: timeInForceToString(order.TimeInForce),
+		ExpireTime:  expireTime,
 	}
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/logger"
+	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// convertSessionToDTO describes a symbol's trading phase and what it allows
+func convertSessionToDTO(symbol string, phase matching.TradingPhase) models.SessionDTO {
+	orderTypes := make([]string, 0)
+	for _, orderType := range phase.OrderTypes() {
+		orderTypes = append(orderTypes, orderTypeToString(orderType))
+	}
+	return models.SessionDTO{
+		Symbol:     symbol,
+		Phase:      phase.String(),
+		OrderTypes: orderTypes,
+		Cancels:    phase.AllowsCancel(),
+		Auction:    phase.IsAuction(),
+	}
+}
+
+// GetSessionHandler handles requests for a symbol's trading phase
+func (eh *EngineHolder) GetSessionHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	phase, _ := eh.Engine.GetPhase(symbol)
+
+	response := models.SessionResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+		},
+		Session: convertSessionToDTO(symbol, phase),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetPhaseHandler handles manual changes of a symbol's trading phase, such as halts
+func (eh *EngineHolder) SetPhaseHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, httpErr := eh.resolveSymbol(r.URL.Query().Get("symbol"))
+	if httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+
+	var req models.SetPhaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, models.ErrBadRequest("Invalid JSON format", map[string]interface{}{"error": err.Error()}))
+		return
+	}
+
+	if httpErr := req.Validate(); httpErr != nil {
+		writeErrorResponse(w, httpErr)
+		return
+	}
+	phase, _ := matching.ParseTradingPhase(req.Phase) // Validated with the request
+
+	trades, err := eh.Engine.SetPhase(symbol, phase)
+	if err != nil {
+		logger.Error("Phase change could not be recorded", map[string]interface{}{
+			"symbol": symbol,
+			"phase":  req.Phase,
+			"error":  err.Error(),
+		})
+		writeErrorResponse(w, models.ErrInternal("Phase change could not be recorded"))
+		return
+	}
+
+	logger.Info("Trading phase changed", map[string]interface{}{
+		"symbol": symbol,
+		"phase":  req.Phase,
+		"trades": len(trades),
+	})
+
+	response := models.SetPhaseResponse{
+		BaseResponse: models.BaseResponse{
+			Success:   true,
+			Timestamp: time.Now().UTC(),
+			Message:   "Trading phase changed",
+		},
+		Session: convertSessionToDTO(symbol, phase),
+		Trades:  eh.convertTradesToDTO(trades),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
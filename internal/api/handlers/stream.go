@@ -0,0 +1,545 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/logger"
+	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/matching"
+	"github.com/gorilla/websocket"
+)
+
+/*
+The stream endpoint pushes market data and order updates over WebSocket, driven by the events
+the engine publishes after each command.
+
+Every client has a bounded queue and a writer goroutine that owns its subscriptions. The engine
+hands each batch of events to every queue without blocking, because it does so on the sequencer.
+A client whose queue is full is a slow consumer: it is dropped rather than allowed to stall
+matching or grow memory without bound.
+
+Subscription requests go through the same queue as events, so the writer sees them in order.
+For top and depth it takes a book snapshot when it applies the request. Events already queued
+with a sequence at or below the snapshot's are skipped, and every later update follows it, so a
+client can apply depth updates to the snapshot without gaps. An auction subscription starts with
+the symbol's current auction state, followed by every indicative uncross the engine publishes.
+*/
+
+// StreamConfig tunes the WebSocket stream
+type StreamConfig struct {
+	SendBuffer   int           // Messages queued per client before it is dropped as a slow consumer
+	WriteTimeout time.Duration // Longest a single write to a client may block
+	PingInterval time.Duration // Keepalive ping period; a client silent for two periods is dropped
+}
+
+// DefaultStreamConfig returns the stream settings used when none are configured
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		SendBuffer:   256,
+		WriteTimeout: 5 * time.Second,
+		PingInterval: 30 * time.Second,
+	}
+}
+
+// StreamHub fans engine events out to WebSocket clients
+type StreamHub struct {
+	holder      *EngineHolder
+	config      StreamConfig
+	upgrader    websocket.Upgrader
+	clients     map[*streamClient]bool
+	mutex       sync.RWMutex   // Protect clients and closed
+	closed      bool           // Set by Close; new connections are refused
+	writers     sync.WaitGroup // Waits for client writers on Close
+	unsubscribe func()         // Stops engine events
+}
+
+func newStreamHub(eh *EngineHolder, cfg StreamConfig) *StreamHub {
+	// Unset fields take their defaults
+	defaults := DefaultStreamConfig()
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = defaults.SendBuffer
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaults.WriteTimeout
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaults.PingInterval
+	}
+
+	hub := &StreamHub{
+		holder:  eh,
+		config:  cfg,
+		clients: make(map[*streamClient]bool),
+		upgrader: websocket.Upgrader{
+			// Browsers may connect from any origin, as the CORS middleware allows for REST
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+	hub.unsubscribe = eh.Engine.Subscribe(hub.publish)
+	return hub
+}
+
+// ClientCount returns the number of connected clients
+func (h *StreamHub) ClientCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.clients)
+}
+
+// Close stops the feed and disconnects every client
+func (h *StreamHub) Close() {
+	h.mutex.Lock()
+	if h.closed {
+		h.mutex.Unlock()
+		return
+	}
+	h.closed = true
+	h.mutex.Unlock()
+
+	// Unsubscribe outside the lock, since the engine may be publishing to the hub
+	h.unsubscribe()
+
+	h.mutex.Lock()
+	for client := range h.clients {
+		client.disconnect(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.mutex.Unlock()
+
+	h.writers.Wait()
+}
+
+// publish runs on the engine's sequencer, so it only queues events
+func (h *StreamHub) publish(events []matching.Event) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for client := range h.clients {
+		select {
+		case client.queue <- streamItem{events: events}:
+		case <-client.done:
+		default:
+			logger.Warn("Dropping slow stream consumer", map[string]interface{}{
+				"remote": client.remote,
+				"buffer": h.config.SendBuffer,
+			})
+			client.disconnect(websocket.ClosePolicyViolation, "slow consumer")
+		}
+	}
+}
+
+func (h *StreamHub) remove(client *streamClient) {
+	h.mutex.Lock()
+	delete(h.clients, client)
+	h.mutex.Unlock()
+}
+
+// StreamHandler upgrades a request to a WebSocket stream
+func (eh *EngineHolder) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	hub := eh.Stream
+
+	conn, err := hub.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		logger.Warn("Stream upgrade failed", map[string]interface{}{
+			"error":  err.Error(),
+			"remote": r.RemoteAddr,
+		})
+		return
+	}
+
+	client := &streamClient{
+		hub:      hub,
+		conn:     conn,
+		remote:   r.RemoteAddr,
+		queue:    make(chan streamItem, hub.config.SendBuffer),
+		done:     make(chan struct{}),
+		trades:   make(map[string]bool),
+		tops:     make(map[string]*topState),
+		depths:   make(map[string]uint64),
+		orders:   make(map[string]bool),
+		auctions: make(map[string]bool),
+	}
+
+	hub.mutex.Lock()
+	if hub.closed {
+		hub.mutex.Unlock()
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(hub.config.WriteTimeout))
+		conn.Close()
+		return
+	}
+	hub.clients[client] = true
+	hub.writers.Add(1)
+	hub.mutex.Unlock()
+
+	logger.Info("Stream client connected", map[string]interface{}{
+		"remote": client.remote,
+	})
+
+	go client.writeLoop()
+	client.readLoop()
+}
+
+// streamItem is one entry in a client's queue: a batch of events or a request
+type streamItem struct {
+	events  []matching.Event
+	request *models.StreamRequest
+}
+
+// topState is the last top of book sent for a symbol
+type topState struct {
+	seq     uint64
+	bestBid *matching.BookLevel
+	bestAsk *matching.BookLevel
+}
+
+// streamClient is one WebSocket connection
+type streamClient struct {
+	hub    *StreamHub
+	conn   *websocket.Conn
+	remote string
+	queue  chan streamItem
+	done   chan struct{} // Closed to stop the client
+	once   sync.Once
+
+	closeCode   int
+	closeReason string
+
+	// Subscriptions, owned by the writer goroutine
+	trades   map[string]bool      // Symbols
+	tops     map[string]*topState // Symbol -> last top sent
+	depths   map[string]uint64    // Symbol -> last book sequence sent
+	orders   map[string]bool      // User IDs
+	auctions map[string]bool      // Symbols
+}
+
+// disconnect stops the client, sending the given close code if the connection still accepts writes
+func (c *streamClient) disconnect(code int, reason string) {
+	c.once.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
+
+// readLoop passes requests to the writer until the connection fails
+func (c *streamClient) readLoop() {
+	pongWait := 2 * c.hub.config.PingInterval
+	c.conn.SetReadLimit(4096)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.disconnect(websocket.CloseNormalClosure, "")
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var req models.StreamRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			req = models.StreamRequest{} // Answered with an error by the writer
+		}
+
+		select {
+		case c.queue <- streamItem{request: &req}:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// writeLoop sends queued messages and keepalive pings until the client is stopped
+func (c *streamClient) writeLoop() {
+	ticker := time.NewTicker(c.hub.config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		c.hub.remove(c)
+		c.hub.writers.Done()
+
+		logger.Info("Stream client disconnected", map[string]interface{}{
+			"remote": c.remote,
+			"reason": c.closeReason,
+		})
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(c.hub.config.WriteTimeout))
+			return
+		case item := <-c.queue:
+			var err error
+			if item.request != nil {
+				err = c.handleRequest(item.request)
+			} else {
+				err = c.handleEvents(item.events)
+			}
+			if err != nil {
+				c.disconnect(websocket.CloseAbnormalClosure, "write failed")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.hub.config.WriteTimeout)); err != nil {
+				c.disconnect(websocket.CloseAbnormalClosure, "ping failed")
+				return
+			}
+		}
+	}
+}
+
+func (c *streamClient) send(msg *models.StreamMessage) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout))
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *streamClient) sendError(httpErr *models.HTTPError) error {
+	return c.send(&models.StreamMessage{
+		Type:      models.StreamError,
+		Timestamp: time.Now().UTC(),
+		Error:     &httpErr.Error,
+	})
+}
+
+// handleRequest applies a subscribe or unsubscribe request
+func (c *streamClient) handleRequest(req *models.StreamRequest) error {
+	eh := c.hub.holder
+
+	if req.Op != "subscribe" && req.Op != "unsubscribe" {
+		return c.sendError(models.ErrBadRequest("Op must be 'subscribe' or 'unsubscribe'",
+			map[string]interface{}{"field": "op", "provided_value": req.Op}))
+	}
+
+	ack := &models.StreamMessage{
+		Type:      models.StreamSubscribed,
+		Channel:   req.Channel,
+		Timestamp: time.Now().UTC(),
+	}
+	if req.Op == "unsubscribe" {
+		ack.Type = models.StreamUnsubscribed
+	}
+
+	// The orders channel is keyed by user; the others by symbol
+	if req.Channel == models.ChannelOrders {
+		if req.UserID == "" {
+			return c.sendError(models.ErrBadRequest("user_id is required for the orders channel",
+				map[string]interface{}{"field": "user_id"}))
+		}
+		ack.UserID = req.UserID
+		if req.Op == "subscribe" {
+			c.orders[req.UserID] = true
+		} else {
+			delete(c.orders, req.UserID)
+		}
+		return c.send(ack)
+	}
+
+	symbol, httpErr := eh.resolveSymbol(req.Symbol)
+	if httpErr != nil {
+		return c.sendError(httpErr)
+	}
+	ack.Symbol = symbol
+
+	if req.Op == "unsubscribe" {
+		switch req.Channel {
+		case models.ChannelTrades:
+			delete(c.trades, symbol)
+		case models.ChannelTop:
+			delete(c.tops, symbol)
+		case models.ChannelDepth:
+			delete(c.depths, symbol)
+		case models.ChannelAuction:
+			delete(c.auctions, symbol)
+		default:
+			return c.sendError(models.ErrInvalidChannelError(req.Channel))
+		}
+		return c.send(ack)
+	}
+
+	switch req.Channel {
+	case models.ChannelTrades:
+		c.trades[symbol] = true
+		return c.send(ack)
+
+	case models.ChannelTop:
+		snapshot, _ := eh.Engine.GetBookSnapshot(symbol, 1)
+		top := &topState{seq: snapshot.Seq}
+		if len(snapshot.Bids) > 0 {
+			top.bestBid = &snapshot.Bids[0]
+		}
+		if len(snapshot.Asks) > 0 {
+			top.bestAsk = &snapshot.Asks[0]
+		}
+		c.tops[symbol] = top
+		if err := c.send(ack); err != nil {
+			return err
+		}
+		return c.send(c.topMessage(symbol, top, time.Now().UTC()))
+
+	case models.ChannelDepth:
+		snapshot, _ := eh.Engine.GetBookSnapshot(symbol, 0)
+		c.depths[symbol] = snapshot.Seq
+		if err := c.send(ack); err != nil {
+			return err
+		}
+		inst := eh.instrumentFor(symbol)
+		return c.send(&models.StreamMessage{
+			Type:      models.StreamDepthSnapshot,
+			Channel:   models.ChannelDepth,
+			Symbol:    symbol,
+			Seq:       snapshot.Seq,
+			Timestamp: time.Now().UTC(),
+			Data: models.StreamDepth{
+				Bids: aggregatePriceLevels(snapshot.Bids, inst, 0, len(snapshot.Bids)),
+				Asks: aggregatePriceLevels(snapshot.Asks, inst, 0, len(snapshot.Asks)),
+			},
+		})
+
+	case models.ChannelAuction:
+		c.auctions[symbol] = true
+		if err := c.send(ack); err != nil {
+			return err
+		}
+		info, _ := eh.Engine.GetAuction(symbol)
+		return c.send(c.auctionMessage(info, time.Now().UTC()))
+	}
+
+	return c.sendError(models.ErrInvalidChannelError(req.Channel))
+}
+
+// handleEvents sends the events of one command that match the client's subscriptions
+func (c *streamClient) handleEvents(events []matching.Event) error {
+	eh := c.hub.holder
+
+	for _, event := range events {
+		timestamp := event.Time.UTC()
+
+		switch event.Type {
+		case matching.TradeEvent:
+			if !c.trades[event.Symbol] {
+				continue
+			}
+			trade := eh.convertTradesToDTO([]*matching.Trade{event.Trade})[0]
+			if err := c.send(&models.StreamMessage{
+				Type:      models.StreamTrade,
+				Channel:   models.ChannelTrades,
+				Symbol:    event.Symbol,
+				Timestamp: timestamp,
+				Data:      trade,
+			}); err != nil {
+				return err
+			}
+
+		case matching.OrderEvent:
+			if !c.orders[event.Order.UserID] {
+				continue
+			}
+			if err := c.send(&models.StreamMessage{
+				Type:      models.StreamOrder,
+				Channel:   models.ChannelOrders,
+				Symbol:    event.Symbol,
+				UserID:    event.Order.UserID,
+				Timestamp: timestamp,
+				Data:      eh.convertOrderToDTO(event.Order),
+			}); err != nil {
+				return err
+			}
+
+		case matching.BookEvent:
+			if err := c.handleBookUpdate(event.Symbol, event.Book, timestamp); err != nil {
+				return err
+			}
+
+		case matching.AuctionEvent:
+			if !c.auctions[event.Symbol] {
+				continue
+			}
+			if err := c.send(c.auctionMessage(*event.Auction, timestamp)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleBookUpdate sends depth updates and top of book changes. Updates already reflected in
+// the snapshot a subscription started from are skipped.
+func (c *streamClient) handleBookUpdate(symbol string, update *matching.BookUpdate, timestamp time.Time) error {
+	if seq, ok := c.depths[symbol]; ok && update.Seq > seq {
+		c.depths[symbol] = update.Seq
+		inst := c.hub.holder.instrumentFor(symbol)
+		if err := c.send(&models.StreamMessage{
+			Type:      models.StreamDepthUpdate,
+			Channel:   models.ChannelDepth,
+			Symbol:    symbol,
+			Seq:       update.Seq,
+			Timestamp: timestamp,
+			Data: models.StreamDepth{
+				Bids: aggregatePriceLevels(update.Bids, inst, 0, len(update.Bids)),
+				Asks: aggregatePriceLevels(update.Asks, inst, 0, len(update.Asks)),
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if top := c.tops[symbol]; top != nil && update.Seq > top.seq {
+		top.seq = update.Seq
+		if sameLevel(top.bestBid, update.BestBid) && sameLevel(top.bestAsk, update.BestAsk) {
+			return nil
+		}
+		top.bestBid, top.bestAsk = update.BestBid, update.BestAsk
+		return c.send(c.topMessage(symbol, top, timestamp))
+	}
+	return nil
+}
+
+func (c *streamClient) topMessage(symbol string, top *topState, timestamp time.Time) *models.StreamMessage {
+	inst := c.hub.holder.instrumentFor(symbol)
+	quote := func(level *matching.BookLevel) *models.BestQuote {
+		if level == nil {
+			return nil
+		}
+		return &models.BestQuote{Price: formatPrice(inst, level.Price), Quantity: level.Quantity}
+	}
+
+	return &models.StreamMessage{
+		Type:      models.StreamTop,
+		Channel:   models.ChannelTop,
+		Symbol:    symbol,
+		Seq:       top.seq,
+		Timestamp: timestamp,
+		Data: models.StreamTopOfBook{
+			BestBid: quote(top.bestBid),
+			BestAsk: quote(top.bestAsk),
+		},
+	}
+}
+
+func (c *streamClient) auctionMessage(info matching.AuctionInfo, timestamp time.Time) *models.StreamMessage {
+	return &models.StreamMessage{
+		Type:      models.StreamAuction,
+		Channel:   models.ChannelAuction,
+		Symbol:    info.Symbol,
+		Timestamp: timestamp,
+		Data:      c.hub.holder.convertAuctionToDTO(info),
+	}
+}
+
+// sameLevel reports whether two top of book levels quote the same price and quantity
+func sameLevel(a, b *matching.BookLevel) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Price == b.Price && a.Quantity == b.Quantity
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/PxPatel/trading-system/internal/api/logger"
 	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/matching"
 )
 
 // GetTradesHandler handles retrieving recent trades
@@ -27,11 +28,21 @@ func (eh *EngineHolder) GetTradesHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	// Get recent trades from engine
-	trades := eh.Engine.GetRecentTrades(limit)
+	// Get recent trades from engine, optionally filtered by symbol
+	var trades []*matching.Trade
+	if symbolStr := r.URL.Query().Get("symbol"); symbolStr != "" {
+		symbol, httpErr := eh.resolveSymbol(symbolStr)
+		if httpErr != nil {
+			writeErrorResponse(w, httpErr)
+			return
+		}
+		trades = eh.Engine.GetRecentTradesBySymbol(symbol, limit)
+	} else {
+		trades = eh.Engine.GetRecentTrades(limit)
+	}
 
 	// Convert to DTOs
-	tradeDTOs := convertTradesToDTO(trades)
+	tradeDTOs := eh.convertTradesToDTO(trades)
 
 	logger.Info("Retrieved trades", map[string]interface{}{
 		"count": len(tradeDTOs),
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// decimalPattern is the only text a Decimal takes: plain digits with an optional sign and fraction
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Decimal is a price kept as exact decimal text so it never passes through a float.
+// It accepts a JSON number or a quoted decimal string, and is written as a JSON number.
+type Decimal string
+
+// UnmarshalJSON reads a JSON number or string without rounding it
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = ""
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	if !decimalPattern.MatchString(text) {
+		return fmt.Errorf("invalid decimal %q", text)
+	}
+
+	*d = Decimal(text)
+	return nil
+}
+
+// MarshalJSON writes the decimal as a JSON number. Text that is not a plain decimal, such as a
+// float's NaN or Inf, is written as a JSON string instead so the response stays valid JSON.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d == "" {
+		return []byte("0"), nil
+	}
+	if !decimalPattern.MatchString(string(d)) {
+		return json.Marshal(string(d))
+	}
+	return []byte(d), nil
+}
+
+// Float64 returns the nearest float, or 0 for an empty decimal
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(string(d), 64)
+	return value
+}
+
+// String returns the decimal text
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// DecimalFromFloat formats a float in its shortest exact form
+func DecimalFromFloat(value float64) Decimal {
+	return Decimal(strconv.FormatFloat(value, 'f', -1, 64))
+}
@@ -1,6 +1,9 @@
 package models
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // ErrorCode represents standard error codes
 type ErrorCode string
@@ -11,10 +14,39 @@ const (
 	ErrInvalidOrderType ErrorCode = "INVALID_ORDER_TYPE"
 	ErrInvalidSide      ErrorCode = "INVALID_SIDE"
 	ErrInvalidPrice     ErrorCode = "INVALID_PRICE"
+	ErrInvalidStopPrice ErrorCode = "INVALID_STOP_PRICE"
 	ErrInvalidQuantity  ErrorCode = "INVALID_QUANTITY"
+	ErrInvalidDisplay   ErrorCode = "INVALID_DISPLAY_QUANTITY"
+	ErrInvalidExecInst  ErrorCode = "INVALID_EXEC_INSTRUCTION"
+	ErrInvalidSTP       ErrorCode = "INVALID_SELF_TRADE_PREVENTION"
+	ErrInvalidTIF       ErrorCode = "INVALID_TIME_IN_FORCE"
+	ErrInvalidExpiry    ErrorCode = "INVALID_EXPIRE_TIME"
+	ErrPriceOffTick     ErrorCode = "PRICE_NOT_ON_TICK"
 	ErrMissingPrice     ErrorCode = "MISSING_PRICE"
 	ErrOrderNotFound    ErrorCode = "ORDER_NOT_FOUND"
+	ErrUnknownSymbol    ErrorCode = "UNKNOWN_SYMBOL"
 	ErrInternalError    ErrorCode = "INTERNAL_ERROR"
+	ErrInvalidChannel   ErrorCode = "INVALID_CHANNEL"
+	ErrPostOnlyCross    ErrorCode = "POST_ONLY_WOULD_CROSS"
+	ErrReduceOnly       ErrorCode = "REDUCE_ONLY_NO_POSITION"
+	ErrMinQuantity      ErrorCode = "MIN_QUANTITY_NOT_MET"
+	ErrOrderRejected    ErrorCode = "ORDER_REJECTED"
+	ErrAuctionRunning   ErrorCode = "AUCTION_IN_PROGRESS"
+	ErrNoAuction        ErrorCode = "NO_AUCTION"
+	ErrInvalidPhase     ErrorCode = "INVALID_PHASE"
+	ErrPhaseNotAllowed  ErrorCode = "NOT_ALLOWED_IN_PHASE"
+	ErrPriceBand        ErrorCode = "OUTSIDE_PRICE_BAND"
+	ErrInvalidPegType   ErrorCode = "INVALID_PEG_TYPE"
+	ErrNoPegReference   ErrorCode = "NO_PEG_REFERENCE"
+	ErrNoTrailReference ErrorCode = "NO_TRAIL_REFERENCE"
+	ErrInvalidGroup     ErrorCode = "INVALID_ORDER_GROUP"
+
+	// Pre-trade risk limits
+	ErrRiskMaxOrderQuantity ErrorCode = "RISK_MAX_ORDER_QUANTITY"
+	ErrRiskMaxOrderNotional ErrorCode = "RISK_MAX_ORDER_NOTIONAL"
+	ErrRiskMaxOpenOrders    ErrorCode = "RISK_MAX_OPEN_ORDERS"
+	ErrRiskMaxOpenNotional  ErrorCode = "RISK_MAX_OPEN_NOTIONAL"
+	ErrRiskPriceDeviation   ErrorCode = "RISK_PRICE_DEVIATION"
 )
 
 // APIError represents a structured error response
@@ -56,7 +88,7 @@ func ErrInvalidOrderIdError(providedType string) *HTTPError {
 
 func ErrInvalidOrderTypeError(providedType string) *HTTPError {
 	return NewHTTPError(http.StatusBadRequest, ErrInvalidOrderType,
-		"Invalid order type, must be 'market' or 'limit'",
+		"Invalid order type, must be 'market', 'limit', 'stop_market' or 'stop_limit'",
 		map[string]interface{}{"provided_value": providedType})
 }
 
@@ -66,18 +98,93 @@ func ErrInvalidSideError(providedSide string) *HTTPError {
 		map[string]interface{}{"provided_value": providedSide})
 }
 
-func ErrInvalidPriceError(price float64) *HTTPError {
+func ErrInvalidPriceError(price Decimal) *HTTPError {
 	return NewHTTPError(http.StatusBadRequest, ErrInvalidPrice,
 		"Price must be greater than 0 for limit orders",
 		map[string]interface{}{"field": "price", "provided_value": price})
 }
 
+func ErrInvalidStopPriceError(stopPrice Decimal) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidStopPrice,
+		"Stop price must be greater than 0 for stop orders",
+		map[string]interface{}{"field": "stop_price", "provided_value": stopPrice})
+}
+
 func ErrInvalidQuantityError(quantity int) *HTTPError {
 	return NewHTTPError(http.StatusBadRequest, ErrInvalidQuantity,
 		"Quantity must be positive",
 		map[string]interface{}{"field": "quantity", "provided_value": quantity})
 }
 
+func ErrInvalidDisplayQuantityError(displayQuantity int) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidDisplay,
+		"Display quantity must be between 0 and quantity, and is only allowed on limit orders",
+		map[string]interface{}{"field": "display_quantity", "provided_value": displayQuantity})
+}
+
+func ErrInvalidExecInstError(field string, message string, provided interface{}) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidExecInst, message,
+		map[string]interface{}{"field": field, "provided_value": provided})
+}
+
+func ErrInvalidSelfTradeModeError(providedMode string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidSTP,
+		"Invalid self-trade prevention, must be 'allow', 'cancel_newest', 'cancel_oldest', 'cancel_both' or 'decrement'",
+		map[string]interface{}{"field": "self_trade_prevention", "provided_value": providedMode})
+}
+
+func ErrInvalidPegTypeError(providedPeg string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidPegType,
+		"Invalid peg type, must be 'primary', 'market' or 'midpoint'",
+		map[string]interface{}{"field": "peg_type", "provided_value": providedPeg})
+}
+
+func ErrInvalidGroupTypeError(providedType string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidGroup,
+		"Invalid order group type, must be 'oco' or 'bracket'",
+		map[string]interface{}{"field": "type", "provided_value": providedType})
+}
+
+func ErrInvalidGroupError(message string, details map[string]interface{}) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidGroup, message, details)
+}
+
+func ErrAmendQuantityError(quantity int, filled int) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidQuantity,
+		"Amended quantity must exceed the filled quantity",
+		map[string]interface{}{"field": "quantity", "provided_value": quantity, "filled_quantity": filled})
+}
+
+func ErrAmendGroupError(quantity int) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidQuantity,
+		"Quantity of an OCO order or a bracket exit is set by its group",
+		map[string]interface{}{"field": "quantity", "provided_value": quantity})
+}
+
+func ErrAmendPriceError(price Decimal) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidPrice,
+		"Price can only be amended on limit and stop limit orders that are not pegged",
+		map[string]interface{}{"field": "price", "provided_value": price})
+}
+
+func ErrInvalidTimeInForceError(providedTIF string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidTIF,
+		"Invalid time in force, must be 'gtc', 'ioc', 'fok', 'gtd' or 'day' (market orders only 'ioc' or 'fok')",
+		map[string]interface{}{"field": "time_in_force", "provided_value": providedTIF})
+}
+
+func ErrInvalidExpireTimeError(expireTime *time.Time) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidExpiry,
+		"Expire time must be in the future for 'gtd' orders",
+		map[string]interface{}{"field": "expire_time", "provided_value": expireTime})
+}
+
+func ErrPriceOffTickError(field string, price Decimal, tickSize string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrPriceOffTick,
+		"Price must be a multiple of the symbol's tick size",
+		map[string]interface{}{"field": field, "provided_value": price, "tick_size": tickSize})
+}
+
 func ErrMissingPriceError() *HTTPError {
 	return NewHTTPError(http.StatusUnprocessableEntity, ErrMissingPrice,
 		"Price is required for limit orders", nil)
@@ -89,6 +196,108 @@ func ErrOrderNotFoundError(orderID uint64) *HTTPError {
 		map[string]interface{}{"order_id": orderID})
 }
 
+func ErrUnknownSymbolError(symbol string) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, ErrUnknownSymbol,
+		"Symbol is not traded on this engine",
+		map[string]interface{}{"field": "symbol", "provided_value": symbol})
+}
+
 func ErrInternal(message string) *HTTPError {
 	return NewHTTPError(http.StatusInternalServerError, ErrInternalError, message, nil)
 }
+
+func ErrInvalidChannelError(channel string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidChannel,
+		"Channel must be one of: trades, top, depth, orders, auction",
+		map[string]interface{}{"field": "channel", "provided_value": channel})
+}
+
+func ErrPostOnlyWouldCrossError(orderID uint64, price Decimal) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrPostOnlyCross,
+		"Post-only order would take liquidity",
+		map[string]interface{}{"order_id": orderID, "price": price})
+}
+
+func ErrReduceOnlyError(orderID uint64) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrReduceOnly,
+		"Reduce-only order has no opposite position to reduce",
+		map[string]interface{}{"order_id": orderID})
+}
+
+func ErrMinQuantityNotMetError(orderID uint64, minQuantity int) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrMinQuantity,
+		"Minimum quantity is not available to execute",
+		map[string]interface{}{"order_id": orderID, "min_quantity": minQuantity})
+}
+
+func ErrOrderRejectedError(orderID uint64, reason string) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrOrderRejected,
+		"Order rejected by the engine",
+		map[string]interface{}{"order_id": orderID, "reason": reason})
+}
+
+func ErrAuctionInProgressError(orderID uint64) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrAuctionRunning,
+		"Symbol is in an auction; only unpegged limit orders that can rest and stop orders are accepted",
+		map[string]interface{}{"order_id": orderID})
+}
+
+func ErrAuctionRunningError(symbol string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, ErrAuctionRunning,
+		"Symbol is already in an auction",
+		map[string]interface{}{"symbol": symbol})
+}
+
+func ErrNoAuctionError(symbol string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, ErrNoAuction,
+		"Symbol is not in an auction",
+		map[string]interface{}{"symbol": symbol})
+}
+
+func ErrInvalidPhaseError(providedPhase string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrInvalidPhase,
+		"Invalid phase, must be 'pre_open', 'opening_auction', 'continuous', 'closing_auction', 'halted', 'reopening_auction' or 'closed'",
+		map[string]interface{}{"field": "phase", "provided_value": providedPhase})
+}
+
+func ErrOrderNotAllowedInPhaseError(orderID uint64, symbol string, phase string) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrPhaseNotAllowed,
+		"Order type is not accepted in the symbol's trading phase",
+		map[string]interface{}{"order_id": orderID, "symbol": symbol, "phase": phase})
+}
+
+// ErrOutsidePriceBandError reports an order or amend whose limit price is outside the symbol's
+// price bands
+func ErrOutsidePriceBandError(orderID uint64, price Decimal, bands PriceBandsDTO) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrPriceBand,
+		"Limit price is outside the symbol's price bands",
+		map[string]interface{}{"order_id": orderID, "price": price, "bands": bands})
+}
+
+// ErrNoPegReferenceError reports a pegged order whose reference price is missing from the book
+func ErrNoPegReferenceError(orderID uint64, pegType string) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrNoPegReference,
+		"Book has no price for the pegged order to follow",
+		map[string]interface{}{"order_id": orderID, "peg_type": pegType})
+}
+
+// ErrNoTrailReferenceError reports a trailing stop without a stop price on a symbol that has not
+// traded
+func ErrNoTrailReferenceError(orderID uint64) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, ErrNoTrailReference,
+		"Symbol has not traded, so a trailing stop needs a stop_price to start from",
+		map[string]interface{}{"order_id": orderID})
+}
+
+// ErrNotAllowedInPhaseError reports a cancel, amend or auction command the symbol's phase refuses
+func ErrNotAllowedInPhaseError(message string, symbol string, phase string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, ErrPhaseNotAllowed, message,
+		map[string]interface{}{"symbol": symbol, "phase": phase})
+}
+
+// ErrRiskLimitError reports an order or amend that would break one of its user's risk limits in
+// the symbol
+func ErrRiskLimitError(code ErrorCode, message string, orderID uint64, userID string, symbol string, limit interface{}) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, code, message,
+		map[string]interface{}{"order_id": orderID, "user_id": userID, "symbol": symbol, "limit": limit})
+}
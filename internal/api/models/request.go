@@ -2,16 +2,46 @@ package models
 
 import (
 	"strings"
+	"time"
 )
 
 // SubmitOrderRequest represents a single order submission
 type SubmitOrderRequest struct {
 	OrderID   string  `json:"order_id"`
 	UserID    string  `json:"user_id"`
-	OrderType string  `json:"order_type"` // "market" | "limit" | "cancel"
+	Symbol    string  `json:"symbol"`     // defaults to the engine's default symbol
+	OrderType string  `json:"order_type"` // "market" | "limit" | "stop_market" | "stop_limit" | "cancel"
 	Side      string  `json:"side"`       // "buy" | "sell"
-	Price     float64 `json:"price"`
+	Price     Decimal `json:"price"`      // decimal number or string, on the symbol's tick grid
+	StopPrice Decimal `json:"stop_price"` // required for stop orders, except trailing stop_market orders
 	Quantity  int     `json:"quantity"`
+
+	TimeInForce string     `json:"time_in_force"` // "gtc" (default) | "ioc" | "fok" | "gtd" | "day"
+	ExpireTime  *time.Time `json:"expire_time"`   // required for "gtd"
+
+	DisplayQuantity int `json:"display_quantity"` // iceberg slice shown in the book; limit orders only, 0 shows all
+
+	PostOnly      bool `json:"post_only"`       // limit orders only: never take liquidity
+	PostOnlySlide bool `json:"post_only_slide"` // reprice a crossing post-only order one tick behind the best instead of rejecting it
+	ReduceOnly    bool `json:"reduce_only"`     // only reduce the user's net position in the symbol
+	MinQuantity   int  `json:"min_quantity"`    // least quantity that must be executable on arrival; market and limit orders only
+
+	SelfTradePrevention string `json:"self_trade_prevention"` // "allow" | "cancel_newest" | "cancel_oldest" | "cancel_both" | "decrement"; defaults to the server's mode
+
+	// Market and stop-market orders only: matching stops at the tightest bound set, and the
+	// remainder is cancelled with reason "price_protection"
+	ProtectionPrice  Decimal `json:"protection_price"`   // worst acceptable price, on the symbol's tick grid
+	MaxSlippageTicks int     `json:"max_slippage_ticks"` // ticks beyond the opposite best
+	MaxSlippageBps   int     `json:"max_slippage_bps"`   // basis points beyond the opposite best
+
+	// Limit orders that can rest: the engine sets the price from the book and keeps it there
+	PegType   string  `json:"peg_type"`   // "primary" | "market" | "midpoint"; price is then ignored
+	PegOffset Decimal `json:"peg_offset"` // added to the reference price, may be negative
+	PegCap    Decimal `json:"peg_cap"`    // highest price a pegged buy and lowest a pegged sell rests at
+
+	// Stop orders only: the stop price follows the last trade price at one of these distances
+	TrailAmount Decimal `json:"trail_amount"` // fixed distance, on the symbol's tick grid
+	TrailBps    int     `json:"trail_bps"`    // distance in basis points of the last trade price
 }
 
 // Validate validates the order request
@@ -23,7 +53,9 @@ func (r *SubmitOrderRequest) Validate() *HTTPError {
 
 	// Validate order_type
 	orderType := strings.ToLower(strings.TrimSpace(r.OrderType))
-	if orderType != "market" && orderType != "limit" && orderType != "cancel" {
+	switch orderType {
+	case "market", "limit", "stop_market", "stop_limit", "cancel":
+	default:
 		return ErrInvalidOrderTypeError(r.OrderType)
 	}
 
@@ -38,13 +70,108 @@ func (r *SubmitOrderRequest) Validate() *HTTPError {
 		return ErrInvalidQuantityError(r.Quantity)
 	}
 
-	// Validate price for limit orders
-	if orderType == "limit" {
-		if r.Price <= 0 {
+	// Validate price for limit orders; pegged orders take theirs from the book
+	pegType := strings.ToLower(strings.TrimSpace(r.PegType))
+	if (orderType == "limit" && pegType == "") || orderType == "stop_limit" {
+		if r.Price.Float64() <= 0 {
 			return ErrInvalidPriceError(r.Price)
 		}
 	}
 
+	// Validate stop price for stop orders; a trailing stop-market can start from the last trade
+	trailing := r.TrailAmount.Float64() != 0 || r.TrailBps != 0
+	if orderType == "stop_limit" || (orderType == "stop_market" && !trailing) {
+		if r.StopPrice.Float64() <= 0 {
+			return ErrInvalidStopPriceError(r.StopPrice)
+		}
+	}
+
+	// Validate iceberg display quantity
+	if r.DisplayQuantity < 0 || r.DisplayQuantity > r.Quantity ||
+		(r.DisplayQuantity > 0 && orderType != "limit") {
+		return ErrInvalidDisplayQuantityError(r.DisplayQuantity)
+	}
+
+	// Validate time in force; market orders can never rest
+	timeInForce := strings.ToLower(strings.TrimSpace(r.TimeInForce))
+	switch timeInForce {
+	case "", "gtc", "ioc", "fok", "gtd", "day":
+	default:
+		return ErrInvalidTimeInForceError(r.TimeInForce)
+	}
+	if (orderType == "market" || orderType == "stop_market") &&
+		timeInForce != "" && timeInForce != "ioc" && timeInForce != "fok" {
+		return ErrInvalidTimeInForceError(r.TimeInForce)
+	}
+	if timeInForce == "gtd" {
+		if r.ExpireTime == nil || !r.ExpireTime.After(time.Now()) {
+			return ErrInvalidExpireTimeError(r.ExpireTime)
+		}
+	}
+
+	// Validate execution instructions
+	if r.PostOnlySlide && !r.PostOnly {
+		return ErrInvalidExecInstError("post_only_slide", "post_only_slide requires post_only", r.PostOnlySlide)
+	}
+	if r.PostOnly {
+		if orderType != "limit" || timeInForce == "ioc" || timeInForce == "fok" || r.MinQuantity > 0 {
+			return ErrInvalidExecInstError("post_only",
+				"post_only requires a limit order that can rest, without min_quantity", r.PostOnly)
+		}
+	}
+	if r.MinQuantity < 0 || r.MinQuantity > r.Quantity ||
+		(r.MinQuantity > 0 && orderType != "limit" && orderType != "market") {
+		return ErrInvalidExecInstError("min_quantity",
+			"min_quantity must be between 0 and quantity, on market or limit orders", r.MinQuantity)
+	}
+
+	// Validate price protection
+	if r.ProtectionPrice.Float64() < 0 || r.MaxSlippageTicks < 0 || r.MaxSlippageBps < 0 {
+		return ErrInvalidExecInstError("protection_price",
+			"protection_price, max_slippage_ticks and max_slippage_bps cannot be negative", r.ProtectionPrice)
+	}
+	if (r.ProtectionPrice.Float64() > 0 || r.MaxSlippageTicks > 0 || r.MaxSlippageBps > 0) &&
+		orderType != "market" && orderType != "stop_market" {
+		return ErrInvalidExecInstError("protection_price",
+			"price protection applies to market and stop_market orders only", r.ProtectionPrice)
+	}
+
+	// Validate pegging
+	switch pegType {
+	case "", "primary", "market", "midpoint":
+	default:
+		return ErrInvalidPegTypeError(r.PegType)
+	}
+	if pegType != "" &&
+		(orderType != "limit" || timeInForce == "ioc" || timeInForce == "fok" || r.MinQuantity > 0) {
+		return ErrInvalidExecInstError("peg_type",
+			"peg_type requires a limit order that can rest, without min_quantity", r.PegType)
+	}
+	if pegType == "" && (r.PegOffset.Float64() != 0 || r.PegCap.Float64() != 0) {
+		return ErrInvalidExecInstError("peg_offset", "peg_offset and peg_cap require peg_type", r.PegOffset)
+	}
+	if r.PegCap.Float64() < 0 {
+		return ErrInvalidExecInstError("peg_cap", "peg_cap cannot be negative", r.PegCap)
+	}
+
+	// Validate trailing stops
+	if trailing && orderType != "stop_market" && orderType != "stop_limit" {
+		return ErrInvalidExecInstError("trail_amount",
+			"trailing applies to stop_market and stop_limit orders only", r.TrailAmount)
+	}
+	if r.TrailAmount.Float64() < 0 || r.TrailBps < 0 || r.TrailBps >= 10000 ||
+		(r.TrailAmount.Float64() > 0 && r.TrailBps > 0) {
+		return ErrInvalidExecInstError("trail_amount",
+			"set one of trail_amount or trail_bps, positive and with trail_bps below 10000", r.TrailAmount)
+	}
+
+	// Validate self-trade prevention
+	switch strings.ToLower(strings.TrimSpace(r.SelfTradePrevention)) {
+	case "", "allow", "cancel_newest", "cancel_oldest", "cancel_both", "decrement":
+	default:
+		return ErrInvalidSelfTradeModeError(r.SelfTradePrevention)
+	}
+
 	if orderType == "cancel" {
 		if strings.TrimSpace(r.OrderID) == "" {
 			return ErrInvalidOrderIdError(r.OrderID)
@@ -54,6 +181,103 @@ func (r *SubmitOrderRequest) Validate() *HTTPError {
 	return nil
 }
 
+// OrderGroupRequest submits orders that the engine links as a group
+type OrderGroupRequest struct {
+	Type   string               `json:"type"`   // "oco" | "bracket"
+	Orders []SubmitOrderRequest `json:"orders"` // oco: two legs of equal quantity; bracket: the entry, then one or two exits
+}
+
+// Validate validates the group request and each of its orders
+func (r *OrderGroupRequest) Validate() *HTTPError {
+	groupType := strings.ToLower(strings.TrimSpace(r.Type))
+	switch groupType {
+	case "oco":
+		if len(r.Orders) != 2 {
+			return ErrInvalidGroupError("An oco group takes exactly two orders",
+				map[string]interface{}{"field": "orders", "provided_size": len(r.Orders)})
+		}
+	case "bracket":
+		if len(r.Orders) < 2 || len(r.Orders) > 3 {
+			return ErrInvalidGroupError("A bracket takes an entry order and one or two exit orders",
+				map[string]interface{}{"field": "orders", "provided_size": len(r.Orders)})
+		}
+	default:
+		return ErrInvalidGroupTypeError(r.Type)
+	}
+
+	for i := range r.Orders {
+		order := &r.Orders[i]
+		if httpErr := order.Validate(); httpErr != nil {
+			return httpErr
+		}
+		if order.UserID != r.Orders[0].UserID || order.Symbol != r.Orders[0].Symbol {
+			return ErrInvalidGroupError("Orders of a group must share user_id and symbol",
+				map[string]interface{}{"field": "orders", "index": i})
+		}
+		orderType := strings.ToLower(strings.TrimSpace(order.OrderType))
+		if orderType == "cancel" || order.PegType != "" || order.ReduceOnly || order.MinQuantity > 0 {
+			return ErrInvalidGroupError("Grouped orders cannot be cancels, pegged, reduce_only or carry min_quantity",
+				map[string]interface{}{"field": "orders", "index": i})
+		}
+
+		// Legs wait in the book or the trigger book for their turn, so they must be able to rest
+		if groupType == "bracket" && i == 0 {
+			continue
+		}
+		timeInForce := strings.ToLower(strings.TrimSpace(order.TimeInForce))
+		if orderType == "market" || (orderType == "limit" && (timeInForce == "ioc" || timeInForce == "fok")) {
+			return ErrInvalidGroupError("OCO orders and bracket exits must be limit orders that can rest, or stop orders",
+				map[string]interface{}{"field": "orders", "index": i})
+		}
+		if groupType == "oco" && order.Quantity != r.Orders[0].Quantity {
+			return ErrInvalidGroupError("Both orders of an oco group must have the same quantity",
+				map[string]interface{}{"field": "orders", "index": i})
+		}
+		if groupType == "bracket" && strings.EqualFold(strings.TrimSpace(order.Side), strings.TrimSpace(r.Orders[0].Side)) {
+			return ErrInvalidGroupError("Bracket exits must be on the opposite side of the entry",
+				map[string]interface{}{"field": "orders", "index": i})
+		}
+	}
+	return nil
+}
+
+// AmendOrderRequest changes the limit price and/or total quantity of a working order
+type AmendOrderRequest struct {
+	Price    Decimal `json:"price,omitempty"`    // new limit price; omitted keeps the current price
+	Quantity int     `json:"quantity,omitempty"` // new total quantity, including filled; omitted keeps the current quantity
+}
+
+// Validate validates the amend request
+func (r *AmendOrderRequest) Validate() *HTTPError {
+	if r.Price == "" && r.Quantity == 0 {
+		return ErrBadRequest("price or quantity is required", nil)
+	}
+
+	if r.Quantity < 0 {
+		return ErrInvalidQuantityError(r.Quantity)
+	}
+
+	if r.Price != "" && r.Price.Float64() <= 0 {
+		return ErrInvalidPriceError(r.Price)
+	}
+
+	return nil
+}
+
+// SetPhaseRequest represents a manual change of a symbol's trading phase
+type SetPhaseRequest struct {
+	Phase string `json:"phase"` // "pre_open" | "opening_auction" | "continuous" | "closing_auction" | "halted" | "reopening_auction" | "closed"
+}
+
+// Validate validates the phase request
+func (r *SetPhaseRequest) Validate() *HTTPError {
+	switch r.Phase {
+	case "pre_open", "opening_auction", "continuous", "closing_auction", "halted", "reopening_auction", "closed":
+		return nil
+	}
+	return ErrInvalidPhaseError(r.Phase)
+}
+
 // BatchOrderRequest represents a batch order submission
 type BatchOrderRequest struct {
 	Orders []SubmitOrderRequest `json:"orders"`
@@ -12,18 +12,38 @@ type BaseResponse struct {
 
 // TradeDTO represents a trade in API responses
 type TradeDTO struct {
-	BuyOrderID  uint64    `json:"buy_order_id"`
-	SellOrderID uint64    `json:"sell_order_id"`
-	Price       float64   `json:"price"`
-	Quantity    int       `json:"quantity"`
-	Timestamp   time.Time `json:"timestamp"`
+	TradeID       uint64    `json:"trade_id"`
+	Symbol        string    `json:"symbol,omitempty"`
+	BuyOrderID    uint64    `json:"buy_order_id"`
+	SellOrderID   uint64    `json:"sell_order_id"`
+	Price         Decimal   `json:"price"`
+	Quantity      int       `json:"quantity"`
+	Timestamp     time.Time `json:"timestamp"`
+	AggressorSide string    `json:"aggressor_side,omitempty"` // "buy" | "sell", empty for an auction uncross
+	MakerOrderID  uint64    `json:"maker_order_id"`
+	TakerOrderID  uint64    `json:"taker_order_id"`
+	MakerUserID   string    `json:"maker_user_id"`
+	TakerUserID   string    `json:"taker_user_id"`
+	MakerFee      Decimal   `json:"maker_fee"` // Negative for a rebate
+	TakerFee      Decimal   `json:"taker_fee"`
 }
 
 // SubmitOrderResponse represents the response for order submission
 type SubmitOrderResponse struct {
 	BaseResponse
-	OrderID uint64      `json:"order_id,omitempty"`
-	Trades  []TradeDTO  `json:"trades,omitempty"`
+	OrderID uint64     `json:"order_id,omitempty"`
+	Trades  []TradeDTO `json:"trades,omitempty"`
+
+	SelfTradeCancelled []uint64 `json:"self_trade_cancelled,omitempty"` // Orders, possibly this one, cancelled by self-trade prevention
+
+	OrderOutcome
+}
+
+// OrderOutcome is where a submitted order stands once the engine has processed it
+type OrderOutcome struct {
+	Status            string `json:"status,omitempty"`
+	CancelledQuantity int    `json:"cancelled_quantity,omitempty"` // Unfilled quantity cancelled, such as a market order's remainder
+	CancelReason      string `json:"cancel_reason,omitempty"`
 }
 
 // BatchOrderResult represents a single order result in batch submission
@@ -33,6 +53,10 @@ type BatchOrderResult struct {
 	OrderID uint64     `json:"order_id,omitempty"`
 	Trades  []TradeDTO `json:"trades,omitempty"`
 	Error   *APIError  `json:"error,omitempty"`
+
+	SelfTradeCancelled []uint64 `json:"self_trade_cancelled,omitempty"`
+
+	OrderOutcome
 }
 
 // BatchOrderSummary provides summary statistics for batch submission
@@ -49,25 +73,63 @@ type BatchOrderResponse struct {
 	Summary BatchOrderSummary  `json:"summary"`
 }
 
+// OrderGroupResponse represents the response for an order group submission
+type OrderGroupResponse struct {
+	BaseResponse
+	GroupID   uint64     `json:"group_id,omitempty"`
+	GroupType string     `json:"group_type,omitempty"`
+	Orders    []OrderDTO `json:"orders,omitempty"` // Each order once the group was placed, in request order
+	Trades    []TradeDTO `json:"trades,omitempty"`
+}
+
 // CancelOrderResponse represents the response for order cancellation
 type CancelOrderResponse struct {
 	BaseResponse
 	OrderID uint64 `json:"order_id,omitempty"`
 }
 
+// AmendOrderResponse represents the response for an order amendment
+type AmendOrderResponse struct {
+	BaseResponse
+	Order  *OrderDTO  `json:"order,omitempty"`
+	Trades []TradeDTO `json:"trades,omitempty"`
+}
+
 // OrderDTO represents an order in API responses
 type OrderDTO struct {
-	OrderID           uint64    `json:"order_id"`
-	UserID            string    `json:"user_id"`
-	Symbol            string    `json:"symbol"`
-	OrderType         string    `json:"order_type"`
-	Side              string    `json:"side"`
-	Price             float64   `json:"price"`
-	Quantity          int       `json:"quantity"`
-	FilledQuantity    int       `json:"filled_quantity,omitempty"`
-	RemainingQuantity int       `json:"remaining_quantity,omitempty"`
-	Status            string    `json:"status,omitempty"`
-	Timestamp         time.Time `json:"timestamp"`
+	OrderID             uint64     `json:"order_id"`
+	UserID              string     `json:"user_id"`
+	Symbol              string     `json:"symbol"`
+	OrderType           string     `json:"order_type"`
+	Side                string     `json:"side"`
+	Price               Decimal    `json:"price"`
+	StopPrice           Decimal    `json:"stop_price,omitempty"`
+	Quantity            int        `json:"quantity"`
+	FilledQuantity      int        `json:"filled_quantity,omitempty"`
+	RemainingQuantity   int        `json:"remaining_quantity,omitempty"`
+	DisplayQuantity     int        `json:"display_quantity,omitempty"`
+	PostOnly            bool       `json:"post_only,omitempty"`
+	PostOnlySlide       bool       `json:"post_only_slide,omitempty"`
+	ReduceOnly          bool       `json:"reduce_only,omitempty"`
+	MinQuantity         int        `json:"min_quantity,omitempty"`
+	SelfTradePrevention string     `json:"self_trade_prevention,omitempty"`
+	ProtectionPrice     Decimal    `json:"protection_price,omitempty"`
+	MaxSlippageTicks    int        `json:"max_slippage_ticks,omitempty"`
+	MaxSlippageBps      int        `json:"max_slippage_bps,omitempty"`
+	PegType             string     `json:"peg_type,omitempty"`
+	PegOffset           Decimal    `json:"peg_offset,omitempty"`
+	PegCap              Decimal    `json:"peg_cap,omitempty"`
+	TrailAmount         Decimal    `json:"trail_amount,omitempty"`
+	TrailBps            int        `json:"trail_bps,omitempty"`
+	GroupType           string     `json:"group_type,omitempty"`
+	GroupID             uint64     `json:"group_id,omitempty"`
+	RejectReason        string     `json:"reject_reason,omitempty"`
+	CancelReason        string     `json:"cancel_reason,omitempty"`
+	AvgFillPrice        Decimal    `json:"avg_fill_price,omitempty"`
+	Status              string     `json:"status,omitempty"`
+	Timestamp           time.Time  `json:"timestamp"`
+	TimeInForce         string     `json:"time_in_force,omitempty"`
+	ExpireTime          *time.Time `json:"expire_time,omitempty"`
 }
 
 // GetOrderResponse represents the response for getting a single order
@@ -85,7 +147,7 @@ type GetOrdersResponse struct {
 
 // PriceLevel represents a price level in the order book
 type PriceLevel struct {
-	Price      float64 `json:"price"`
+	Price      Decimal `json:"price"`
 	Quantity   int     `json:"quantity"`
 	OrderCount int     `json:"order_count"`
 }
@@ -93,16 +155,16 @@ type PriceLevel struct {
 // OrderBookResponse represents the full order book
 type OrderBookResponse struct {
 	BaseResponse
-	Symbol    string       `json:"symbol"`
-	Bids      []PriceLevel `json:"bids"`
-	Asks      []PriceLevel `json:"asks"`
-	Spread    float64      `json:"spread,omitempty"`
-	MidPrice  float64      `json:"mid_price,omitempty"`
+	Symbol   string       `json:"symbol"`
+	Bids     []PriceLevel `json:"bids"`
+	Asks     []PriceLevel `json:"asks"`
+	Spread   Decimal      `json:"spread,omitempty"`
+	MidPrice Decimal      `json:"mid_price,omitempty"`
 }
 
 // BestQuote represents the best bid or ask
 type BestQuote struct {
-	Price    float64 `json:"price"`
+	Price    Decimal `json:"price"`
 	Quantity int     `json:"quantity"`
 }
 
@@ -112,8 +174,8 @@ type TopOfBookResponse struct {
 	Symbol   string     `json:"symbol"`
 	BestBid  *BestQuote `json:"best_bid,omitempty"`
 	BestAsk  *BestQuote `json:"best_ask,omitempty"`
-	Spread   float64    `json:"spread,omitempty"`
-	MidPrice float64    `json:"mid_price,omitempty"`
+	Spread   Decimal    `json:"spread,omitempty"`
+	MidPrice Decimal    `json:"mid_price,omitempty"`
 }
 
 // GetTradesResponse represents the response for getting trades
@@ -123,6 +185,85 @@ type GetTradesResponse struct {
 	Count  int        `json:"count"`
 }
 
+// AuctionDTO represents a symbol's call auction. While it runs, the price, volume and imbalance
+// are the indicative uncross; after an uncross they are what executed.
+type AuctionDTO struct {
+	Symbol    string  `json:"symbol"`
+	Running   bool    `json:"running"`
+	Price     Decimal `json:"price,omitempty"`
+	Volume    int     `json:"volume"`
+	Imbalance int     `json:"imbalance"` // Buy minus sell quantity at the price
+}
+
+// AuctionResponse represents the state of a symbol's auction
+type AuctionResponse struct {
+	BaseResponse
+	Auction AuctionDTO `json:"auction"`
+}
+
+// UncrossResponse represents the result of uncrossing an auction
+type UncrossResponse struct {
+	BaseResponse
+	Auction AuctionDTO `json:"auction"`
+	Trades  []TradeDTO `json:"trades"`
+}
+
+// SessionDTO represents a symbol's trading phase and what it allows
+type SessionDTO struct {
+	Symbol     string   `json:"symbol"`
+	Phase      string   `json:"phase"`
+	OrderTypes []string `json:"order_types"` // Order types accepted in the phase
+	Cancels    bool     `json:"cancels"`     // Whether orders may be cancelled or amended
+	Auction    bool     `json:"auction"`     // Whether orders collect for an uncross
+}
+
+// SessionResponse represents the trading phase of a symbol
+type SessionResponse struct {
+	BaseResponse
+	Session SessionDTO `json:"session"`
+}
+
+// SetPhaseResponse represents the result of a phase change, with the trades of any uncross
+type SetPhaseResponse struct {
+	BaseResponse
+	Session SessionDTO `json:"session"`
+	Trades  []TradeDTO `json:"trades"`
+}
+
+// PriceBandsDTO represents a symbol's price bands; bounds are left out for bands that are not set
+type PriceBandsDTO struct {
+	Symbol      string     `json:"symbol"`
+	Reference   Decimal    `json:"reference_price,omitempty"` // Centre of the static band
+	StaticLow   Decimal    `json:"static_low,omitempty"`
+	StaticHigh  Decimal    `json:"static_high,omitempty"`
+	DynamicLow  Decimal    `json:"dynamic_low,omitempty"`
+	DynamicHigh Decimal    `json:"dynamic_high,omitempty"`
+	ReopenAt    *time.Time `json:"reopen_at,omitempty"` // When a volatility halt or reopening auction moves on
+}
+
+// PriceBandsResponse represents the price bands of a symbol
+type PriceBandsResponse struct {
+	BaseResponse
+	Bands PriceBandsDTO `json:"bands"`
+}
+
+// FeesDTO represents a user's fee tier in a symbol; rates are in basis points, negative for a rebate
+type FeesDTO struct {
+	UserID    string `json:"user_id"`
+	Symbol    string `json:"symbol"`
+	Tier      string `json:"tier,omitempty"` // Empty when the symbol charges no fees
+	Assigned  bool   `json:"assigned"`       // Tier assigned to the user rather than reached by volume
+	MakerBps  int    `json:"maker_bps"`
+	TakerBps  int    `json:"taker_bps"`
+	Volume30d int64  `json:"volume_30d"` // Quantity traded in the symbol over the last 30 UTC days
+}
+
+// FeesResponse represents a user's fee tier in a symbol
+type FeesResponse struct {
+	BaseResponse
+	Fees FeesDTO `json:"fees"`
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status        string    `json:"status"`
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Stream channels a client can subscribe to
+const (
+	ChannelTrades  = "trades"  // Trades for a symbol
+	ChannelTop     = "top"     // Best bid and ask for a symbol, sent when either changes
+	ChannelDepth   = "depth"   // Full L2 snapshot for a symbol, then incremental level updates
+	ChannelOrders  = "orders"  // Updates to one user's orders across all symbols
+	ChannelAuction = "auction" // Auction state and indicative uncross for a symbol
+)
+
+// Stream message types sent to clients
+const (
+	StreamSubscribed    = "subscribed"
+	StreamUnsubscribed  = "unsubscribed"
+	StreamError         = "error"
+	StreamTrade         = "trade"
+	StreamTop           = "top"
+	StreamDepthSnapshot = "depth_snapshot"
+	StreamDepthUpdate   = "depth_update"
+	StreamOrder         = "order"
+	StreamAuction       = "auction"
+)
+
+// StreamRequest is a subscription request sent by a stream client
+type StreamRequest struct {
+	Op      string `json:"op"`                // subscribe or unsubscribe
+	Channel string `json:"channel"`           // trades, top, depth, orders or auction
+	Symbol  string `json:"symbol,omitempty"`  // Defaults to the default symbol; unused by orders
+	UserID  string `json:"user_id,omitempty"` // Required by orders
+}
+
+// StreamMessage is a message pushed to a stream client
+type StreamMessage struct {
+	Type      string      `json:"type"`
+	Channel   string      `json:"channel,omitempty"`
+	Symbol    string      `json:"symbol,omitempty"`
+	UserID    string      `json:"user_id,omitempty"`
+	Seq       uint64      `json:"seq,omitempty"` // Book sequence, for top and depth messages
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+}
+
+// StreamTopOfBook is the data of a top message
+type StreamTopOfBook struct {
+	BestBid *BestQuote `json:"best_bid,omitempty"`
+	BestAsk *BestQuote `json:"best_ask,omitempty"`
+}
+
+// StreamDepth is the data of depth_snapshot and depth_update messages. In an update, a level
+// with quantity 0 has been removed.
+type StreamDepth struct {
+	Bids []PriceLevel `json:"bids"`
+	Asks []PriceLevel `json:"asks"`
+}
@@ -34,12 +34,22 @@ func SetupRoutes(engineHolder *handlers.EngineHolder) http.Handler {
 		}
 	})
 
+	mux.HandleFunc("/api/v1/orders/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			engineHolder.OrderGroupHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/api/v1/orders/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			engineHolder.GetOrderHandler(w, r)
 		case http.MethodDelete:
 			engineHolder.CancelOrderHandler(w, r)
+		case http.MethodPatch:
+			engineHolder.AmendOrderHandler(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -62,6 +72,64 @@ func SetupRoutes(engineHolder *handlers.EngineHolder) http.Handler {
 		}
 	})
 
+	// Auction endpoints
+	mux.HandleFunc("/api/v1/auction", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			engineHolder.GetAuctionHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/auction/start", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			engineHolder.StartAuctionHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/auction/uncross", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			engineHolder.UncrossHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Trading session endpoints
+	mux.HandleFunc("/api/v1/session", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			engineHolder.GetSessionHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/admin/phase", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			engineHolder.SetPhaseHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/bands", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			engineHolder.GetPriceBandsHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/fees", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			engineHolder.GetFeesHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Trade endpoints
 	mux.HandleFunc("/api/v1/trades", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
@@ -71,6 +139,15 @@ func SetupRoutes(engineHolder *handlers.EngineHolder) http.Handler {
 		}
 	})
 
+	// Streaming endpoint
+	mux.HandleFunc("/api/v1/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			engineHolder.StreamHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Apply middleware (order matters: Recovery -> CORS -> Logging -> Handler)
 	handler := middleware.Recovery(mux)
 	handler = middleware.CORS(handler)
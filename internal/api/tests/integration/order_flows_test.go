@@ -3,10 +3,14 @@ package integration
 import (
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/PxPatel/trading-system/internal/api/models"
 	"github.com/PxPatel/trading-system/internal/api/tests/testutils"
+	"github.com/PxPatel/trading-system/internal/matching"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,7 +38,7 @@ func TestSimpleMarketOrderFlow(t *testing.T) {
 	assert.True(t, buyResp.Success)
 	assert.NotZero(t, buyResp.OrderID)
 	assert.Len(t, buyResp.Trades, 1, "Should have 1 trade")
-	assert.Equal(t, 100.0, buyResp.Trades[0].Price, "Should execute at best ask price")
+	assert.Equal(t, models.Decimal("100.00"), buyResp.Trades[0].Price, "Should execute at best ask price")
 	assert.Equal(t, 10, buyResp.Trades[0].Quantity)
 
 	// Step 3: Verify orderbook still has the second sell order
@@ -77,10 +81,10 @@ func TestLimitOrderAddToBookFlow(t *testing.T) {
 	assert.True(t, ob.Success)
 	assert.Len(t, ob.Bids, 1)
 	assert.Len(t, ob.Asks, 1)
-	assert.Equal(t, 99.0, ob.Bids[0].Price)
-	assert.Equal(t, 101.0, ob.Asks[0].Price)
-	assert.Equal(t, 2.0, ob.Spread)
-	assert.Equal(t, 100.0, ob.MidPrice)
+	assert.Equal(t, models.Decimal("99.00"), ob.Bids[0].Price)
+	assert.Equal(t, models.Decimal("101.00"), ob.Asks[0].Price)
+	assert.Equal(t, models.Decimal("2.00"), ob.Spread)
+	assert.Equal(t, models.Decimal("100.00"), ob.MidPrice)
 }
 
 // TestAggressiveLimitOrderFlow tests limit orders that match immediately
@@ -101,7 +105,7 @@ func TestAggressiveLimitOrderFlow(t *testing.T) {
 
 	assert.True(t, buyResp.Success)
 	assert.Len(t, buyResp.Trades, 1)
-	assert.Equal(t, 100.0, buyResp.Trades[0].Price)
+	assert.Equal(t, models.Decimal("100.00"), buyResp.Trades[0].Price)
 	assert.Equal(t, 10, buyResp.Trades[0].Quantity)
 
 	// Verify remaining quantity in orderbook
@@ -136,9 +140,28 @@ func TestPartialFillFlow(t *testing.T) {
 	totalFilled := buyResp.Trades[0].Quantity + buyResp.Trades[1].Quantity
 	assert.Equal(t, 13, totalFilled, "Should fill 5 + 8 = 13")
 
+	// Trades are numbered and name the resting order as maker
+	for i, maker := range []string{"alice", "bob"} {
+		trade := buyResp.Trades[i]
+		assert.Equal(t, uint64(i+1), trade.TradeID)
+		assert.Equal(t, "buy", trade.AggressorSide)
+		assert.Equal(t, buyResp.OrderID, trade.TakerOrderID)
+		assert.Equal(t, trade.SellOrderID, trade.MakerOrderID)
+		assert.Equal(t, "charlie", trade.TakerUserID)
+		assert.Equal(t, maker, trade.MakerUserID)
+	}
+
 	// Verify trades persist to disk
 	persistedTrades := ts.ReadTradeLog()
-	assert.Len(t, persistedTrades, 2, "Trades should be persisted")
+	require.Len(t, persistedTrades, 2, "Trades should be persisted")
+	assert.Equal(t, uint64(2), persistedTrades[1].ID)
+	assert.Equal(t, "bob", persistedTrades[1].MakerUserID)
+
+	tradesResp := ts.Get("/api/v1/trades")
+	var recent models.GetTradesResponse
+	testutils.DecodeJSON(t, tradesResp, &recent)
+	require.Len(t, recent.Trades, 2)
+	assert.Equal(t, uint64(2), recent.Trades[0].TradeID, "Newest trade first")
 }
 
 // TestOrderCancellationFlow tests cancelling orders
@@ -242,7 +265,7 @@ func TestCrossedOrderBookFlow(t *testing.T) {
 
 	// Should match at seller's price (100.0), not buyer's price
 	assert.Len(t, buyResp.Trades, 1)
-	assert.Equal(t, 100.0, buyResp.Trades[0].Price, "Should execute at resting order price")
+	assert.Equal(t, models.Decimal("100.00"), buyResp.Trades[0].Price, "Should execute at resting order price")
 	assert.Equal(t, 10, buyResp.Trades[0].Quantity)
 
 	// Book should be empty
@@ -270,11 +293,11 @@ func TestMultiLevelExecutionFlow(t *testing.T) {
 	assert.Len(t, buyResp.Trades, 3, "Should match 3 price levels")
 
 	// Verify trade prices (price improvement - executes at resting order prices)
-	assert.Equal(t, 100.0, buyResp.Trades[0].Price)
+	assert.Equal(t, models.Decimal("100.00"), buyResp.Trades[0].Price)
 	assert.Equal(t, 5, buyResp.Trades[0].Quantity)
-	assert.Equal(t, 101.0, buyResp.Trades[1].Price)
+	assert.Equal(t, models.Decimal("101.00"), buyResp.Trades[1].Price)
 	assert.Equal(t, 10, buyResp.Trades[1].Quantity)
-	assert.Equal(t, 102.0, buyResp.Trades[2].Price)
+	assert.Equal(t, models.Decimal("102.00"), buyResp.Trades[2].Price)
 	assert.Equal(t, 3, buyResp.Trades[2].Quantity)
 
 	// Verify remaining asks
@@ -283,6 +306,1045 @@ func TestMultiLevelExecutionFlow(t *testing.T) {
 	testutils.DecodeJSON(t, obResp, &ob)
 
 	assert.Len(t, ob.Asks, 1, "One ask level should remain")
-	assert.Equal(t, 102.0, ob.Asks[0].Price)
+	assert.Equal(t, models.Decimal("102.00"), ob.Asks[0].Price)
 	assert.Equal(t, 5, ob.Asks[0].Quantity, "5 units remain from original 8")
 }
+
+// TestMultiSymbolFlow tests that books and trades are separated by symbol
+func TestMultiSymbolFlow(t *testing.T) {
+	ts := testutils.NewTestServerWithSymbols(t, "COOTX", "ABCD")
+	defer ts.Close()
+
+	sell := testutils.NewLimitSellOrder("alice", 100.0, 10)
+	sell.Symbol = "ABCD"
+	resp := ts.Post("/api/v1/orders", sell)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Default symbol book is untouched
+	obResp := ts.Get("/api/v1/orderbook")
+	var defaultBook models.OrderBookResponse
+	testutils.DecodeJSON(t, obResp, &defaultBook)
+	assert.Equal(t, "COOTX", defaultBook.Symbol)
+	assert.Len(t, defaultBook.Asks, 0)
+
+	obResp = ts.Get("/api/v1/orderbook?symbol=abcd")
+	var abcdBook models.OrderBookResponse
+	testutils.DecodeJSON(t, obResp, &abcdBook)
+	assert.Equal(t, "ABCD", abcdBook.Symbol)
+	require.Len(t, abcdBook.Asks, 1)
+	assert.Equal(t, models.Decimal("100.00"), abcdBook.Asks[0].Price)
+
+	// Match on ABCD
+	buy := testutils.NewMarketBuyOrder("bob", 10)
+	buy.Symbol = "ABCD"
+	resp = ts.Post("/api/v1/orders", buy)
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &buyResp)
+	require.Len(t, buyResp.Trades, 1)
+	assert.Equal(t, "ABCD", buyResp.Trades[0].Symbol)
+
+	tradesResp := ts.Get("/api/v1/trades?symbol=COOTX")
+	var cootxTrades models.GetTradesResponse
+	testutils.DecodeJSON(t, tradesResp, &cootxTrades)
+	assert.Equal(t, 0, cootxTrades.Count)
+
+	tradesResp = ts.Get("/api/v1/trades?symbol=ABCD")
+	var abcdTrades models.GetTradesResponse
+	testutils.DecodeJSON(t, tradesResp, &abcdTrades)
+	assert.Equal(t, 1, abcdTrades.Count)
+}
+
+// TestUnknownSymbolFlow tests that unknown symbols are rejected
+func TestUnknownSymbolFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	order := testutils.NewLimitBuyOrder("alice", 99.0, 10)
+	order.Symbol = "NOPE"
+	resp := ts.Post("/api/v1/orders", order)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	require.NotNil(t, errResp.Error)
+	assert.Equal(t, models.ErrUnknownSymbol, errResp.Error.Code)
+
+	obResp := ts.Get("/api/v1/orderbook?symbol=NOPE")
+	assert.Equal(t, http.StatusNotFound, obResp.StatusCode)
+	obResp.Body.Close()
+}
+
+// TestStopOrderFlow tests submitting a stop order and triggering it via a trade
+func TestStopOrderFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0, 5)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 102.0, 5)).Body.Close()
+
+	stop := models.SubmitOrderRequest{
+		UserID:    "carol",
+		OrderType: "stop_market",
+		Side:      "buy",
+		StopPrice: "100",
+		Quantity:  5,
+	}
+	resp := ts.Post("/api/v1/orders", stop)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stopResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &stopResp)
+	assert.Len(t, stopResp.Trades, 0, "Stop should rest until triggered")
+
+	buy := ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder("bob", 5))
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, buy, &buyResp)
+	require.Len(t, buyResp.Trades, 2, "Trade at 100 should fire the stop")
+	assert.Equal(t, stopResp.OrderID, buyResp.Trades[1].BuyOrderID)
+	assert.Equal(t, models.Decimal("102.00"), buyResp.Trades[1].Price)
+
+	// Missing stop price is rejected
+	stop.StopPrice = ""
+	bad := ts.Post("/api/v1/orders", stop)
+	require.Equal(t, http.StatusBadRequest, bad.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, bad, &errResp)
+	assert.Equal(t, models.ErrInvalidStopPrice, errResp.Error.Code)
+}
+
+// TestTimeInForceFlow tests IOC and FOK handling through the API
+func TestTimeInForceFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0, 5)).Body.Close()
+
+	// FOK larger than available depth is killed
+	fok := testutils.NewLimitBuyOrder("bob", 100.0, 10)
+	fok.TimeInForce = "fok"
+	resp := ts.Post("/api/v1/orders", fok)
+	var fokResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &fokResp)
+	assert.Len(t, fokResp.Trades, 0)
+
+	// IOC fills what it can and leaves nothing behind
+	ioc := testutils.NewLimitBuyOrder("bob", 100.0, 10)
+	ioc.TimeInForce = "ioc"
+	resp = ts.Post("/api/v1/orders", ioc)
+	var iocResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &iocResp)
+	require.Len(t, iocResp.Trades, 1)
+	assert.Equal(t, 5, iocResp.Trades[0].Quantity)
+
+	bidLevels, askLevels := ts.GetOrderBookDepth()
+	assert.Equal(t, 0, bidLevels)
+	assert.Equal(t, 0, askLevels)
+
+	// GTD without an expiry is rejected
+	gtd := testutils.NewLimitBuyOrder("bob", 99.0, 10)
+	gtd.TimeInForce = "gtd"
+	resp = ts.Post("/api/v1/orders", gtd)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidExpiry, errResp.Error.Code)
+
+	// Market orders cannot be GTC
+	market := testutils.NewMarketBuyOrder("bob", 1)
+	market.TimeInForce = "gtc"
+	resp = ts.Post("/api/v1/orders", market)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// TestTickSizeFlow tests fixed-point prices and tick size validation through the API
+func TestTickSizeFlow(t *testing.T) {
+	inst, err := matching.NewInstrument("ABCD", 2, "0.05")
+	require.NoError(t, err)
+	ts := testutils.NewTestServerWithInstruments(t, inst)
+	defer ts.Close()
+
+	// Prices off the 0.05 grid are rejected
+	offTick := testutils.NewLimitSellOrder("alice", 100.03, 10)
+	offTick.Symbol = "ABCD"
+	resp := ts.Post("/api/v1/orders", offTick)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrPriceOffTick, errResp.Error.Code)
+
+	// Equal decimal prices land on one level however they are written
+	first := testutils.NewLimitSellOrder("alice", 0.3, 10)
+	first.Symbol = "ABCD"
+	second := first
+	second.Price = "0.30"
+	ts.Post("/api/v1/orders", first).Body.Close()
+	ts.Post("/api/v1/orders", second).Body.Close()
+
+	obResp := ts.Get("/api/v1/orderbook?symbol=ABCD")
+	var book models.OrderBookResponse
+	testutils.DecodeJSON(t, obResp, &book)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, models.Decimal("0.30"), book.Asks[0].Price)
+	assert.Equal(t, 2, book.Asks[0].OrderCount)
+
+	// Prices may also be sent as decimal strings
+	resp = ts.Post("/api/v1/orders", map[string]interface{}{
+		"user_id": "bob", "symbol": "ABCD", "order_type": "limit", "side": "buy", "price": "0.25", "quantity": 10,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Anything but plain decimal digits is rejected with a well-formed error
+	for _, price := range []string{"-Inf", "NaN", "+1", "0x1p3", "1e2"} {
+		resp = ts.Post("/api/v1/orders", map[string]interface{}{
+			"user_id": "bob", "symbol": "ABCD", "order_type": "limit", "side": "buy", "price": price, "quantity": 10,
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, price)
+		var badResp models.BaseResponse
+		testutils.DecodeJSON(t, resp, &badResp)
+		assert.Equal(t, models.ErrInvalidRequest, badResp.Error.Code, price)
+	}
+
+	// Mid price keeps the extra half-unit digit
+
+	topResp := ts.Get("/api/v1/orderbook/top?symbol=ABCD")
+	var top models.TopOfBookResponse
+	testutils.DecodeJSON(t, topResp, &top)
+	assert.Equal(t, models.Decimal("0.05"), top.Spread)
+	assert.Equal(t, models.Decimal("0.275"), top.MidPrice)
+}
+
+// TestParallelClientsFlow tests that concurrent submitters and readers see a consistent book
+func TestParallelClientsFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	const clients, ordersPerClient = 8, 25
+	var filled atomic.Int64
+	var wg sync.WaitGroup
+
+	for c := 0; c < clients; c++ {
+		wg.Add(3)
+
+		// Seller posts unit offers
+		go func(c int) {
+			defer wg.Done()
+			for i := 0; i < ordersPerClient; i++ {
+				resp := ts.Post("/api/v1/orders", testutils.NewLimitSellOrder(fmt.Sprintf("seller%d", c), 100.0, 1))
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				resp.Body.Close()
+			}
+		}(c)
+
+		// Buyer lifts whatever is on offer
+		go func(c int) {
+			defer wg.Done()
+			for i := 0; i < ordersPerClient; i++ {
+				resp := ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder(fmt.Sprintf("buyer%d", c), 1))
+				var result models.SubmitOrderResponse
+				testutils.DecodeJSON(t, resp, &result)
+				for _, trade := range result.Trades {
+					filled.Add(int64(trade.Quantity))
+				}
+			}
+		}(c)
+
+		// Reader polls the book while it changes
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ordersPerClient; i++ {
+				for _, path := range []string{"/api/v1/orderbook", "/api/v1/orderbook/top", "/api/v1/orders"} {
+					resp := ts.Get(path)
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every offered unit is either filled or still resting
+	snapshot, ok := ts.Engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	require.True(t, ok)
+	resting := 0
+	for _, level := range snapshot.Asks {
+		resting += level.Quantity
+	}
+	assert.Equal(t, clients*ordersPerClient, int(filled.Load())+resting)
+	assert.Empty(t, snapshot.Bids)
+}
+
+// TestOrderLifecycleFlow tests order status and fill reporting through the API
+func TestOrderLifecycleFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	submit := func(req interface{}) uint64 {
+		var resp models.SubmitOrderResponse
+		testutils.DecodeJSON(t, ts.Post("/api/v1/orders", req), &resp)
+		require.True(t, resp.Success)
+		return resp.OrderID
+	}
+	getOrder := func(id uint64) *models.OrderDTO {
+		resp := ts.Get(fmt.Sprintf("/api/v1/orders/%d", id))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var orderResp models.GetOrderResponse
+		testutils.DecodeJSON(t, resp, &orderResp)
+		require.NotNil(t, orderResp.Order)
+		return orderResp.Order
+	}
+
+	sellID := submit(testutils.NewLimitSellOrder("alice", 100.0, 5))
+	submit(testutils.NewLimitSellOrder("alice", 101.0, 10))
+	buyID := submit(testutils.NewLimitBuyOrder("bob", 101.0, 8))
+	restingID := submit(testutils.NewLimitBuyOrder("carol", 95.0, 4))
+
+	// Fully filled orders remain queryable
+	sell := getOrder(sellID)
+	assert.Equal(t, "filled", sell.Status)
+	assert.Equal(t, 5, sell.FilledQuantity)
+	assert.Equal(t, 0, sell.RemainingQuantity)
+
+	buy := getOrder(buyID)
+	assert.Equal(t, "filled", buy.Status)
+	assert.Equal(t, 8, buy.Quantity)
+	assert.Equal(t, models.Decimal("100.38"), buy.AvgFillPrice, "5 @ 100.00 and 3 @ 101.00")
+
+	resting := getOrder(restingID)
+	assert.Equal(t, "new", resting.Status)
+	assert.Equal(t, 4, resting.RemainingQuantity)
+	assert.Empty(t, resting.AvgFillPrice)
+
+	cancelResp := ts.Delete(fmt.Sprintf("/api/v1/orders/%d", restingID))
+	require.Equal(t, http.StatusOK, cancelResp.StatusCode)
+	cancelResp.Body.Close()
+
+	resting = getOrder(restingID)
+	assert.Equal(t, "cancelled", resting.Status)
+	assert.Equal(t, 4, resting.Quantity)
+	assert.Equal(t, 0, resting.RemainingQuantity)
+
+	// Filter the order list by status
+	var list models.GetOrdersResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orders?status=partially_filled"), &list)
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, 7, list.Orders[0].RemainingQuantity)
+	assert.Equal(t, 3, list.Orders[0].FilledQuantity)
+
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orders?status=filled"), &list)
+	assert.Len(t, list.Orders, 2)
+}
+
+// TestAmendOrderFlow tests PATCH amendments, including one that crosses the spread
+func TestAmendOrderFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	submit := func(req interface{}) uint64 {
+		var resp models.SubmitOrderResponse
+		testutils.DecodeJSON(t, ts.Post("/api/v1/orders", req), &resp)
+		require.True(t, resp.Success)
+		return resp.OrderID
+	}
+	amend := func(id uint64, req models.AmendOrderRequest) *http.Response {
+		return ts.Patch(fmt.Sprintf("/api/v1/orders/%d", id), req)
+	}
+
+	firstID := submit(testutils.NewLimitBuyOrder("alice", 99.0, 10))
+	secondID := submit(testutils.NewLimitBuyOrder("bob", 99.0, 10))
+	submit(testutils.NewLimitSellOrder("carol", 100.0, 4))
+
+	// Reducing quantity keeps alice first in the queue
+	resp := amend(firstID, models.AmendOrderRequest{Quantity: 6})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var amendResp models.AmendOrderResponse
+	testutils.DecodeJSON(t, resp, &amendResp)
+	require.NotNil(t, amendResp.Order)
+	assert.Equal(t, 6, amendResp.Order.Quantity)
+	assert.Empty(t, amendResp.Trades)
+
+	var sellResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewMarketSellOrder("dave", 2)), &sellResp)
+	require.Len(t, sellResp.Trades, 1)
+	assert.Equal(t, firstID, sellResp.Trades[0].BuyOrderID)
+
+	// Repricing bob through the ask trades at once
+	resp = amend(secondID, models.AmendOrderRequest{Price: "100.00"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	amendResp = models.AmendOrderResponse{}
+	testutils.DecodeJSON(t, resp, &amendResp)
+	require.Len(t, amendResp.Trades, 1)
+	assert.Equal(t, 4, amendResp.Trades[0].Quantity)
+	assert.Equal(t, "partially_filled", amendResp.Order.Status)
+	assert.Equal(t, 6, amendResp.Order.RemainingQuantity)
+
+	tests := []struct {
+		name    string
+		orderID uint64
+		request models.AmendOrderRequest
+		status  int
+		code    models.ErrorCode
+	}{
+		{"NothingToChange", firstID, models.AmendOrderRequest{}, http.StatusBadRequest, models.ErrInvalidRequest},
+		{"BelowFilled", firstID, models.AmendOrderRequest{Quantity: 2}, http.StatusBadRequest, models.ErrInvalidQuantity},
+		{"NegativeQuantity", firstID, models.AmendOrderRequest{Quantity: -1}, http.StatusBadRequest, models.ErrInvalidQuantity},
+		{"OffTick", firstID, models.AmendOrderRequest{Price: "99.005"}, http.StatusBadRequest, models.ErrPriceOffTick},
+		{"UnknownOrder", 999999, models.AmendOrderRequest{Quantity: 5}, http.StatusNotFound, models.ErrOrderNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := amend(tt.orderID, tt.request)
+			require.Equal(t, tt.status, resp.StatusCode)
+			var errResp models.BaseResponse
+			testutils.DecodeJSON(t, resp, &errResp)
+			require.NotNil(t, errResp.Error)
+			assert.Equal(t, tt.code, errResp.Error.Code)
+		})
+	}
+}
+
+// TestIcebergOrderFlow tests that an iceberg shows one slice in the book and reports all fills
+func TestIcebergOrderFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	iceberg := testutils.NewLimitSellOrder("alice", 100.0, 50)
+	iceberg.DisplayQuantity = 10
+	var icebergResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", iceberg), &icebergResp)
+	require.True(t, icebergResp.Success)
+
+	var book models.OrderBookResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orderbook"), &book)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, 10, book.Asks[0].Quantity, "Only the visible slice is shown")
+
+	var top models.TopOfBookResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orderbook/top"), &top)
+	require.NotNil(t, top.BestAsk)
+	assert.Equal(t, 10, top.BestAsk.Quantity)
+
+	// A buy larger than the slice trades through refreshes of the reserve
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder("bob", 25)), &buyResp)
+	assert.Len(t, buyResp.Trades, 3)
+
+	var orderResp models.GetOrderResponse
+	testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", icebergResp.OrderID)), &orderResp)
+	require.NotNil(t, orderResp.Order)
+	assert.Equal(t, "partially_filled", orderResp.Order.Status)
+	assert.Equal(t, 25, orderResp.Order.FilledQuantity)
+	assert.Equal(t, 25, orderResp.Order.RemainingQuantity)
+	assert.Equal(t, 10, orderResp.Order.DisplayQuantity)
+
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orderbook"), &book)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, 5, book.Asks[0].Quantity, "Current slice after 5 of it filled")
+
+	// Display quantity is only valid on limit orders, within the order quantity
+	market := testutils.NewMarketBuyOrder("bob", 10)
+	market.DisplayQuantity = 2
+	oversized := testutils.NewLimitBuyOrder("bob", 99.0, 10)
+	oversized.DisplayQuantity = 11
+	for _, req := range []models.SubmitOrderRequest{market, oversized} {
+		resp := ts.Post("/api/v1/orders", req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var errResp models.BaseResponse
+		testutils.DecodeJSON(t, resp, &errResp)
+		assert.Equal(t, models.ErrInvalidDisplay, errResp.Error.Code)
+	}
+}
+
+// TestExecutionInstructionsFlow tests post-only, reduce-only and minimum-quantity orders
+func TestExecutionInstructionsFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 101.0, 10)).Body.Close()
+
+	requireRejected := func(req models.SubmitOrderRequest, status int, code models.ErrorCode) {
+		t.Helper()
+		resp := ts.Post("/api/v1/orders", req)
+		require.Equal(t, status, resp.StatusCode)
+		var errResp models.BaseResponse
+		testutils.DecodeJSON(t, resp, &errResp)
+		require.NotNil(t, errResp.Error)
+		assert.Equal(t, code, errResp.Error.Code)
+	}
+
+	// A crossing post-only buy is rejected, or slid behind the best ask
+	postOnly := testutils.NewLimitBuyOrder("dave", 101.0, 5)
+	postOnly.PostOnly = true
+	requireRejected(postOnly, http.StatusUnprocessableEntity, models.ErrPostOnlyCross)
+
+	postOnly.PostOnlySlide = true
+	var slideResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", postOnly), &slideResp)
+	require.True(t, slideResp.Success)
+	assert.Empty(t, slideResp.Trades)
+
+	var orderResp models.GetOrderResponse
+	testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", slideResp.OrderID)), &orderResp)
+	require.NotNil(t, orderResp.Order)
+	assert.Equal(t, models.Decimal("100.99"), orderResp.Order.Price)
+	assert.True(t, orderResp.Order.PostOnly)
+	assert.True(t, orderResp.Order.PostOnlySlide)
+
+	// Bob has no position to reduce until he buys
+	reduce := testutils.NewMarketSellOrder("bob", 10)
+	reduce.ReduceOnly = true
+	requireRejected(reduce, http.StatusUnprocessableEntity, models.ErrReduceOnly)
+
+	ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder("bob", 4)).Body.Close()
+	assert.Equal(t, 4, ts.Engine.GetPosition("bob", matching.DefaultSymbol))
+
+	var reduceResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", reduce), &reduceResp)
+	require.True(t, reduceResp.Success)
+	require.Len(t, reduceResp.Trades, 1)
+	assert.Equal(t, 4, reduceResp.Trades[0].Quantity, "Clipped to the position")
+	assert.Equal(t, 0, ts.Engine.GetPosition("bob", matching.DefaultSymbol))
+
+	// Only 6 remain offered, so a minimum of 8 cannot execute
+	minQty := testutils.NewLimitBuyOrder("carol", 101.0, 10)
+	minQty.MinQuantity = 8
+	requireRejected(minQty, http.StatusUnprocessableEntity, models.ErrMinQuantity)
+
+	// Instructions on order types that cannot carry them
+	slideOnly := testutils.NewLimitBuyOrder("bob", 99.0, 5)
+	slideOnly.PostOnlySlide = true
+	postMarket := testutils.NewMarketBuyOrder("bob", 5)
+	postMarket.PostOnly = true
+	overMin := testutils.NewLimitBuyOrder("bob", 99.0, 5)
+	overMin.MinQuantity = 6
+	for _, req := range []models.SubmitOrderRequest{slideOnly, postMarket, overMin} {
+		requireRejected(req, http.StatusBadRequest, models.ErrInvalidExecInst)
+	}
+}
+
+// TestSelfTradePreventionFlow tests that a user's crossing orders are cancelled instead of trading
+func TestSelfTradePreventionFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	var restingResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0, 10)), &restingResp)
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 5)).Body.Close()
+
+	// Alice's own ask is cancelled and her buy trades with bob's behind it
+	buy := testutils.NewLimitBuyOrder("alice", 100.0, 8)
+	buy.SelfTradePrevention = "cancel_oldest"
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", buy), &buyResp)
+	require.True(t, buyResp.Success)
+	require.Len(t, buyResp.Trades, 1)
+	assert.Equal(t, 5, buyResp.Trades[0].Quantity)
+	assert.Equal(t, []uint64{restingResp.OrderID}, buyResp.SelfTradeCancelled)
+
+	var orderResp models.GetOrderResponse
+	testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", restingResp.OrderID)), &orderResp)
+	require.NotNil(t, orderResp.Order)
+	assert.Equal(t, "cancelled", orderResp.Order.Status)
+	assert.Equal(t, "self_trade", orderResp.Order.CancelReason)
+
+	// The remainder rests; an opposing order from alice cancelling itself leaves it alone
+	sell := testutils.NewLimitSellOrder("alice", 100.0, 2)
+	sell.SelfTradePrevention = "cancel_newest"
+	var sellResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", sell), &sellResp)
+	assert.Empty(t, sellResp.Trades)
+	assert.Equal(t, []uint64{sellResp.OrderID}, sellResp.SelfTradeCancelled)
+
+	testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", buyResp.OrderID)), &orderResp)
+	require.NotNil(t, orderResp.Order)
+	assert.Equal(t, "partially_filled", orderResp.Order.Status)
+	assert.Equal(t, 3, orderResp.Order.RemainingQuantity)
+	assert.Equal(t, "cancel_oldest", orderResp.Order.SelfTradePrevention)
+
+	invalid := testutils.NewLimitBuyOrder("alice", 99.0, 1)
+	invalid.SelfTradePrevention = "cancel_everything"
+	resp := ts.Post("/api/v1/orders", invalid)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidSTP, errResp.Error.Code)
+}
+
+// TestAuctionFlow tests collecting orders in an auction, the indicative uncross and the uncross
+func TestAuctionFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	var auctionResp models.AuctionResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/auction/start", nil), &auctionResp)
+	require.True(t, auctionResp.Success)
+	assert.True(t, auctionResp.Auction.Running)
+
+	resp := ts.Post("/api/v1/auction/start", nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Crossing orders rest without trading
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 101.0, 10)), &buyResp)
+	assert.Empty(t, buyResp.Trades)
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 6)).Body.Close()
+
+	// Market orders cannot wait for the uncross
+	resp = ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder("carol", 5))
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrAuctionRunning, errResp.Error.Code)
+
+	testutils.DecodeJSON(t, ts.Get("/api/v1/auction"), &auctionResp)
+	assert.Equal(t, models.AuctionDTO{Symbol: "COOTX", Running: true, Price: "100.00", Volume: 6, Imbalance: 4}, auctionResp.Auction)
+
+	var uncrossResp models.UncrossResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/auction/uncross", nil), &uncrossResp)
+	require.True(t, uncrossResp.Success)
+	assert.Equal(t, models.AuctionDTO{Symbol: "COOTX", Price: "100.00", Volume: 6, Imbalance: 4}, uncrossResp.Auction)
+	require.Len(t, uncrossResp.Trades, 1)
+	assert.Equal(t, models.Decimal("100.00"), uncrossResp.Trades[0].Price)
+	assert.Equal(t, 6, uncrossResp.Trades[0].Quantity)
+
+	resp = ts.Post("/api/v1/auction/uncross", nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrNoAuction, errResp.Error.Code)
+}
+
+func TestTradingPhaseFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	var sessionResp models.SessionResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/session"), &sessionResp)
+	require.True(t, sessionResp.Success)
+	assert.Equal(t, "continuous", sessionResp.Session.Phase)
+
+	resp := ts.Post("/api/v1/admin/phase", map[string]string{"phase": "lunch"})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Pre-open collects orders for the opening auction
+	var phaseResp models.SetPhaseResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/admin/phase", map[string]string{"phase": "pre_open"}), &phaseResp)
+	require.True(t, phaseResp.Success)
+	assert.Equal(t, models.SessionDTO{
+		Symbol:     "COOTX",
+		Phase:      "pre_open",
+		OrderTypes: []string{"limit", "stop_market", "stop_limit"},
+		Cancels:    true,
+		Auction:    true,
+	}, phaseResp.Session)
+
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 101.0, 10)), &buyResp)
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 6)).Body.Close()
+
+	resp = ts.Post("/api/v1/orders", testutils.NewMarketBuyOrder("carol", 5))
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrPhaseNotAllowed, errResp.Error.Code)
+
+	// The opening auction refuses cancels and manual uncrosses
+	ts.Post("/api/v1/admin/phase", map[string]string{"phase": "opening_auction"}).Body.Close()
+	resp = ts.Delete(fmt.Sprintf("/api/v1/orders/%d", buyResp.OrderID))
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrPhaseNotAllowed, errResp.Error.Code)
+	assert.Equal(t, "opening_auction", errResp.Error.Details["phase"])
+
+	resp = ts.Post("/api/v1/auction/uncross", nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Continuous trading opens with the uncross
+	testutils.DecodeJSON(t, ts.Post("/api/v1/admin/phase", map[string]string{"phase": "continuous"}), &phaseResp)
+	require.Len(t, phaseResp.Trades, 1)
+	assert.Equal(t, models.Decimal("100.00"), phaseResp.Trades[0].Price)
+	assert.Equal(t, 6, phaseResp.Trades[0].Quantity)
+
+	// A halt accepts cancels only
+	testutils.DecodeJSON(t, ts.Post("/api/v1/admin/phase", map[string]string{"phase": "halted"}), &phaseResp)
+	assert.Empty(t, phaseResp.Session.OrderTypes)
+	resp = ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 101.0, 4))
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp.Body.Close()
+	resp = ts.Delete(fmt.Sprintf("/api/v1/orders/%d", buyResp.OrderID))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// TestPriceBandFlow tests rejections outside the price bands and a volatility halt
+func TestPriceBandFlow(t *testing.T) {
+	ts := testutils.NewTestServerWithPriceBands(t, matching.PriceBands{
+		StaticBps:    500,
+		DynamicBps:   100,
+		HaltMoveBps:  150,
+		HaltWindow:   time.Minute,
+		HaltDuration: time.Minute,
+	})
+	defer ts.Close()
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 1)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 100.0, 1)).Body.Close()
+
+	var bandsResp models.PriceBandsResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/bands"), &bandsResp)
+	require.True(t, bandsResp.Success)
+	assert.Equal(t, models.PriceBandsDTO{
+		Symbol:      "COOTX",
+		Reference:   "100.00",
+		StaticLow:   "95.00",
+		StaticHigh:  "105.00",
+		DynamicLow:  "99.00",
+		DynamicHigh: "101.00",
+	}, bandsResp.Bands)
+
+	// A bid through the dynamic band is rejected with the bands in the details
+	resp := ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 102.0, 1))
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrPriceBand, errResp.Error.Code)
+	assert.Equal(t, 102.0, errResp.Error.Details["price"])
+	assert.NotNil(t, errResp.Error.Details["bands"])
+
+	// Walking the price up 1.6% halts the symbol
+	for _, price := range []float64{101.0, 101.6} {
+		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", price, 1)).Body.Close()
+		ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", price, 1)).Body.Close()
+	}
+	var sessionResp models.SessionResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/session"), &sessionResp)
+	assert.Equal(t, "halted", sessionResp.Session.Phase)
+
+	testutils.DecodeJSON(t, ts.Get("/api/v1/bands"), &bandsResp)
+	require.NotNil(t, bandsResp.Bands.ReopenAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *bandsResp.Bands.ReopenAt, 10*time.Second)
+}
+
+// TestMarketProtectionFlow tests that a protected market order's response reports its cancelled
+// remainder and why
+func TestMarketProtectionFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 5)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.5, 5)).Body.Close()
+
+	order := testutils.NewMarketBuyOrder("alice", 10)
+	order.MaxSlippageTicks = 20
+	var submitResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", order), &submitResp)
+	require.True(t, submitResp.Success)
+	require.Len(t, submitResp.Trades, 1)
+	assert.Equal(t, "cancelled", submitResp.Status)
+	assert.Equal(t, 5, submitResp.CancelledQuantity)
+	assert.Equal(t, "price_protection", submitResp.CancelReason)
+
+	// Once the book runs out the remainder is cancelled for lack of liquidity
+	order = testutils.NewMarketBuyOrder("alice", 10)
+	order.ProtectionPrice = "101.00"
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", order), &submitResp)
+	assert.Equal(t, 5, submitResp.CancelledQuantity)
+	assert.Equal(t, "no_liquidity", submitResp.CancelReason)
+
+	// Protection only applies to market orders
+	limit := testutils.NewLimitBuyOrder("alice", 100.0, 1)
+	limit.MaxSlippageBps = 10
+	resp := ts.Post("/api/v1/orders", limit)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidExecInst, errResp.Error.Code)
+}
+
+// TestPeggedOrderFlow tests that a pegged order rests at its reference and follows the book
+func TestPeggedOrderFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	// Without a bid there is nothing for a primary peg to follow
+	peg := models.SubmitOrderRequest{UserID: "alice", OrderType: "limit", Side: "buy", Quantity: 5, PegType: "primary"}
+	resp := ts.Post("/api/v1/orders", peg)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrNoPegReference, errResp.Error.Code)
+
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 99.0, 5)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 101.0, 5)).Body.Close()
+
+	peg.PegOffset = "0.10"
+	var submitResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", peg), &submitResp)
+	require.True(t, submitResp.Success)
+	assert.Empty(t, submitResp.Trades)
+
+	getOrder := func() *models.OrderDTO {
+		var orderResp models.GetOrderResponse
+		testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", submitResp.OrderID)), &orderResp)
+		require.NotNil(t, orderResp.Order)
+		return orderResp.Order
+	}
+	order := getOrder()
+	assert.Equal(t, models.Decimal("99.10"), order.Price)
+	assert.Equal(t, "primary", order.PegType)
+	assert.Equal(t, models.Decimal("0.10"), order.PegOffset)
+
+	// A better bid moves the peg up with it
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 99.5, 5)).Body.Close()
+	assert.Equal(t, models.Decimal("99.60"), getOrder().Price)
+
+	var ob models.OrderBookResponse
+	testutils.DecodeJSON(t, ts.Get("/api/v1/orderbook"), &ob)
+	require.Len(t, ob.Bids, 3)
+	assert.Equal(t, models.Decimal("99.60"), ob.Bids[0].Price)
+
+	// Pegging only applies to limit orders that can rest
+	ioc := models.SubmitOrderRequest{UserID: "alice", OrderType: "limit", Side: "buy", Quantity: 5, PegType: "midpoint", TimeInForce: "ioc"}
+	resp = ts.Post("/api/v1/orders", ioc)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidExecInst, errResp.Error.Code)
+}
+
+// TestTrailingStopFlow tests that a trailing stop's trigger follows trades and shows on the order
+func TestTrailingStopFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	stop := models.SubmitOrderRequest{UserID: "carol", OrderType: "stop_market", Side: "sell", Quantity: 1, TrailAmount: "1.00"}
+
+	// Before the symbol trades there is nothing to trail
+	resp := ts.Post("/api/v1/orders", stop)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrNoTrailReference, errResp.Error.Code)
+
+	tradeAt := func(price float64) {
+		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", price, 1)).Body.Close()
+		ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", price, 1)).Body.Close()
+	}
+	tradeAt(100.0)
+
+	var submitResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", stop), &submitResp)
+	require.True(t, submitResp.Success)
+
+	getOrder := func() *models.OrderDTO {
+		var orderResp models.GetOrderResponse
+		testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", submitResp.OrderID)), &orderResp)
+		require.NotNil(t, orderResp.Order)
+		return orderResp.Order
+	}
+	order := getOrder()
+	assert.Equal(t, models.Decimal("99.00"), order.StopPrice)
+	assert.Equal(t, models.Decimal("1.00"), order.TrailAmount)
+
+	// The trigger rises with the market and holds as it falls back
+	tradeAt(102.0)
+	assert.Equal(t, models.Decimal("101.00"), getOrder().StopPrice)
+	tradeAt(101.5)
+	assert.Equal(t, models.Decimal("101.00"), getOrder().StopPrice)
+
+	// Trailing only applies to stop orders
+	limit := testutils.NewLimitBuyOrder("alice", 100.0, 1)
+	limit.TrailBps = 50
+	resp = ts.Post("/api/v1/orders", limit)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidExecInst, errResp.Error.Code)
+}
+
+// TestOrderGroupFlow tests a bracket whose exits activate as the entry fills, and an OCO pair
+// that cancels its other leg once one fills
+func TestOrderGroupFlow(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	entry := testutils.NewLimitBuyOrder("carol", 100.0, 5)
+	takeProfit := testutils.NewLimitSellOrder("carol", 105.0, 5)
+	stopLoss := models.SubmitOrderRequest{UserID: "carol", OrderType: "stop_market", Side: "sell", Quantity: 5, StopPrice: "95.00"}
+
+	var groupResp models.OrderGroupResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders/groups", models.OrderGroupRequest{
+		Type:   "bracket",
+		Orders: []models.SubmitOrderRequest{entry, takeProfit, stopLoss},
+	}), &groupResp)
+	require.True(t, groupResp.Success)
+	require.Len(t, groupResp.Orders, 3)
+	assert.Equal(t, "bracket", groupResp.GroupType)
+	assert.Equal(t, groupResp.Orders[0].OrderID, groupResp.GroupID)
+	assert.Equal(t, "new", groupResp.Orders[0].Status)
+	assert.Equal(t, "pending", groupResp.Orders[1].Status)
+	assert.Equal(t, "pending", groupResp.Orders[2].Status)
+
+	getOrder := func(orderID uint64) *models.OrderDTO {
+		var orderResp models.GetOrderResponse
+		testutils.DecodeJSON(t, ts.Get(fmt.Sprintf("/api/v1/orders/%d", orderID)), &orderResp)
+		require.NotNil(t, orderResp.Order)
+		return orderResp.Order
+	}
+
+	// A partial fill of the entry activates the exits at the filled quantity
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 100.0, 2)).Body.Close()
+	order := getOrder(groupResp.Orders[1].OrderID)
+	assert.Equal(t, "new", order.Status)
+	assert.Equal(t, 2, order.Quantity)
+	assert.Equal(t, groupResp.GroupID, order.GroupID)
+	assert.Equal(t, "bracket", order.GroupType)
+
+	// The take-profit closing the position ends the bracket
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 105.0, 2)).Body.Close()
+	assert.Equal(t, "filled", getOrder(groupResp.Orders[1].OrderID).Status)
+	for _, order := range []*models.OrderDTO{getOrder(groupResp.Orders[0].OrderID), getOrder(groupResp.Orders[2].OrderID)} {
+		assert.Equal(t, "cancelled", order.Status)
+		assert.Equal(t, "order_group", order.CancelReason)
+	}
+
+	// An OCO pair shares its quantity, so filling one leg cancels the other
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders/groups", models.OrderGroupRequest{
+		Type: "oco",
+		Orders: []models.SubmitOrderRequest{
+			testutils.NewLimitSellOrder("carol", 110.0, 3),
+			{UserID: "carol", OrderType: "stop_market", Side: "sell", Quantity: 3, StopPrice: "90.00"},
+		},
+	}), &groupResp)
+	require.True(t, groupResp.Success)
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 110.0, 3)).Body.Close()
+	assert.Equal(t, "cancelled", getOrder(groupResp.Orders[1].OrderID).Status)
+
+	// Legs of an OCO pair must share one quantity
+	resp := ts.Post("/api/v1/orders/groups", models.OrderGroupRequest{
+		Type: "oco",
+		Orders: []models.SubmitOrderRequest{
+			testutils.NewLimitSellOrder("carol", 110.0, 3),
+			testutils.NewLimitBuyOrder("carol", 90.0, 2),
+		},
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var errResp models.BaseResponse
+	testutils.DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, models.ErrInvalidGroup, errResp.Error.Code)
+}
+
+// TestFeesFlow tests maker and taker fees on trades, in the trade log, and a user's tier as their
+// volume grows
+func TestFeesFlow(t *testing.T) {
+	ts := testutils.NewTestServerWithFees(t, matching.FeeSchedule{
+		Tiers: []matching.FeeTier{
+			{Name: "standard", MinVolume: 0, MakerBps: -1, TakerBps: 5},
+			{Name: "pro", MinVolume: 10, MakerBps: 0, TakerBps: 3},
+		},
+	})
+	defer ts.Close()
+
+	getFees := func(userID string) models.FeesDTO {
+		var feesResp models.FeesResponse
+		testutils.DecodeJSON(t, ts.Get("/api/v1/fees?user_id="+userID), &feesResp)
+		require.True(t, feesResp.Success)
+		return feesResp.Fees
+	}
+	assert.Equal(t, models.FeesDTO{UserID: "bob", Symbol: "COOTX", Tier: "standard", MakerBps: -1, TakerBps: 5}, getFees("bob"))
+
+	// Notional 1005.00: bob's taker fee rounds up and alice's rebate towards zero
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.5, 20)).Body.Close()
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 100.5, 10)), &buyResp)
+	require.Len(t, buyResp.Trades, 1)
+	assert.Equal(t, models.Decimal("-0.10"), buyResp.Trades[0].MakerFee)
+	assert.Equal(t, models.Decimal("0.51"), buyResp.Trades[0].TakerFee)
+
+	persisted := ts.ReadTradeLog()
+	require.Len(t, persisted, 1)
+	assert.Equal(t, matching.Price(-10), persisted[0].MakerFee)
+	assert.Equal(t, matching.Price(51), persisted[0].TakerFee)
+
+	// Bob's volume has reached the pro tier for his next trade
+	fees := getFees("bob")
+	assert.Equal(t, "pro", fees.Tier)
+	assert.Equal(t, int64(10), fees.Volume30d)
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 100.5, 10)), &buyResp)
+	require.Len(t, buyResp.Trades, 1)
+	assert.Equal(t, models.Decimal("0.31"), buyResp.Trades[0].TakerFee)
+	assert.Equal(t, models.Decimal("0.00"), buyResp.Trades[0].MakerFee)
+
+	resp := ts.Get("/api/v1/fees")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// TestRiskLimitsFlow tests that each risk limit rejects orders and amends with its own code,
+// naming the limit, and that users with their own limits are checked against those
+func TestRiskLimitsFlow(t *testing.T) {
+	ts := testutils.NewTestServerWithRisk(t, matching.RiskRules{
+		Default: matching.RiskLimits{
+			MaxOrderQuantity:     100,
+			MaxOrderNotional:     500000, // 5000.00
+			MaxPriceDeviationBps: 500,
+			MaxOpenOrders:        3,
+			MaxOpenNotional:      800000, // 8000.00
+		},
+		Users: map[string]matching.RiskLimits{"mm": {MaxOrderQuantity: 1000}},
+	})
+	defer ts.Close()
+
+	requireRisk := func(resp *http.Response, code models.ErrorCode, limit interface{}) {
+		t.Helper()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		var errResp models.BaseResponse
+		testutils.DecodeJSON(t, resp, &errResp)
+		require.NotNil(t, errResp.Error)
+		assert.Equal(t, code, errResp.Error.Code)
+		assert.Equal(t, limit, errResp.Error.Details["limit"])
+	}
+
+	requireRisk(ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 10.0, 101)), models.ErrRiskMaxOrderQuantity, 100.0)
+	requireRisk(ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 60.0, 100)), models.ErrRiskMaxOrderNotional, 5000.0)
+
+	// The market maker may send larger orders, and no other limit applies to it
+	var mmResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("mm", 100.0, 1000)), &mmResp)
+	require.True(t, mmResp.Success)
+
+	// Buys more than 5% through the 100.00 ask are refused
+	requireRisk(ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 105.01, 1)), models.ErrRiskPriceDeviation, 500.0)
+
+	var first, second models.SubmitOrderResponse
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 90.0, 40)), &first)
+	testutils.DecodeJSON(t, ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 90.0, 40)), &second)
+	requireRisk(ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 90.0, 10)), models.ErrRiskMaxOpenNotional, 8000.0)
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 110.0, 1)).Body.Close()
+	requireRisk(ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 110.0, 1)), models.ErrRiskMaxOpenOrders, 3.0)
+
+	// An amend raising the quantity is checked like a new order
+	requireRisk(ts.Patch(fmt.Sprintf("/api/v1/orders/%d", first.OrderID), models.AmendOrderRequest{Quantity: 50}),
+		models.ErrRiskMaxOpenNotional, 8000.0)
+	resp := ts.Patch(fmt.Sprintf("/api/v1/orders/%d", first.OrderID), models.AmendOrderRequest{Quantity: 30})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// A group is rejected as a whole
+	requireRisk(ts.Post("/api/v1/orders/groups", models.OrderGroupRequest{
+		Type: "oco",
+		Orders: []models.SubmitOrderRequest{
+			testutils.NewLimitSellOrder("alice", 120.0, 1),
+			{UserID: "alice", OrderType: "stop_market", Side: "sell", Quantity: 1, StopPrice: "80.00"},
+		},
+	}), models.ErrRiskMaxOpenOrders, 3.0)
+	working := 0
+	for _, order := range ts.Engine.GetOrdersByUser("alice") {
+		if !order.Status.IsTerminal() {
+			working++
+		}
+	}
+	assert.Equal(t, 3, working)
+}
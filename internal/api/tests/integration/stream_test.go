@@ -0,0 +1,236 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/api/handlers"
+	"github.com/PxPatel/trading-system/internal/api/models"
+	"github.com/PxPatel/trading-system/internal/api/tests/testutils"
+	"github.com/PxPatel/trading-system/internal/matching"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamMessage is a stream message with its data left encoded
+type streamMessage struct {
+	models.StreamMessage
+	Data json.RawMessage `json:"data"`
+}
+
+func readStream(t *testing.T, conn *websocket.Conn) streamMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg streamMessage
+	require.NoError(t, conn.ReadJSON(&msg), "Failed to read stream message")
+	return msg
+}
+
+func subscribe(t *testing.T, conn *websocket.Conn, req models.StreamRequest) {
+	t.Helper()
+	req.Op = "subscribe"
+	require.NoError(t, conn.WriteJSON(req))
+	ack := readStream(t, conn)
+	require.Equal(t, models.StreamSubscribed, ack.Type, "Unexpected reply %+v", ack)
+}
+
+func decodeData(t *testing.T, msg streamMessage, target interface{}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(msg.Data, target))
+}
+
+// TestStreamTradesAndOrders tests trade and own-order updates pushed after REST submissions
+func TestStreamTradesAndOrders(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	conn := ts.Dial()
+	defer conn.Close()
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelTrades})
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelOrders, UserID: "bob"})
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0, 10)).Body.Close()
+	resp := ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 100.0, 4))
+	var buyResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &buyResp)
+
+	// Alice's resting order is not bob's, so the trade comes first
+	msg := readStream(t, conn)
+	require.Equal(t, models.StreamTrade, msg.Type)
+	var trade models.TradeDTO
+	decodeData(t, msg, &trade)
+	assert.Equal(t, models.Decimal("100.00"), trade.Price)
+	assert.Equal(t, 4, trade.Quantity)
+	assert.Equal(t, buyResp.OrderID, trade.BuyOrderID)
+
+	msg = readStream(t, conn)
+	require.Equal(t, models.StreamOrder, msg.Type)
+	var order models.OrderDTO
+	decodeData(t, msg, &order)
+	assert.Equal(t, buyResp.OrderID, order.OrderID)
+	assert.Equal(t, "filled", order.Status)
+	assert.Equal(t, 4, order.FilledQuantity)
+}
+
+// TestStreamDepthSnapshotAndUpdates tests an L2 snapshot followed by sequenced level updates
+func TestStreamDepthSnapshotAndUpdates(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	resp := ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 99.0, 10))
+	var bidResp models.SubmitOrderResponse
+	testutils.DecodeJSON(t, resp, &bidResp)
+
+	conn := ts.Dial()
+	defer conn.Close()
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelDepth, Symbol: matching.DefaultSymbol})
+
+	snapshot := readStream(t, conn)
+	require.Equal(t, models.StreamDepthSnapshot, snapshot.Type)
+	var depth models.StreamDepth
+	decodeData(t, snapshot, &depth)
+	assert.Equal(t, []models.PriceLevel{{Price: "99.00", Quantity: 10, OrderCount: 1}}, depth.Bids)
+	assert.Empty(t, depth.Asks)
+
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 101.0, 5)).Body.Close()
+	update := readStream(t, conn)
+	require.Equal(t, models.StreamDepthUpdate, update.Type)
+	assert.Equal(t, snapshot.Seq+1, update.Seq)
+	decodeData(t, update, &depth)
+	assert.Empty(t, depth.Bids)
+	assert.Equal(t, []models.PriceLevel{{Price: "101.00", Quantity: 5, OrderCount: 1}}, depth.Asks)
+
+	ts.Delete(fmt.Sprintf("/api/v1/orders/%d", bidResp.OrderID)).Body.Close()
+	update = readStream(t, conn)
+	assert.Equal(t, snapshot.Seq+2, update.Seq)
+	decodeData(t, update, &depth)
+	assert.Equal(t, []models.PriceLevel{{Price: "99.00", Quantity: 0, OrderCount: 0}}, depth.Bids, "Removed level has quantity 0")
+}
+
+// TestStreamTopOfBook tests that top of book is pushed only when the best quotes change
+func TestStreamTopOfBook(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	conn := ts.Dial()
+	defer conn.Close()
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelTop})
+
+	initial := readStream(t, conn)
+	require.Equal(t, models.StreamTop, initial.Type)
+	var top models.StreamTopOfBook
+	decodeData(t, initial, &top)
+	assert.Nil(t, top.BestBid)
+	assert.Nil(t, top.BestAsk)
+
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 99.0, 10)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", 98.0, 10)).Body.Close() // Behind the best bid
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", 101.0, 5)).Body.Close()
+
+	msg := readStream(t, conn)
+	decodeData(t, msg, &top)
+	require.NotNil(t, top.BestBid)
+	assert.Equal(t, models.Decimal("99.00"), top.BestBid.Price)
+	assert.Nil(t, top.BestAsk)
+
+	msg = readStream(t, conn)
+	assert.Equal(t, initial.Seq+3, msg.Seq, "The 98.00 bid did not change the top")
+	decodeData(t, msg, &top)
+	require.NotNil(t, top.BestAsk)
+	assert.Equal(t, models.Decimal("101.00"), top.BestAsk.Price)
+	assert.Equal(t, 5, top.BestAsk.Quantity)
+}
+
+// TestStreamRequestErrors tests error replies to invalid subscriptions
+func TestStreamRequestErrors(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	conn := ts.Dial()
+	defer conn.Close()
+
+	tests := []struct {
+		name    string
+		request interface{}
+		code    models.ErrorCode
+	}{
+		{"UnknownChannel", models.StreamRequest{Op: "subscribe", Channel: "quotes"}, models.ErrInvalidChannel},
+		{"UnknownSymbol", models.StreamRequest{Op: "subscribe", Channel: models.ChannelTrades, Symbol: "NOPE"}, models.ErrUnknownSymbol},
+		{"OrdersWithoutUser", models.StreamRequest{Op: "subscribe", Channel: models.ChannelOrders}, models.ErrInvalidRequest},
+		{"UnknownOp", models.StreamRequest{Op: "watch", Channel: models.ChannelTrades}, models.ErrInvalidRequest},
+		{"Malformed", "not a request", models.ErrInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, conn.WriteJSON(tt.request))
+			msg := readStream(t, conn)
+			require.Equal(t, models.StreamError, msg.Type)
+			require.NotNil(t, msg.Error)
+			assert.Equal(t, tt.code, msg.Error.Code)
+		})
+	}
+}
+
+// TestStreamSlowConsumer tests that a client that stops reading is disconnected
+func TestStreamSlowConsumer(t *testing.T) {
+	ts := testutils.NewTestServerWithStream(t, handlers.StreamConfig{
+		SendBuffer:   4,
+		WriteTimeout: 100 * time.Millisecond,
+	})
+	defer ts.Close()
+
+	conn := ts.Dial()
+	defer conn.Close()
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelDepth})
+	readStream(t, conn) // Snapshot
+
+	// Stop reading and flood the book until the server gives up on the client
+	for i := 0; i < 200000 && ts.Holder.Stream.ClientCount() > 0; i++ {
+		order := matching.NewOrder(ts.Engine.GenerateOrderID(), "alice", matching.LimitOrder, matching.Buy, 9900, 1)
+		ts.Engine.PlaceOrder(order)
+		ts.Engine.CancelOrder(order.ID)
+	}
+	require.Eventually(t, func() bool { return ts.Holder.Stream.ClientCount() == 0 },
+		2*time.Second, 10*time.Millisecond, "Slow consumer still connected")
+
+	// The book is unaffected and REST still answers
+	resp := ts.Get("/api/v1/orderbook")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// TestStreamAuction tests the auction state on subscribe and indicative uncross updates
+func TestStreamAuction(t *testing.T) {
+	ts := testutils.NewTestServer(t)
+	defer ts.Close()
+
+	conn := ts.Dial()
+	defer conn.Close()
+	subscribe(t, conn, models.StreamRequest{Channel: models.ChannelAuction})
+
+	msg := readStream(t, conn)
+	require.Equal(t, models.StreamAuction, msg.Type)
+	var auction models.AuctionDTO
+	decodeData(t, msg, &auction)
+	assert.False(t, auction.Running)
+
+	ts.Post("/api/v1/auction/start", nil).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0, 10)).Body.Close()
+	ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("bob", 100.5, 4)).Body.Close()
+
+	var updates []models.AuctionDTO
+	for len(updates) < 3 {
+		msg = readStream(t, conn)
+		require.Equal(t, models.StreamAuction, msg.Type)
+		decodeData(t, msg, &auction)
+		updates = append(updates, auction)
+	}
+	assert.True(t, updates[0].Running)
+	assert.Equal(t, 0, updates[1].Volume)
+	assert.Equal(t, models.AuctionDTO{Symbol: "COOTX", Running: true, Price: "100.00", Volume: 4, Imbalance: -6}, updates[2])
+}
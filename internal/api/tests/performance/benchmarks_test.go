@@ -21,7 +21,7 @@ func BenchmarkOrderSubmissionThroughput(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		order := testutils.NewLimitBuyOrder("user", 100.0+float64(i%100)*0.01, 10)
+		order := testutils.NewLimitBuyOrder("user", float64(10000+i%100)/100, 10)
 		resp := ts.Post("/api/v1/orders", order)
 		require.Equal(b, 200, resp.StatusCode)
 		resp.Body.Close()
@@ -38,7 +38,7 @@ func BenchmarkMarketOrderExecution(b *testing.B) {
 
 	// Pre-populate orderbook with liquidity
 	for i := 0; i < 100; i++ {
-		price := 100.0 + float64(i)*0.01
+		price := float64(10000+i) / 100
 		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", price, 10))
 	}
 
@@ -62,8 +62,8 @@ func BenchmarkOrderBookSnapshot(b *testing.B) {
 
 	// Populate orderbook with 50 levels each side
 	for i := 0; i < 50; i++ {
-		bidPrice := 99.0 - float64(i)*0.01
-		askPrice := 101.0 + float64(i)*0.01
+		bidPrice := float64(9900-i) / 100
+		askPrice := float64(10100+i) / 100
 		ts.Post("/api/v1/orders", testutils.NewLimitBuyOrder("alice", bidPrice, 10))
 		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("bob", askPrice, 10))
 	}
@@ -120,7 +120,7 @@ func BenchmarkConcurrentOrderSubmission(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		i := 0
 		for pb.Next() {
-			order := testutils.NewLimitBuyOrder("user", 100.0+float64(i%100)*0.01, 10)
+			order := testutils.NewLimitBuyOrder("user", float64(10000+i%100)/100, 10)
 			resp := ts.Post("/api/v1/orders", order)
 			require.Equal(b, 200, resp.StatusCode)
 			resp.Body.Close()
@@ -148,7 +148,7 @@ func TestHighFrequencyTradingSimulation(t *testing.T) {
 	// Liquidity provider: continuously adds limit orders
 	liquidityProvider := func() {
 		for start := time.Now(); time.Since(start) < duration; {
-			price := 100.0 + float64(time.Now().UnixNano()%100)*0.01
+			price := float64(10000+time.Now().UnixNano()%100) / 100
 			ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("lp", price, 10))
 			orderCount.Add(1)
 			time.Sleep(10 * time.Millisecond)
@@ -238,7 +238,7 @@ func TestLoadStressTest(t *testing.T) {
 			for i := 0; i < ordersPerWorker; i++ {
 				order := testutils.NewLimitBuyOrder(
 					fmt.Sprintf("worker%d", workerID),
-					100.0+float64(i%50)*0.01,
+					float64(10000+i%50)/100,
 					5,
 				)
 
@@ -281,7 +281,7 @@ func TestLatencyMeasurement(t *testing.T) {
 
 	// Pre-populate with liquidity
 	for i := 0; i < 50; i++ {
-		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", 100.0+float64(i)*0.01, 10))
+		ts.Post("/api/v1/orders", testutils.NewLimitSellOrder("alice", float64(10000+i)/100, 10))
 	}
 
 	numRequests := 1000
@@ -12,7 +12,6 @@ func NewMarketBuyOrder(userID string, quantity int) models.SubmitOrderRequest {
 		UserID:    userID,
 		OrderType: "market",
 		Side:      "buy",
-		Price:     0,
 		Quantity:  quantity,
 	}
 }
@@ -23,7 +22,6 @@ func NewMarketSellOrder(userID string, quantity int) models.SubmitOrderRequest {
 		UserID:    userID,
 		OrderType: "market",
 		Side:      "sell",
-		Price:     0,
 		Quantity:  quantity,
 	}
 }
@@ -34,7 +32,7 @@ func NewLimitBuyOrder(userID string, price float64, quantity int) models.SubmitO
 		UserID:    userID,
 		OrderType: "limit",
 		Side:      "buy",
-		Price:     price,
+		Price:     models.DecimalFromFloat(price),
 		Quantity:  quantity,
 	}
 }
@@ -45,7 +43,7 @@ func NewLimitSellOrder(userID string, price float64, quantity int) models.Submit
 		UserID:    userID,
 		OrderType: "limit",
 		Side:      "sell",
-		Price:     price,
+		Price:     models.DecimalFromFloat(price),
 		Quantity:  quantity,
 	}
 }
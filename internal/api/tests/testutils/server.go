@@ -8,11 +8,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/PxPatel/trading-system/internal/api/handlers"
 	"github.com/PxPatel/trading-system/internal/api/routes"
 	"github.com/PxPatel/trading-system/internal/matching"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,30 +23,79 @@ import (
 type TestServer struct {
 	Server       *httptest.Server
 	Engine       *matching.Engine
+	Holder       *handlers.EngineHolder
 	TradeLogPath string
 	t            testing.TB
 }
 
 // NewTestServer creates a new test server with a fresh engine
 func NewTestServer(t testing.TB) *TestServer {
+	return NewTestServerWithSymbols(t, matching.DefaultSymbol)
+}
+
+// NewTestServerWithSymbols creates a new test server with a book for each symbol
+func NewTestServerWithSymbols(t testing.TB, symbols ...string) *TestServer {
+	return newTestServer(t, &matching.EngineConfig{Symbols: symbols})
+}
+
+// NewTestServerWithInstruments creates a new test server with custom price precision and tick sizes
+func NewTestServerWithInstruments(t testing.TB, instruments ...matching.Instrument) *TestServer {
+	return newTestServer(t, &matching.EngineConfig{Instruments: instruments})
+}
+
+// NewTestServerWithPriceBands creates a new test server whose default symbol has price bands
+func NewTestServerWithPriceBands(t testing.TB, bands matching.PriceBands) *TestServer {
+	return newTestServer(t, &matching.EngineConfig{
+		Symbols:    []string{matching.DefaultSymbol},
+		PriceBands: map[string]matching.PriceBands{matching.DefaultSymbol: bands},
+	})
+}
+
+// NewTestServerWithFees creates a new test server whose default symbol charges fees
+func NewTestServerWithFees(t testing.TB, schedule matching.FeeSchedule) *TestServer {
+	return newTestServer(t, &matching.EngineConfig{
+		Symbols: []string{matching.DefaultSymbol},
+		Fees:    map[string]matching.FeeSchedule{matching.DefaultSymbol: schedule},
+	})
+}
+
+// NewTestServerWithRisk creates a new test server whose default symbol checks risk limits
+func NewTestServerWithRisk(t testing.TB, rules matching.RiskRules) *TestServer {
+	return newTestServer(t, &matching.EngineConfig{
+		Symbols: []string{matching.DefaultSymbol},
+		Risk:    map[string]matching.RiskRules{matching.DefaultSymbol: rules},
+	})
+}
+
+// NewTestServerWithStream creates a new test server with custom WebSocket stream settings
+func NewTestServerWithStream(t testing.TB, streamCfg handlers.StreamConfig) *TestServer {
+	return newTestServerWithStream(t, &matching.EngineConfig{Symbols: []string{matching.DefaultSymbol}}, streamCfg)
+}
+
+func newTestServer(t testing.TB, cfg *matching.EngineConfig) *TestServer {
+	return newTestServerWithStream(t, cfg, handlers.DefaultStreamConfig())
+}
+
+func newTestServerWithStream(t testing.TB, cfg *matching.EngineConfig, streamCfg handlers.StreamConfig) *TestServer {
 	// Create temporary trade log file
 	tmpDir := t.TempDir()
 	tradeLogPath := filepath.Join(tmpDir, "test_trades.log")
 
 	// Create engine with test configuration
-	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
-		TradeHistorySize: 100,
-		TradeLogPath:     tradeLogPath,
-	})
+	cfg.TradeHistorySize = 100
+	cfg.TradeLogPath = tradeLogPath
+	cfg.OrderRetention = time.Hour
+	engine := matching.NewEngineWithConfig(cfg)
 
 	// Create handler and server
-	engineHolder := handlers.NewEngineHolder(engine)
+	engineHolder := handlers.NewEngineHolderWithStream(engine, streamCfg)
 	handler := routes.SetupRoutes(engineHolder)
 	server := httptest.NewServer(handler)
 
 	return &TestServer{
 		Server:       server,
 		Engine:       engine,
+		Holder:       engineHolder,
 		TradeLogPath: tradeLogPath,
 		t:            t,
 	}
@@ -51,6 +103,7 @@ func NewTestServer(t testing.TB) *TestServer {
 
 // Close cleans up the test server
 func (ts *TestServer) Close() {
+	ts.Holder.Close()
 	ts.Server.Close()
 	ts.Engine.Close()
 	// Cleanup is automatic via t.TempDir()
@@ -78,6 +131,29 @@ func (ts *TestServer) Post(path string, body interface{}) *http.Response {
 	return resp
 }
 
+// Dial opens a WebSocket connection to the stream endpoint
+func (ts *TestServer) Dial() *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(ts.URL(), "http") + "/api/v1/stream"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(ts.t, err, "WebSocket dial failed")
+	return conn
+}
+
+// Patch makes a PATCH request with JSON body
+func (ts *TestServer) Patch(path string, body interface{}) *http.Response {
+	jsonBody, err := json.Marshal(body)
+	require.NoError(ts.t, err, "Failed to marshal request body")
+
+	req, err := http.NewRequest("PATCH", ts.URL()+path, bytes.NewBuffer(jsonBody))
+	require.NoError(ts.t, err, "Failed to create PATCH request")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	require.NoError(ts.t, err, "PATCH request failed")
+	return resp
+}
+
 // Delete makes a DELETE request
 func (ts *TestServer) Delete(path string) *http.Response {
 	req, err := http.NewRequest("DELETE", ts.URL()+path, nil)
@@ -122,9 +198,8 @@ func (ts *TestServer) ReadTradeLog() []matching.Trade {
 
 // GetOrderBookDepth returns the current orderbook depth
 func (ts *TestServer) GetOrderBookDepth() (bidLevels, askLevels int) {
-	bidPrices := ts.Engine.GetOrderBook().GetAllBids()
-	askPrices := ts.Engine.GetOrderBook().GetAllAsks()
-	return len(bidPrices), len(askPrices)
+	snapshot, _ := ts.Engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	return len(snapshot.Bids), len(snapshot.Asks)
 }
 
 // GetTrackedOrderCount returns the number of tracked orders
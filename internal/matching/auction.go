@@ -0,0 +1,348 @@
+package matching
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+/*
+A call auction collects orders for a symbol without matching them, then executes everything
+that crosses at one price. While a symbol is in an auction, limit orders that can rest join the
+book even if they cross it, stop orders wait in the trigger book, and orders that must execute on
+arrival (market, IOC, FOK and minimum-quantity orders) are rejected.
+
+The uncross price is the one that maximises executable volume. Ties are broken by the smallest
+imbalance between buy and sell quantity at the price, then by distance from the reference price,
+the symbol's last trade, and finally by the lower price. Every order priced at or better than the
+uncross price is then matched in price-time priority, each trade at the uncross price; iceberg
+reserves take part, and self-trade prevention and reduce-only caps apply as in continuous
+trading. Stops triggered by the uncross fire once continuous trading has resumed.
+
+The indicative price, volume and imbalance are recomputed after every command that changes a
+book in auction and published as an AuctionEvent. Starting an auction and uncrossing are
+journaled like orders, so replay restores the auction state.
+
+StartAuction and Uncross run an unscheduled auction during continuous trading. The opening and
+closing auctions start and uncross with the symbol's trading phase instead (see session.go).
+*/
+
+// Auction errors
+var (
+	ErrUnknownSymbol  = errors.New("symbol is not registered")
+	ErrAuctionRunning = errors.New("symbol is already in an auction")
+	ErrNoAuction      = errors.New("symbol is not in an auction")
+)
+
+// AuctionInfo is the state of a symbol's call auction
+type AuctionInfo struct {
+	Symbol    string
+	Running   bool  // Orders are being collected for an uncross
+	Price     Price // Equilibrium price, 0 if the book does not cross
+	Volume    int   // Quantity executable at Price
+	Imbalance int   // Buy minus sell quantity at Price; positive when buyers are left over
+}
+
+// StartAuction stops continuous matching for a symbol until Uncross. Only a symbol in continuous
+// trading can start one; the auctions of the trading day are run by its phases.
+func (e *Engine) StartAuction(symbol string) error {
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		err = e.startAuction(symbol)
+	}) {
+		return ErrEngineClosed
+	}
+	return err
+}
+
+func (e *Engine) startAuction(symbol string) error {
+	if e.GetOrderBookForSymbol(symbol) == nil {
+		return ErrUnknownSymbol
+	}
+	if e.auctions[symbol] {
+		return ErrAuctionRunning
+	}
+	if e.phases[symbol] != PhaseContinuous {
+		return ErrNotContinuous
+	}
+	if err := e.record(&JournalEntry{Type: JournalAuction, Symbol: symbol}); err != nil {
+		return err
+	}
+	e.beginAuction(symbol)
+	return nil
+}
+
+func (e *Engine) beginAuction(symbol string) {
+	e.auctions[symbol] = true
+	e.markAuction(symbol)
+}
+
+// Uncross ends a symbol's auction, executing every crossing order at the equilibrium price, and
+// resumes continuous matching. It returns the auction as executed and its trades, followed by
+// those of any stop orders they triggered.
+func (e *Engine) Uncross(symbol string) (AuctionInfo, []*Trade, error) {
+	var info AuctionInfo
+	var trades []*Trade
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		info, trades, err = e.uncross(symbol)
+	}) {
+		return AuctionInfo{}, nil, ErrEngineClosed
+	}
+	return info, trades, err
+}
+
+func (e *Engine) uncross(symbol string) (AuctionInfo, []*Trade, error) {
+	book := e.GetOrderBookForSymbol(symbol)
+	if book == nil {
+		return AuctionInfo{}, nil, ErrUnknownSymbol
+	}
+	if !e.auctions[symbol] {
+		return AuctionInfo{}, nil, ErrNoAuction
+	}
+	// Auctions of the trading day end with their phase
+	if e.phases[symbol] != PhaseContinuous {
+		return AuctionInfo{}, nil, ErrNotContinuous
+	}
+	if err := e.record(&JournalEntry{Type: JournalUncross, Symbol: symbol}); err != nil {
+		return AuctionInfo{}, nil, err
+	}
+
+	info, trades := e.executeUncross(book, symbol)
+	return info, append(trades, e.processTriggeredStops(book, symbol, trades)...), nil
+}
+
+// executeUncross ends a symbol's auction and matches every crossing order at the equilibrium
+// price, leaving any stops it triggers to the caller
+func (e *Engine) executeUncross(book *OrderBook, symbol string) (AuctionInfo, []*Trade) {
+	info := e.auctionInfo(symbol)
+	delete(e.auctions, symbol)
+	e.markAuction(symbol)
+	info.Running = false
+	if info.Volume == 0 {
+		return info, nil
+	}
+
+	var trades []*Trade
+	for {
+		bidPrice, bids := book.GetBestBid()
+		askPrice, asks := book.GetBestAsk()
+		if len(bids) == 0 || len(asks) == 0 || bidPrice < info.Price || askPrice > info.Price {
+			break
+		}
+		bid, ask := bids[0], asks[0]
+
+		// A resting reduce-only order with no position left to reduce, or a group leg its group
+		// has closed, is cancelled
+		if e.cancelIfFlat(book, bid) || e.cancelIfFlat(book, ask) {
+			continue
+		}
+
+		// The later of two orders of one user is treated as the incoming order
+		if bid.UserID == ask.UserID {
+			incoming, resting := bid, ask
+			if ask.ID > bid.ID {
+				incoming, resting = ask, bid
+			}
+			if incoming.PreventsSelfTrade() {
+				if e.preventSelfTrade(incoming, resting, book.DeleteOrderById) {
+					book.DeleteOrderById(incoming.ID)
+				}
+				incoming.VisibleSize = min(incoming.VisibleSize, incoming.Size)
+				e.markLevel(incoming)
+				continue
+			}
+		}
+
+		fillSize := min(e.tradableSize(bid), e.tradableSize(ask))
+		maker, taker := bid, ask
+		if bid.ID > ask.ID {
+			maker, taker = ask, bid
+		}
+		trade := &Trade{
+			ID:           e.newTradeID(),
+			Symbol:       symbol,
+			BuyOrderID:   bid.ID,
+			SellOrderID:  ask.ID,
+			Price:        info.Price,
+			Size:         fillSize,
+			Timestamp:    e.commandTime,
+			MakerOrderID: maker.ID,
+			TakerOrderID: taker.ID,
+			MakerUserID:  maker.UserID,
+			TakerUserID:  taker.UserID,
+		}
+		e.chargeFees(trade)
+		trades = append(trades, trade)
+		e.AddTradeToHistory(trade)
+		e.markTrade(trade)
+
+		for _, order := range []*Order{bid, ask} {
+			order.Fill(trade.Price, fillSize)
+			e.addPosition(order, fillSize)
+			e.markOrder(order)
+			e.markLevel(order)
+		}
+		e.settleFill(book, bid, book.DeleteBidOrder)
+		e.settleFill(book, ask, book.DeleteAskOrder)
+	}
+
+	if len(trades) > 0 {
+		e.setLastTradePrice(symbol, info.Price)
+		e.resetReference(symbol, info.Price)
+	}
+	return info, trades
+}
+
+// cancelIfFlat cancels a resting order that tradableSize leaves nothing to trade
+func (e *Engine) cancelIfFlat(book *OrderBook, order *Order) bool {
+	if e.tradableSize(order) > 0 {
+		return false
+	}
+	book.DeleteOrderById(order.ID)
+	e.markLevel(order)
+	e.finishFlat(order)
+	return true
+}
+
+// GetAuction returns the state of a symbol's auction, with the indicative uncross while it is
+// running, or false if the symbol is not registered
+func (e *Engine) GetAuction(symbol string) (AuctionInfo, bool) {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	if e.GetOrderBookForSymbol(symbol) == nil {
+		return AuctionInfo{}, false
+	}
+	return e.auctionInfo(symbol), true
+}
+
+// InAuction reports whether a symbol is collecting orders for an uncross
+func (e *Engine) InAuction(symbol string) bool {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+	return e.auctions[symbol]
+}
+
+// auctionInfo computes the equilibrium of a symbol's book as it stands. The book only crosses
+// while it is in an auction, so outside one the result has no volume.
+func (e *Engine) auctionInfo(symbol string) AuctionInfo {
+	info := AuctionInfo{Symbol: symbol, Running: e.auctions[symbol]}
+	book := e.GetOrderBookForSymbol(symbol)
+
+	// Cumulative quantity on each side, best price first
+	bidPrices, bidTotals := e.cumulativeSizes(book.bids)
+	askPrices, askTotals := e.cumulativeSizes(book.asks)
+	if len(bidPrices) == 0 || len(askPrices) == 0 || bidPrices[0] < askPrices[0] {
+		return info
+	}
+
+	// Buy quantity at or above a price and sell quantity at or below it
+	demand := func(price Price) int {
+		if n := sort.Search(len(bidPrices), func(i int) bool { return bidPrices[i] < price }); n > 0 {
+			return bidTotals[n-1]
+		}
+		return 0
+	}
+	supply := func(price Price) int {
+		if n := sort.Search(len(askPrices), func(i int) bool { return askPrices[i] > price }); n > 0 {
+			return askTotals[n-1]
+		}
+		return 0
+	}
+
+	reference, hasReference := e.GetLastTradePrice(symbol)
+	distance := func(price Price) Price {
+		if !hasReference {
+			return 0
+		}
+		if price > reference {
+			return price - reference
+		}
+		return reference - price
+	}
+
+	// Candidate prices are the limits within the crossed range
+	for _, price := range slices.Concat(bidPrices, askPrices) {
+		if price > bidPrices[0] || price < askPrices[0] {
+			continue
+		}
+		volume := min(demand(price), supply(price))
+		imbalance := demand(price) - supply(price)
+
+		better := info.Volume == 0 || volume > info.Volume
+		if volume == info.Volume {
+			switch {
+			case abs(imbalance) != abs(info.Imbalance):
+				better = abs(imbalance) < abs(info.Imbalance)
+			case distance(price) != distance(info.Price):
+				better = distance(price) < distance(info.Price)
+			default:
+				better = price < info.Price
+			}
+		}
+		if better && volume > 0 {
+			info.Price, info.Volume, info.Imbalance = price, volume, imbalance
+		}
+	}
+	return info
+}
+
+// cumulativeSizes lists a side's prices best first, with the quantity that could trade at each
+// price or better. Iceberg reserves count in full and reduce-only orders only up to the position
+// they can close.
+func (e *Engine) cumulativeSizes(side *priceLevelList) ([]Price, []int) {
+	var prices []Price
+	var totals []int
+	total := 0
+	side.Each(func(level *PriceLevel) bool {
+		for _, order := range level.Orders {
+			size := order.Size
+			if order.ReduceOnly {
+				size = min(size, e.reducibleSize(order))
+			}
+			total += size
+		}
+		prices = append(prices, level.Price)
+		totals = append(totals, total)
+		return true
+	})
+	return prices, totals
+}
+
+// restInAuction adds an order to the book without matching, as a symbol in auction does
+func (e *Engine) restInAuction(book *OrderBook, order *Order) {
+	if order.OrderType != LimitOrder || !order.CanRest() {
+		e.finishUnlessWorking(order)
+		return
+	}
+	order.RefreshDisplay()
+	if order.Side == Buy {
+		book.AddBidOrder(order)
+	} else {
+		book.AddAskOrder(order)
+	}
+	e.markLevel(order)
+}
+
+// waitsForAuction reports whether an order can join an auction, which only limit orders that
+// rest and stop orders can. Pegged orders cannot, having no reliable price to follow.
+func waitsForAuction(order *Order) bool {
+	switch order.OrderType {
+	case LimitOrder:
+		return order.CanRest() && order.MinQuantity == 0 && order.Peg == PegNone
+	case StopMarketOrder, StopLimitOrder:
+		return true
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,269 @@
+package matching
+
+import (
+	"math"
+	"time"
+)
+
+/*
+Price bands keep a symbol's orders and trades near its recent prices. Each limit is in basis
+points of the price it is measured from, and a zero limit disables it.
+
+The static band is centred on the reference price: the price of the last uncross that traded, or
+the symbol's first trade before one. Limit prices of limit and stop-limit orders outside it are
+rejected on arrival and on amend, in every phase.
+
+The dynamic band is centred on the last trade and bounds orders that trade on arrival. A limit
+order priced beyond it on the side it would trade through is rejected, except while the symbol
+collects orders for an auction. Market orders, and stop-market orders once triggered, trade only
+at prices inside both bands; a remainder the bands stop is cancelled with CancelPriceBand. A
+triggered stop-limit priced beyond the dynamic band is cancelled the same way.
+
+A circuit breaker tracks the high and low trade prices over the last HaltWindow. When the range
+exceeds HaltMoveBps of the low, the symbol is halted and any stop cascade in progress stops, its
+remaining stops returning to the trigger book. The halt follows deterministically from the
+command that caused it, so it is not journaled itself. After HaltDuration the symbol enters a
+reopening auction, which uncrosses into continuous trading after ReopenAuction; both steps are
+taken by ReopenHalted and journaled as phase changes. A halt with no HaltDuration waits for
+SetPhase, as does any phase set by hand.
+*/
+
+// PriceBands limits how far a symbol's prices may move. Zero values disable each limit.
+type PriceBands struct {
+	StaticBps  int // Band around the reference price, in basis points
+	DynamicBps int // Band around the last trade, in basis points
+
+	// Circuit breaker: halt when prices range more than HaltMoveBps within HaltWindow
+	HaltMoveBps   int
+	HaltWindow    time.Duration
+	HaltDuration  time.Duration // How long a volatility halt lasts; 0 waits for SetPhase
+	ReopenAuction time.Duration // How long the reopening auction collects orders; 0 reopens at once
+}
+
+// PriceBandInfo is the state of a symbol's price bands
+type PriceBandInfo struct {
+	Symbol      string
+	Bands       PriceBands
+	Reference   Price     // Centre of the static band, 0 before the symbol trades
+	StaticLow   Price     // 0 when there is no static band
+	StaticHigh  Price     // 0 when there is no static band
+	DynamicLow  Price     // 0 when there is no dynamic band
+	DynamicHigh Price     // 0 when there is no dynamic band
+	ReopenAt    time.Time // When the halt or reopening auction moves on; zero if it waits for SetPhase
+}
+
+// bandState is what a symbol's bands remember between commands
+type bandState struct {
+	Reference Price
+	Highs     []priceSample // Trades in the halt window whose price no later trade reached, falling
+	Lows      []priceSample // Trades in the halt window whose price no later trade went below, rising
+	ReopenAt  time.Time     // When ReopenHalted moves the symbol on; zero if it does not
+}
+
+// priceSample is a trade price in the halt window
+type priceSample struct {
+	Time  time.Time
+	Price Price
+}
+
+// addSample adds a trade to the window and drops trades from before since
+func (s *bandState) addSample(sample priceSample, since time.Time) {
+	for len(s.Highs) > 0 && s.Highs[len(s.Highs)-1].Price <= sample.Price {
+		s.Highs = s.Highs[:len(s.Highs)-1]
+	}
+	for len(s.Lows) > 0 && s.Lows[len(s.Lows)-1].Price >= sample.Price {
+		s.Lows = s.Lows[:len(s.Lows)-1]
+	}
+	s.Highs = append(s.Highs, sample)
+	s.Lows = append(s.Lows, sample)
+
+	for s.Highs[0].Time.Before(since) {
+		s.Highs = s.Highs[1:]
+	}
+	for s.Lows[0].Time.Before(since) {
+		s.Lows = s.Lows[1:]
+	}
+}
+
+// resetWindow forgets the trades in the halt window
+func (s *bandState) resetWindow() {
+	s.Highs, s.Lows = nil, nil
+}
+
+// bandAround returns the prices bps basis points either side of a centre price
+func bandAround(centre Price, bps int) (Price, Price) {
+	width := centre * Price(bps) / 10000
+	return centre - width, centre + width
+}
+
+// GetPriceBands returns the state of a symbol's price bands, or false if the symbol is not
+// registered
+func (e *Engine) GetPriceBands(symbol string) (PriceBandInfo, bool) {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	if e.GetOrderBookForSymbol(symbol) == nil {
+		return PriceBandInfo{}, false
+	}
+	info := PriceBandInfo{Symbol: symbol, Bands: e.bandsFor(symbol)}
+	if state := e.bandStates[symbol]; state != nil {
+		info.Reference, info.ReopenAt = state.Reference, state.ReopenAt
+	}
+	info.StaticLow, info.StaticHigh, _ = e.staticBand(symbol)
+	info.DynamicLow, info.DynamicHigh, _ = e.dynamicBand(symbol)
+	return info, true
+}
+
+// bandsFor returns a symbol's configured price bands
+func (e *Engine) bandsFor(symbol string) PriceBands {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+	return e.priceBands[symbol]
+}
+
+// bandStateFor returns a symbol's band state, creating it on first use, or nil if the symbol
+// has no price bands
+func (e *Engine) bandStateFor(symbol string) *bandState {
+	if state, ok := e.bandStates[symbol]; ok {
+		return state
+	}
+	if e.bandsFor(symbol) == (PriceBands{}) {
+		return nil
+	}
+	state := &bandState{}
+	e.bandStates[symbol] = state
+	return state
+}
+
+// staticBand returns the static band's bounds, or false if there is none yet
+func (e *Engine) staticBand(symbol string) (Price, Price, bool) {
+	bands := e.bandsFor(symbol)
+	state := e.bandStates[symbol]
+	if bands.StaticBps == 0 || state == nil || state.Reference == 0 {
+		return 0, 0, false
+	}
+	low, high := bandAround(state.Reference, bands.StaticBps)
+	return low, high, true
+}
+
+// dynamicBand returns the dynamic band's bounds, or false if there is none yet
+func (e *Engine) dynamicBand(symbol string) (Price, Price, bool) {
+	bands := e.bandsFor(symbol)
+	lastPrice, traded := e.GetLastTradePrice(symbol)
+	if bands.DynamicBps == 0 || !traded {
+		return 0, 0, false
+	}
+	low, high := bandAround(lastPrice, bands.DynamicBps)
+	return low, high, true
+}
+
+// withinBands reports whether a limit price is inside the symbol's static band and, for an order
+// that trades on arrival, not beyond its dynamic band on the side it would trade through
+func (e *Engine) withinBands(symbol string, side SideType, price Price, trades bool) bool {
+	if low, high, ok := e.staticBand(symbol); ok && (price < low || price > high) {
+		return false
+	}
+	if !trades {
+		return true
+	}
+	low, high, ok := e.dynamicBand(symbol)
+	return !ok || (side == Buy && price <= high) || (side == Sell && price >= low)
+}
+
+// tradesOnArrival reports whether a limit order would match as it arrives rather than collect
+// for an auction
+func (e *Engine) tradesOnArrival(order *Order) bool {
+	return order.OrderType == LimitOrder && order.Peg == PegNone && !e.auctions[order.Symbol]
+}
+
+// bandLimit returns the worst price a market order on a side may trade at under the symbol's
+// bands
+func (e *Engine) bandLimit(symbol string, side SideType) Price {
+	low, high := Price(0), Price(math.MaxInt64)
+	if staticLow, staticHigh, ok := e.staticBand(symbol); ok {
+		low, high = max(low, staticLow), min(high, staticHigh)
+	}
+	if dynamicLow, dynamicHigh, ok := e.dynamicBand(symbol); ok {
+		low, high = max(low, dynamicLow), min(high, dynamicHigh)
+	}
+	if side == Buy {
+		return high
+	}
+	return low
+}
+
+// trackVolatility runs after trades in continuous trading. The first trade sets the reference
+// price, and a range wider than the circuit breaker allows halts the symbol.
+func (e *Engine) trackVolatility(symbol string, trades []*Trade) {
+	state := e.bandStateFor(symbol)
+	if state == nil {
+		return
+	}
+	if state.Reference == 0 {
+		state.Reference = trades[0].Price
+	}
+
+	bands := e.bandsFor(symbol)
+	if bands.HaltMoveBps == 0 || bands.HaltWindow <= 0 {
+		return
+	}
+	since := e.commandTime.Add(-bands.HaltWindow)
+	for _, trade := range trades {
+		state.addSample(priceSample{Time: e.commandTime, Price: trade.Price}, since)
+	}
+
+	high, low := state.Highs[0].Price, state.Lows[0].Price
+	if (high-low)*10000 <= low*Price(bands.HaltMoveBps) {
+		return
+	}
+	e.phases[symbol] = PhaseHalted
+	state.resetWindow()
+	state.ReopenAt = time.Time{}
+	if bands.HaltDuration > 0 {
+		state.ReopenAt = e.commandTime.Add(bands.HaltDuration)
+	}
+}
+
+// resetReference centres the static band on an uncross price and restarts the halt window
+func (e *Engine) resetReference(symbol string, price Price) {
+	if state := e.bandStateFor(symbol); state != nil {
+		state.Reference = price
+		state.resetWindow()
+	}
+}
+
+// scheduleReopen runs on every phase change. A reopening auction uncrosses after the symbol's
+// ReopenAuction; any other phase change, such as a halt set by hand, cancels a scheduled reopen.
+func (e *Engine) scheduleReopen(symbol string, phase TradingPhase) {
+	state := e.bandStateFor(symbol)
+	if state == nil {
+		return
+	}
+	state.ReopenAt = time.Time{}
+	if bands := e.bandsFor(symbol); phase == PhaseReopeningAuction && bands.ReopenAuction > 0 {
+		state.ReopenAt = e.commandTime.Add(bands.ReopenAuction)
+	}
+}
+
+// ReopenHalted moves on every symbol whose volatility halt or reopening auction is due at now. A
+// halted symbol enters its reopening auction, and a reopening auction uncrosses into continuous
+// trading. It returns the trades of the uncrosses.
+func (e *Engine) ReopenHalted(now time.Time) []*Trade {
+	var trades []*Trade
+	e.submit(func() {
+		e.commandTime = time.Now()
+		for _, symbol := range e.GetSymbols() {
+			state := e.bandStates[symbol]
+			if state == nil || state.ReopenAt.IsZero() || now.Before(state.ReopenAt) {
+				continue
+			}
+			next := PhaseContinuous
+			if e.phases[symbol] == PhaseHalted && e.bandsFor(symbol).ReopenAuction > 0 {
+				next = PhaseReopeningAuction
+			}
+			uncrossed, _ := e.setPhase(symbol, next)
+			trades = append(trades, uncrossed...)
+		}
+	})
+	return trades
+}
@@ -2,6 +2,7 @@ package matching
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
@@ -11,24 +12,83 @@ import (
 )
 
 type Engine struct {
-	orderBook      *OrderBook
-	incomingOrders chan *Order
-	trades         chan *Trade
-	orderTracker   map[uint64]*Order // Track all orders for O(1) lookup
-	trackerMutex   sync.RWMutex      // Protect order tracker
-	tradeHistory   []*Trade          // Recent trades in memory
-	historyMutex   sync.RWMutex      // Protect trade history
-	maxHistory     int               // Max trades to keep in memory
-	nextOrderID    uint64            // Atomic counter for order IDs
-	tradePersister *TradePersister   // Handles trade persistence to disk
+	orderBooks     map[string]*OrderBook   // One book per symbol
+	triggerBooks   map[string]*TriggerBook // Resting stop orders per symbol
+	instruments    map[string]Instrument   // Price precision and tick size per symbol
+	booksMutex     sync.RWMutex            // Protect order and trigger book registries
+	lastPrices     map[string]Price        // Last trade price per symbol
+	priceMutex     sync.RWMutex            // Protect last trade prices
+	commands       chan *command           // Mutations queued for the sequencer
+	stateMutex     sync.RWMutex            // Held for writing by the sequencer while it applies a command
+	startOnce      sync.Once               // Guards sequencer start
+	stopped        chan struct{}           // Closed by Close to stop the sequencer
+	sequencer      sync.WaitGroup          // Waits for the sequencer goroutine on Close
+	orderTracker   map[uint64]*Order       // Track all orders for O(1) lookup
+	doneOrders     map[uint64]*Order       // Terminal orders kept for queries until retention lapses
+	doneQueue      []*Order                // Terminal orders, oldest first, for pruning
+	orderRetention time.Duration           // How long terminal orders stay queryable
+	trackerMutex   sync.RWMutex            // Protect order tracker and terminal orders
+	tradeHistory   []*Trade                // Recent trades in memory
+	historyMutex   sync.RWMutex            // Protect trade history
+	maxHistory     int                     // Max trades to keep in memory
+	nextOrderID    uint64                  // Atomic counter for order IDs
+	tradePersister *TradePersister         // Handles trade persistence to disk
+	stopExpiry     chan struct{}           // Stops the expiry worker, nil if not running
+	journal        *Journal                // Write-ahead command log, nil if journaling is disabled
+	replaying      bool                    // Set while the journal is replayed on startup
+	commandTime    time.Time               // When the command being applied was sequenced
+	snapshotDir    string                  // Where snapshots are written, empty if disabled
+	snapshotRetain int                     // Snapshots kept on disk
+	snapshotSeq    uint64                  // Journal sequence of the snapshot recovered from
+	stopSnapshots  chan struct{}           // Stops the snapshot worker, nil if not running
+	snapshotWorker sync.WaitGroup          // Waits for an in-flight snapshot on Close
+	events         eventBatch              // Changes made by the command being applied
+	bookSeq        map[string]uint64       // Book update sequence per symbol
+	subscribers    map[uint64]EventHandler // Receive the events of each command
+	nextSubscriber uint64                  // Last subscriber ID issued
+	eventMutex     sync.RWMutex            // Protect subscribers
+	positions      map[positionKey]int     // Net filled quantity per user and symbol, positive when long
+	selfTradeMode  SelfTradeMode           // Self-trade prevention for orders that do not set it
+	auctions       map[string]bool         // Symbols collecting orders for a call auction uncross
+	phases         map[string]TradingPhase // Trading phase per symbol; absent symbols trade continuously
+	stopSession    chan struct{}           // Stops the schedule worker, nil if not running
+
+	policies map[string]MatchingPolicy // Allocation among orders at a price level per symbol, guarded by booksMutex
+
+	priceBands map[string]PriceBands // Price bands and circuit breaker per symbol, guarded by booksMutex
+	bandStates map[string]*bandState // Reference price, halt window and reopen time per banded symbol
+
+	pegged map[string][]*Order // Resting pegged orders per symbol in arrival order; may hold orders since removed
+
+	nextTradeID uint64 // Last trade ID issued, advanced by the sequencer
+
+	fees    map[string]FeeSchedule      // Fee tiers per symbol, fixed at construction; symbols without one trade free
+	volumes map[positionKey][]volumeDay // Quantity traded per user and symbol by UTC day, oldest first, for fee tiers
+
+	groups   map[uint64]*orderGroup // Working OCO pairs and brackets by group ID
+	grouping bool                   // Set while a journaled group places its orders
+
+	risk map[string]RiskRules // Pre-trade limits per symbol, fixed at construction; symbols without them are unchecked
 }
 
+// Trade is one execution between a buy and a sell order. The maker is the order that was resting
+// in the book and the taker the order that traded against it; in an auction uncross, which has
+// no aggressor, the later of the two orders is the taker.
 type Trade struct {
-	BuyOrderID  uint64
-	SellOrderID uint64
-	Price       float64
-	Size        int
-	Timestamp   time.Time
+	ID            uint64    `json:"trade_id"` // Increases by one with every trade
+	Symbol        string    `json:"symbol"`
+	BuyOrderID    uint64    `json:"buy_order_id"`
+	SellOrderID   uint64    `json:"sell_order_id"`
+	Price         Price     `json:"price"`
+	Size          int       `json:"quantity"`
+	Timestamp     time.Time `json:"timestamp"`
+	AggressorSide SideType  `json:"aggressor_side"` // Side of the taker, NoActionSide for an auction uncross
+	MakerOrderID  uint64    `json:"maker_order_id"`
+	TakerOrderID  uint64    `json:"taker_order_id"`
+	MakerUserID   string    `json:"maker_user_id"`
+	TakerUserID   string    `json:"taker_user_id"`
+	MakerFee      Price     `json:"maker_fee"` // In price units, negative for a rebate; see fees.go
+	TakerFee      Price     `json:"taker_fee"`
 }
 
 // TradePersister handles writing trades to disk
@@ -67,33 +127,443 @@ func (tp *TradePersister) Close() error {
 type EngineConfig struct {
 	TradeHistorySize int
 	TradeLogPath     string
+	Symbols          []string     // Instruments to open books for (defaults to DefaultSymbol)
+	Instruments      []Instrument // Quote conventions; symbols without one use DefaultInstrument
+
+	// Allocation among the orders at a price level, per symbol; symbols without one match FIFO
+	MatchingPolicies map[string]MatchingPolicy
+
+	// Expiry of GTD and DAY orders; the worker is disabled when ExpiryCheckInterval is 0
+	ExpiryCheckInterval time.Duration
+	DaySessionEnd       time.Duration // Time of day DAY orders are swept, as an offset from midnight
+
+	// Command journal replayed on startup; journaling is disabled when JournalPath is empty
+	JournalPath         string
+	JournalSync         SyncPolicy
+	JournalSyncInterval time.Duration // Only used by SyncInterval
+
+	// How long filled, cancelled, rejected and expired orders stay queryable; 0 drops them at once
+	OrderRetention time.Duration
+
+	// Snapshots bound recovery time; they require a journal. The worker is disabled when
+	// SnapshotInterval is 0, but recovery still loads snapshots from SnapshotDir.
+	SnapshotDir      string
+	SnapshotInterval time.Duration
+	SnapshotRetain   int // Snapshots kept on disk, at least 1
+
+	// Self-trade prevention for orders that do not set their own; SelfTradeDefault allows self-trades
+	SelfTradePrevention SelfTradeMode
+
+	// Daily trading phases of every symbol; without one symbols trade continuously unless SetPhase
+	// moves them
+	Schedule *Schedule
+
+	// Price bands and volatility halts per symbol; symbols without them trade at any price.
+	// Halted symbols reopen on the expiry worker's ticks.
+	PriceBands map[string]PriceBands
+
+	// Maker and taker fee tiers per symbol, each checked with FeeSchedule.Validate; symbols
+	// without one trade free
+	Fees map[string]FeeSchedule
+
+	// Pre-trade risk limits per symbol; symbols without them accept orders of any size
+	Risk map[string]RiskRules
+}
+
+// ErrEngineClosed is returned for commands submitted after Close
+var ErrEngineClosed = errors.New("engine is closed")
+
+// RejectError is returned when the engine rejects an order, or an amend, for the given reason.
+// A rejected order is still reported with StatusRejected and its RejectReason.
+type RejectError struct {
+	Reason RejectReason
 }
 
+func (err *RejectError) Error() string {
+	return "order rejected: " + err.Reason.String()
+}
+
+// Amend errors
+var (
+	ErrOrderNotWorking = errors.New("order is not working")
+	ErrAmendQuantity   = errors.New("amended quantity must exceed the filled quantity")
+	ErrAmendPrice      = errors.New("order has no limit price to amend")
+)
+
 func NewEngine() *Engine {
 	return NewEngineWithConfig(&EngineConfig{
 		TradeHistorySize: 1000,
 		TradeLogPath:     "trades.log",
+		Symbols:          []string{DefaultSymbol},
 	})
 }
 
-// NewEngineWithConfig creates a new engine with custom configuration
+// NewEngineWithConfig creates a new engine with custom configuration, replaying its journal if
+// one is configured. It panics if the journal cannot be replayed; use OpenEngine to handle that.
 func NewEngineWithConfig(cfg *EngineConfig) *Engine {
+	engine, err := OpenEngine(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// OpenEngine creates an engine and rebuilds its state from the configured journal
+func OpenEngine(cfg *EngineConfig) (*Engine, error) {
 	persister, err := NewTradePersister(cfg.TradeLogPath)
 	if err != nil {
 		// Fallback to no persistence if file can't be opened
 		persister = nil
 	}
 
-	return &Engine{
-		orderBook:      NewOrderBook(),
-		incomingOrders: make(chan *Order),
-		trades:         make(chan *Trade),
+	engine := &Engine{
+		orderBooks:     make(map[string]*OrderBook),
+		triggerBooks:   make(map[string]*TriggerBook),
+		instruments:    make(map[string]Instrument),
+		policies:       make(map[string]MatchingPolicy),
+		priceBands:     make(map[string]PriceBands),
+		bandStates:     make(map[string]*bandState),
+		pegged:         make(map[string][]*Order),
+		groups:         make(map[uint64]*orderGroup),
+		fees:           make(map[string]FeeSchedule),
+		risk:           make(map[string]RiskRules),
+		volumes:        make(map[positionKey][]volumeDay),
+		lastPrices:     make(map[string]Price),
+		commands:       make(chan *command),
+		stopped:        make(chan struct{}),
 		orderTracker:   make(map[uint64]*Order),
+		doneOrders:     make(map[uint64]*Order),
+		bookSeq:        make(map[string]uint64),
+		subscribers:    make(map[uint64]EventHandler),
+		positions:      make(map[positionKey]int),
+		auctions:       make(map[string]bool),
+		phases:         make(map[string]TradingPhase),
+		selfTradeMode:  cfg.SelfTradePrevention,
+		orderRetention: cfg.OrderRetention,
 		tradeHistory:   make([]*Trade, 0, cfg.TradeHistorySize),
 		maxHistory:     cfg.TradeHistorySize,
 		nextOrderID:    1,
 		tradePersister: persister,
 	}
+
+	if engine.selfTradeMode == SelfTradeDefault {
+		engine.selfTradeMode = SelfTradeAllow
+	}
+
+	for _, inst := range cfg.Instruments {
+		engine.AddInstrument(inst)
+	}
+	symbols := cfg.Symbols
+	if len(symbols) == 0 && len(cfg.Instruments) == 0 {
+		symbols = []string{DefaultSymbol}
+	}
+	for _, symbol := range symbols {
+		engine.AddSymbol(symbol)
+	}
+	for symbol, policy := range cfg.MatchingPolicies {
+		engine.policies[symbol] = policy
+	}
+	for symbol, bands := range cfg.PriceBands {
+		engine.priceBands[symbol] = bands
+	}
+	for symbol, schedule := range cfg.Fees {
+		engine.fees[symbol] = schedule
+	}
+	for symbol, rules := range cfg.Risk {
+		engine.risk[symbol] = rules
+	}
+
+	if cfg.JournalPath != "" {
+		if err := engine.recover(cfg); err != nil {
+			engine.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.ExpiryCheckInterval > 0 {
+		engine.stopExpiry = make(chan struct{})
+		go engine.runExpiryWorker(cfg.ExpiryCheckInterval, cfg.DaySessionEnd)
+	}
+
+	if engine.snapshotDir != "" && cfg.SnapshotInterval > 0 {
+		engine.stopSnapshots = make(chan struct{})
+		engine.snapshotWorker.Add(1)
+		go engine.runSnapshotWorker(cfg.SnapshotInterval)
+	}
+
+	engine.Start()
+
+	// Symbols recovered in another phase catch up with the schedule before the engine is used
+	if cfg.Schedule != nil {
+		scheduled := cfg.Schedule.PhaseAt(time.Now())
+		engine.followSchedule(scheduled)
+		engine.stopSession = make(chan struct{})
+		go engine.runSessionWorker(cfg.Schedule, scheduled, engine.stopSession)
+	}
+	return engine, nil
+}
+
+// recover loads the newest snapshot, then replays the journal entries after it
+func (e *Engine) recover(cfg *EngineConfig) error {
+	if cfg.SnapshotDir != "" {
+		if err := os.MkdirAll(cfg.SnapshotDir, 0755); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		e.snapshotDir = cfg.SnapshotDir
+		e.snapshotRetain = max(cfg.SnapshotRetain, 1)
+
+		state, err := loadLatestSnapshot(cfg.SnapshotDir)
+		if err != nil {
+			return err
+		}
+		if state != nil {
+			if err := e.restoreSnapshot(state); err != nil {
+				return err
+			}
+		}
+	}
+
+	journal, err := OpenJournal(cfg.JournalPath, cfg.JournalSync, cfg.JournalSyncInterval, e.replayEntry)
+	if err != nil {
+		return err
+	}
+	e.journal = journal
+
+	// A journal behind the snapshot would reuse sequence numbers the snapshot already covers
+	if journal.Seq() < e.snapshotSeq {
+		return fmt.Errorf("journal ends at entry %d, before snapshot at entry %d", journal.Seq(), e.snapshotSeq)
+	}
+	return nil
+}
+
+// replayEntry applies a journaled command during startup, before the sequencer runs
+func (e *Engine) replayEntry(entry *JournalEntry) error {
+	// Entries up to the snapshot are already reflected in the restored state
+	if entry.Seq <= e.snapshotSeq {
+		return nil
+	}
+
+	e.replaying = true
+	defer func() { e.replaying = false }()
+	e.commandTime = entry.Time
+
+	switch entry.Type {
+	case JournalPlace:
+		if entry.Order == nil {
+			return fmt.Errorf("place entry has no order")
+		}
+		// Orders rejected during execution were journaled and are rejected again
+		var rejectErr *RejectError
+		if _, err := e.placeOrder(entry.Order); err != nil && !errors.As(err, &rejectErr) {
+			return err
+		}
+		// Order IDs are never reissued after a restart
+		if entry.Order.ID > e.nextOrderID {
+			e.nextOrderID = entry.Order.ID
+		}
+	case JournalGroup:
+		if len(entry.Orders) == 0 {
+			return fmt.Errorf("group entry has no orders")
+		}
+		if _, err := e.placeGroup(entry.Group, entry.Orders); err != nil {
+			return err
+		}
+		for _, order := range entry.Orders {
+			if order.ID > e.nextOrderID {
+				e.nextOrderID = order.ID
+			}
+		}
+	case JournalCancel:
+		status := entry.Status
+		if !status.IsTerminal() {
+			status = StatusCancelled
+		}
+		if _, err := e.cancelOrder(entry.OrderID, status); err != nil {
+			return err
+		}
+	case JournalAmend:
+		if _, err := e.amendOrder(entry.OrderID, entry.Price, entry.Size); err != nil {
+			return err
+		}
+	case JournalAuction:
+		if err := e.startAuction(entry.Symbol); err != nil {
+			return err
+		}
+	case JournalUncross:
+		if _, _, err := e.uncross(entry.Symbol); err != nil {
+			return err
+		}
+	case JournalPhase:
+		if _, err := e.setPhase(entry.Symbol, entry.Phase); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown entry type %q", entry.Type)
+	}
+
+	// Settle groups, reprice pegs and advance book sequences exactly as the live command did
+	e.settleGroups()
+	e.repegOrders()
+	e.publishEvents()
+	return nil
+}
+
+// record appends a command to the journal before it is applied
+func (e *Engine) record(entry *JournalEntry) error {
+	if e.journal == nil || e.replaying || e.grouping {
+		return nil
+	}
+	entry.Time = e.commandTime
+	return e.journal.Append(entry)
+}
+
+// runExpiryWorker periodically expires GTD orders, reopens halted symbols that are due, and
+// sweeps DAY orders at session end
+func (e *Engine) runExpiryWorker(interval time.Duration, sessionEnd time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	nextSweep := nextSessionEnd(time.Now(), sessionEnd)
+	for {
+		select {
+		case <-e.stopExpiry:
+			return
+		case now := <-ticker.C:
+			e.ExpireOrders(now)
+			e.ReopenHalted(now)
+			if !now.Before(nextSweep) {
+				e.SweepDayOrders()
+				nextSweep = nextSessionEnd(now, sessionEnd)
+			}
+		}
+	}
+}
+
+// nextSessionEnd returns the first session end strictly after now
+func nextSessionEnd(now time.Time, sessionEnd time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := midnight.Add(sessionEnd)
+	if !end.After(now) {
+		end = midnight.AddDate(0, 0, 1).Add(sessionEnd)
+	}
+	return end
+}
+
+// ExpireOrders cancels every GTD order whose expiry has passed and returns them
+func (e *Engine) ExpireOrders(now time.Time) []*Order {
+	var expired []*Order
+	e.submit(func() {
+		e.commandTime = time.Now()
+		expired = e.cancelWhere(StatusExpired, func(order *Order) bool {
+			return order.IsExpired(now)
+		})
+		e.pruneDoneOrders()
+	})
+	return expired
+}
+
+// SweepDayOrders cancels every working DAY order and returns them
+func (e *Engine) SweepDayOrders() []*Order {
+	var swept []*Order
+	e.submit(func() {
+		e.commandTime = time.Now()
+		swept = e.cancelWhere(StatusExpired, func(order *Order) bool {
+			return order.TimeInForce == Day
+		})
+	})
+	return swept
+}
+
+// cancelWhere runs on the sequencer
+func (e *Engine) cancelWhere(status OrderStatus, match func(*Order) bool) []*Order {
+	var cancelled []*Order
+	for _, order := range e.trackedOrders() {
+		if !match(order) {
+			continue
+		}
+		if cancelledOrder, _ := e.cancelOrder(order.ID, status); cancelledOrder {
+			cancelled = append(cancelled, order)
+		}
+	}
+	return cancelled
+}
+
+// AddSymbol registers an order book for a symbol, returning the existing book if already registered
+func (e *Engine) AddSymbol(symbol string) *OrderBook {
+	return e.AddInstrument(DefaultInstrument(symbol))
+}
+
+// AddInstrument registers an order book quoted with the instrument's precision and tick size.
+// An already registered symbol keeps its book and instrument.
+func (e *Engine) AddInstrument(inst Instrument) *OrderBook {
+	e.booksMutex.Lock()
+	defer e.booksMutex.Unlock()
+
+	if book, ok := e.orderBooks[inst.Symbol]; ok {
+		return book
+	}
+	book := NewOrderBook()
+	e.orderBooks[inst.Symbol] = book
+	e.triggerBooks[inst.Symbol] = NewTriggerBook()
+	e.instruments[inst.Symbol] = inst
+	return book
+}
+
+// GetInstrument returns the quote conventions for a symbol and whether it is registered
+func (e *Engine) GetInstrument(symbol string) (Instrument, bool) {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+	inst, ok := e.instruments[symbol]
+	return inst, ok
+}
+
+// GetMatchingPolicy returns how a symbol allocates among the orders at a price level
+func (e *Engine) GetMatchingPolicy(symbol string) MatchingPolicy {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+	if policy, ok := e.policies[symbol]; ok {
+		return policy
+	}
+	return FIFO{}
+}
+
+// HasSymbol reports whether the engine has a book for the symbol
+func (e *Engine) HasSymbol(symbol string) bool {
+	return e.GetOrderBookForSymbol(symbol) != nil
+}
+
+// GetTriggerBookForSymbol returns the stop order book for a symbol, or nil if it is not registered
+func (e *Engine) GetTriggerBookForSymbol(symbol string) *TriggerBook {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+	return e.triggerBooks[symbol]
+}
+
+// GetLastTradePrice returns the last trade price for a symbol and whether it has traded
+func (e *Engine) GetLastTradePrice(symbol string) (Price, bool) {
+	e.priceMutex.RLock()
+	defer e.priceMutex.RUnlock()
+	price, ok := e.lastPrices[symbol]
+	return price, ok
+}
+
+func (e *Engine) setLastTradePrice(symbol string, price Price) {
+	e.priceMutex.Lock()
+	defer e.priceMutex.Unlock()
+	e.lastPrices[symbol] = price
+}
+
+// GetSymbols returns all registered symbols in sorted order
+func (e *Engine) GetSymbols() []string {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+
+	symbols := make([]string, 0, len(e.orderBooks))
+	for symbol := range e.orderBooks {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+	return symbols
 }
 
 // GenerateOrderID generates a unique order ID
@@ -115,49 +585,122 @@ func (e *Engine) UntrackOrder(orderID uint64) {
 	delete(e.orderTracker, orderID)
 }
 
-// GetOrder retrieves an order by ID
+// GetOrder returns a copy of a working or retained terminal order, or nil if it is unknown
 func (e *Engine) GetOrder(orderID uint64) *Order {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	if order := e.trackedOrder(orderID); order != nil {
+		return e.copyOrder(order)
+	}
 	e.trackerMutex.RLock()
 	defer e.trackerMutex.RUnlock()
-	return e.orderTracker[orderID]
+	return e.copyOrder(e.doneOrders[orderID])
 }
 
-// GetAllOrders returns all tracked orders
+// GetAllOrders returns copies of all working and retained terminal orders
 func (e *Engine) GetAllOrders() []*Order {
+	return e.copyOrdersWhere(func(*Order) bool { return true })
+}
+
+// GetOrdersByUser returns copies of all orders for a specific user
+func (e *Engine) GetOrdersByUser(userID string) []*Order {
+	return e.copyOrdersWhere(func(order *Order) bool { return order.UserID == userID })
+}
+
+// GetOrdersBySide returns copies of all orders for a specific side
+func (e *Engine) GetOrdersBySide(side SideType) []*Order {
+	return e.copyOrdersWhere(func(order *Order) bool { return order.Side == side })
+}
+
+func (e *Engine) copyOrdersWhere(match func(*Order) bool) []*Order {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	orders := make([]*Order, 0)
+	for _, order := range e.trackedOrders() {
+		if match(order) {
+			orders = append(orders, e.copyOrder(order))
+		}
+	}
+
 	e.trackerMutex.RLock()
 	defer e.trackerMutex.RUnlock()
-
-	orders := make([]*Order, 0, len(e.orderTracker))
-	for _, order := range e.orderTracker {
-		orders = append(orders, order)
+	for _, order := range e.doneQueue {
+		if match(order) {
+			orders = append(orders, e.copyOrder(order))
+		}
 	}
 	return orders
 }
 
-// GetOrdersByUser returns all orders for a specific user
-func (e *Engine) GetOrdersByUser(userID string) []*Order {
+func (e *Engine) copyOrder(order *Order) *Order {
+	if order == nil {
+		return nil
+	}
+	clone := *order
+	return &clone
+}
+
+// trackedOrder looks up a working order without taking stateMutex
+func (e *Engine) trackedOrder(orderID uint64) *Order {
 	e.trackerMutex.RLock()
 	defer e.trackerMutex.RUnlock()
+	return e.orderTracker[orderID]
+}
 
-	orders := make([]*Order, 0)
-	for _, order := range e.orderTracker {
-		if order.UserID == userID {
-			orders = append(orders, order)
-		}
+// finishOrder moves an order out of the working set once it reaches a terminal status
+func (e *Engine) finishOrder(order *Order, status OrderStatus) {
+	order.Status = status
+	order.DoneTime = e.commandTime
+	e.UntrackOrder(order.ID)
+	e.markOrder(order)
+
+	e.trackerMutex.Lock()
+	if e.orderRetention > 0 {
+		e.doneOrders[order.ID] = order
+		e.doneQueue = append(e.doneQueue, order)
 	}
-	return orders
+	e.trackerMutex.Unlock()
+
+	e.pruneDoneOrders()
 }
 
-// GetOrdersBySide returns all orders for a specific side
-func (e *Engine) GetOrdersBySide(side SideType) []*Order {
+// finishUnlessWorking ends an order that left matching with a remainder it cannot rest
+func (e *Engine) finishUnlessWorking(order *Order) {
+	if order.Size == 0 {
+		e.finishOrder(order, StatusFilled)
+	} else {
+		e.finishOrder(order, StatusCancelled)
+	}
+}
+
+// rejectOrder ends an order the engine refuses and returns the error reported to its submitter
+func (e *Engine) rejectOrder(order *Order, reason RejectReason) error {
+	order.RejectReason = reason
+	e.finishOrder(order, StatusRejected)
+	return &RejectError{Reason: reason}
+}
+
+// pruneDoneOrders drops terminal orders whose retention has lapsed
+func (e *Engine) pruneDoneOrders() {
+	e.trackerMutex.Lock()
+	defer e.trackerMutex.Unlock()
+
+	for len(e.doneQueue) > 0 && e.commandTime.Sub(e.doneQueue[0].DoneTime) >= e.orderRetention {
+		delete(e.doneOrders, e.doneQueue[0].ID)
+		e.doneQueue = e.doneQueue[1:]
+	}
+}
+
+// trackedOrders lists working orders without taking stateMutex
+func (e *Engine) trackedOrders() []*Order {
 	e.trackerMutex.RLock()
 	defer e.trackerMutex.RUnlock()
 
-	orders := make([]*Order, 0)
+	orders := make([]*Order, 0, len(e.orderTracker))
 	for _, order := range e.orderTracker {
-		if order.Side == side {
-			orders = append(orders, order)
-		}
+		orders = append(orders, order)
 	}
 	return orders
 }
@@ -174,12 +717,29 @@ func (e *Engine) AddTradeToHistory(trade *Trade) {
 		e.tradeHistory = e.tradeHistory[len(e.tradeHistory)-e.maxHistory:]
 	}
 
-	// Persist to disk
-	if e.tradePersister != nil {
-		go e.tradePersister.WriteTrade(trade)
+	// Persist to disk, unless replaying trades that were already written
+	if e.tradePersister != nil && !e.replaying {
+		e.tradePersister.WriteTrade(trade)
 	}
 }
 
+// GetRecentTradesBySymbol returns recent trades for a single symbol, newest first
+func (e *Engine) GetRecentTradesBySymbol(symbol string, limit int) []*Trade {
+	e.historyMutex.RLock()
+	defer e.historyMutex.RUnlock()
+
+	trades := make([]*Trade, 0)
+	for i := len(e.tradeHistory) - 1; i >= 0; i-- {
+		if limit > 0 && len(trades) >= limit {
+			break
+		}
+		if e.tradeHistory[i].Symbol == symbol {
+			trades = append(trades, e.tradeHistory[i])
+		}
+	}
+	return trades
+}
+
 // GetRecentTrades returns recent trades from memory
 func (e *Engine) GetRecentTrades(limit int) []*Trade {
 	e.historyMutex.RLock()
@@ -197,52 +757,415 @@ func (e *Engine) GetRecentTrades(limit int) []*Trade {
 	return trades
 }
 
-// Close cleanly shuts down the engine
+// Close cleanly shuts down the engine. Orders submitted afterwards are ignored.
 func (e *Engine) Close() error {
+	select {
+	case <-e.stopped:
+	default:
+		close(e.stopped)
+	}
+	e.sequencer.Wait() // The journal and trade log are closed only once no command can write them
+	if e.stopExpiry != nil {
+		close(e.stopExpiry)
+		e.stopExpiry = nil
+	}
+	if e.stopSession != nil {
+		close(e.stopSession)
+		e.stopSession = nil
+	}
+	if e.stopSnapshots != nil {
+		close(e.stopSnapshots)
+		e.stopSnapshots = nil
+		e.snapshotWorker.Wait()
+	}
+
+	var err error
+	if e.journal != nil {
+		err = e.journal.Close()
+		e.journal = nil
+	}
 	if e.tradePersister != nil {
-		return e.tradePersister.Close()
+		if closeErr := e.tradePersister.Close(); err == nil {
+			err = closeErr
+		}
 	}
-	return nil
+	return err
+}
+
+// BookSnapshot is a consistent view of a symbol's price levels, best first on each side
+type BookSnapshot struct {
+	Symbol string
+	Seq    uint64 // Book sequence the snapshot reflects; see BookUpdate
+	Bids   []BookLevel
+	Asks   []BookLevel
 }
 
-// GetOrderBook returns the order book
+// GetBookSnapshot returns up to depth levels per side for a symbol, or false if the symbol
+// is not registered. A depth of 0 returns every level.
+func (e *Engine) GetBookSnapshot(symbol string, depth int) (BookSnapshot, bool) {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	book := e.GetOrderBookForSymbol(symbol)
+	if book == nil {
+		return BookSnapshot{}, false
+	}
+	return BookSnapshot{
+		Symbol: symbol,
+		Seq:    e.bookSeq[symbol],
+		Bids:   book.GetBidLevels(depth),
+		Asks:   book.GetAskLevels(depth),
+	}, true
+}
+
+// GetOrderBook returns the order book for the default symbol
 func (e *Engine) GetOrderBook() *OrderBook {
-	return e.orderBook
+	return e.GetOrderBookForSymbol(DefaultSymbol)
+}
+
+// GetOrderBookForSymbol returns the order book for a symbol, or nil if it is not registered.
+// The book is mutated by the sequencer, so use GetBookSnapshot while the engine is serving orders.
+func (e *Engine) GetOrderBookForSymbol(symbol string) *OrderBook {
+	e.booksMutex.RLock()
+	defer e.booksMutex.RUnlock()
+	return e.orderBooks[symbol]
+}
+
+// CancelOrder removes a working order from its book and reports whether it was found
+func (e *Engine) CancelOrder(orderId uint64) bool {
+	deleted, _ := e.SubmitCancel(orderId)
+	return deleted
+}
+
+// SubmitCancel is CancelOrder, also returning an error if the cancel could not be journaled
+func (e *Engine) SubmitCancel(orderId uint64) (bool, error) {
+	var deleted bool
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		deleted, err = e.cancelByUser(orderId)
+	}) {
+		return false, ErrEngineClosed
+	}
+	return deleted, err
 }
 
-func (e *Engine) Start() {
-	for order := range e.incomingOrders {
-		trades := e.PlaceOrder(order)
-		for _, trade := range trades {
-			e.trades <- trade
+// cancelOrder removes a working order, leaving it with the given terminal status
+func (e *Engine) cancelOrder(orderId uint64, status OrderStatus) (bool, error) {
+	// Route by the tracked order's symbol, otherwise search every book
+	if order := e.trackedOrder(orderId); order != nil {
+		if err := e.record(&JournalEntry{Type: JournalCancel, OrderID: orderId, Status: status}); err != nil {
+			return false, err
+		}
+		// A held bracket exit is in no book
+		if order.Status != StatusPending && !e.deleteFromSymbol(order.Symbol, orderId) {
+			return false, nil
 		}
+		e.finishOrder(order, status)
+		return true, nil
 	}
+
+	for _, symbol := range e.GetSymbols() {
+		if e.deleteFromSymbol(symbol, orderId) {
+			e.UntrackOrder(orderId)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (e *Engine) CancelOrder(orderId uint64) bool {
-	deleted := e.orderBook.DeleteOrderById(orderId)
-	if deleted {
-		e.UntrackOrder(orderId)
+// deleteFromSymbol removes an order from a symbol's order book or trigger book
+func (e *Engine) deleteFromSymbol(symbol string, orderId uint64) bool {
+	if book := e.GetOrderBookForSymbol(symbol); book != nil {
+		if order := book.SearchById(orderId); order != nil && book.DeleteOrderById(orderId) {
+			e.markLevel(order)
+			return true
+		}
 	}
-	return deleted
+	if triggers := e.GetTriggerBookForSymbol(symbol); triggers != nil && triggers.DeleteStopOrder(orderId) {
+		return true
+	}
+	return false
+}
+
+// AmendOrder changes the limit price and total quantity of a working order and returns any
+// trades it produced. A zero price or quantity leaves that field unchanged. Reducing quantity
+// keeps the order's place in its queue; changing the price or increasing quantity moves it to
+// the back of the queue at its new price, matching first if the new price crosses the spread.
+func (e *Engine) AmendOrder(orderId uint64, price Price, quantity int) ([]*Trade, error) {
+	var trades []*Trade
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		trades, err = e.amendOrder(orderId, price, quantity)
+	}) {
+		return nil, ErrEngineClosed
+	}
+	return trades, err
+}
+
+func (e *Engine) amendOrder(orderId uint64, price Price, quantity int) ([]*Trade, error) {
+	order := e.trackedOrder(orderId)
+	if order == nil {
+		return nil, ErrOrderNotWorking
+	}
+
+	if price == 0 {
+		price = order.Price
+	}
+	if quantity == 0 {
+		quantity = order.OriginalSize
+	}
+	if price != order.Price && ((order.OrderType != LimitOrder && order.OrderType != StopLimitOrder) || order.Peg != PegNone) {
+		return nil, ErrAmendPrice
+	}
+	if inst, _ := e.GetInstrument(order.Symbol); !inst.IsOnTick(price) {
+		return nil, ErrOffTick
+	}
+	if quantity <= order.FilledSize {
+		return nil, ErrAmendQuantity
+	}
+	if quantity != order.OriginalSize && order.IsGroupLeg() {
+		return nil, ErrAmendGroup
+	}
+	if price == order.Price && quantity == order.OriginalSize {
+		return nil, nil
+	}
+
+	// An amend cancels the old terms and enters new ones, so the phase must allow both
+	if phase := e.phases[order.Symbol]; !phase.AllowsCancel() || !phase.AllowsOrderType(order.OrderType) {
+		return nil, &RejectError{Reason: RejectPhase}
+	}
+
+	book := e.GetOrderBookForSymbol(order.Symbol)
+
+	// A new price must be inside the price bands, as for a new order
+	if price != order.Price && !e.withinBands(order.Symbol, order.Side, price, e.tradesOnArrival(order)) {
+		return nil, &RejectError{Reason: RejectPriceBand}
+	}
+
+	// An amend that adds exposure must stay inside the user's risk limits
+	if price != order.Price || quantity > order.OriginalSize {
+		if reason := e.checkRisk(order, price, quantity, quantity-order.FilledSize, 0); reason != RejectNone {
+			return nil, &RejectError{Reason: reason}
+		}
+	}
+
+	// A post-only order keeps its old terms rather than being rejected for a crossing amend
+	if order.PostOnly == PostOnlyReject && price != order.Price && crossesBook(book, order.Side, price) {
+		return nil, &RejectError{Reason: RejectPostOnly}
+	}
+
+	if err := e.record(&JournalEntry{Type: JournalAmend, OrderID: orderId, Price: price, Size: quantity}); err != nil {
+		return nil, err
+	}
+
+	// A pure size reduction keeps time priority, so the order is changed where it rests
+	if price == order.Price && quantity < order.OriginalSize {
+		order.OriginalSize = quantity
+		order.Size = quantity - order.FilledSize
+		order.VisibleSize = min(order.VisibleSize, order.Size)
+		e.markOrder(order)
+		if book.SearchById(orderId) != nil {
+			e.markLevel(order)
+		}
+		return nil, nil
+	}
+
+	// A held bracket exit only takes its new price, as it has no place in a book
+	if order.Status == StatusPending {
+		order.Price = price
+		e.markOrder(order)
+		return nil, nil
+	}
+
+	e.deleteFromSymbol(order.Symbol, orderId)
+	order.Price = price
+	order.OriginalSize = quantity
+	order.Size = quantity - order.FilledSize
+	e.markOrder(order)
+
+	// Untriggered stops go back to the trigger book, behind stops at the same stop price
+	if order.OrderType == StopMarketOrder || order.OrderType == StopLimitOrder {
+		e.GetTriggerBookForSymbol(order.Symbol).AddStopOrder(order)
+		return nil, nil
+	}
+
+	trades := e.executeOrder(book, order)
+	return append(trades, e.processTriggeredStops(book, order.Symbol, trades)...), nil
 }
 
+// PlaceOrder routes an order to its symbol's book and returns every trade it produced,
+// including trades from any stop orders it triggered. It runs on the sequencer and
+// returns once the order has been fully processed.
 func (e *Engine) PlaceOrder(incomingOrder *Order) []*Trade {
+	trades, _ := e.SubmitOrder(incomingOrder)
+	return trades
+}
+
+// SubmitOrder is PlaceOrder, also returning an error if the order could not be journaled.
+// An order that fails to journal is not applied.
+func (e *Engine) SubmitOrder(incomingOrder *Order) ([]*Trade, error) {
+	var trades []*Trade
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		trades, err = e.placeOrder(incomingOrder)
+	}) {
+		return nil, ErrEngineClosed
+	}
+	return trades, err
+}
+
+func (e *Engine) placeOrder(incomingOrder *Order) ([]*Trade, error) {
+	if incomingOrder.OrderType == CancelOrder {
+		_, err := e.cancelByUser(incomingOrder.ID)
+		return nil, err
+	}
+
+	// Route to the book for the order's symbol
+	book := e.GetOrderBookForSymbol(incomingOrder.Symbol)
+	if book == nil {
+		return nil, e.rejectOrder(incomingOrder, RejectUnknownSymbol)
+	}
+
+	// Prices off the symbol's tick grid are rejected
+	if inst, _ := e.GetInstrument(incomingOrder.Symbol); !inst.IsOrderOnTick(incomingOrder) {
+		return nil, e.rejectOrder(incomingOrder, RejectOffTick)
+	}
+
+	// A good-till-date order that has already expired never works
+	if incomingOrder.IsExpired(e.commandTime) {
+		return nil, e.rejectOrder(incomingOrder, RejectExpired)
+	}
+
+	// Each trading phase accepts only some order types. A bracket's exits are held until its entry
+	// fills, see groups.go, and wait for a phase that accepts them.
+	held := incomingOrder.Group == GroupBracket && incomingOrder.IsGroupLeg()
+	if !held && !e.phases[incomingOrder.Symbol].AllowsOrderType(incomingOrder.OrderType) {
+		return nil, e.rejectOrder(incomingOrder, RejectPhase)
+	}
+
+	// Orders that must execute on arrival cannot wait for an auction's uncross
+	if e.auctions[incomingOrder.Symbol] && !waitsForAuction(incomingOrder) {
+		return nil, e.rejectOrder(incomingOrder, RejectAuction)
+	}
+
+	// A pegged order takes its price from the book as it arrives
+	if incomingOrder.Peg != PegNone {
+		price, ok := e.pegPrice(book, incomingOrder)
+		if !ok {
+			return nil, e.rejectOrder(incomingOrder, RejectPegReference)
+		}
+		incomingOrder.Price = price
+	}
+
+	// A trailing stop without a stop price starts trailing from the last trade
+	if incomingOrder.OrderType == StopMarketOrder && incomingOrder.IsTrailing() && incomingOrder.StopPrice == 0 {
+		lastPrice, traded := e.GetLastTradePrice(incomingOrder.Symbol)
+		stopPrice, ok := e.trailingStopPrice(incomingOrder, lastPrice)
+		if !traded || !ok {
+			return nil, e.rejectOrder(incomingOrder, RejectTrailReference)
+		}
+		incomingOrder.StopPrice = stopPrice
+	}
+
+	// Limit prices must stay inside the symbol's price bands
+	if (incomingOrder.OrderType == LimitOrder || incomingOrder.OrderType == StopLimitOrder) &&
+		!e.withinBands(incomingOrder.Symbol, incomingOrder.Side, incomingOrder.Price, e.tradesOnArrival(incomingOrder)) {
+		return nil, e.rejectOrder(incomingOrder, RejectPriceBand)
+	}
+
+	// Orders must stay inside their user's risk limits; group legs were checked with their group
+	if !e.grouping {
+		if reason := e.checkRisk(incomingOrder, incomingOrder.Price, incomingOrder.Size, incomingOrder.Size, 1); reason != RejectNone {
+			return nil, e.rejectOrder(incomingOrder, reason)
+		}
+	}
+
+	// Orders that do not choose self-trade prevention take the engine's mode
+	if incomingOrder.SelfTradePrevention == SelfTradeDefault {
+		incomingOrder.SelfTradePrevention = e.selfTradeMode
+	}
+
+	// A reduce-only order is clipped to the position it can close
+	if incomingOrder.ReduceOnly {
+		reducible := e.reducibleSize(incomingOrder)
+		if reducible == 0 {
+			return nil, e.rejectOrder(incomingOrder, RejectReduceOnly)
+		}
+		if incomingOrder.Size > reducible {
+			incomingOrder.Size = reducible
+			incomingOrder.OriginalSize = reducible
+		}
+	}
+
+	// A minimum quantity must be executable the moment the order arrives
+	if incomingOrder.MinQuantity > 0 && (incomingOrder.OrderType == MarketOrder || incomingOrder.OrderType == LimitOrder) &&
+		!e.hasLiquidityFor(book, incomingOrder, min(incomingOrder.MinQuantity, incomingOrder.Size)) {
+		return nil, e.rejectOrder(incomingOrder, RejectMinQuantity)
+	}
+
+	if incomingOrder.OriginalSize == 0 {
+		incomingOrder.OriginalSize = incomingOrder.Size
+	}
+	incomingOrder.Status = StatusNew
+	e.markOrder(incomingOrder)
+
+	// Journal the order as submitted, before any fill changes it
+	if err := e.record(&JournalEntry{Type: JournalPlace, Order: incomingOrder}); err != nil {
+		return nil, err
+	}
+
 	// Track the order
-	if incomingOrder.OrderType != CancelOrder {
-		e.TrackOrder(incomingOrder)
+	e.TrackOrder(incomingOrder)
+
+	if held {
+		incomingOrder.Status = StatusPending
+		return nil, nil
 	}
 
 	var trades []*Trade
 
 	switch incomingOrder.OrderType {
+	case MarketOrder, LimitOrder:
+		trades = e.executeOrder(book, incomingOrder)
+		if incomingOrder.Status == StatusRejected {
+			return nil, &RejectError{Reason: incomingOrder.RejectReason}
+		}
+		if incomingOrder.Peg != PegNone && book.SearchById(incomingOrder.ID) != nil {
+			e.addPeg(incomingOrder)
+		}
+	case StopMarketOrder, StopLimitOrder:
+		trades = e.enterStop(book, incomingOrder)
+	default:
+		return nil, e.rejectOrder(incomingOrder, RejectInvalid)
+	}
+
+	return append(trades, e.processTriggeredStops(book, incomingOrder.Symbol, trades)...), nil
+}
+
+// executeOrder matches a market or limit order against the book and records its trades
+func (e *Engine) executeOrder(book *OrderBook, order *Order) []*Trade {
+	var trades []*Trade
+
+	// During an auction orders rest without matching until the uncross
+	if e.auctions[order.Symbol] {
+		e.restInAuction(book, order)
+		return nil
+	}
+
+	// Fill-or-kill needs its whole size available before any trade is created
+	if order.TimeInForce == FillOrKill && !e.hasLiquidityFor(book, order, order.Size) {
+		e.finishOrder(order, StatusCancelled)
+		return nil
+	}
+
+	switch order.OrderType {
 	case MarketOrder:
-		trades = e.executeMarketOrder(incomingOrder)
+		trades = e.executeMarketOrder(book, order)
 	case LimitOrder:
-		trades = e.executeLimitOrder(incomingOrder)
-	case CancelOrder:
-		e.CancelOrder(incomingOrder.ID)
-		return nil
+		trades = e.executeLimitOrder(book, order)
 	default:
 		return nil
 	}
@@ -250,141 +1173,474 @@ func (e *Engine) PlaceOrder(incomingOrder *Order) []*Trade {
 	// Add trades to history
 	for _, trade := range trades {
 		e.AddTradeToHistory(trade)
+		e.markTrade(trade)
+	}
+	if len(trades) > 0 {
+		e.setLastTradePrice(order.Symbol, trades[len(trades)-1].Price)
+		e.trackVolatility(order.Symbol, trades)
 	}
 
-	// If market order is fully filled, untrack it (it won't be in the book)
-	if incomingOrder.OrderType == MarketOrder {
-		e.UntrackOrder(incomingOrder.ID)
+	// Market orders never rest, so they end here filled or with the remainder cancelled, unless
+	// self-trade prevention has already cancelled them
+	if order.OrderType == MarketOrder && e.trackedOrder(order.ID) != nil {
+		e.finishUnlessWorking(order)
 	}
 
 	return trades
 }
 
-func (e *Engine) executeMarketOrder(incomingOrder *Order) []*Trade {
-	var trades []*Trade
-	sizeRemaining := incomingOrder.Size
+// hasLiquidityFor reports whether the opposite side can fill size of the order within its limit
+func (e *Engine) hasLiquidityFor(book *OrderBook, order *Order, size int) bool {
+	_, fifo := e.GetMatchingPolicy(order.Symbol).(FIFO)
+	limitPrice := order.Price
+	if order.OrderType == MarketOrder {
+		limitPrice, _ = e.marketLimit(book, order)
+	}
+	if order.Side == Buy {
+		if order.PreventsSelfTrade() {
+			return reachableSize(book.asks, order, size, fifo, func(price Price) bool { return price <= limitPrice }) >= size
+		}
+		return book.GetAskQuantityAtOrBelow(limitPrice) >= size
+	}
 
-	var getBestPrice func() (float64, []*Order)
-	var deleteOrder func(uint64) bool
+	if order.PreventsSelfTrade() {
+		return reachableSize(book.bids, order, size, fifo, func(price Price) bool { return price >= limitPrice }) >= size
+	}
+	return book.GetBidQuantityAtOrAbove(limitPrice) >= size
+}
 
-	if incomingOrder.Side == Buy {
-		getBestPrice = e.orderBook.GetBestAsk
-		deleteOrder = e.orderBook.DeleteAskOrder
-	} else {
-		getBestPrice = e.orderBook.GetBestBid
-		deleteOrder = e.orderBook.DeleteBidOrder
+// reachableSize returns how much of the opposite side an order that prevents self-trades can fill,
+// counting up to size. Unless it cancels the resting side, matching stops at the first order of
+// its own user, and iceberg slices ahead of that order are refreshed behind it. Under an
+// allocating policy nothing at that order's level is counted.
+func reachableSize(side *priceLevelList, order *Order, size int, fifo bool, within func(Price) bool) int {
+	available := 0
+	side.Each(func(level *PriceLevel) bool {
+		if !within(level.Price) {
+			return false
+		}
+		stop := -1
+		if order.SelfTradePrevention != SelfTradeCancelOldest {
+			stop = slices.IndexFunc(level.Orders, func(resting *Order) bool { return resting.UserID == order.UserID })
+		}
+		if stop >= 0 {
+			if !fifo {
+				return false
+			}
+			for _, ahead := range level.Orders[:stop] {
+				available += ahead.DisplayedSize()
+			}
+			return false
+		}
+		for _, resting := range level.Orders {
+			if resting.UserID != order.UserID {
+				available += resting.Size
+			}
+		}
+		return available < size
+	})
+	return available
+}
+
+// crossesBook reports whether a limit price on a side would trade against the opposite best
+func crossesBook(book *OrderBook, side SideType, price Price) bool {
+	if side == Buy {
+		bestAsk, asks := book.GetBestAsk()
+		return len(asks) > 0 && price >= bestAsk
+	}
+	bestBid, bids := book.GetBestBid()
+	return len(bids) > 0 && price <= bestBid
+}
+
+// slidePrice moves a post-only order one tick behind the opposite best, reporting false if
+// that would leave no valid price
+func (e *Engine) slidePrice(order *Order, oppositeBest Price) bool {
+	inst, _ := e.GetInstrument(order.Symbol)
+	price := oppositeBest + inst.TickSize
+	if order.Side == Buy {
+		price = oppositeBest - inst.TickSize
+	}
+	if price <= 0 {
+		return false
+	}
+	order.Price = price
+	return true
+}
+
+// processTriggeredStops fires stop orders whose stop price was reached by the given trades.
+// Stops are executed in trigger order, and trades from fired stops can trigger further stops.
+// Trailing stops move with each round of trades before it is checked. A volatility halt ends the
+// cascade, returning the stops still queued to the trigger book.
+func (e *Engine) processTriggeredStops(book *OrderBook, symbol string, trades []*Trade) []*Trade {
+	triggers := e.GetTriggerBookForSymbol(symbol)
+	if len(trades) == 0 || triggers.Len() == 0 {
+		return nil
+	}
+	e.trailStops(triggers, trades)
+	if e.phases[symbol] == PhaseHalted {
+		return nil
 	}
 
-	for sizeRemaining > 0 {
-		_, orderBlock := getBestPrice()
+	var cascaded []*Trade
+	queue := triggers.PopTriggered(trades[len(trades)-1].Price)
+
+	for len(queue) > 0 {
+		stop := queue[0]
+		queue = queue[1:]
 
-		// Check if liquidity available
-		if len(orderBlock) == 0 {
-			// No liquidity and market order fails (or handle otherwise)
+		stopTrades := e.fireStop(book, stop)
+		cascaded = append(cascaded, stopTrades...)
+
+		if e.phases[symbol] == PhaseHalted {
+			for _, queued := range queue {
+				triggers.AddStopOrder(queued)
+			}
 			break
 		}
-		oppositeOrder := orderBlock[0]
-
-		// Determine fill size
-		fillSize := sizeRemaining
-		if oppositeOrder.Size < sizeRemaining {
-			fillSize = oppositeOrder.Size
+		if len(stopTrades) > 0 {
+			e.trailStops(triggers, stopTrades)
+			queue = append(queue, triggers.PopTriggered(stopTrades[len(stopTrades)-1].Price)...)
 		}
+	}
 
-		// Create trade
-		trade := e.createTrade(incomingOrder, oppositeOrder, fillSize)
-		trades = append(trades, trade)
+	return cascaded
+}
 
-		// Update sizes
-		sizeRemaining -= fillSize
-		oppositeOrder.Size -= fillSize
+// enterStop rests a stop order in the trigger book unless the market is already through its stop
+// price, when it fires at once. During an auction stops wait for the uncross price.
+func (e *Engine) enterStop(book *OrderBook, stop *Order) []*Trade {
+	lastPrice, traded := e.GetLastTradePrice(stop.Symbol)
+	if !traded || !IsStopTriggered(stop, lastPrice) || e.auctions[stop.Symbol] {
+		e.GetTriggerBookForSymbol(stop.Symbol).AddStopOrder(stop)
+		return nil
+	}
+	return e.fireStop(book, stop)
+}
 
-		// Remove if fully filled
-		if oppositeOrder.Size == 0 {
-			deleteOrder(oppositeOrder.ID)
-			e.UntrackOrder(oppositeOrder.ID)
-		}
+// fireStop executes a triggered stop. A stop-limit priced beyond the price bands is cancelled
+// instead.
+func (e *Engine) fireStop(book *OrderBook, stop *Order) []*Trade {
+	order := activateStopOrder(stop)
+	if order.OrderType == LimitOrder && !e.withinBands(order.Symbol, order.Side, order.Price, true) {
+		order.CancelReason = CancelPriceBand
+		e.finishOrder(order, StatusCancelled)
+		return nil
+	}
+	return e.executeOrder(book, order)
+}
+
+// activateStopOrder converts a triggered stop into the order it becomes
+func activateStopOrder(order *Order) *Order {
+	switch order.OrderType {
+	case StopMarketOrder:
+		order.OrderType = MarketOrder
+	case StopLimitOrder:
+		order.OrderType = LimitOrder
 	}
+	return order
+}
+
+func (e *Engine) executeMarketOrder(book *OrderBook, incomingOrder *Order) []*Trade {
+	var getBestPrice func() (Price, []*Order)
+	var deleteOrder func(uint64) bool
+	var canMatch func(Price) bool
 
+	// A market order takes any price inside its protection and the symbol's price bands while
+	// liquidity lasts
+	limitPrice, reason := e.marketLimit(book, incomingOrder)
+	if incomingOrder.Side == Buy {
+		getBestPrice = book.GetBestAsk
+		deleteOrder = book.DeleteAskOrder
+		canMatch = func(bestPrice Price) bool { return bestPrice <= limitPrice }
+	} else {
+		getBestPrice = book.GetBestBid
+		deleteOrder = book.DeleteBidOrder
+		canMatch = func(bestPrice Price) bool { return bestPrice >= limitPrice }
+	}
+
+	trades, cancelled := e.matchBook(book, incomingOrder, getBestPrice, deleteOrder, canMatch)
+
+	// A remainder is cancelled with the reason matching stopped: the book ran out, or the next
+	// price is beyond a bound
+	if !cancelled && incomingOrder.Size > 0 {
+		bestPrice, orderBlock := getBestPrice()
+		switch {
+		case len(orderBlock) == 0:
+			incomingOrder.CancelReason = CancelNoLiquidity
+		case !canMatch(bestPrice):
+			incomingOrder.CancelReason = reason
+		}
+	}
 	return trades
 }
 
-func (e *Engine) executeLimitOrder(incomingOrder *Order) []*Trade {
-	var trades []*Trade
+// marketLimit returns the worst price a market order may trade at, and the reason a remainder
+// stopped there is cancelled: the order's own protection or the symbol's price bands
+func (e *Engine) marketLimit(book *OrderBook, order *Order) (Price, CancelReason) {
+	limitPrice, reason := e.bandLimit(order.Symbol, order.Side), CancelPriceBand
+	protection, ok := e.protectionLimit(book, order)
+	if ok && ((order.Side == Buy && protection < limitPrice) || (order.Side == Sell && protection > limitPrice)) {
+		limitPrice, reason = protection, CancelProtection
+	}
+	return limitPrice, reason
+}
 
-	sizeRemaining := incomingOrder.Size
+// protectionLimit returns the tightest of a market order's protection price and slippage bounds,
+// or false if it sets none that apply. Slippage is measured from the opposite best.
+func (e *Engine) protectionLimit(book *OrderBook, order *Order) (Price, bool) {
+	var limits []Price
+	if order.ProtectionPrice > 0 {
+		limits = append(limits, order.ProtectionPrice)
+	}
 
-	var getBestPrice func() (float64, []*Order)
+	touch, orders := book.GetBestAsk()
+	direction := Price(1)
+	if order.Side == Sell {
+		touch, orders = book.GetBestBid()
+		direction = -1
+	}
+	if len(orders) > 0 {
+		inst, _ := e.GetInstrument(order.Symbol)
+		if order.MaxSlippageTicks > 0 {
+			limits = append(limits, touch+direction*inst.TickSize*Price(order.MaxSlippageTicks))
+		}
+		if order.MaxSlippageBps > 0 {
+			limits = append(limits, touch+direction*touch*Price(order.MaxSlippageBps)/10000)
+		}
+	}
+
+	if len(limits) == 0 {
+		return 0, false
+	}
+	if order.Side == Buy {
+		return slices.Min(limits), true
+	}
+	return slices.Max(limits), true
+}
+
+func (e *Engine) executeLimitOrder(book *OrderBook, incomingOrder *Order) []*Trade {
+	var getBestPrice func() (Price, []*Order)
 	var addOrder func(*Order) bool
 	var deleteOrder func(uint64) bool
-	var canMatch func(float64, float64) bool
+	var canMatch func(Price, Price) bool
 
 	if incomingOrder.Side == Buy {
-		getBestPrice = e.orderBook.GetBestAsk
-		addOrder = e.orderBook.AddBidOrder
-		deleteOrder = e.orderBook.DeleteAskOrder
-		canMatch = func(limitPrice, bestPrice float64) bool {
+		getBestPrice = book.GetBestAsk
+		addOrder = book.AddBidOrder
+		deleteOrder = book.DeleteAskOrder
+		canMatch = func(limitPrice, bestPrice Price) bool {
 			return limitPrice >= bestPrice // Buy at or above ask
 		}
 	} else {
-		getBestPrice = e.orderBook.GetBestBid
-		addOrder = e.orderBook.AddAskOrder
-		deleteOrder = e.orderBook.DeleteBidOrder
-		canMatch = func(limitPrice, bestPrice float64) bool {
+		getBestPrice = book.GetBestBid
+		addOrder = book.AddAskOrder
+		deleteOrder = book.DeleteBidOrder
+		canMatch = func(limitPrice, bestPrice Price) bool {
 			return limitPrice <= bestPrice // Sell at or below bid
 		}
 	}
 
+	// A post-only order that would take liquidity is rejected, or slid one tick behind the opposite best
+	if incomingOrder.PostOnly != PostOnlyOff {
+		if bestPrice, orderBlock := getBestPrice(); len(orderBlock) > 0 && canMatch(incomingOrder.Price, bestPrice) {
+			if incomingOrder.PostOnly == PostOnlyReject || !e.slidePrice(incomingOrder, bestPrice) {
+				e.rejectOrder(incomingOrder, RejectPostOnly)
+				return nil
+			}
+		}
+	}
+
 	// Try to match
-	for sizeRemaining > 0 {
+	trades, cancelled := e.matchBook(book, incomingOrder, getBestPrice, deleteOrder, func(bestPrice Price) bool {
+		return canMatch(incomingOrder.Price, bestPrice)
+	})
+	if cancelled {
+		return trades
+	}
+
+	// Add remaining to book unless time in force forbids resting or a reduce-only order is done reducing
+	if incomingOrder.Size > 0 && incomingOrder.CanRest() && (!incomingOrder.ReduceOnly || e.reducibleSize(incomingOrder) > 0) {
+		incomingOrder.RefreshDisplay()
+		addOrder(incomingOrder)
+		e.markLevel(incomingOrder)
+	} else {
+		e.finishUnlessWorking(incomingOrder)
+	}
+
+	return trades
+}
+
+// matchBook trades an incoming order against the opposite side, best price first, while it has
+// size left and canMatch accepts the best price. The symbol's matching policy allocates among the
+// orders at each level. It reports whether self-trade prevention cancelled the incoming order.
+func (e *Engine) matchBook(book *OrderBook, incomingOrder *Order, getBestPrice func() (Price, []*Order),
+	deleteOrder func(uint64) bool, canMatch func(Price) bool) ([]*Trade, bool) {
+	var trades []*Trade
+	policy := e.GetMatchingPolicy(incomingOrder.Symbol)
+
+	for incomingOrder.Size > 0 {
 		bestPrice, orderBlock := getBestPrice()
 
 		// Check if there is current liquidity or we can match
-		if len(orderBlock) == 0 || !canMatch(incomingOrder.Price, bestPrice) {
+		if len(orderBlock) == 0 || !canMatch(bestPrice) {
 			break
 		}
 
-		oppositeOrder := orderBlock[0]
-
-		// Determine fill size
-		fillSize := sizeRemaining
-		if oppositeOrder.Size < sizeRemaining {
-			fillSize = oppositeOrder.Size
+		// The level changes as orders fill, so allocate over a copy of its queue. Resting
+		// reduce-only orders with no position left to reduce, and group legs their group has
+		// closed, are cancelled.
+		resting := make([]*Order, 0, len(orderBlock))
+		sizes := make([]int, 0, len(orderBlock))
+		for _, order := range slices.Clone(orderBlock) {
+			size := e.tradableSize(order)
+			if size == 0 && (order.ReduceOnly || order.IsGroupLeg()) {
+				deleteOrder(order.ID)
+				e.markLevel(order)
+				e.finishFlat(order)
+				continue
+			}
+			resting = append(resting, order)
+			sizes = append(sizes, size)
+		}
+		if len(resting) == 0 {
+			continue
 		}
 
-		// Create trade
-		trade := e.createTrade(incomingOrder, oppositeOrder, fillSize)
-		trades = append(trades, trade)
+		// An iceberg trades only its visible slice before it is refreshed, a reduce-only order
+		// only what still reduces its position, and a group leg only what its group has left
+		quantity := incomingOrder.Size
+		if incomingOrder.ReduceOnly {
+			quantity = min(quantity, e.reducibleSize(incomingOrder))
+		}
+		if open, ok := e.groupOpen(incomingOrder); ok {
+			quantity = min(quantity, open)
+		}
+		if quantity == 0 {
+			break
+		}
 
-		// Update sizes
-		sizeRemaining -= fillSize
-		oppositeOrder.Size -= fillSize
+		matched := false
+		for i, fillSize := range policy.Allocate(resting, sizes, quantity) {
+			if fillSize == 0 {
+				continue
+			}
+			oppositeOrder := resting[i]
+			matched = true
+
+			// Orders of one user meet only as self-trade prevention allows
+			if oppositeOrder.UserID == incomingOrder.UserID && incomingOrder.PreventsSelfTrade() {
+				if e.preventSelfTrade(incomingOrder, oppositeOrder, deleteOrder) {
+					return trades, true
+				}
+				continue
+			}
+
+			// Self-trade decrements may have shrunk the incoming order since the allocation
+			fillSize = min(fillSize, incomingOrder.Size)
+			if fillSize == 0 {
+				break
+			}
+
+			// Create trade
+			trade := e.createTrade(incomingOrder, oppositeOrder, fillSize)
+			trades = append(trades, trade)
+
+			// Update sizes
+			incomingOrder.Fill(trade.Price, fillSize)
+			oppositeOrder.Fill(trade.Price, fillSize)
+			e.addPosition(incomingOrder, fillSize)
+			e.addPosition(oppositeOrder, fillSize)
+			e.markOrder(incomingOrder)
+			e.markOrder(oppositeOrder)
+			e.markLevel(oppositeOrder)
+
+			e.settleFill(book, oppositeOrder, deleteOrder)
+		}
 
-		// Remove if fully filled
-		if oppositeOrder.Size == 0 {
-			deleteOrder(oppositeOrder.ID)
-			e.UntrackOrder(oppositeOrder.ID)
+		// A policy that allocates nothing would never make progress
+		if !matched {
+			break
 		}
 	}
 
-	// Add remaining to book
-	if sizeRemaining > 0 {
-		incomingOrder.Size = sizeRemaining
-		addOrder(incomingOrder)
-	} else {
-		// Fully filled, untrack the incoming order
-		e.UntrackOrder(incomingOrder.ID)
+	return trades, false
+}
+
+// preventSelfTrade resolves an incoming order meeting a resting order of the same user without a
+// trade, by its self-trade prevention mode. It reports whether the incoming order was cancelled.
+func (e *Engine) preventSelfTrade(incoming *Order, resting *Order, deleteOrder func(uint64) bool) bool {
+	var cancelIncoming, cancelResting bool
+	switch incoming.SelfTradePrevention {
+	case SelfTradeCancelNewest:
+		cancelIncoming = true
+	case SelfTradeCancelOldest:
+		cancelResting = true
+	case SelfTradeCancelBoth:
+		cancelIncoming, cancelResting = true, true
+	case SelfTradeDecrement:
+		size := min(incoming.Size, resting.Size)
+		incoming.Size -= size
+		resting.Size -= size
+		resting.VisibleSize = min(resting.VisibleSize, resting.Size)
+		cancelIncoming, cancelResting = incoming.Size == 0, resting.Size == 0
+		e.markOrder(incoming)
+		e.markOrder(resting)
+		e.markLevel(resting)
 	}
 
-	return trades
+	if cancelResting {
+		deleteOrder(resting.ID)
+		e.markLevel(resting)
+		e.cancelSelfTrade(incoming, resting)
+	}
+	if cancelIncoming {
+		e.cancelSelfTrade(incoming, incoming)
+	}
+	return cancelIncoming
+}
+
+// cancelSelfTrade ends an order removed by self-trade prevention and reports it on the incoming order
+func (e *Engine) cancelSelfTrade(incoming *Order, order *Order) {
+	order.CancelReason = CancelSelfTrade
+	e.finishOrder(order, StatusCancelled)
+	incoming.SelfTradeCancelled = append(incoming.SelfTradeCancelled, order.ID)
+}
+
+// settleFill removes a resting order that has filled or is done reducing, or shows the next
+// slice of an iceberg whose visible quantity has filled
+func (e *Engine) settleFill(book *OrderBook, order *Order, deleteOrder func(uint64) bool) {
+	if order.Size == 0 {
+		deleteOrder(order.ID)
+		e.finishOrder(order, StatusFilled)
+	} else if order.ReduceOnly && e.reducibleSize(order) == 0 {
+		deleteOrder(order.ID)
+		e.finishOrder(order, StatusCancelled)
+	} else if order.DisplayedSize() == 0 {
+		replenishIceberg(book, order)
+	}
+}
+
+// replenishIceberg shows the next slice of an iceberg whose visible quantity has filled. The new
+// slice loses time priority and joins the back of its price level.
+func replenishIceberg(book *OrderBook, order *Order) {
+	order.RefreshDisplay()
+	book.MoveToBack(order.ID)
 }
 
 func (e *Engine) createTrade(incoming *Order, opposite *Order, size int) *Trade {
 	trade := &Trade{
-		Price:     opposite.Price, // Always execute at resting order price
-		Size:      size,
-		Timestamp: time.Now(),
+		ID:            e.newTradeID(),
+		Symbol:        incoming.Symbol,
+		Price:         opposite.Price, // Always execute at resting order price
+		Size:          size,
+		Timestamp:     e.commandTime,
+		AggressorSide: incoming.Side,
+		MakerOrderID:  opposite.ID,
+		TakerOrderID:  incoming.ID,
+		MakerUserID:   opposite.UserID,
+		TakerUserID:   incoming.UserID,
 	}
+	e.chargeFees(trade)
 
 	if incoming.Side == Buy {
 		trade.BuyOrderID = incoming.ID
@@ -396,3 +1652,10 @@ func (e *Engine) createTrade(incoming *Order, opposite *Order, size int) *Trade
 
 	return trade
 }
+
+// newTradeID issues the next trade ID. IDs follow the sequence of commands, so replaying the
+// journal issues the same IDs again.
+func (e *Engine) newTradeID() uint64 {
+	e.nextTradeID++
+	return e.nextTradeID
+}
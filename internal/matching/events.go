@@ -0,0 +1,228 @@
+package matching
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"time"
+)
+
+/*
+The engine publishes what each command changed as a batch of events, so market data feeds can
+push updates instead of polling. A batch holds the trades in execution order, the final state of
+every order the command touched, and one book update per symbol listing the price levels whose
+resting quantity changed. Symbols in a call auction also get their indicative uncross.
+
+Book updates carry a per-symbol sequence number that increases by one for every command that
+changes the book. A BookSnapshot reports the sequence it reflects, so a consumer can take a
+snapshot and apply the updates after it without gaps. Replay advances the sequence exactly as
+live matching did, and snapshots save it, so sequence numbers survive a restart.
+
+Handlers run on the sequencer while it holds stateMutex, after the command has been applied and
+before the next one starts. They must not block and must not call back into the engine.
+*/
+
+// EventType identifies what an Event reports
+type EventType int
+
+const (
+	TradeEvent   EventType = iota + 1 // A trade was executed
+	OrderEvent                        // An order was accepted, filled, or reached a terminal status
+	BookEvent                         // Resting quantity changed at one or more price levels
+	AuctionEvent                      // An auction started, ended, or its indicative uncross may have changed
+)
+
+// Event is one change made by a command
+type Event struct {
+	Type    EventType
+	Symbol  string
+	Time    time.Time    // When the command was sequenced
+	Trade   *Trade       // TradeEvent only
+	Order   *Order       // OrderEvent only; a copy of the order after the command
+	Book    *BookUpdate  // BookEvent only
+	Auction *AuctionInfo // AuctionEvent only
+}
+
+// BookUpdate lists the price levels of one symbol changed by a command
+type BookUpdate struct {
+	Seq     uint64      // Book sequence number after this update
+	Bids    []BookLevel // Changed bid levels, best first; a Quantity of 0 removes the level
+	Asks    []BookLevel // Changed ask levels, best first; a Quantity of 0 removes the level
+	BestBid *BookLevel  // Top of book after the update, nil if the side is empty
+	BestAsk *BookLevel
+}
+
+// EventHandler receives the events of one command
+type EventHandler func(events []Event)
+
+// levelKey identifies a price level touched by a command
+type levelKey struct {
+	symbol string
+	side   SideType
+	price  Price
+}
+
+// eventBatch collects the changes of the command being applied
+type eventBatch struct {
+	trades   []*Trade
+	orders   []*Order
+	touched  map[uint64]bool
+	levels   map[levelKey]bool
+	auctions map[string]bool // Symbols whose auction started or ended
+	groups   map[uint64]bool // Order groups to settle once the command has been applied
+}
+
+// reset empties the batch, keeping its storage for the next command
+func (b *eventBatch) reset() {
+	clear(b.trades)
+	clear(b.orders)
+	b.trades = b.trades[:0]
+	b.orders = b.orders[:0]
+	clear(b.touched)
+	clear(b.levels)
+	clear(b.auctions)
+	clear(b.groups)
+}
+
+// Subscribe registers a handler for the events of every later command. The returned function
+// removes it.
+func (e *Engine) Subscribe(handler EventHandler) (unsubscribe func()) {
+	e.eventMutex.Lock()
+	defer e.eventMutex.Unlock()
+
+	e.nextSubscriber++
+	id := e.nextSubscriber
+	e.subscribers[id] = handler
+
+	return func() {
+		e.eventMutex.Lock()
+		defer e.eventMutex.Unlock()
+		delete(e.subscribers, id)
+	}
+}
+
+// markTrade records a trade executed by the current command
+func (e *Engine) markTrade(trade *Trade) {
+	e.events.trades = append(e.events.trades, trade)
+}
+
+// markOrder records that the current command changed an order, and that its group must be settled
+func (e *Engine) markOrder(order *Order) {
+	if order.Group != GroupNone {
+		e.markGroup(order.GroupID)
+	}
+	if e.events.touched == nil {
+		e.events.touched = make(map[uint64]bool)
+	}
+	if !e.events.touched[order.ID] {
+		e.events.touched[order.ID] = true
+		e.events.orders = append(e.events.orders, order)
+	}
+}
+
+// markLevel records that the current command changed resting quantity at an order's price
+func (e *Engine) markLevel(order *Order) {
+	if e.events.levels == nil {
+		e.events.levels = make(map[levelKey]bool)
+	}
+	e.events.levels[levelKey{symbol: order.Symbol, side: order.Side, price: order.Price}] = true
+}
+
+// markAuction records that the current command started or ended a symbol's auction
+func (e *Engine) markAuction(symbol string) {
+	if e.events.auctions == nil {
+		e.events.auctions = make(map[string]bool)
+	}
+	e.events.auctions[symbol] = true
+}
+
+// publishEvents advances the book sequences and delivers the current command's events. It runs
+// on the sequencer, or during replay, once the command has been applied.
+func (e *Engine) publishEvents() {
+	batch := &e.events
+	defer batch.reset()
+
+	// Group touched levels by symbol and advance each symbol's sequence once
+	levels := make(map[string][]levelKey)
+	for key := range batch.levels {
+		levels[key.symbol] = append(levels[key.symbol], key)
+	}
+	symbols := make([]string, 0, len(levels))
+	for symbol := range levels {
+		e.bookSeq[symbol]++
+		symbols = append(symbols, symbol)
+	}
+
+	e.eventMutex.RLock()
+	defer e.eventMutex.RUnlock()
+	if len(e.subscribers) == 0 {
+		return
+	}
+
+	events := make([]Event, 0, len(batch.trades)+len(batch.orders)+len(symbols))
+	for _, trade := range batch.trades {
+		events = append(events, Event{Type: TradeEvent, Symbol: trade.Symbol, Time: e.commandTime, Trade: trade})
+	}
+	for _, order := range batch.orders {
+		events = append(events, Event{Type: OrderEvent, Symbol: order.Symbol, Time: e.commandTime, Order: e.copyOrder(order)})
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		events = append(events, Event{
+			Type:   BookEvent,
+			Symbol: symbol,
+			Time:   e.commandTime,
+			Book:   e.bookUpdate(symbol, levels[symbol]),
+		})
+	}
+
+	// Auctions that started or ended, and running auctions whose book changed
+	auctions := make([]string, 0, len(batch.auctions))
+	for symbol := range batch.auctions {
+		auctions = append(auctions, symbol)
+	}
+	for _, symbol := range symbols {
+		if e.auctions[symbol] && !batch.auctions[symbol] {
+			auctions = append(auctions, symbol)
+		}
+	}
+	sort.Strings(auctions)
+	for _, symbol := range auctions {
+		info := e.auctionInfo(symbol)
+		events = append(events, Event{Type: AuctionEvent, Symbol: symbol, Time: e.commandTime, Auction: &info})
+	}
+
+	if len(events) == 0 {
+		return
+	}
+	for _, handler := range e.subscribers {
+		handler(events)
+	}
+}
+
+// bookUpdate reads the current state of the touched levels of one symbol
+func (e *Engine) bookUpdate(symbol string, keys []levelKey) *BookUpdate {
+	book := e.GetOrderBookForSymbol(symbol)
+	update := &BookUpdate{Seq: e.bookSeq[symbol]}
+
+	for _, key := range keys {
+		level := book.GetLevel(key.side, key.price)
+		if key.side == Buy {
+			update.Bids = append(update.Bids, level)
+		} else {
+			update.Asks = append(update.Asks, level)
+		}
+	}
+	slices.SortFunc(update.Bids, func(a, b BookLevel) int { return cmp.Compare(b.Price, a.Price) })
+	slices.SortFunc(update.Asks, func(a, b BookLevel) int { return cmp.Compare(a.Price, b.Price) })
+
+	if best := book.GetBestBidLevel(); best != nil {
+		level := book.GetLevel(Buy, best.Price)
+		update.BestBid = &level
+	}
+	if best := book.GetBestAskLevel(); best != nil {
+		level := book.GetLevel(Sell, best.Price)
+		update.BestAsk = &level
+	}
+	return update
+}
@@ -0,0 +1,172 @@
+package matching
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+Fees are charged on every trade of a symbol with a fee schedule, to both sides: the maker at its
+tier's maker rate and the taker at its taker rate, each in basis points of the trade's notional
+(price times quantity, in price units). A negative rate is a rebate. Charges are rounded up to
+the next price unit and rebates towards zero, and a tier may not rebate makers more than it
+charges takers. In an auction uncross the later order of each trade pays the taker rate.
+
+A user's tier in a symbol is the highest tier whose MinVolume the user's 30-day volume has
+reached, unless the schedule assigns the user a tier by name. The 30-day volume is the quantity
+the user traded in the symbol, as maker or taker, on the current UTC day and the 29 before it;
+it is counted from trade timestamps, so replay rebuilds it, and snapshots carry it. The tier is
+looked up before each trade is counted, so a trade never pays the rate it unlocks.
+*/
+
+// FeeVolumeDays is how many UTC days of trading count towards a user's fee tier
+const FeeVolumeDays = 30
+
+// FeeTier is a level of maker and taker rates
+type FeeTier struct {
+	Name      string
+	MinVolume int64 // 30-day traded quantity from which users reach the tier
+	MakerBps  int   // Rate charged to the resting order, negative for a rebate
+	TakerBps  int   // Rate charged to the incoming order
+}
+
+// FeeSchedule holds the fee tiers of a symbol
+type FeeSchedule struct {
+	Tiers     []FeeTier         // By rising MinVolume; the first starts at 0
+	UserTiers map[string]string // Tiers assigned to users by name, whatever their volume
+}
+
+// Validate checks that the tiers start at no volume, rise, and do not pay out more than they charge
+func (s FeeSchedule) Validate() error {
+	if len(s.Tiers) == 0 || s.Tiers[0].MinVolume != 0 {
+		return fmt.Errorf("fee schedule needs a first tier from volume 0")
+	}
+	names := make(map[string]bool)
+	for i, tier := range s.Tiers {
+		if tier.Name == "" || names[tier.Name] {
+			return fmt.Errorf("fee tier names must be set and unique")
+		}
+		names[tier.Name] = true
+		if i > 0 && tier.MinVolume <= s.Tiers[i-1].MinVolume {
+			return fmt.Errorf("fee tier %s must start above the volume of %s", tier.Name, s.Tiers[i-1].Name)
+		}
+		if tier.TakerBps < 0 || tier.MakerBps+tier.TakerBps < 0 {
+			return fmt.Errorf("fee tier %s rebates more than it charges", tier.Name)
+		}
+	}
+	for userID, name := range s.UserTiers {
+		if !names[name] {
+			return fmt.Errorf("user %s is assigned unknown fee tier %q", userID, name)
+		}
+	}
+	return nil
+}
+
+// tierFor returns the tier of a user with a 30-day volume, and whether it was assigned
+func (s FeeSchedule) tierFor(userID string, volume int64) (FeeTier, bool) {
+	if name, ok := s.UserTiers[userID]; ok {
+		for _, tier := range s.Tiers {
+			if tier.Name == name {
+				return tier, true
+			}
+		}
+	}
+	current := s.Tiers[0]
+	for _, tier := range s.Tiers[1:] {
+		if volume >= tier.MinVolume {
+			current = tier
+		}
+	}
+	return current, false
+}
+
+// FeeInfo is a user's fee tier in a symbol
+type FeeInfo struct {
+	UserID   string
+	Symbol   string
+	Tier     FeeTier // Zero for a symbol without fees
+	Assigned bool    // The schedule assigns the tier rather than the user's volume
+	Volume   int64   // Quantity traded over the last FeeVolumeDays UTC days
+}
+
+// volumeDay is the quantity a user traded in a symbol on one UTC day
+type volumeDay struct {
+	Day      time.Time
+	Quantity int64
+}
+
+// GetFeeInfo returns a user's current fee tier and 30-day volume in a symbol, or false for an
+// unknown symbol
+func (e *Engine) GetFeeInfo(userID string, symbol string) (FeeInfo, bool) {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	if e.GetOrderBookForSymbol(symbol) == nil {
+		return FeeInfo{}, false
+	}
+	info := FeeInfo{UserID: userID, Symbol: symbol}
+	info.Volume = e.volumeSince(positionKey{userID: userID, symbol: symbol}, feeWindowStart(time.Now()))
+	if schedule, ok := e.fees[symbol]; ok {
+		info.Tier, info.Assigned = schedule.tierFor(userID, info.Volume)
+	}
+	return info, true
+}
+
+// feeWindowStart returns the first UTC day counted towards fee tiers at a time
+func feeWindowStart(at time.Time) time.Time {
+	return at.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-FeeVolumeDays)
+}
+
+// volumeSince returns the quantity a user traded in a symbol from a day on
+func (e *Engine) volumeSince(key positionKey, since time.Time) int64 {
+	var volume int64
+	for _, day := range e.volumes[key] {
+		if !day.Day.Before(since) {
+			volume += day.Quantity
+		}
+	}
+	return volume
+}
+
+// addVolume counts a fill towards a user's volume and drops days that no longer count
+func (e *Engine) addVolume(userID string, symbol string, size int, at time.Time) {
+	key := positionKey{userID: userID, symbol: symbol}
+	since, today := feeWindowStart(at), at.UTC().Truncate(24*time.Hour)
+
+	days := e.volumes[key]
+	for len(days) > 0 && days[0].Day.Before(since) {
+		days = days[1:]
+	}
+	if n := len(days); n > 0 && days[n-1].Day.Equal(today) {
+		days[n-1].Quantity += int64(size)
+	} else {
+		days = append(days, volumeDay{Day: today, Quantity: int64(size)})
+	}
+	e.volumes[key] = days
+}
+
+// chargeFees sets the fees of a new trade from its parties' tiers, then counts it towards their
+// volume
+func (e *Engine) chargeFees(trade *Trade) {
+	if schedule, ok := e.fees[trade.Symbol]; ok {
+		since := feeWindowStart(trade.Timestamp)
+		makerVolume := e.volumeSince(positionKey{userID: trade.MakerUserID, symbol: trade.Symbol}, since)
+		takerVolume := e.volumeSince(positionKey{userID: trade.TakerUserID, symbol: trade.Symbol}, since)
+		maker, _ := schedule.tierFor(trade.MakerUserID, makerVolume)
+		taker, _ := schedule.tierFor(trade.TakerUserID, takerVolume)
+		notional := trade.Price * Price(trade.Size)
+		trade.MakerFee = feeAmount(notional, maker.MakerBps)
+		trade.TakerFee = feeAmount(notional, taker.TakerBps)
+	}
+	e.addVolume(trade.MakerUserID, trade.Symbol, trade.Size, trade.Timestamp)
+	e.addVolume(trade.TakerUserID, trade.Symbol, trade.Size, trade.Timestamp)
+}
+
+// feeAmount returns bps of a notional, rounding charges up and rebates towards zero
+func feeAmount(notional Price, bps int) Price {
+	fee := notional * Price(bps)
+	if fee > 0 {
+		return (fee + 9999) / 10000
+	}
+	return fee / 10000
+}
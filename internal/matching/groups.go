@@ -0,0 +1,349 @@
+package matching
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+/*
+An order group links orders of one user and symbol that are submitted together and settle as a
+unit. Groups are submitted with SubmitGroup and journaled as one command; each order is then
+placed as usual, and the group is settled once the command that changed any of its orders has
+been applied.
+
+An OCO (one-cancels-other) pair is two resting limit or stop orders of equal size, such as a
+take-profit and a stop-loss, that share that size between them. A fill on either leg reduces the
+other by the same quantity, and once either leg is done, by filling, cancellation or expiry, the
+other is cancelled with CancelGroup. Fills against a leg are capped at the size the pair has
+left, so the two legs can never fill more than it between them.
+
+A bracket is an entry order with one or two exit orders on the opposite side, usually a
+take-profit limit and a stop-loss stop, sized to the entry. The exits are held with
+StatusPending, in no book, until the entry fills. They are then activated at the entry's filled
+quantity and resized in place, keeping their priority, as further fills arrive. Exits share the
+position the entry opened: a fill on one reduces the others, and fills are capped at what is
+left. An entry that ends without filling cancels its exits, and once exits have closed the whole
+position the rest of the bracket is cancelled. An exit whose type the symbol's trading phase
+does not accept stays pending until the phase changes.
+
+Groups are settled deterministically from each command, so only their submission is journaled.
+Group legs cannot be pegged, reduce-only or carry a minimum quantity, and their quantity cannot
+be amended; the entry's can.
+*/
+
+// GroupType is how the orders of a group are linked
+type GroupType int
+
+const (
+	GroupNone    GroupType = iota // An order outside any group
+	GroupOCO                      // Two orders sharing one size; either one filling cancels the other
+	GroupBracket                  // An entry whose exits activate as it fills
+)
+
+var groupTypeNames = map[GroupType]string{
+	GroupNone:    "",
+	GroupOCO:     "oco",
+	GroupBracket: "bracket",
+}
+
+func (g GroupType) String() string {
+	if name, ok := groupTypeNames[g]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseGroupType parses "oco" or "bracket"
+func ParseGroupType(value string) (GroupType, error) {
+	for group, name := range groupTypeNames {
+		if name == value && group != GroupNone {
+			return group, nil
+		}
+	}
+	return GroupNone, fmt.Errorf("unknown order group type %q", value)
+}
+
+// Order group errors
+var (
+	ErrInvalidGroup = errors.New("invalid order group")
+	ErrAmendGroup   = errors.New("quantity of a grouped order is set by its group")
+)
+
+// orderGroup is a working group; its orders stay listed after they reach a terminal status
+type orderGroup struct {
+	Type     GroupType
+	Quantity int      // Size an OCO pair shares between its legs
+	Orders   []*Order // As submitted; a bracket's entry comes first
+}
+
+// open returns how much more the legs of a group may fill between them
+func (g *orderGroup) open() int {
+	open, legs := g.Quantity, g.Orders
+	if g.Type == GroupBracket {
+		open, legs = g.Orders[0].FilledSize, g.Orders[1:]
+	}
+	for _, leg := range legs {
+		open -= leg.FilledSize
+	}
+	return max(open, 0)
+}
+
+// SubmitGroup places the orders of a group and returns every trade they produced. An OCO pair
+// takes two orders; a bracket takes its entry followed by one or two exits. Orders of a valid
+// group are placed even if some of them are then rejected, which settles the rest of the group;
+// an invalid group is not applied and returns ErrInvalidGroup.
+func (e *Engine) SubmitGroup(kind GroupType, orders []*Order) ([]*Trade, error) {
+	var trades []*Trade
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		trades, err = e.placeGroup(kind, orders)
+	}) {
+		return nil, ErrEngineClosed
+	}
+	return trades, err
+}
+
+// validateGroup checks that orders can form a group of the given type
+func validateGroup(kind GroupType, orders []*Order) error {
+	switch kind {
+	case GroupOCO:
+		if len(orders) != 2 || orders[0].Size != orders[1].Size {
+			return ErrInvalidGroup
+		}
+	case GroupBracket:
+		if len(orders) < 2 || len(orders) > 3 {
+			return ErrInvalidGroup
+		}
+	default:
+		return ErrInvalidGroup
+	}
+
+	ids := make(map[uint64]bool)
+	for _, order := range orders {
+		if order.UserID != orders[0].UserID || order.Symbol != orders[0].Symbol || ids[order.ID] {
+			return ErrInvalidGroup
+		}
+		ids[order.ID] = true
+		if order.Peg != PegNone || order.ReduceOnly || order.MinQuantity > 0 {
+			return ErrInvalidGroup
+		}
+		switch order.OrderType {
+		case MarketOrder, LimitOrder, StopMarketOrder, StopLimitOrder:
+		default:
+			return ErrInvalidGroup
+		}
+	}
+
+	// Legs wait in the book or the trigger book for their turn, so they must be able to rest
+	legs := orders
+	if kind == GroupBracket {
+		legs = orders[1:]
+	}
+	for _, leg := range legs {
+		if leg.OrderType == MarketOrder || (leg.OrderType == LimitOrder && !leg.CanRest()) {
+			return ErrInvalidGroup
+		}
+		if kind == GroupBracket && leg.Side == orders[0].Side {
+			return ErrInvalidGroup
+		}
+	}
+	return nil
+}
+
+// placeGroup journals a group as one command and places its orders: a bracket's exits first, so
+// they are held before its entry can fill, and an OCO pair in the order given
+func (e *Engine) placeGroup(kind GroupType, orders []*Order) ([]*Trade, error) {
+	if err := validateGroup(kind, orders); err != nil {
+		return nil, err
+	}
+
+	group := &orderGroup{Type: kind, Orders: orders}
+	placement := orders
+	switch kind {
+	case GroupOCO:
+		group.Quantity = orders[0].Size
+	case GroupBracket:
+		for _, exit := range orders[1:] {
+			exit.Size = orders[0].Size
+			exit.OriginalSize = orders[0].Size
+		}
+		placement = append(slices.Clone(orders[1:]), orders[0])
+	}
+	for _, order := range orders {
+		order.Group = kind
+		order.GroupID = orders[0].ID
+	}
+
+	// Every order of the group must stay inside the user's risk limits, or none is placed
+	for _, order := range orders {
+		if reason := e.checkRisk(order, order.Price, order.Size, order.Size, len(orders)); reason != RejectNone {
+			for _, rejected := range orders {
+				e.rejectOrder(rejected, reason)
+			}
+			return nil, &RejectError{Reason: reason}
+		}
+	}
+
+	if err := e.record(&JournalEntry{Type: JournalGroup, Group: kind, Orders: orders}); err != nil {
+		return nil, err
+	}
+	e.groups[orders[0].ID] = group
+
+	// The group entry already journals its orders
+	e.grouping = true
+	defer func() { e.grouping = false }()
+
+	var trades []*Trade
+	for _, order := range placement {
+		placed, _ := e.placeOrder(order)
+		trades = append(trades, placed...)
+	}
+	return trades, nil
+}
+
+// groupOpen returns how much more a group leg may fill, or false if no group limits the order
+func (e *Engine) groupOpen(order *Order) (int, bool) {
+	if !order.IsGroupLeg() {
+		return 0, false
+	}
+	group, ok := e.groups[order.GroupID]
+	if !ok {
+		return 0, false
+	}
+	return group.open(), true
+}
+
+// markGroup records that the current command changed an order of a group
+func (e *Engine) markGroup(groupID uint64) {
+	if e.events.groups == nil {
+		e.events.groups = make(map[uint64]bool)
+	}
+	e.events.groups[groupID] = true
+}
+
+// markSymbolGroups records that the groups of a symbol must be settled, for exits waiting on its
+// trading phase
+func (e *Engine) markSymbolGroups(symbol string) {
+	for id, group := range e.groups {
+		if group.Orders[0].Symbol == symbol {
+			e.markGroup(id)
+		}
+	}
+}
+
+// settleGroups settles every group the current command changed, in group order, until settling
+// changes no further group. It runs once the command has been applied, before pegs are repriced.
+func (e *Engine) settleGroups() {
+	for len(e.events.groups) > 0 {
+		ids := slices.Sorted(maps.Keys(e.events.groups))
+		clear(e.events.groups)
+		for _, id := range ids {
+			e.settleGroup(id)
+		}
+	}
+}
+
+// settleGroup applies a group's links to its working orders, and drops the group once all its
+// orders are done
+func (e *Engine) settleGroup(id uint64) {
+	group, ok := e.groups[id]
+	if !ok {
+		return
+	}
+	if group.Type == GroupOCO {
+		e.settleOCO(group)
+	} else {
+		e.settleBracket(group)
+	}
+
+	if !slices.ContainsFunc(group.Orders, func(order *Order) bool { return !order.Status.IsTerminal() }) {
+		delete(e.groups, id)
+	}
+}
+
+// settleOCO cancels the working leg once the other is done, and otherwise shrinks the legs to
+// the size the pair has left
+func (e *Engine) settleOCO(group *orderGroup) {
+	open := group.open()
+	if slices.ContainsFunc(group.Orders, func(leg *Order) bool { return leg.Status.IsTerminal() }) {
+		open = 0
+	}
+	for _, leg := range group.Orders {
+		if !leg.Status.IsTerminal() && leg.Size > open {
+			e.resizeLeg(leg, open)
+		}
+	}
+}
+
+// settleBracket activates and sizes a bracket's exits from its entry's fills, and cancels what is
+// left once the entry ends unfilled or the exits have closed its position
+func (e *Engine) settleBracket(group *orderGroup) {
+	entry, exits := group.Orders[0], group.Orders[1:]
+	open := group.open()
+	closed := open == 0 && entry.FilledSize > 0
+
+	for _, exit := range exits {
+		switch {
+		case exit.Status.IsTerminal():
+		case closed || (entry.Status.IsTerminal() && entry.FilledSize == 0):
+			e.resizeLeg(exit, 0)
+		case entry.FilledSize == 0:
+			// Nothing to exit yet
+		case exit.Status == StatusPending:
+			if e.phases[exit.Symbol].AllowsOrderType(exit.OrderType) {
+				e.activateExit(exit, open)
+			}
+		case exit.Size != open:
+			e.resizeLeg(exit, open)
+		}
+	}
+
+	if closed && !entry.Status.IsTerminal() {
+		e.cancelLeg(entry)
+	}
+}
+
+// resizeLeg changes a working group order's remaining size in place, cancelling it at 0
+func (e *Engine) resizeLeg(order *Order, size int) {
+	if size == 0 {
+		e.cancelLeg(order)
+		return
+	}
+	order.OriginalSize = order.FilledSize + size
+	order.Size = size
+	order.VisibleSize = min(order.VisibleSize, size)
+	e.markOrder(order)
+	if book := e.GetOrderBookForSymbol(order.Symbol); book.SearchById(order.ID) != nil {
+		e.markLevel(order)
+	}
+}
+
+// cancelLeg cancels a working order on behalf of its group
+func (e *Engine) cancelLeg(order *Order) {
+	if order.Status != StatusPending {
+		e.deleteFromSymbol(order.Symbol, order.ID)
+	}
+	order.CancelReason = CancelGroup
+	e.finishOrder(order, StatusCancelled)
+}
+
+// activateExit enters a held bracket exit at size, as it would have been placed
+func (e *Engine) activateExit(exit *Order, size int) {
+	exit.Size = size
+	exit.OriginalSize = size
+	exit.Status = StatusNew
+	e.markOrder(exit)
+
+	book := e.GetOrderBookForSymbol(exit.Symbol)
+	var trades []*Trade
+	if exit.OrderType == StopMarketOrder || exit.OrderType == StopLimitOrder {
+		trades = e.enterStop(book, exit)
+	} else {
+		trades = e.executeOrder(book, exit)
+	}
+	e.processTriggeredStops(book, exit.Symbol, trades)
+}
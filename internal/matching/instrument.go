@@ -0,0 +1,162 @@
+package matching
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Prices are fixed-point integers so equal prices always land on the same level. A Price counts
+units of 10^-Precision of the symbol's quote currency: with Precision 2, 100.25 is Price(10025).
+TickSize is in the same units, and every order price must be a whole number of ticks.
+
+Decimal text is only used at the edges (API, config). Parsing is exact, so "0.3" never drifts.
+*/
+
+// Price is a fixed-point price in units of 10^-Precision of the instrument
+type Price int64
+
+const (
+	DefaultPricePrecision = 2
+	DefaultTickSize       = Price(1)
+
+	// maxPricePrecision keeps 10^Precision well inside int64
+	maxPricePrecision = 9
+)
+
+var ErrOffTick = errors.New("price is not a multiple of the tick size")
+
+// Instrument describes how a symbol is quoted
+type Instrument struct {
+	Symbol    string
+	Precision int   // Decimal places in a price
+	TickSize  Price // Minimum price increment, in price units
+}
+
+// DefaultInstrument returns a symbol quoted to cents with a one cent tick
+func DefaultInstrument(symbol string) Instrument {
+	return Instrument{
+		Symbol:    symbol,
+		Precision: DefaultPricePrecision,
+		TickSize:  DefaultTickSize,
+	}
+}
+
+// NewInstrument builds an instrument from a decimal tick size such as "0.05"
+func NewInstrument(symbol string, precision int, tickSize string) (Instrument, error) {
+	inst := Instrument{Symbol: symbol, Precision: precision, TickSize: 1}
+	if err := inst.Validate(); err != nil {
+		return Instrument{}, err
+	}
+
+	tick, err := inst.ParsePrice(tickSize)
+	if err != nil {
+		return Instrument{}, fmt.Errorf("tick size %q for %s: %w", tickSize, symbol, err)
+	}
+	inst.TickSize = tick
+	return inst, inst.Validate()
+}
+
+// Validate checks that the precision and tick size are usable
+func (inst Instrument) Validate() error {
+	if inst.Precision < 0 || inst.Precision > maxPricePrecision {
+		return fmt.Errorf("precision for %s must be between 0 and %d", inst.Symbol, maxPricePrecision)
+	}
+	if inst.TickSize <= 0 {
+		return fmt.Errorf("tick size for %s must be positive", inst.Symbol)
+	}
+	return nil
+}
+
+// IsOnTick reports whether a price is a whole number of ticks
+func (inst Instrument) IsOnTick(price Price) bool {
+	return price%inst.TickSize == 0
+}
+
+// ParsePrice converts a decimal string to a Price, rejecting prices off the tick grid
+func (inst Instrument) ParsePrice(text string) (Price, error) {
+	price, err := inst.parseUnits(text)
+	if err != nil {
+		return 0, err
+	}
+	if !inst.IsOnTick(price) {
+		return 0, ErrOffTick
+	}
+	return price, nil
+}
+
+// ParseAmount converts a decimal string to price units at the instrument's precision without the
+// tick check, for amounts such as notional limits that need not be a whole number of ticks
+func (inst Instrument) ParseAmount(text string) (Price, error) {
+	amount, err := inst.parseUnits(text)
+	if errors.Is(err, ErrOffTick) {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", strings.TrimSpace(text), inst.Precision)
+	}
+	return amount, err
+}
+
+// parseUnits converts a decimal string to price units, returning ErrOffTick for nonzero digits
+// past the precision
+func (inst Instrument) parseUnits(text string) (Price, error) {
+	text = strings.TrimSpace(text)
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+
+	whole, frac, _ := strings.Cut(text, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid price %q", text)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	// Digits past the precision are only allowed if they are zeros
+	if len(frac) > inst.Precision {
+		if strings.Trim(frac[inst.Precision:], "0") != "" {
+			return 0, ErrOffTick
+		}
+		frac = frac[:inst.Precision]
+	}
+	frac += strings.Repeat("0", inst.Precision-len(frac))
+
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid price %q", sign+text)
+		}
+	}
+
+	units, err := strconv.ParseInt(sign+whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q: %w", sign+text, err)
+	}
+	return Price(units), nil
+}
+
+// FormatPrice renders a Price as a decimal string with the instrument's precision
+func (inst Instrument) FormatPrice(price Price) string {
+	text := strconv.FormatInt(int64(price), 10)
+	if inst.Precision == 0 {
+		return text
+	}
+
+	sign := ""
+	if price < 0 {
+		sign, text = "-", text[1:]
+	}
+	if len(text) <= inst.Precision {
+		text = strings.Repeat("0", inst.Precision-len(text)+1) + text
+	}
+	split := len(text) - inst.Precision
+	return sign + text[:split] + "." + text[split:]
+}
+
+// IsOrderOnTick reports whether an order's limit, stop, protection, peg and trail prices are whole
+// ticks
+func (inst Instrument) IsOrderOnTick(order *Order) bool {
+	return inst.IsOnTick(order.Price) && inst.IsOnTick(order.StopPrice) && inst.IsOnTick(order.ProtectionPrice) &&
+		inst.IsOnTick(order.PegOffset) && inst.IsOnTick(order.PegCap) && inst.IsOnTick(order.TrailAmount)
+}
@@ -0,0 +1,220 @@
+package matching
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+The journal is a write-ahead log of the commands that changed engine state. The sequencer
+appends a place, cancel, amend, auction, uncross, phase or group entry before applying it, so an
+order is on disk before it is acknowledged. Entries are JSON lines numbered from 1 with no gaps.
+
+On startup the engine replays the journal through the same matching code that produced it.
+Matching is deterministic given the command order and the time recorded in each entry, so
+replay rebuilds the books, the order tracker and the ID counter exactly. Instrument
+configuration must not change between runs for this to hold.
+
+A crash can leave a partial last line. Replay stops at the first line that does not decode
+or is out of sequence, and truncates the file there before new entries are appended.
+*/
+
+// JournalEntryType identifies the command a journal entry records
+type JournalEntryType string
+
+const (
+	JournalPlace  JournalEntryType = "place"
+	JournalCancel JournalEntryType = "cancel"
+	JournalAmend  JournalEntryType = "amend"
+
+	JournalAuction JournalEntryType = "auction" // A symbol entered a call auction
+	JournalUncross JournalEntryType = "uncross" // A symbol's auction was uncrossed
+	JournalPhase   JournalEntryType = "phase"   // A symbol moved to another trading phase
+	JournalGroup   JournalEntryType = "group"   // Orders were submitted together as an order group
+)
+
+// JournalEntry is one sequenced command
+type JournalEntry struct {
+	Seq     uint64           `json:"seq"`
+	Type    JournalEntryType `json:"type"`
+	Time    time.Time        `json:"time"`               // When the sequencer applied the command
+	Order   *Order           `json:"order,omitempty"`    // Order as submitted, for place entries
+	OrderID uint64           `json:"order_id,omitempty"` // Order to remove or amend, for cancel and amend entries
+	Status  OrderStatus      `json:"status,omitempty"`   // Terminal status of a cancel, cancelled or expired
+	Price   Price            `json:"price,omitempty"`    // New limit price, for amend entries
+	Size    int              `json:"size,omitempty"`     // New total quantity, for amend entries
+	Symbol  string           `json:"symbol,omitempty"`   // Symbol of auction, uncross and phase entries
+	Phase   TradingPhase     `json:"phase,omitempty"`    // New trading phase, for phase entries
+	Group   GroupType        `json:"group,omitempty"`    // Type of the order group, for group entries
+	Orders  []*Order         `json:"orders,omitempty"`   // Orders as submitted, for group entries
+}
+
+// SyncPolicy controls when journal writes are flushed to stable storage
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync after every entry, before the command is acknowledged
+	SyncInterval                   // fsync in the background on a fixed interval
+	SyncNever                      // Leave flushing to the operating system
+)
+
+// ParseSyncPolicy parses "always", "interval" or "never"
+func ParseSyncPolicy(value string) (SyncPolicy, error) {
+	switch value {
+	case "always":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	}
+	return SyncAlways, fmt.Errorf("unknown journal sync policy %q", value)
+}
+
+// Journal appends sequenced commands to a file
+type Journal struct {
+	file     *os.File
+	mutex    sync.Mutex
+	seq      uint64
+	policy   SyncPolicy
+	dirty    atomic.Bool   // Written since the last interval sync
+	stopSync chan struct{} // Stops the interval sync worker, nil if not running
+}
+
+// OpenJournal opens or creates a journal, passing every valid entry to replay in sequence
+// order. A torn tail is truncated so later appends continue the sequence.
+func OpenJournal(path string, policy SyncPolicy, interval time.Duration, replay func(*JournalEntry) error) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	journal := &Journal{file: file, policy: policy}
+	if err := journal.replay(replay); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if policy == SyncInterval && interval > 0 {
+		journal.stopSync = make(chan struct{})
+		go journal.runSyncWorker(interval)
+	}
+	return journal, nil
+}
+
+func (j *Journal) replay(apply func(*JournalEntry) error) error {
+	reader := bufio.NewReader(j.file)
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read journal: %w", err)
+		}
+		if len(line) == 0 || !bytes.HasSuffix(line, []byte("\n")) {
+			break
+		}
+
+		var entry JournalEntry
+		if json.Unmarshal(line, &entry) != nil || entry.Seq != j.seq+1 {
+			break
+		}
+		if apply != nil {
+			if err := apply(&entry); err != nil {
+				return fmt.Errorf("failed to replay journal entry %d: %w", entry.Seq, err)
+			}
+		}
+		j.seq = entry.Seq
+		offset += int64(len(line))
+	}
+
+	// Drop anything after the last good entry and append from there
+	if err := j.file.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate journal: %w", err)
+	}
+	if _, err := j.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek journal: %w", err)
+	}
+	return nil
+}
+
+// Append assigns the next sequence number to an entry and writes it
+func (j *Journal) Append(entry *JournalEntry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entry.Seq = j.seq + 1
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+
+	switch j.policy {
+	case SyncAlways:
+		if err := j.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync journal: %w", err)
+		}
+	case SyncInterval:
+		j.dirty.Store(true)
+	}
+
+	j.seq = entry.Seq
+	return nil
+}
+
+// Sync flushes every entry written so far to stable storage
+func (j *Journal) Sync() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+// Seq returns the sequence number of the last entry written
+func (j *Journal) Seq() uint64 {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.seq
+}
+
+func (j *Journal) runSyncWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if j.dirty.Swap(false) {
+				j.file.Sync()
+			}
+		case <-j.stopSync:
+			return
+		}
+	}
+}
+
+// Close flushes and closes the journal
+func (j *Journal) Close() error {
+	if j.stopSync != nil {
+		close(j.stopSync)
+		j.stopSync = nil
+	}
+	if err := j.file.Sync(); err != nil {
+		j.file.Close()
+		return err
+	}
+	return j.file.Close()
+}
@@ -1,6 +1,12 @@
 package matching
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultSymbol is the instrument used when an order does not name one
+const DefaultSymbol = "COOTX"
 
 type OrderType int
 
@@ -21,16 +27,230 @@ const (
 	Sell
 )
 
+// TimeInForce controls how long an order stays working
+type TimeInForce int
+
+const (
+	GoodTillCancel    TimeInForce = iota // Rests until filled or cancelled
+	ImmediateOrCancel                    // Fills what it can, cancels the rest
+	FillOrKill                           // Fills completely or not at all
+	GoodTillDate                         // Rests until ExpireTime
+	Day                                  // Rests until the session end sweep
+)
+
+// PostOnlyMode controls a limit order that would take liquidity on arrival
+type PostOnlyMode int
+
+const (
+	PostOnlyOff    PostOnlyMode = iota // May take liquidity
+	PostOnlyReject                     // Rejected if it would cross the book
+	PostOnlySlide                      // Repriced one tick behind the opposite best if it would cross
+)
+
+// SelfTradeMode controls what happens when an incoming order would trade with a resting order
+// of the same user. The incoming order's mode decides.
+type SelfTradeMode int
+
+const (
+	SelfTradeDefault      SelfTradeMode = iota // Use the engine's configured mode
+	SelfTradeAllow                             // Trade as with any other user
+	SelfTradeCancelNewest                      // Cancel the incoming order's remainder
+	SelfTradeCancelOldest                      // Cancel the resting order and keep matching
+	SelfTradeCancelBoth                        // Cancel the resting order and the incoming remainder
+	SelfTradeDecrement                         // Reduce both by the smaller size and cancel whichever is left empty
+)
+
+var selfTradeModeNames = map[SelfTradeMode]string{
+	SelfTradeDefault:      "",
+	SelfTradeAllow:        "allow",
+	SelfTradeCancelNewest: "cancel_newest",
+	SelfTradeCancelOldest: "cancel_oldest",
+	SelfTradeCancelBoth:   "cancel_both",
+	SelfTradeDecrement:    "decrement",
+}
+
+func (m SelfTradeMode) String() string {
+	if name, ok := selfTradeModeNames[m]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseSelfTradeMode parses "allow", "cancel_newest", "cancel_oldest", "cancel_both" or "decrement"
+func ParseSelfTradeMode(value string) (SelfTradeMode, error) {
+	for mode, name := range selfTradeModeNames {
+		if name == value && mode != SelfTradeDefault {
+			return mode, nil
+		}
+	}
+	return SelfTradeDefault, fmt.Errorf("unknown self-trade prevention mode %q", value)
+}
+
+// CancelReason records why the engine, rather than the user or time in force, cancelled an order
+type CancelReason int
+
+const (
+	CancelNone        CancelReason = iota
+	CancelSelfTrade                // Removed by self-trade prevention
+	CancelPriceBand                // Kept from trading beyond the symbol's price bands
+	CancelProtection               // Market order reached its protection price or maximum slippage
+	CancelNoLiquidity              // Market order exhausted the opposite side of the book
+	CancelGroup                    // Ended by the other orders of its group
+)
+
+var cancelReasonNames = map[CancelReason]string{
+	CancelNone:        "",
+	CancelSelfTrade:   "self_trade",
+	CancelPriceBand:   "price_band",
+	CancelProtection:  "price_protection",
+	CancelNoLiquidity: "no_liquidity",
+	CancelGroup:       "order_group",
+}
+
+func (r CancelReason) String() string {
+	if name, ok := cancelReasonNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// RejectReason records why the engine rejected an order
+type RejectReason int
+
+const (
+	RejectNone             RejectReason = iota
+	RejectUnknownSymbol                 // Symbol has no book
+	RejectOffTick                       // Price is not on the symbol's tick grid
+	RejectExpired                       // Good-till-date order arrived after its expiry
+	RejectInvalid                       // Order type cannot be executed
+	RejectPostOnly                      // Post-only order would have taken liquidity
+	RejectReduceOnly                    // Reduce-only order had no position to reduce
+	RejectMinQuantity                   // Less than the minimum quantity could execute on arrival
+	RejectAuction                       // Order must execute on arrival but the symbol is in an auction
+	RejectPhase                         // Symbol's trading phase does not accept the order type
+	RejectPriceBand                     // Limit price is outside the symbol's price bands
+	RejectPegReference                  // Pegged order arrived with no price to follow
+	RejectTrailReference                // Trailing stop arrived with no stop price and no trade to trail
+	RejectMaxOrderQuantity              // Quantity is above the user's limit; see risk.go
+	RejectMaxOrderNotional              // Notional is above the user's limit
+	RejectMaxOpenOrders                 // User already has the most working orders allowed
+	RejectMaxOpenNotional               // User's working notional on the side would exceed the limit
+	RejectPriceDeviation                // Limit price is too far through the touch
+)
+
+var rejectReasonNames = map[RejectReason]string{
+	RejectNone:             "",
+	RejectUnknownSymbol:    "unknown_symbol",
+	RejectOffTick:          "price_off_tick",
+	RejectExpired:          "already_expired",
+	RejectInvalid:          "invalid_order",
+	RejectPostOnly:         "post_only_would_cross",
+	RejectReduceOnly:       "reduce_only_no_position",
+	RejectMinQuantity:      "min_quantity_not_met",
+	RejectAuction:          "auction_in_progress",
+	RejectPhase:            "not_allowed_in_phase",
+	RejectPriceBand:        "outside_price_band",
+	RejectPegReference:     "no_peg_reference",
+	RejectTrailReference:   "no_trail_reference",
+	RejectMaxOrderQuantity: "max_order_quantity",
+	RejectMaxOrderNotional: "max_order_notional",
+	RejectMaxOpenOrders:    "max_open_orders",
+	RejectMaxOpenNotional:  "max_open_notional",
+	RejectPriceDeviation:   "price_deviation",
+}
+
+func (r RejectReason) String() string {
+	if name, ok := rejectReasonNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// OrderStatus is where an order is in its lifecycle
+type OrderStatus int
+
+const (
+	StatusNew             OrderStatus = iota // Accepted, nothing filled yet
+	StatusPartiallyFilled                    // Some quantity filled, remainder still working
+	StatusFilled                             // Fully filled
+	StatusCancelled                          // Removed before filling completely, by request or time in force
+	StatusRejected                           // Refused by the engine
+	StatusExpired                            // Removed by GTD expiry or the DAY sweep
+	StatusPending                            // Bracket exit held until its entry fills
+)
+
+var orderStatusNames = map[OrderStatus]string{
+	StatusNew:             "new",
+	StatusPartiallyFilled: "partially_filled",
+	StatusFilled:          "filled",
+	StatusCancelled:       "cancelled",
+	StatusRejected:        "rejected",
+	StatusExpired:         "expired",
+	StatusPending:         "pending",
+}
+
+func (s OrderStatus) String() string {
+	if name, ok := orderStatusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// IsTerminal reports whether the order can no longer trade
+func (s OrderStatus) IsTerminal() bool {
+	return s == StatusFilled || s == StatusCancelled || s == StatusRejected || s == StatusExpired
+}
+
 type Order struct {
 	ID        uint64
 	UserID    string
 	Symbol    string
 	OrderType OrderType
 	Side      SideType
-	Price     float64
-	StopPrice float64
-	Size      int
+	Price     Price
+	StopPrice Price
+	Size      int // Remaining quantity, reduced as the order fills
 	TimeStamp time.Time
+
+	TimeInForce TimeInForce
+	ExpireTime  time.Time // Only used by GoodTillDate
+
+	DisplaySize int // Slice of an iceberg order shown in the book; 0 shows the full size
+	VisibleSize int // Unfilled part of an iceberg's current slice, refreshed from the reserve
+
+	PostOnly    PostOnlyMode // Limit orders only: never take liquidity on arrival
+	ReduceOnly  bool         // May only reduce the user's net position in the symbol
+	MinQuantity int          // Least quantity that must be able to execute on arrival, or the order is rejected
+
+	SelfTradePrevention SelfTradeMode // Applied when this order arrives against its user's resting orders
+	SelfTradeCancelled  []uint64      // Orders, possibly this one, cancelled by self-trade prevention as it matched
+
+	// Market and stop-market orders only: matching stops at the tightest of these bounds, with
+	// slippage measured from the opposite best as the order starts matching. 0 disables each.
+	ProtectionPrice  Price // Worst acceptable price
+	MaxSlippageTicks int   // Ticks beyond the opposite best
+	MaxSlippageBps   int   // Basis points beyond the opposite best
+
+	// Resting limit orders only: the engine sets Price from the book, see peg.go
+	Peg       PegType
+	PegOffset Price // Added to the reference price; negative offsets are below it
+	PegCap    Price // Highest price a pegged buy and lowest a pegged sell will rest at; 0 for none
+
+	// Stop orders only: StopPrice trails the last trade price by one of these, see trailing.go
+	TrailAmount Price // Fixed distance from the last trade
+	TrailBps    int   // Distance in basis points of the last trade
+
+	// Orders submitted together as an OCO pair or a bracket, see groups.go
+	Group   GroupType
+	GroupID uint64 // ID of the bracket's entry or the pair's first order
+
+	OriginalSize   int          // Quantity as submitted
+	FilledSize     int          // Cumulative filled quantity
+	FilledNotional int64        // Sum of fill price times fill size, for the average fill price
+	Status         OrderStatus  // Lifecycle state
+	RejectReason   RejectReason // Why the order was rejected, if it was
+	CancelReason   CancelReason // Why the engine cancelled the order, if it did
+	DoneTime       time.Time    // When the order reached a terminal status
 }
 
 func (o *Order) IsValid() bool {
@@ -40,22 +260,140 @@ func (o *Order) IsValid() bool {
 	if o.Size <= 0 {
 		return false
 	}
-	if o.OrderType == LimitOrder && o.Price <= 0 {
+	if (o.OrderType == LimitOrder || o.OrderType == StopLimitOrder) && o.Price <= 0 && o.Peg == PegNone {
+		return false
+	}
+	if (o.OrderType == StopMarketOrder || o.OrderType == StopLimitOrder) && o.StopPrice <= 0 &&
+		(o.OrderType != StopMarketOrder || !o.IsTrailing()) {
+		return false
+	}
+	if o.TimeInForce == GoodTillDate && o.ExpireTime.IsZero() {
+		return false
+	}
+	if o.DisplaySize < 0 || (o.DisplaySize > 0 && o.OrderType != LimitOrder) {
+		return false
+	}
+	// A post-only order must be able to rest, and never executes on arrival
+	if o.PostOnly != PostOnlyOff && (o.OrderType != LimitOrder || !o.CanRest() || o.MinQuantity > 0) {
+		return false
+	}
+	if o.MinQuantity < 0 || o.MinQuantity > o.Size ||
+		(o.MinQuantity > 0 && o.OrderType != LimitOrder && o.OrderType != MarketOrder) {
+		return false
+	}
+	if o.ProtectionPrice < 0 || o.MaxSlippageTicks < 0 || o.MaxSlippageBps < 0 {
+		return false
+	}
+	if o.HasProtection() && o.OrderType != MarketOrder && o.OrderType != StopMarketOrder {
+		return false
+	}
+	// A pegged order never trades on arrival, so it must be able to rest
+	if _, ok := pegTypeNames[o.Peg]; !ok || o.PegCap < 0 {
+		return false
+	}
+	if o.Peg != PegNone && (o.OrderType != LimitOrder || !o.CanRest() || o.MinQuantity > 0) {
+		return false
+	}
+	// A trailing stop trails by one distance, short of the whole price
+	if o.TrailAmount < 0 || o.TrailBps < 0 || o.TrailBps >= 10000 || (o.TrailAmount > 0 && o.TrailBps > 0) {
+		return false
+	}
+	if o.IsTrailing() && o.OrderType != StopMarketOrder && o.OrderType != StopLimitOrder {
 		return false
 	}
 	return true
 }
 
-func NewOrder(id uint64, userId string, orderType OrderType, side SideType, price float64, quantity int) *Order {
+// Fill records an execution against the order and updates its remaining size and status
+func (o *Order) Fill(price Price, size int) {
+	o.Size -= size
+	o.FilledSize += size
+	o.FilledNotional += int64(price) * int64(size)
+	o.VisibleSize = max(o.VisibleSize-size, 0)
+	if o.Size == 0 {
+		o.Status = StatusFilled
+	} else {
+		o.Status = StatusPartiallyFilled
+	}
+}
+
+// AvgFillPrice returns the size-weighted average fill price, rounded to the nearest price unit
+func (o *Order) AvgFillPrice() Price {
+	if o.FilledSize == 0 {
+		return 0
+	}
+	size := int64(o.FilledSize)
+	return Price((o.FilledNotional + size/2) / size)
+}
+
+// IsIceberg reports whether the order shows only a slice of its size in the book
+func (o *Order) IsIceberg() bool {
+	return o.DisplaySize > 0
+}
+
+// DisplayedSize returns the quantity the order shows in the book
+func (o *Order) DisplayedSize() int {
+	if !o.IsIceberg() {
+		return o.Size
+	}
+	return o.VisibleSize
+}
+
+// RefreshDisplay shows the next slice of an iceberg order from its hidden reserve
+func (o *Order) RefreshDisplay() {
+	if o.IsIceberg() {
+		o.VisibleSize = min(o.DisplaySize, o.Size)
+	}
+}
+
+// PreventsSelfTrade reports whether the order must not trade with its own user's resting orders
+func (o *Order) PreventsSelfTrade() bool {
+	return o.SelfTradePrevention != SelfTradeDefault && o.SelfTradePrevention != SelfTradeAllow
+}
+
+// IsTrailing reports whether a stop order's stop price follows the market
+func (o *Order) IsTrailing() bool {
+	return o.TrailAmount > 0 || o.TrailBps > 0
+}
+
+// IsGroupLeg reports whether the order's fills are capped by its group: either order of an OCO
+// pair, or a bracket's exit
+func (o *Order) IsGroupLeg() bool {
+	return o.Group == GroupOCO || (o.Group == GroupBracket && o.ID != o.GroupID)
+}
+
+// HasProtection reports whether a market order bounds the prices it may trade at
+func (o *Order) HasProtection() bool {
+	return o.ProtectionPrice > 0 || o.MaxSlippageTicks > 0 || o.MaxSlippageBps > 0
+}
+
+// CanRest reports whether an unfilled remainder may be added to the book
+func (o *Order) CanRest() bool {
+	return o.TimeInForce != ImmediateOrCancel && o.TimeInForce != FillOrKill
+}
+
+// IsExpired reports whether a good-till-date order has passed its expiry
+func (o *Order) IsExpired(now time.Time) bool {
+	return o.TimeInForce == GoodTillDate && !now.Before(o.ExpireTime)
+}
+
+func NewOrder(id uint64, userId string, orderType OrderType, side SideType, price Price, quantity int) *Order {
+	return NewOrderWithSymbol(id, userId, DefaultSymbol, orderType, side, price, quantity)
+}
+
+// NewOrderWithSymbol creates an order for a specific instrument
+func NewOrderWithSymbol(id uint64, userId string, symbol string, orderType OrderType, side SideType, price Price, quantity int) *Order {
 	return &Order{
 		ID:        id,
 		UserID:    userId,
-		Symbol:    "COOTX",
+		Symbol:    symbol,
 		OrderType: orderType,
 		Side:      side,
 		Price:     price,
 		Size:      quantity,
 		TimeStamp: time.Now(),
+
+		OriginalSize: quantity,
 	}
 }
 
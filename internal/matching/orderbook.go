@@ -1,38 +1,39 @@
 package matching
 
-import "math"
-
 /*
 Data structure to hold best bid and best ask at the top
 Sorted
 Efficiently find and remove order
-Heap would be great, but not efficient find
-Red-Black tree would not necessary have smallest at the top, but not hard to find it in log(n) time (all the way to the left)
-
-Red-Black tree approach
-Order books have multiple price levels.
-Price level only matters if there is atleast an order there
-Each node of the tree can be a price level. Each of it can store an array of pointers to orders
-New orders at a new price would create node and add to array
-New orders at a existing price node would add on to the array
-Can use a set instead of an array for hashing. Maybe a sorted set. Might be too much overhead
-
-What are alternatives. Maybe combination of structures. min/max heap to keep the best price of one side. Store a pointer inside to the order array
-Only the best bid and ask matter. So heap works well
-Can also use hash for quick check on if the price level node exists AND for efficient find
-
-Conclusion: Min/Max heap + hash + array of *Order in memory
+
+Each side is a skip list of price levels ordered best-first (bids descending, asks ascending).
+The head of the list is the best price, so best bid/ask is O(1) and depth snapshots are a
+single in-order walk. Inserting or removing a price level is O(log n) in the number of levels.
+Each level also sits in a price -> level map so adding to an existing price is O(1).
+
+Each price level holds a FIFO slice of *Order for time priority.
+
+An order ID -> level index makes search O(1) and cancel O(k) in the size of that one level,
+instead of scanning every level on the side.
 */
 
 type OrderBook struct {
-	bids map[float64][]*Order // bid side
-	asks map[float64][]*Order // ask side
+	bids  *priceLevelList // bid side, highest price first
+	asks  *priceLevelList // ask side, lowest price first
+	index map[uint64]*orderEntry
+}
+
+// orderEntry locates a resting order in the book
+type orderEntry struct {
+	order *Order
+	level *PriceLevel
+	side  *priceLevelList
 }
 
 func NewOrderBook() *OrderBook {
 	return &OrderBook{
-		bids: make(map[float64][]*Order),
-		asks: make(map[float64][]*Order),
+		bids:  newPriceLevelList(func(a, b Price) bool { return a > b }),
+		asks:  newPriceLevelList(func(a, b Price) bool { return a < b }),
+		index: make(map[uint64]*orderEntry),
 	}
 }
 
@@ -41,187 +42,270 @@ type PriceLevelOrders struct {
 	Asks []*Order
 }
 
-func (orderBook *OrderBook) GetOrdersByPrice(priceLevel float64) *PriceLevelOrders {
+func (orderBook *OrderBook) GetOrdersByPrice(priceLevel Price) *PriceLevelOrders {
 	return &PriceLevelOrders{
-		Bids: orderBook.bids[priceLevel],
-		Asks: orderBook.asks[priceLevel],
+		Bids: orderBook.GetBidsAtPrice(priceLevel),
+		Asks: orderBook.GetAsksAtPrice(priceLevel),
 	}
 }
 
 // Search, given orderId
 func (orderBook *OrderBook) SearchById(orderId uint64) *Order {
-	// Check bids
-	foundOrder, ok, _, _ := findOrderInBookSide(orderBook.bids, orderId)
-	if ok {
-		return foundOrder
-	}
-
-	// Check asks
-	foundOrder, ok, _, _ = findOrderInBookSide(orderBook.asks, orderId)
-	if ok {
-		return foundOrder
+	if entry, ok := orderBook.index[orderId]; ok {
+		return entry.order
 	}
 	return nil
 }
 
-func findOrderInBookSide(bookSide map[float64][]*Order, orderId uint64) (*Order, bool, float64, int) {
-	for priceLevel, orderBlock := range bookSide {
-		for i, order := range orderBlock {
-			if order.ID == orderId {
-				return order, true, priceLevel, i
-			}
-		}
-	}
-	return nil, false, 0.0, 0
+func (orderBook *OrderBook) GetBestBid() (Price, []*Order) {
+	return bestOfSide(orderBook.bids)
 }
 
-func (orderBook *OrderBook) GetBestBid() (float64, []*Order) {
-	bids := orderBook.bids
+func (orderBook *OrderBook) GetBestAsk() (Price, []*Order) {
+	return bestOfSide(orderBook.asks)
+}
 
-	if len(bids) == 0 {
+func bestOfSide(side *priceLevelList) (Price, []*Order) {
+	level := side.Best()
+	if level == nil {
 		return 0.0, nil
 	}
+	return level.Price, level.Orders
+}
 
-	max := 0.0
-	for priceLevel := range bids {
-		if priceLevel > max {
-			max = priceLevel
-		}
-	}
+// GetBestBidLevel returns the best bid price level, or nil if there are no bids
+func (orderBook *OrderBook) GetBestBidLevel() *PriceLevel {
+	return orderBook.bids.Best()
+}
 
-	return max, bids[max]
+// GetBestAskLevel returns the best ask price level, or nil if there are no asks
+func (orderBook *OrderBook) GetBestAskLevel() *PriceLevel {
+	return orderBook.asks.Best()
 }
 
-func (orderBook *OrderBook) GetBestAsk() (float64, []*Order) {
-	asks := orderBook.asks
+func (orderBook *OrderBook) DeleteBidBlock(priceLevel Price) bool {
+	return orderBook.deletePriceBlock(orderBook.bids, priceLevel)
+}
 
-	if len(asks) == 0 {
-		return 0.0, nil
+func (orderBook *OrderBook) DeleteAskBlock(priceLevel Price) bool {
+	return orderBook.deletePriceBlock(orderBook.asks, priceLevel)
+}
+
+func (orderBook *OrderBook) deletePriceBlock(side *priceLevelList, priceLevel Price) bool {
+	level := side.Get(priceLevel)
+	if level == nil {
+		return false
 	}
 
-	min := math.MaxFloat64
-	for priceLevel := range asks {
-		if priceLevel < min {
-			min = priceLevel
+	for _, order := range level.Orders {
+		if entry, ok := orderBook.index[order.ID]; ok && entry.level == level {
+			delete(orderBook.index, order.ID)
 		}
 	}
-
-	return min, asks[min]
+	return side.Remove(priceLevel)
 }
 
-func (orderBook *OrderBook) DeleteBidBlock(priceLevel float64) bool {
-	return DeletePriceBlock(orderBook.bids, priceLevel)
+func (orderBook *OrderBook) DeleteOrderById(orderId uint64) bool {
+	return orderBook.DeleteBidOrder(orderId) || orderBook.DeleteAskOrder(orderId)
 }
 
-func (orderBook *OrderBook) DeleteAskBlock(priceLevel float64) bool {
-	return DeletePriceBlock(orderBook.asks, priceLevel)
+func (orderBook *OrderBook) DeleteBidOrder(orderId uint64) bool {
+	return orderBook.deleteOrder(orderBook.bids, orderId)
 }
 
-func DeletePriceBlock(bookSide map[float64][]*Order, priceLevel float64) bool {
-	_, ok := bookSide[priceLevel]
-	if ok {
-		delete(bookSide, priceLevel)
-		return true
-	}
-	return false
+func (orderBook *OrderBook) DeleteAskOrder(orderId uint64) bool {
+	return orderBook.deleteOrder(orderBook.asks, orderId)
 }
 
-func (orderBook *OrderBook) DeleteOrderById(orderId uint64) bool {
-	return orderBook.DeleteBidOrder(orderId) || orderBook.DeleteAskOrder(orderId)
-}
+func (orderBook *OrderBook) deleteOrder(side *priceLevelList, orderId uint64) bool {
+	entry, ok := orderBook.index[orderId]
+	if !ok || entry.side != side {
+		return false
+	}
 
-func (orderBook *OrderBook) DeleteBidOrder(orderId uint64) bool {
-	_, ok, priceLevel, index := findOrderInBookSide(orderBook.bids, orderId)
-	if ok {
-		block := orderBook.bids[priceLevel]
-		block = append(block[:index], block[index+1:]...)
-		orderBook.bids[priceLevel] = block
-
-		// Clean up empty price level
-		if len(block) == 0 {
-			delete(orderBook.bids, priceLevel)
+	level := entry.level
+	for i, order := range level.Orders {
+		if order == entry.order {
+			if i == 0 {
+				level.Orders = level.Orders[1:]
+			} else {
+				level.Orders = append(level.Orders[:i], level.Orders[i+1:]...)
+			}
+			break
 		}
-		return true
 	}
+	delete(orderBook.index, orderId)
 
-	return false
+	// Clean up empty price level
+	if len(level.Orders) == 0 {
+		side.Remove(level.Price)
+	}
+	return true
 }
 
-func (orderBook *OrderBook) DeleteAskOrder(orderId uint64) bool {
-	_, ok, priceLevel, index := findOrderInBookSide(orderBook.asks, orderId)
-	if ok {
-		block := orderBook.asks[priceLevel]
-		block = append(block[:index], block[index+1:]...)
-		orderBook.asks[priceLevel] = block
-
-		// Clean up empty price level
-		if len(block) == 0 {
-			delete(orderBook.asks, priceLevel)
-		}
-		return true
+// MoveToBack moves a resting order behind every other order at its price level
+func (orderBook *OrderBook) MoveToBack(orderId uint64) bool {
+	entry, ok := orderBook.index[orderId]
+	if !ok {
+		return false
 	}
 
-	return false
+	level := entry.level
+	for i, order := range level.Orders {
+		if order == entry.order {
+			copy(level.Orders[i:], level.Orders[i+1:])
+			level.Orders[len(level.Orders)-1] = order
+			break
+		}
+	}
+	return true
 }
 
 func (orderBook *OrderBook) AddBidOrder(newOrder *Order) bool {
-	block := orderBook.bids[newOrder.Price]
-	block = append(block, newOrder)
-	orderBook.bids[newOrder.Price] = block
-	return true
+	return orderBook.addOrder(orderBook.bids, newOrder)
 }
 
 func (orderBook *OrderBook) AddAskOrder(newOrder *Order) bool {
-	block := orderBook.asks[newOrder.Price]
-	block = append(block, newOrder)
-	orderBook.asks[newOrder.Price] = block
+	return orderBook.addOrder(orderBook.asks, newOrder)
+}
+
+func (orderBook *OrderBook) addOrder(side *priceLevelList, newOrder *Order) bool {
+	level := side.GetOrCreate(newOrder.Price)
+	level.Orders = append(level.Orders, newOrder)
+	orderBook.index[newOrder.ID] = &orderEntry{order: newOrder, level: level, side: side}
 	return true
 }
 
 // GetAllBids returns all bid price levels sorted from highest to lowest
-func (orderBook *OrderBook) GetAllBids() []float64 {
-	prices := make([]float64, 0, len(orderBook.bids))
-	for price := range orderBook.bids {
-		prices = append(prices, price)
-	}
+func (orderBook *OrderBook) GetAllBids() []Price {
+	return pricesOfSide(orderBook.bids)
+}
 
-	// Sort descending (highest bid first)
-	for i := 0; i < len(prices); i++ {
-		for j := i + 1; j < len(prices); j++ {
-			if prices[i] < prices[j] {
-				prices[i], prices[j] = prices[j], prices[i]
-			}
-		}
-	}
+// GetAllAsks returns all ask price levels sorted from lowest to highest
+func (orderBook *OrderBook) GetAllAsks() []Price {
+	return pricesOfSide(orderBook.asks)
+}
 
+func pricesOfSide(side *priceLevelList) []Price {
+	prices := make([]Price, 0, side.Len())
+	side.Each(func(level *PriceLevel) bool {
+		prices = append(prices, level.Price)
+		return true
+	})
 	return prices
 }
 
-// GetAllAsks returns all ask price levels sorted from lowest to highest
-func (orderBook *OrderBook) GetAllAsks() []float64 {
-	prices := make([]float64, 0, len(orderBook.asks))
-	for price := range orderBook.asks {
-		prices = append(prices, price)
-	}
+// GetBidOrders returns every resting bid in priority order: best price first, then arrival
+func (orderBook *OrderBook) GetBidOrders() []*Order {
+	return ordersOfSide(orderBook.bids)
+}
 
-	// Sort ascending (lowest ask first)
-	for i := 0; i < len(prices); i++ {
-		for j := i + 1; j < len(prices); j++ {
-			if prices[i] > prices[j] {
-				prices[i], prices[j] = prices[j], prices[i]
-			}
+// GetAskOrders returns every resting ask in priority order: best price first, then arrival
+func (orderBook *OrderBook) GetAskOrders() []*Order {
+	return ordersOfSide(orderBook.asks)
+}
+
+func ordersOfSide(side *priceLevelList) []*Order {
+	orders := make([]*Order, 0)
+	side.Each(func(level *PriceLevel) bool {
+		orders = append(orders, level.Orders...)
+		return true
+	})
+	return orders
+}
+
+// BookLevel is the aggregate displayed quantity at one price; hidden iceberg reserves are excluded
+type BookLevel struct {
+	Price      Price
+	Quantity   int
+	OrderCount int
+}
+
+// GetBidLevels returns up to depth bid levels, best first. A depth of 0 returns every level.
+func (orderBook *OrderBook) GetBidLevels(depth int) []BookLevel {
+	return levelsOfSide(orderBook.bids, depth)
+}
+
+// GetAskLevels returns up to depth ask levels, best first. A depth of 0 returns every level.
+func (orderBook *OrderBook) GetAskLevels(depth int) []BookLevel {
+	return levelsOfSide(orderBook.asks, depth)
+}
+
+func levelsOfSide(side *priceLevelList, depth int) []BookLevel {
+	levels := make([]BookLevel, 0, side.Len())
+	side.Each(func(level *PriceLevel) bool {
+		if depth > 0 && len(levels) >= depth {
+			return false
 		}
+		levels = append(levels, BookLevel{
+			Price:      level.Price,
+			Quantity:   level.DisplayedSize(),
+			OrderCount: len(level.Orders),
+		})
+		return true
+	})
+	return levels
+}
+
+// GetLevel returns the resting quantity at one price on a side, with a Quantity of 0 if no
+// orders rest there
+func (orderBook *OrderBook) GetLevel(side SideType, price Price) BookLevel {
+	levels := orderBook.asks
+	if side == Buy {
+		levels = orderBook.bids
 	}
+	level := levels.Get(price)
+	if level == nil {
+		return BookLevel{Price: price}
+	}
+	return BookLevel{Price: price, Quantity: level.DisplayedSize(), OrderCount: len(level.Orders)}
+}
 
-	return prices
+// GetAskQuantityAtOrBelow returns the total executable ask quantity, hidden reserves included,
+// priced at or below a limit
+func (orderBook *OrderBook) GetAskQuantityAtOrBelow(limitPrice Price) int {
+	total := 0
+	orderBook.asks.Each(func(level *PriceLevel) bool {
+		if level.Price > limitPrice {
+			return false
+		}
+		total += level.TotalSize()
+		return true
+	})
+	return total
+}
+
+// GetBidQuantityAtOrAbove returns the total executable bid quantity, hidden reserves included,
+// priced at or above a limit
+func (orderBook *OrderBook) GetBidQuantityAtOrAbove(limitPrice Price) int {
+	total := 0
+	orderBook.bids.Each(func(level *PriceLevel) bool {
+		if level.Price < limitPrice {
+			return false
+		}
+		total += level.TotalSize()
+		return true
+	})
+	return total
 }
 
 // GetBidsAtPrice returns all bid orders at a specific price
-func (orderBook *OrderBook) GetBidsAtPrice(price float64) []*Order {
-	return orderBook.bids[price]
+func (orderBook *OrderBook) GetBidsAtPrice(price Price) []*Order {
+	if level := orderBook.bids.Get(price); level != nil {
+		return level.Orders
+	}
+	return nil
 }
 
 // GetAsksAtPrice returns all ask orders at a specific price
-func (orderBook *OrderBook) GetAsksAtPrice(price float64) []*Order {
-	return orderBook.asks[price]
+func (orderBook *OrderBook) GetAsksAtPrice(price Price) []*Order {
+	if level := orderBook.asks.Get(price); level != nil {
+		return level.Orders
+	}
+	return nil
+}
+
+// OrderCount returns the number of resting orders indexed in the book
+func (orderBook *OrderBook) OrderCount() int {
+	return len(orderBook.index)
 }
@@ -0,0 +1,178 @@
+package matching
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+A pegged order is a limit order whose price follows the book instead of being set by its user.
+Its reference is the best bid or ask, or the midpoint between them, counting only orders that
+are not pegged themselves, so pegs never chase each other. The order's price is the reference
+plus its offset, held at its cap, rounded to the tick on the passive side and kept one tick
+behind the opposite best. A pegged order therefore never takes liquidity: it rests on arrival
+and is only ever filled by orders that reach it.
+
+	peg       buy reference   sell reference
+	primary   best bid        best ask
+	market    best ask        best bid
+	midpoint  midpoint        midpoint
+
+Pegged orders are repriced after every command that changes their symbol's book, in the order
+they arrived. An order whose price changes leaves its level and joins the back of the queue at
+the new price, as an amend would; one whose price is unchanged keeps its place. Repricing
+follows the book's own best prices, so it is not checked against the price bands again. A peg
+whose reference disappears keeps its last price until one returns, while a new order without a
+reference is rejected with RejectPegReference. Pegs are not repriced during a call auction,
+whose book may cross, and cannot be entered while one runs.
+
+Repricing follows deterministically from each command, so it is not journaled; replay and
+snapshots restore the same prices.
+*/
+
+// PegType is what a pegged order's price follows
+type PegType int
+
+const (
+	PegNone     PegType = iota // An ordinary order with its own price
+	PegPrimary                 // Best price on the order's own side
+	PegMarket                  // Best price on the opposite side
+	PegMidpoint                // Midpoint between the best bid and ask
+)
+
+var pegTypeNames = map[PegType]string{
+	PegNone:     "",
+	PegPrimary:  "primary",
+	PegMarket:   "market",
+	PegMidpoint: "midpoint",
+}
+
+func (p PegType) String() string {
+	if name, ok := pegTypeNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParsePegType parses "primary", "market" or "midpoint"
+func ParsePegType(value string) (PegType, error) {
+	for peg, name := range pegTypeNames {
+		if name == value && peg != PegNone {
+			return peg, nil
+		}
+	}
+	return PegNone, fmt.Errorf("unknown peg type %q", value)
+}
+
+// bestUnpegged returns the best price on a side among orders that are not pegged, or false if
+// there is none
+func bestUnpegged(side *priceLevelList) (Price, bool) {
+	var best Price
+	found := false
+	side.Each(func(level *PriceLevel) bool {
+		found = slices.ContainsFunc(level.Orders, func(order *Order) bool { return order.Peg == PegNone })
+		best = level.Price
+		return !found
+	})
+	return best, found
+}
+
+// pegPrice returns the price a pegged order should rest at, or false if its reference is missing
+// or leaves no valid price
+func (e *Engine) pegPrice(book *OrderBook, order *Order) (Price, bool) {
+	own, hasOwn := bestUnpegged(book.bids)
+	opposite, hasOpposite := bestUnpegged(book.asks)
+	if order.Side == Sell {
+		own, hasOwn, opposite, hasOpposite = opposite, hasOpposite, own, hasOwn
+	}
+
+	var reference Price
+	switch {
+	case order.Peg == PegPrimary && hasOwn:
+		reference = own
+	case order.Peg == PegMarket && hasOpposite:
+		reference = opposite
+	case order.Peg == PegMidpoint && hasOwn && hasOpposite && order.Side == Buy:
+		reference = (own + opposite) / 2 // Midpoints round down for bids
+	case order.Peg == PegMidpoint && hasOwn && hasOpposite:
+		reference = (own + opposite + 1) / 2 // and up for offers
+	default:
+		return 0, false
+	}
+
+	inst, _ := e.GetInstrument(order.Symbol)
+	price := reference + order.PegOffset
+	if order.Side == Buy {
+		if order.PegCap > 0 {
+			price = min(price, order.PegCap)
+		}
+		price -= price % inst.TickSize
+		if bestAsk, asks := book.GetBestAsk(); len(asks) > 0 {
+			price = min(price, bestAsk-inst.TickSize)
+		}
+	} else {
+		if order.PegCap > 0 {
+			price = max(price, order.PegCap)
+		}
+		if remainder := price % inst.TickSize; remainder != 0 {
+			price += inst.TickSize - remainder
+		}
+		if bestBid, bids := book.GetBestBid(); len(bids) > 0 {
+			price = max(price, bestBid+inst.TickSize)
+		}
+	}
+	return price, price > 0
+}
+
+// addPeg starts repricing a pegged order that has come to rest
+func (e *Engine) addPeg(order *Order) {
+	e.pegged[order.Symbol] = append(e.pegged[order.Symbol], order)
+}
+
+// repegOrders reprices the pegged orders of every symbol whose book the current command changed.
+// It runs once the command has been applied, before its events are published.
+func (e *Engine) repegOrders() {
+	var symbols []string
+	for key := range e.events.levels {
+		if len(e.pegged[key.symbol]) > 0 && !slices.Contains(symbols, key.symbol) {
+			symbols = append(symbols, key.symbol)
+		}
+	}
+	for _, symbol := range symbols {
+		e.repeg(symbol)
+	}
+}
+
+// repeg moves each of a symbol's resting pegged orders to its current peg price, dropping those
+// that have left the book
+func (e *Engine) repeg(symbol string) {
+	if e.auctions[symbol] {
+		return
+	}
+	book := e.GetOrderBookForSymbol(symbol)
+
+	working := e.pegged[symbol][:0]
+	for _, order := range e.pegged[symbol] {
+		if book.SearchById(order.ID) != order {
+			continue
+		}
+		working = append(working, order)
+
+		price, ok := e.pegPrice(book, order)
+		if !ok || price == order.Price {
+			continue
+		}
+		book.DeleteOrderById(order.ID)
+		e.markLevel(order)
+		order.Price = price
+		if order.Side == Buy {
+			book.AddBidOrder(order)
+		} else {
+			book.AddAskOrder(order)
+		}
+		e.markLevel(order)
+		e.markOrder(order)
+	}
+	clear(e.pegged[symbol][len(working):])
+	e.pegged[symbol] = working
+}
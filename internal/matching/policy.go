@@ -0,0 +1,126 @@
+package matching
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+Price priority is fixed: an incoming order always trades the best opposite price first. What a
+symbol's MatchingPolicy decides is how the quantity traded at one price level is shared among the
+orders resting there.
+
+The engine asks the policy for an allocation, applies it, and asks again at the new best level
+while the incoming order has size left. FIFO gives everything to the order at the head of the
+queue, so orders trade strictly in arrival order. ProRata shares the quantity in proportion to
+resting size, optionally after a priority fill for the top order or lead market makers.
+
+Allocations must be deterministic: journal replay re-runs them and has to produce the same
+trades.
+*/
+
+// MatchingPolicy allocates quantity among the orders resting at one price level
+type MatchingPolicy interface {
+	// Allocate splits quantity among orders, given in time priority with the size each can trade
+	// now. It returns the fill for each order, in total the lesser of quantity and the sizes' sum.
+	Allocate(orders []*Order, sizes []int, quantity int) []int
+}
+
+// FIFO fills the order at the head of the level first: price-time priority
+type FIFO struct{}
+
+func (FIFO) Allocate(orders []*Order, sizes []int, quantity int) []int {
+	fills := make([]int, len(orders))
+	if len(orders) > 0 {
+		fills[0] = min(quantity, sizes[0])
+	}
+	return fills
+}
+
+// ProRata shares quantity among the orders at a level in proportion to their size
+type ProRata struct {
+	MinAllocation int // Pro-rata shares below this are not given; the lots go to the rounding leftover
+
+	TopOrder bool // The oldest order at the level is filled first, up to its size
+
+	LeadMarketMakers []string // Users allocated LMMPercent of the quantity before the pro-rata share
+	LMMPercent       int      // Percent of the incoming quantity, rounded down, reserved for lead market makers
+}
+
+func (p ProRata) Allocate(orders []*Order, sizes []int, quantity int) []int {
+	fills := make([]int, len(orders))
+	if p.TopOrder && len(orders) > 0 {
+		fills[0] = min(quantity, sizes[0])
+		quantity -= fills[0]
+	}
+
+	if len(p.LeadMarketMakers) > 0 && p.LMMPercent > 0 {
+		isLeadMarketMaker := func(i int) bool { return slices.Contains(p.LeadMarketMakers, orders[i].UserID) }
+		quantity -= p.share(fills, sizes, quantity*p.LMMPercent/100, isLeadMarketMaker)
+	}
+
+	p.share(fills, sizes, quantity, func(int) bool { return true })
+	return fills
+}
+
+// share allocates quantity over the eligible orders' unfilled sizes and returns how much it gave.
+// Shares are rounded down; the lots left over go to eligible orders in time priority.
+func (p ProRata) share(fills []int, sizes []int, quantity int, eligible func(int) bool) int {
+	total := 0
+	for i := range sizes {
+		if eligible(i) {
+			total += sizes[i] - fills[i]
+		}
+	}
+	quantity = min(quantity, total)
+	if quantity <= 0 {
+		return 0
+	}
+
+	allocated := 0
+	for i := range sizes {
+		if !eligible(i) {
+			continue
+		}
+		share := int(int64(quantity) * int64(sizes[i]-fills[i]) / int64(total))
+		if share > 0 && share >= p.MinAllocation {
+			fills[i] += share
+			allocated += share
+		}
+	}
+
+	for i := range sizes {
+		if allocated == quantity {
+			break
+		}
+		if eligible(i) {
+			extra := min(quantity-allocated, sizes[i]-fills[i])
+			fills[i] += extra
+			allocated += extra
+		}
+	}
+	return allocated
+}
+
+// NewMatchingPolicy returns the policy named "fifo", "pro_rata", "pro_rata_top_order" or
+// "pro_rata_lmm". The pro-rata policies take their parameters from params.
+func NewMatchingPolicy(name string, params ProRata) (MatchingPolicy, error) {
+	switch name {
+	case "fifo":
+		return FIFO{}, nil
+	case "pro_rata":
+		return ProRata{MinAllocation: params.MinAllocation}, nil
+	case "pro_rata_top_order":
+		return ProRata{MinAllocation: params.MinAllocation, TopOrder: true}, nil
+	case "pro_rata_lmm":
+		if len(params.LeadMarketMakers) == 0 || params.LMMPercent <= 0 || params.LMMPercent > 100 {
+			return nil, fmt.Errorf("pro_rata_lmm needs lead market makers and a percent between 1 and 100")
+		}
+		return ProRata{
+			MinAllocation:    params.MinAllocation,
+			LeadMarketMakers: params.LeadMarketMakers,
+			LMMPercent:       params.LMMPercent,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown matching policy %q", name)
+}
@@ -0,0 +1,70 @@
+package matching
+
+/*
+The engine keeps each user's net filled quantity per symbol: buys add, sells subtract. It is the
+only position notion in the system and exists so reduce-only orders can be enforced. Positions
+start at zero when a user first trades and are rebuilt by journal replay or restored from a
+snapshot like the rest of the engine state.
+
+Reduce-only orders are clipped to the position they can close when they arrive, and every fill
+against one is capped again at the position left at that moment, so orders filled elsewhere in
+the meantime can never flip a position. A working reduce-only order with nothing left to reduce
+is cancelled.
+*/
+
+// positionKey identifies one user's position in one symbol
+type positionKey struct {
+	userID string
+	symbol string
+}
+
+// GetPosition returns a user's net filled quantity in a symbol, positive when long
+func (e *Engine) GetPosition(userID string, symbol string) int {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+	return e.positions[positionKey{userID: userID, symbol: symbol}]
+}
+
+// addPosition records a fill of size against an order's user
+func (e *Engine) addPosition(order *Order, size int) {
+	key := positionKey{userID: order.UserID, symbol: order.Symbol}
+	if order.Side == Sell {
+		size = -size
+	}
+	if position := e.positions[key] + size; position != 0 {
+		e.positions[key] = position
+	} else {
+		delete(e.positions, key)
+	}
+}
+
+// reducibleSize returns how much of its user's position an order would close if it filled
+func (e *Engine) reducibleSize(order *Order) int {
+	position := e.positions[positionKey{userID: order.UserID, symbol: order.Symbol}]
+	if order.Side == Buy {
+		return max(-position, 0)
+	}
+	return max(position, 0)
+}
+
+// tradableSize returns how much of a resting order can trade now: its visible size, limited for
+// reduce-only orders by the position left to reduce and for group legs by what their group has left
+func (e *Engine) tradableSize(order *Order) int {
+	size := order.DisplayedSize()
+	if order.ReduceOnly {
+		size = min(size, e.reducibleSize(order))
+	}
+	if open, ok := e.groupOpen(order); ok {
+		size = min(size, open)
+	}
+	return size
+}
+
+// finishFlat cancels a resting order that tradableSize leaves nothing to trade, once it has been
+// removed from the book
+func (e *Engine) finishFlat(order *Order) {
+	if !order.ReduceOnly {
+		order.CancelReason = CancelGroup
+	}
+	e.finishOrder(order, StatusCancelled)
+}
@@ -0,0 +1,142 @@
+package matching
+
+import "math/rand"
+
+// maxSkipHeight bounds the skip list tower height; 2^24 levels is far beyond any real book
+const maxSkipHeight = 24
+
+// PriceLevel is a single price in one side of the book with its FIFO queue of orders
+type PriceLevel struct {
+	Price  Price
+	Orders []*Order
+
+	next []*PriceLevel // Skip list forward pointers, one per tower height
+}
+
+// TotalSize returns the sum of order sizes resting at this level, including hidden iceberg reserves
+func (level *PriceLevel) TotalSize() int {
+	total := 0
+	for _, order := range level.Orders {
+		total += order.Size
+	}
+	return total
+}
+
+// DisplayedSize returns the quantity shown at this level, counting only each iceberg's visible slice
+func (level *PriceLevel) DisplayedSize() int {
+	total := 0
+	for _, order := range level.Orders {
+		total += order.DisplayedSize()
+	}
+	return total
+}
+
+// priceLevelList keeps one side's price levels ordered best-first in a skip list,
+// with a map for O(1) lookup of a level by price
+type priceLevelList struct {
+	head   *PriceLevel
+	height int
+	byKey  map[Price]*PriceLevel
+	better func(a, b Price) bool // Reports whether price a has priority over price b
+	rng    *rand.Rand
+}
+
+func newPriceLevelList(better func(a, b Price) bool) *priceLevelList {
+	return &priceLevelList{
+		head:   &PriceLevel{next: make([]*PriceLevel, maxSkipHeight)},
+		height: 1,
+		byKey:  make(map[Price]*PriceLevel),
+		better: better,
+		rng:    rand.New(rand.NewSource(1)),
+	}
+}
+
+func (list *priceLevelList) randomHeight() int {
+	height := 1
+	for height < maxSkipHeight && list.rng.Intn(4) == 0 {
+		height++
+	}
+	return height
+}
+
+// Len returns the number of price levels
+func (list *priceLevelList) Len() int {
+	return len(list.byKey)
+}
+
+// Best returns the highest priority level, or nil if the side is empty
+func (list *priceLevelList) Best() *PriceLevel {
+	return list.head.next[0]
+}
+
+// Get returns the level at a price, or nil
+func (list *priceLevelList) Get(price Price) *PriceLevel {
+	return list.byKey[price]
+}
+
+// GetOrCreate returns the level at a price, inserting an empty one in order if needed
+func (list *priceLevelList) GetOrCreate(price Price) *PriceLevel {
+	if level, ok := list.byKey[price]; ok {
+		return level
+	}
+
+	var update [maxSkipHeight]*PriceLevel
+	node := list.head
+	for h := list.height - 1; h >= 0; h-- {
+		for node.next[h] != nil && list.better(node.next[h].Price, price) {
+			node = node.next[h]
+		}
+		update[h] = node
+	}
+
+	height := list.randomHeight()
+	if height > list.height {
+		for h := list.height; h < height; h++ {
+			update[h] = list.head
+		}
+		list.height = height
+	}
+
+	level := &PriceLevel{Price: price, next: make([]*PriceLevel, height)}
+	for h := 0; h < height; h++ {
+		level.next[h] = update[h].next[h]
+		update[h].next[h] = level
+	}
+
+	list.byKey[price] = level
+	return level
+}
+
+// Remove unlinks the level at a price, returning false if none exists
+func (list *priceLevelList) Remove(price Price) bool {
+	level, ok := list.byKey[price]
+	if !ok {
+		return false
+	}
+
+	node := list.head
+	for h := list.height - 1; h >= 0; h-- {
+		for node.next[h] != nil && node.next[h] != level && list.better(node.next[h].Price, price) {
+			node = node.next[h]
+		}
+		if node.next[h] == level {
+			node.next[h] = level.next[h]
+		}
+	}
+
+	for list.height > 1 && list.head.next[list.height-1] == nil {
+		list.height--
+	}
+
+	delete(list.byKey, price)
+	return true
+}
+
+// Each visits levels best-first until fn returns false
+func (list *priceLevelList) Each(fn func(level *PriceLevel) bool) {
+	for level := list.head.next[0]; level != nil; level = level.next[0] {
+		if !fn(level) {
+			return
+		}
+	}
+}
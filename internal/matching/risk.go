@@ -0,0 +1,137 @@
+package matching
+
+/*
+Pre-trade risk checks cap what a user may send and keep working in a symbol. They run on the
+sequencer as an order arrives, once its price is known and before it is journaled, so a rejected
+order leaves no trace, and again for an amend that raises an order's quantity or changes its
+price. Each limit is 0 when disabled and rejects with its own reason:
+
+  - MaxOrderQuantity caps the quantity of one order (RejectMaxOrderQuantity).
+  - MaxOrderNotional caps its price times quantity, in price units (RejectMaxOrderNotional).
+  - MaxPriceDeviationBps caps how far a limit order's price may be through the touch, in basis
+    points of it: above the best ask for a buy, below the best bid for a sell. With that side of
+    the book empty the check uses the best price on the order's own side, then the last trade,
+    and is skipped if there is neither (RejectPriceDeviation).
+  - MaxOpenOrders caps the user's working orders in the symbol, the new one included
+    (RejectMaxOpenOrders).
+  - MaxOpenNotional caps the notional of the user's working orders on the order's side, the new
+    one included (RejectMaxOpenNotional).
+
+The legs of an OCO pair or bracket are checked one by one as the group arrives, each counting
+the whole group towards the open orders but only itself towards the notional, as at most one
+leg of each side works at a time. Notional is the order's limit price times its quantity; a
+stop-market order counts at its stop price and a market order at the price it would first trade
+at, the touch as above. Working orders count their remaining quantity, held bracket exits
+included, and are read from the order tracker, so the exposure is always current. A user's own
+limits in a symbol replace its default limits as a whole. Replay skips the checks: journaled
+orders were accepted when they arrived, whatever the limits are now.
+*/
+
+// RiskLimits caps one user's orders in one symbol. Zero values disable each limit.
+type RiskLimits struct {
+	MaxOrderQuantity     int
+	MaxOrderNotional     Price // In price units, like a trade's fees
+	MaxPriceDeviationBps int
+	MaxOpenOrders        int
+	MaxOpenNotional      Price // Per side, in price units
+}
+
+// RiskRules holds the limits of a symbol
+type RiskRules struct {
+	Default RiskLimits            // Limits of users without their own
+	Users   map[string]RiskLimits // Limits of particular users, replacing Default
+}
+
+// GetRiskLimits returns the limits a user's orders in a symbol are checked against
+func (e *Engine) GetRiskLimits(userID string, symbol string) RiskLimits {
+	rules := e.risk[symbol]
+	if limits, ok := rules.Users[userID]; ok {
+		return limits
+	}
+	return rules.Default
+}
+
+// riskTouch returns the price an order is checked against for price deviation and valued at when
+// it has no price of its own, or false if the symbol has no price yet
+func (e *Engine) riskTouch(book *OrderBook, order *Order) (Price, bool) {
+	own, opposite := book.GetBestBidLevel(), book.GetBestAskLevel()
+	if order.Side == Sell {
+		own, opposite = opposite, own
+	}
+	switch {
+	case opposite != nil:
+		return opposite.Price, true
+	case own != nil:
+		return own.Price, true
+	}
+	return e.GetLastTradePrice(order.Symbol)
+}
+
+// riskPrice returns the price an order's notional is counted at
+func riskPrice(order *Order, price Price) Price {
+	if order.OrderType == StopMarketOrder {
+		return order.StopPrice
+	}
+	return price
+}
+
+// openExposure returns how many working orders a user has in a symbol and their notional on a
+// side, leaving out one order
+func (e *Engine) openExposure(userID string, symbol string, side SideType, except uint64) (int, Price) {
+	e.trackerMutex.RLock()
+	defer e.trackerMutex.RUnlock()
+
+	count, notional := 0, Price(0)
+	for _, order := range e.orderTracker {
+		if order.UserID != userID || order.Symbol != symbol || order.ID == except {
+			continue
+		}
+		count++
+		if order.Side == side {
+			notional += riskPrice(order, order.Price) * Price(order.Size)
+		}
+	}
+	return count, notional
+}
+
+// checkRisk returns why an order of quantity, with remaining still to fill, at price would break
+// its user's limits, or RejectNone. Added is how many working orders it comes with: 1 for a new
+// order, those of its group for a group leg and 0 for an amend.
+func (e *Engine) checkRisk(order *Order, price Price, quantity int, remaining int, added int) RejectReason {
+	limits := e.GetRiskLimits(order.UserID, order.Symbol)
+	if limits == (RiskLimits{}) || e.replaying {
+		return RejectNone
+	}
+
+	if limits.MaxOrderQuantity > 0 && quantity > limits.MaxOrderQuantity {
+		return RejectMaxOrderQuantity
+	}
+
+	book := e.GetOrderBookForSymbol(order.Symbol)
+	touch, hasTouch := e.riskTouch(book, order)
+	price = riskPrice(order, price)
+	if order.OrderType == MarketOrder {
+		price = touch
+	}
+	if limits.MaxOrderNotional > 0 && price*Price(quantity) > limits.MaxOrderNotional {
+		return RejectMaxOrderNotional
+	}
+
+	if limits.MaxPriceDeviationBps > 0 && hasTouch && order.OrderType == LimitOrder {
+		deviation := touch * Price(limits.MaxPriceDeviationBps) / 10000
+		if (order.Side == Buy && price > touch+deviation) || (order.Side == Sell && price < touch-deviation) {
+			return RejectPriceDeviation
+		}
+	}
+
+	if limits.MaxOpenOrders > 0 || limits.MaxOpenNotional > 0 {
+		count, notional := e.openExposure(order.UserID, order.Symbol, order.Side, order.ID)
+		if limits.MaxOpenOrders > 0 && count+added > limits.MaxOpenOrders {
+			return RejectMaxOpenOrders
+		}
+		if limits.MaxOpenNotional > 0 && notional+price*Price(remaining) > limits.MaxOpenNotional {
+			return RejectMaxOpenNotional
+		}
+	}
+	return RejectNone
+}
@@ -0,0 +1,73 @@
+package matching
+
+/*
+Every mutation of the books, trigger books and order tracker runs on one sequencer goroutine, in
+the order it was submitted. Callers such as HTTP handlers hand a command to the sequencer and
+block until it has been applied, so PlaceOrder and CancelOrder keep their synchronous signatures.
+
+The sequencer holds stateMutex for writing while it applies a command. Readers take it for
+reading, so a snapshot never observes a half-matched order and many readers can run at once.
+
+Code that already runs on the sequencer must call the unexported variants (placeOrder,
+cancelOrder, trackedOrders) rather than the public methods, which would deadlock.
+*/
+
+// command is one unit of work for the sequencer
+type command struct {
+	apply    func()
+	done     chan struct{}
+	panicked interface{} // Set if apply panicked, re-raised on the submitting goroutine
+}
+
+// Start launches the sequencer goroutine. NewEngineWithConfig calls it, and later calls are no-ops.
+func (e *Engine) Start() {
+	e.startOnce.Do(func() {
+		e.sequencer.Add(1)
+		go e.runSequencer()
+	})
+}
+
+func (e *Engine) runSequencer() {
+	defer e.sequencer.Done()
+	for {
+		select {
+		case cmd := <-e.commands:
+			e.applyCommand(cmd)
+		case <-e.stopped:
+			return
+		}
+	}
+}
+
+func (e *Engine) applyCommand(cmd *command) {
+	e.stateMutex.Lock()
+	defer func() {
+		if cmd.panicked = recover(); cmd.panicked != nil {
+			e.events.reset() // Drop the changes of the failed command
+		}
+		e.stateMutex.Unlock()
+		close(cmd.done)
+	}()
+	cmd.apply()
+	e.settleGroups()
+	e.repegOrders()
+	e.publishEvents()
+}
+
+// submit runs fn on the sequencer and waits for it to finish.
+// It reports false without running fn if the engine has been closed.
+func (e *Engine) submit(fn func()) bool {
+	cmd := &command{apply: fn, done: make(chan struct{})}
+
+	select {
+	case e.commands <- cmd:
+	case <-e.stopped:
+		return false
+	}
+
+	<-cmd.done
+	if cmd.panicked != nil {
+		panic(cmd.panicked)
+	}
+	return true
+}
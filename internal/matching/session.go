@@ -0,0 +1,277 @@
+package matching
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+/*
+Each symbol is in one trading phase, which decides the order types it accepts, whether users may
+cancel, and whether orders match. A symbol is in continuous trading unless a schedule or SetPhase
+moves it elsewhere.
+
+	phase              orders                  cancels  matching
+	pre_open           limit, stop             yes      collected for the opening uncross
+	opening_auction    limit                   no       collected for the opening uncross
+	continuous         all                     yes      continuous
+	closing_auction    limit                   no       collected for the closing uncross
+	halted             none                    yes      none
+	reopening_auction  limit, stop             yes      collected for the uncross that ends a halt
+	closed             none                    yes      none
+
+The collecting phases run a call auction (see auction.go). Leaving one for continuous trading or
+closed uncrosses the book; a halt freezes it as it is, auction included, until the symbol resumes.
+Amends count as a cancel and a new order, so they need both. Expiry and engine cancels, such as
+self-trade prevention, apply in every phase.
+
+A Schedule moves every symbol through the same phases each trading day. Days it does not run and
+holidays stay closed. A halted symbol stays halted through scheduled changes, except into
+closed, until it is resumed with SetPhase or, after a volatility halt, reopens through its
+reopening auction (see bands.go). Phase changes are journaled like orders.
+*/
+
+// TradingPhase is the part of the trading day a symbol is in
+type TradingPhase int
+
+const (
+	PhaseContinuous       TradingPhase = iota // Orders match as they arrive
+	PhasePreOpen                              // Orders collect for the opening auction and may be cancelled
+	PhaseOpeningAuction                       // Limit orders collect for the opening uncross; no cancels
+	PhaseClosingAuction                       // Limit orders collect for the closing uncross; no cancels
+	PhaseHalted                               // Trading is suspended; orders may only be cancelled
+	PhaseClosed                               // Outside the trading day; orders may only be cancelled
+	PhaseReopeningAuction                     // Orders collect for the uncross that ends a halt
+)
+
+var tradingPhaseNames = map[TradingPhase]string{
+	PhaseContinuous:       "continuous",
+	PhasePreOpen:          "pre_open",
+	PhaseOpeningAuction:   "opening_auction",
+	PhaseClosingAuction:   "closing_auction",
+	PhaseHalted:           "halted",
+	PhaseClosed:           "closed",
+	PhaseReopeningAuction: "reopening_auction",
+}
+
+func (p TradingPhase) String() string {
+	if name, ok := tradingPhaseNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseTradingPhase parses "pre_open", "opening_auction", "continuous", "closing_auction",
+// "halted", "reopening_auction" or "closed"
+func ParseTradingPhase(value string) (TradingPhase, error) {
+	for phase, name := range tradingPhaseNames {
+		if name == value {
+			return phase, nil
+		}
+	}
+	return PhaseContinuous, fmt.Errorf("unknown trading phase %q", value)
+}
+
+// phaseRule is what a trading phase allows
+type phaseRule struct {
+	orderTypes []OrderType
+	cancels    bool
+	auction    bool // Orders collect for an uncross instead of matching
+}
+
+var phaseRules = map[TradingPhase]phaseRule{
+	PhasePreOpen:          {orderTypes: []OrderType{LimitOrder, StopMarketOrder, StopLimitOrder}, cancels: true, auction: true},
+	PhaseOpeningAuction:   {orderTypes: []OrderType{LimitOrder}, auction: true},
+	PhaseContinuous:       {orderTypes: []OrderType{MarketOrder, LimitOrder, StopMarketOrder, StopLimitOrder}, cancels: true},
+	PhaseClosingAuction:   {orderTypes: []OrderType{LimitOrder}, auction: true},
+	PhaseHalted:           {cancels: true},
+	PhaseClosed:           {cancels: true},
+	PhaseReopeningAuction: {orderTypes: []OrderType{LimitOrder, StopMarketOrder, StopLimitOrder}, cancels: true, auction: true},
+}
+
+// OrderTypes returns the order types the phase accepts
+func (p TradingPhase) OrderTypes() []OrderType {
+	return slices.Clone(phaseRules[p].orderTypes)
+}
+
+// AllowsOrderType reports whether the phase accepts new orders of a type
+func (p TradingPhase) AllowsOrderType(orderType OrderType) bool {
+	return slices.Contains(phaseRules[p].orderTypes, orderType)
+}
+
+// AllowsCancel reports whether users may cancel orders in the phase
+func (p TradingPhase) AllowsCancel() bool {
+	return phaseRules[p].cancels
+}
+
+// IsAuction reports whether orders collect for an uncross in the phase
+func (p TradingPhase) IsAuction() bool {
+	return phaseRules[p].auction
+}
+
+// Trading phase errors
+var (
+	ErrCancelNotAllowed = errors.New("cancels are not accepted in the symbol's trading phase")
+	ErrNotContinuous    = errors.New("symbol is not in continuous trading")
+)
+
+// SetPhase moves a symbol to a trading phase and returns the trades of any uncross it caused
+func (e *Engine) SetPhase(symbol string, phase TradingPhase) ([]*Trade, error) {
+	var trades []*Trade
+	var err error
+	if !e.submit(func() {
+		e.commandTime = time.Now()
+		trades, err = e.setPhase(symbol, phase)
+	}) {
+		return nil, ErrEngineClosed
+	}
+	return trades, err
+}
+
+func (e *Engine) setPhase(symbol string, phase TradingPhase) ([]*Trade, error) {
+	book := e.GetOrderBookForSymbol(symbol)
+	if book == nil {
+		return nil, ErrUnknownSymbol
+	}
+	if _, ok := tradingPhaseNames[phase]; !ok {
+		return nil, fmt.Errorf("unknown trading phase %d", phase)
+	}
+	if e.phases[symbol] == phase {
+		return nil, nil
+	}
+	if err := e.record(&JournalEntry{Type: JournalPhase, Symbol: symbol, Phase: phase}); err != nil {
+		return nil, err
+	}
+	e.phases[symbol] = phase
+	e.scheduleReopen(symbol, phase)
+	e.markSymbolGroups(symbol)
+
+	switch {
+	case phase.IsAuction() && !e.auctions[symbol]:
+		e.beginAuction(symbol)
+	case !phase.IsAuction() && phase != PhaseHalted && e.auctions[symbol]:
+		// Stops triggered by the uncross only fire if trading continues
+		_, trades := e.executeUncross(book, symbol)
+		if phase == PhaseContinuous {
+			trades = append(trades, e.processTriggeredStops(book, symbol, trades)...)
+		}
+		return trades, nil
+	}
+	return nil, nil
+}
+
+// GetPhase returns a symbol's trading phase, or false if the symbol is not registered
+func (e *Engine) GetPhase(symbol string) (TradingPhase, bool) {
+	e.stateMutex.RLock()
+	defer e.stateMutex.RUnlock()
+
+	if e.GetOrderBookForSymbol(symbol) == nil {
+		return PhaseContinuous, false
+	}
+	return e.phases[symbol], true
+}
+
+// cancelByUser is cancelOrder for a cancel a user asked for, which the order's phase may refuse
+func (e *Engine) cancelByUser(orderId uint64) (bool, error) {
+	if order := e.trackedOrder(orderId); order != nil && !e.phases[order.Symbol].AllowsCancel() {
+		return false, ErrCancelNotAllowed
+	}
+	return e.cancelOrder(orderId, StatusCancelled)
+}
+
+// Schedule moves every symbol through the trading phases of each day
+type Schedule struct {
+	Phases   []ScheduledPhase // In order of start time; a trading day is closed before the first
+	Days     []time.Weekday   // Days the schedule runs; empty means every day
+	Holidays []time.Time      // Dates that stay closed, compared by year, month and day
+	Location *time.Location   // Time zone of start times and dates; nil means local time
+}
+
+// ScheduledPhase starts a trading phase at a time of day
+type ScheduledPhase struct {
+	Start time.Duration // Offset from midnight
+	Phase TradingPhase
+}
+
+// PhaseAt returns the phase the schedule sets at a time
+func (s *Schedule) PhaseAt(t time.Time) TradingPhase {
+	t = s.in(t)
+	if !s.isTradingDay(t) {
+		return PhaseClosed
+	}
+
+	phase := PhaseClosed
+	offset := t.Sub(midnight(t))
+	for _, scheduled := range s.Phases {
+		if offset < scheduled.Start {
+			break
+		}
+		phase = scheduled.Phase
+	}
+	return phase
+}
+
+// NextChange returns the first time after t at which the scheduled phase may change: the next
+// start time, or the next midnight if none is left today
+func (s *Schedule) NextChange(t time.Time) time.Time {
+	t = s.in(t)
+	today := midnight(t)
+	for _, scheduled := range s.Phases {
+		if start := today.Add(scheduled.Start); start.After(t) {
+			return start
+		}
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+}
+
+func (s *Schedule) in(t time.Time) time.Time {
+	if s.Location != nil {
+		return t.In(s.Location)
+	}
+	return t.Local()
+}
+
+func (s *Schedule) isTradingDay(t time.Time) bool {
+	if len(s.Days) > 0 && !slices.Contains(s.Days, t.Weekday()) {
+		return false
+	}
+	for _, holiday := range s.Holidays {
+		if holiday.Year() == t.Year() && holiday.Month() == t.Month() && holiday.Day() == t.Day() {
+			return false
+		}
+	}
+	return true
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// runSessionWorker applies the schedule's phase at each change until stop is closed
+func (e *Engine) runSessionWorker(schedule *Schedule, scheduled TradingPhase, stop <-chan struct{}) {
+	for {
+		timer := time.NewTimer(time.Until(schedule.NextChange(time.Now())))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			if phase := schedule.PhaseAt(now); phase != scheduled {
+				scheduled = phase
+				e.followSchedule(phase)
+			}
+		}
+	}
+}
+
+// followSchedule moves every symbol to a scheduled phase, leaving halted symbols halted until
+// the close
+func (e *Engine) followSchedule(phase TradingPhase) {
+	for _, symbol := range e.GetSymbols() {
+		if current, _ := e.GetPhase(symbol); current == PhaseHalted && phase != PhaseClosed {
+			continue
+		}
+		e.SetPhase(symbol, phase)
+	}
+}
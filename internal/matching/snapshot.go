@@ -0,0 +1,431 @@
+package matching
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+/*
+A snapshot is the engine state as of one journal sequence number. Recovery loads the newest
+snapshot that verifies and replays only the journal entries after that sequence, so restart
+time is bounded by the snapshot interval rather than the whole history.
+
+On disk a snapshot is a fixed header followed by a JSON payload:
+
+	magic    [8]byte  "MESNAP\x00\x00"
+	version  uint32   snapshotVersion
+	checksum uint32   CRC-32 (IEEE) of the payload
+	length   uint64   payload size in bytes
+
+Files are named snapshot-<seq>.snap. They are written to a temporary name, synced and renamed,
+and the journal is synced first so it always reaches the snapshot's sequence. A snapshot that
+is truncated, fails its checksum or has an unknown version is skipped in favour of an older one.
+*/
+
+const (
+	snapshotVersion    = 2
+	snapshotHeaderSize = 24
+	snapshotPrefix     = "snapshot-"
+	snapshotSuffix     = ".snap"
+)
+
+var snapshotMagic = [8]byte{'M', 'E', 'S', 'N', 'A', 'P'}
+
+// ErrSnapshotCorrupt is returned for a snapshot file that does not verify
+var ErrSnapshotCorrupt = errors.New("snapshot is corrupt")
+
+// snapshotState is the payload of a snapshot file
+type snapshotState struct {
+	Seq         uint64           // Last journal entry reflected in the state
+	Time        time.Time        // When the snapshot was taken
+	NextOrderID uint64           // Order ID counter
+	NextTradeID uint64           // Last trade ID issued
+	Orders      []*Order         // Every working order
+	Done        []*Order         // Retained terminal orders, oldest first
+	Books       []snapshotBook   // Priority order of each symbol's resting and stop orders
+	LastPrices  map[string]Price // Last trade price per symbol
+	Trades      []*Trade         // Recent trade history, oldest first
+	Groups      []snapshotGroup  // Working order groups, by group ID
+}
+
+// snapshotBook lists order IDs in priority order, so restoring them in sequence rebuilds
+// the same queues
+type snapshotBook struct {
+	Symbol    string
+	Seq       uint64 // Book update sequence
+	Bids      []uint64
+	Asks      []uint64
+	BuyStops  []uint64
+	SellStops []uint64
+	Positions map[string]int         // Net position per user
+	Auction   bool                   // Collecting orders for a call auction
+	Phase     TradingPhase           // Trading phase
+	Bands     *bandState             // Price band state, nil for a symbol without bands
+	Volumes   map[string][]volumeDay // Daily traded quantity per user, for fee tiers
+}
+
+// snapshotGroup is a working order group. Its orders are saved whole, as those that are done may
+// no longer be retained; working and retained orders are restored to the same pointers.
+type snapshotGroup struct {
+	Type     GroupType
+	Quantity int
+	Orders   []*Order
+}
+
+// WriteSnapshot writes the current state to the snapshot directory and removes snapshots
+// beyond the retention count. It returns the path written.
+func (e *Engine) WriteSnapshot() (string, error) {
+	if e.snapshotDir == "" || e.journal == nil {
+		return "", fmt.Errorf("snapshots require a snapshot directory and a journal")
+	}
+
+	// Encode on the sequencer so no order changes mid-snapshot
+	var payload []byte
+	var seq uint64
+	var err error
+	if !e.submit(func() {
+		state := e.captureSnapshot()
+		seq = state.Seq
+		payload, err = json.Marshal(state)
+	}) {
+		return "", ErrEngineClosed
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	// The journal must reach the snapshot's sequence before the snapshot exists
+	if err := e.journal.Sync(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(e.snapshotDir, snapshotFileName(seq))
+	if err := writeSnapshotFile(path, payload); err != nil {
+		return "", err
+	}
+	return path, pruneSnapshots(e.snapshotDir, e.snapshotRetain)
+}
+
+// captureSnapshot runs on the sequencer
+func (e *Engine) captureSnapshot() *snapshotState {
+	state := &snapshotState{
+		Seq:         e.journal.Seq(),
+		Time:        time.Now(),
+		NextOrderID: atomic.LoadUint64(&e.nextOrderID),
+		NextTradeID: e.nextTradeID,
+		Orders:      e.trackedOrders(),
+		LastPrices:  make(map[string]Price),
+	}
+	sort.Slice(state.Orders, func(i, j int) bool { return state.Orders[i].ID < state.Orders[j].ID })
+
+	e.trackerMutex.RLock()
+	state.Done = append([]*Order(nil), e.doneQueue...)
+	e.trackerMutex.RUnlock()
+
+	positions := make(map[string]map[string]int)
+	for key, position := range e.positions {
+		if positions[key.symbol] == nil {
+			positions[key.symbol] = make(map[string]int)
+		}
+		positions[key.symbol][key.userID] = position
+	}
+	volumes := make(map[string]map[string][]volumeDay)
+	for key, days := range e.volumes {
+		if volumes[key.symbol] == nil {
+			volumes[key.symbol] = make(map[string][]volumeDay)
+		}
+		volumes[key.symbol][key.userID] = days
+	}
+
+	for _, symbol := range e.GetSymbols() {
+		book := e.GetOrderBookForSymbol(symbol)
+		triggers := e.GetTriggerBookForSymbol(symbol)
+		state.Books = append(state.Books, snapshotBook{
+			Symbol:    symbol,
+			Seq:       e.bookSeq[symbol],
+			Bids:      orderIDs(book.GetBidOrders()),
+			Asks:      orderIDs(book.GetAskOrders()),
+			BuyStops:  orderIDs(triggers.GetBuyStops()),
+			SellStops: orderIDs(triggers.GetSellStops()),
+			Positions: positions[symbol],
+			Auction:   e.auctions[symbol],
+			Phase:     e.phases[symbol],
+			Bands:     e.bandStates[symbol],
+			Volumes:   volumes[symbol],
+		})
+		if price, ok := e.GetLastTradePrice(symbol); ok {
+			state.LastPrices[symbol] = price
+		}
+	}
+
+	e.historyMutex.RLock()
+	state.Trades = append([]*Trade(nil), e.tradeHistory...)
+	e.historyMutex.RUnlock()
+
+	for _, id := range slices.Sorted(maps.Keys(e.groups)) {
+		group := e.groups[id]
+		state.Groups = append(state.Groups, snapshotGroup{Type: group.Type, Quantity: group.Quantity, Orders: group.Orders})
+	}
+
+	return state
+}
+
+func orderIDs(orders []*Order) []uint64 {
+	ids := make([]uint64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	return ids
+}
+
+// restoreSnapshot loads a snapshot into a freshly created engine, before the sequencer runs
+func (e *Engine) restoreSnapshot(state *snapshotState) error {
+	orders := make(map[uint64]*Order, len(state.Orders))
+	for _, order := range state.Orders {
+		orders[order.ID] = order
+		e.TrackOrder(order)
+	}
+
+	restore := func(symbol string, ids []uint64, add func(*Order) bool) error {
+		for _, id := range ids {
+			order, ok := orders[id]
+			if !ok {
+				return fmt.Errorf("snapshot book %s references untracked order %d", symbol, id)
+			}
+			add(order)
+		}
+		return nil
+	}
+
+	for _, saved := range state.Books {
+		book := e.GetOrderBookForSymbol(saved.Symbol)
+		triggers := e.GetTriggerBookForSymbol(saved.Symbol)
+		if book == nil {
+			return fmt.Errorf("snapshot has orders for unknown symbol %s", saved.Symbol)
+		}
+		e.bookSeq[saved.Symbol] = saved.Seq
+		if saved.Auction {
+			e.auctions[saved.Symbol] = true
+		}
+		if saved.Phase != PhaseContinuous {
+			e.phases[saved.Symbol] = saved.Phase
+		}
+		if saved.Bands != nil {
+			e.bandStates[saved.Symbol] = saved.Bands
+		}
+		for userID, position := range saved.Positions {
+			e.positions[positionKey{userID: userID, symbol: saved.Symbol}] = position
+		}
+		for userID, days := range saved.Volumes {
+			e.volumes[positionKey{userID: userID, symbol: saved.Symbol}] = days
+		}
+		if err := restore(saved.Symbol, saved.Bids, book.AddBidOrder); err != nil {
+			return err
+		}
+		if err := restore(saved.Symbol, saved.Asks, book.AddAskOrder); err != nil {
+			return err
+		}
+		if err := restore(saved.Symbol, saved.BuyStops, triggers.AddStopOrder); err != nil {
+			return err
+		}
+		if err := restore(saved.Symbol, saved.SellStops, triggers.AddStopOrder); err != nil {
+			return err
+		}
+	}
+
+	// Pegs are repriced in arrival order, which their IDs follow
+	for _, order := range state.Orders {
+		if book := e.GetOrderBookForSymbol(order.Symbol); order.Peg != PegNone && book != nil && book.SearchById(order.ID) != nil {
+			e.addPeg(order)
+		}
+	}
+
+	for _, order := range state.Done {
+		e.doneOrders[order.ID] = order
+		e.doneQueue = append(e.doneQueue, order)
+	}
+
+	for _, saved := range state.Groups {
+		group := &orderGroup{Type: saved.Type, Quantity: saved.Quantity}
+		for _, order := range saved.Orders {
+			if tracked, ok := orders[order.ID]; ok {
+				order = tracked
+			} else if done, ok := e.doneOrders[order.ID]; ok {
+				order = done
+			}
+			group.Orders = append(group.Orders, order)
+		}
+		if len(group.Orders) == 0 {
+			return fmt.Errorf("snapshot has an order group without orders")
+		}
+		e.groups[group.Orders[0].GroupID] = group
+	}
+
+	for symbol, price := range state.LastPrices {
+		e.setLastTradePrice(symbol, price)
+	}
+	// Restored trades are already in the trade log
+	e.replaying = true
+	for _, trade := range state.Trades {
+		e.AddTradeToHistory(trade)
+	}
+	e.replaying = false
+
+	if state.NextOrderID > e.nextOrderID {
+		e.nextOrderID = state.NextOrderID
+	}
+	e.nextTradeID = state.NextTradeID
+	e.snapshotSeq = state.Seq
+	return nil
+}
+
+func (e *Engine) runSnapshotWorker(interval time.Duration) {
+	defer e.snapshotWorker.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.WriteSnapshot()
+		case <-e.stopSnapshots:
+			return
+		}
+	}
+}
+
+func snapshotFileName(seq uint64) string {
+	return fmt.Sprintf("%s%020d%s", snapshotPrefix, seq, snapshotSuffix)
+}
+
+func writeSnapshotFile(path string, payload []byte) error {
+	var header [snapshotHeaderSize]byte
+	copy(header[:8], snapshotMagic[:])
+	binary.BigEndian.PutUint32(header[8:12], snapshotVersion)
+	binary.BigEndian.PutUint32(header[12:16], crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint64(header[16:24], uint64(len(payload)))
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	if _, err := file.Write(append(header[:], payload...)); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+	return nil
+}
+
+// readSnapshotFile loads and verifies one snapshot file
+func readSnapshotFile(path string) (*snapshotState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < snapshotHeaderSize || !bytes.Equal(data[:8], snapshotMagic[:]) {
+		return nil, ErrSnapshotCorrupt
+	}
+	if version := binary.BigEndian.Uint32(data[8:12]); version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	payload := data[snapshotHeaderSize:]
+	if uint64(len(payload)) != binary.BigEndian.Uint64(data[16:24]) ||
+		crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[12:16]) {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	var state snapshotState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, ErrSnapshotCorrupt
+	}
+	return &state, nil
+}
+
+// listSnapshots returns snapshot paths in a directory, newest first
+func listSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	type snapshotFile struct {
+		path string
+		seq  uint64
+	}
+	var files []snapshotFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, snapshotFile{path: filepath.Join(dir, name), seq: seq})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].seq > files[j].seq })
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+	return paths, nil
+}
+
+// loadLatestSnapshot returns the newest snapshot that verifies, or nil if there is none
+func loadLatestSnapshot(dir string) (*snapshotState, error) {
+	paths, err := listSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if state, err := readSnapshotFile(path); err == nil {
+			return state, nil
+		}
+	}
+	return nil, nil
+}
+
+// pruneSnapshots removes all but the newest retain snapshots
+func pruneSnapshots(dir string, retain int) error {
+	paths, err := listSnapshots(dir)
+	if err != nil || retain < 1 || len(paths) <= retain {
+		return err
+	}
+	for _, path := range paths[retain:] {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old snapshot: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package matching
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func bidQueue(engine *matching.Engine, price matching.Price) []uint64 {
+	var ids []uint64
+	for _, order := range engine.GetOrderBook().GetBidsAtPrice(price) {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
+
+// TestAmendReduceKeepsPriority tests that reducing quantity leaves the order at the front
+func TestAmendReduceKeepsPriority(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	first := placeLimit(engine, matching.Buy, 9900, 10)
+	second := placeLimit(engine, matching.Buy, 9900, 10)
+
+	if _, err := engine.AmendOrder(first, 0, 4); err != nil {
+		t.Fatalf("AmendOrder() error = %v", err)
+	}
+
+	if got := bidQueue(engine, 9900); !reflect.DeepEqual(got, []uint64{first, second}) {
+		t.Errorf("Queue %v, want %v", got, []uint64{first, second})
+	}
+	order := engine.GetOrder(first)
+	if order.Size != 4 || order.OriginalSize != 4 {
+		t.Errorf("Amended order has size %d of %d, want 4 of 4", order.Size, order.OriginalSize)
+	}
+	if level := engine.GetOrderBook().GetLevel(matching.Buy, 9900); level.Quantity != 14 {
+		t.Errorf("Level quantity %d, want 14", level.Quantity)
+	}
+}
+
+// TestAmendLosesPriority tests that a size increase or price change moves the order to the back
+func TestAmendLosesPriority(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	first := placeLimit(engine, matching.Buy, 9900, 10)
+	second := placeLimit(engine, matching.Buy, 9900, 10)
+
+	if _, err := engine.AmendOrder(first, 0, 15); err != nil {
+		t.Fatalf("AmendOrder() error = %v", err)
+	}
+	if got := bidQueue(engine, 9900); !reflect.DeepEqual(got, []uint64{second, first}) {
+		t.Errorf("After size increase queue %v, want %v", got, []uint64{second, first})
+	}
+
+	third := placeLimit(engine, matching.Buy, 9800, 10)
+	if _, err := engine.AmendOrder(second, 9800, 0); err != nil {
+		t.Fatalf("AmendOrder() error = %v", err)
+	}
+	if got := bidQueue(engine, 9800); !reflect.DeepEqual(got, []uint64{third, second}) {
+		t.Errorf("After price change queue %v, want %v", got, []uint64{third, second})
+	}
+	if got := bidQueue(engine, 9900); !reflect.DeepEqual(got, []uint64{first}) {
+		t.Errorf("Old level queue %v, want %v", got, []uint64{first})
+	}
+}
+
+// TestAmendCrossingMatches tests that an amend through the spread trades before resting
+func TestAmendCrossingMatches(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	ask := placeLimit(engine, matching.Sell, 10100, 4)
+	bid := placeLimit(engine, matching.Buy, 9900, 10)
+
+	trades, err := engine.AmendOrder(bid, 10100, 0)
+	if err != nil {
+		t.Fatalf("AmendOrder() error = %v", err)
+	}
+	if len(trades) != 1 || trades[0].Price != 10100 || trades[0].Size != 4 || trades[0].BuyOrderID != bid {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+
+	checkOrderStatus(t, engine, ask, matching.StatusFilled, 4)
+	order := checkOrderStatus(t, engine, bid, matching.StatusPartiallyFilled, 4)
+	if order.Size != 6 {
+		t.Errorf("Remaining size %d, want 6", order.Size)
+	}
+	if got := bidQueue(engine, 10100); !reflect.DeepEqual(got, []uint64{bid}) {
+		t.Errorf("Remainder not resting at the new price, queue %v", got)
+	}
+}
+
+// TestAmendAfterPartialFill tests that quantity is the total including what has filled
+func TestAmendAfterPartialFill(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	bid := placeLimit(engine, matching.Buy, 10000, 10)
+	placeLimit(engine, matching.Sell, 10000, 6)
+
+	if _, err := engine.AmendOrder(bid, 0, 6); !errors.Is(err, matching.ErrAmendQuantity) {
+		t.Errorf("Amend to the filled quantity error = %v, want %v", err, matching.ErrAmendQuantity)
+	}
+
+	if _, err := engine.AmendOrder(bid, 0, 8); err != nil {
+		t.Fatalf("AmendOrder() error = %v", err)
+	}
+	order := checkOrderStatus(t, engine, bid, matching.StatusPartiallyFilled, 6)
+	if order.Size != 2 || order.OriginalSize != 8 {
+		t.Errorf("Amended order has size %d of %d, want 2 of 8", order.Size, order.OriginalSize)
+	}
+}
+
+// TestAmendRejections tests amends that leave the order unchanged
+func TestAmendRejections(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	bid := placeLimit(engine, matching.Buy, 9900, 10)
+	cancelled := placeLimit(engine, matching.Buy, 9800, 10)
+	engine.CancelOrder(cancelled)
+
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.StopMarketOrder, matching.Sell, 0, 5)
+	stop.StopPrice = 9000
+	engine.PlaceOrder(stop)
+
+	tests := []struct {
+		name     string
+		orderID  uint64
+		price    matching.Price
+		quantity int
+		want     error
+	}{
+		{"UnknownOrder", 999, 9900, 0, matching.ErrOrderNotWorking},
+		{"CancelledOrder", cancelled, 9900, 0, matching.ErrOrderNotWorking},
+		{"StopMarketPrice", stop.ID, 9100, 0, matching.ErrAmendPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := engine.AmendOrder(tt.orderID, tt.price, tt.quantity); !errors.Is(err, tt.want) {
+				t.Errorf("AmendOrder() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if order := engine.GetOrder(bid); order.Price != 9900 || order.Size != 10 {
+		t.Errorf("Rejected amend changed the order to %d x %d", order.Price, order.Size)
+	}
+}
+
+// TestAmendReplay tests that amends are journaled and replayed with the same queue order
+func TestAmendReplay(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+	first := placeLimit(engine, matching.Buy, 9900, 10)
+	second := placeLimit(engine, matching.Buy, 9900, 10)
+	engine.AmendOrder(first, 0, 12)
+	engine.AmendOrder(second, 0, 5)
+	wantBook, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	engine.Close()
+
+	recovered := newJournaledEngine(t, dir)
+	defer recovered.Close()
+
+	if got := bidQueue(recovered, 9900); !reflect.DeepEqual(got, []uint64{second, first}) {
+		t.Errorf("Recovered queue %v, want %v", got, []uint64{second, first})
+	}
+	if gotBook, _ := recovered.GetBookSnapshot(matching.DefaultSymbol, 0); !reflect.DeepEqual(gotBook, wantBook) {
+		t.Errorf("Recovered book %+v, want %+v", gotBook, wantBook)
+	}
+	if order := recovered.GetOrder(first); order.Size != 12 || order.OriginalSize != 12 {
+		t.Errorf("Recovered order has size %d of %d, want 12 of 12", order.Size, order.OriginalSize)
+	}
+}
@@ -0,0 +1,252 @@
+package matching
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func startAuction(t *testing.T, engine *matching.Engine) {
+	t.Helper()
+	if err := engine.StartAuction(matching.DefaultSymbol); err != nil {
+		t.Fatalf("StartAuction() error = %v", err)
+	}
+}
+
+func checkAuction(t *testing.T, engine *matching.Engine, want matching.AuctionInfo) {
+	t.Helper()
+	want.Symbol = matching.DefaultSymbol
+	if got, _ := engine.GetAuction(matching.DefaultSymbol); got != want {
+		t.Errorf("Auction %+v, want %+v", got, want)
+	}
+}
+
+// TestAuctionCollectsOrders tests that orders rest without matching and that orders which must
+// execute on arrival are rejected
+func TestAuctionCollectsOrders(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	startAuction(t, engine)
+
+	placeLimit(engine, matching.Sell, 10000, 10)
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10100, 10)); len(trades) != 0 {
+		t.Fatalf("Crossing order traded during the auction: %+v", trades)
+	}
+	bestBid, _ := engine.GetOrderBook().GetBestBid()
+	bestAsk, _ := engine.GetOrderBook().GetBestAsk()
+	if bestBid != 10100 || bestAsk != 10000 {
+		t.Errorf("Book is %d / %d, want a crossed 10100 / 10000", bestBid, bestAsk)
+	}
+
+	market := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.MarketOrder, matching.Buy, 0, 5)
+	ioc := newUserLimit(engine, "user2", matching.Buy, 10100, 5)
+	ioc.TimeInForce = matching.ImmediateOrCancel
+	minQty := newUserLimit(engine, "user2", matching.Buy, 10100, 5)
+	minQty.MinQuantity = 2
+	for _, order := range []*matching.Order{market, ioc, minQty} {
+		_, err := engine.SubmitOrder(order)
+		checkRejected(t, err, matching.RejectAuction)
+	}
+
+	// Stops wait in the trigger book, even when already through the last price
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.StopMarketOrder, matching.Buy, 0, 5)
+	stop.StopPrice = 10000
+	if _, err := engine.SubmitOrder(stop); err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+	checkOrderStatus(t, engine, stop.ID, matching.StatusNew, 0)
+}
+
+// TestAuctionUncross tests the equilibrium price and that every crossing order executes at it
+func TestAuctionUncross(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	startAuction(t, engine)
+
+	bids := []uint64{
+		placeLimit(engine, matching.Buy, 10200, 10),
+		placeLimit(engine, matching.Buy, 10100, 20),
+		placeLimit(engine, matching.Buy, 10000, 30),
+	}
+	asks := []uint64{
+		placeLimit(engine, matching.Sell, 9900, 15),
+		placeLimit(engine, matching.Sell, 10000, 15),
+		placeLimit(engine, matching.Sell, 10100, 20),
+	}
+
+	// 10000 and 10100 both execute 30; 10100 leaves the smaller imbalance
+	want := matching.AuctionInfo{Running: true, Price: 10100, Volume: 30, Imbalance: -20}
+	checkAuction(t, engine, want)
+
+	info, trades, err := engine.Uncross(matching.DefaultSymbol)
+	if err != nil {
+		t.Fatalf("Uncross() error = %v", err)
+	}
+	want.Symbol, want.Running = matching.DefaultSymbol, false
+	if info != want {
+		t.Errorf("Uncross() = %+v, want %+v", info, want)
+	}
+
+	total := 0
+	for _, trade := range trades {
+		if trade.Price != 10100 {
+			t.Errorf("Trade at %d, want 10100", trade.Price)
+		}
+		total += trade.Size
+	}
+	if total != 30 {
+		t.Errorf("Uncross traded %d, want 30", total)
+	}
+
+	checkOrderStatus(t, engine, bids[0], matching.StatusFilled, 10)
+	checkOrderStatus(t, engine, bids[1], matching.StatusFilled, 20)
+	checkOrderStatus(t, engine, bids[2], matching.StatusNew, 0)
+	checkOrderStatus(t, engine, asks[2], matching.StatusNew, 0)
+	if price, _ := engine.GetLastTradePrice(matching.DefaultSymbol); price != 10100 {
+		t.Errorf("Last trade price %d, want 10100", price)
+	}
+
+	// Continuous matching resumes
+	checkAuction(t, engine, matching.AuctionInfo{})
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10100, 5)); len(trades) != 1 {
+		t.Errorf("Order after the uncross produced %d trades, want 1", len(trades))
+	}
+}
+
+// TestAuctionReferencePriceTieBreak tests that equal volume and imbalance fall back to the price
+// nearest the last trade, then to the lower price
+func TestAuctionReferencePriceTieBreak(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	startAuction(t, engine)
+	placeLimit(engine, matching.Buy, 10100, 10)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	checkAuction(t, engine, matching.AuctionInfo{Running: true, Price: 10000, Volume: 10})
+
+	engine = newRetainingEngine(t, 0)
+	placeLimit(engine, matching.Sell, 10200, 1)
+	placeLimit(engine, matching.Buy, 10200, 1) // Last trade at 10200
+	startAuction(t, engine)
+	placeLimit(engine, matching.Buy, 10100, 10)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	checkAuction(t, engine, matching.AuctionInfo{Running: true, Price: 10100, Volume: 10})
+}
+
+// TestAuctionIcebergAndStops tests that iceberg reserves take part in the uncross and that
+// stops triggered by it fire once continuous trading resumes
+func TestAuctionIcebergAndStops(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	startAuction(t, engine)
+
+	placeIceberg(engine, matching.Sell, 10000, 50, 5)
+	placeLimit(engine, matching.Sell, 10200, 10)
+	placeLimit(engine, matching.Buy, 10000, 30)
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.StopMarketOrder, matching.Buy, 0, 5)
+	stop.StopPrice = 10000
+	engine.PlaceOrder(stop)
+	checkAuction(t, engine, matching.AuctionInfo{Running: true, Price: 10000, Volume: 30, Imbalance: -20})
+
+	_, trades, err := engine.Uncross(matching.DefaultSymbol)
+	if err != nil {
+		t.Fatalf("Uncross() error = %v", err)
+	}
+	total := 0
+	for _, trade := range trades {
+		total += trade.Size
+	}
+	if total != 35 {
+		t.Errorf("Uncross and stop traded %d, want 35", total)
+	}
+	checkOrderStatus(t, engine, stop.ID, matching.StatusFilled, 5)
+}
+
+// TestAuctionSelfTrade tests self-trade prevention between a user's orders at the uncross
+func TestAuctionSelfTrade(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	startAuction(t, engine)
+
+	resting, _ := placeSelfTrade(engine, matching.Sell, 10000, 10, matching.SelfTradeAllow)
+	incoming, _ := placeSelfTrade(engine, matching.Buy, 10000, 10, matching.SelfTradeCancelOldest)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 4))
+
+	_, trades, _ := engine.Uncross(matching.DefaultSymbol)
+	if len(trades) != 1 || trades[0].Size != 4 || trades[0].BuyOrderID != incoming.ID {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+	checkOrderStatus(t, engine, resting.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, resting.ID, matching.CancelSelfTrade)
+}
+
+// TestAuctionEvents tests that the indicative uncross is published while the auction runs
+func TestAuctionEvents(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	batches := collectEvents(engine)
+
+	startAuction(t, engine)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	placeLimit(engine, matching.Buy, 10100, 4)
+	engine.Uncross(matching.DefaultSymbol)
+
+	var auctions []matching.AuctionInfo
+	for _, batch := range *batches {
+		for _, event := range eventsOfType(batch, matching.AuctionEvent) {
+			auctions = append(auctions, *event.Auction)
+		}
+	}
+	want := []matching.AuctionInfo{
+		{Symbol: matching.DefaultSymbol, Running: true},
+		{Symbol: matching.DefaultSymbol, Running: true},
+		{Symbol: matching.DefaultSymbol, Running: true, Price: 10000, Volume: 4, Imbalance: -6},
+		{Symbol: matching.DefaultSymbol},
+	}
+	if !reflect.DeepEqual(auctions, want) {
+		t.Errorf("Auction events %+v, want %+v", auctions, want)
+	}
+}
+
+// TestAuctionErrors tests auction commands that do not apply
+func TestAuctionErrors(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+
+	if _, _, err := engine.Uncross(matching.DefaultSymbol); !errors.Is(err, matching.ErrNoAuction) {
+		t.Errorf("Uncross() without an auction error = %v", err)
+	}
+	startAuction(t, engine)
+	if err := engine.StartAuction(matching.DefaultSymbol); !errors.Is(err, matching.ErrAuctionRunning) {
+		t.Errorf("StartAuction() twice error = %v", err)
+	}
+	if err := engine.StartAuction("UNKNOWN"); !errors.Is(err, matching.ErrUnknownSymbol) {
+		t.Errorf("StartAuction() for an unknown symbol error = %v", err)
+	}
+}
+
+// TestAuctionRecovered tests that a running auction survives a snapshot and journal replay
+func TestAuctionRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	startAuction(t, engine)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	placeLimit(engine, matching.Buy, 10100, 4)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	checkAuction(t, recovered, matching.AuctionInfo{Running: true, Price: 10000, Volume: 4, Imbalance: -6})
+	if _, trades, _ := recovered.Uncross(matching.DefaultSymbol); len(trades) != 1 {
+		t.Fatalf("Uncross() after recovery produced %d trades, want 1", len(trades))
+	}
+	wantBook, _ := recovered.GetBookSnapshot(matching.DefaultSymbol, 0)
+	recovered.Close()
+
+	// The uncross replays from the journal
+	replayed := newSnapshottingEngine(t, dir)
+	defer replayed.Close()
+	if replayed.InAuction(matching.DefaultSymbol) {
+		t.Error("Auction still running after replaying its uncross")
+	}
+	if gotBook, _ := replayed.GetBookSnapshot(matching.DefaultSymbol, 0); !reflect.DeepEqual(gotBook, wantBook) {
+		t.Errorf("Replayed book %+v, want %+v", gotBook, wantBook)
+	}
+}
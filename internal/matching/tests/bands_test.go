@@ -0,0 +1,200 @@
+package matching
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func newBandedEngine(t *testing.T, dir string, bands matching.PriceBands) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+		cfg.SnapshotDir = filepath.Join(dir, "snapshots")
+		cfg.OrderRetention = time.Hour
+		cfg.PriceBands = map[string]matching.PriceBands{matching.DefaultSymbol: bands}
+	})
+}
+
+// trade has user2 buy one lot from user1 at a price
+func trade(t *testing.T, engine *matching.Engine, price matching.Price) {
+	t.Helper()
+	placeLimit(engine, matching.Sell, price, 1)
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, price, 1)); len(trades) != 1 {
+		t.Fatalf("Trade at %d produced %d trades, want 1", price, len(trades))
+	}
+}
+
+// TestStaticBand tests that limit prices outside the band around the reference are rejected on
+// arrival and on amend
+func TestStaticBand(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{StaticBps: 500})
+
+	// Without a reference there is no band yet
+	if _, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Buy, 5000, 1)); err != nil {
+		t.Fatalf("SubmitOrder() before the first trade error = %v", err)
+	}
+	trade(t, engine, 10000)
+	if info, _ := engine.GetPriceBands(matching.DefaultSymbol); info.Reference != 10000 ||
+		info.StaticLow != 9500 || info.StaticHigh != 10500 {
+		t.Errorf("Price bands %+v, want 9500 - 10500 around 10000", info)
+	}
+
+	high := newUserLimit(engine, "user3", matching.Buy, 10600, 1)
+	_, err := engine.SubmitOrder(high)
+	checkRejected(t, err, matching.RejectPriceBand)
+	checkOrderStatus(t, engine, high.ID, matching.StatusRejected, 0)
+	_, err = engine.SubmitOrder(newUserLimit(engine, "user3", matching.Sell, 9400, 1))
+	checkRejected(t, err, matching.RejectPriceBand)
+
+	stopLimit := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopLimitOrder, matching.Buy, 11000, 1)
+	stopLimit.StopPrice = 10200
+	_, err = engine.SubmitOrder(stopLimit)
+	checkRejected(t, err, matching.RejectPriceBand)
+
+	low := newUserLimit(engine, "user3", matching.Buy, 9500, 1)
+	if _, err := engine.SubmitOrder(low); err != nil {
+		t.Fatalf("SubmitOrder() at the band edge error = %v", err)
+	}
+	_, err = engine.AmendOrder(low.ID, 9400, 0)
+	checkRejected(t, err, matching.RejectPriceBand)
+	checkOrderStatus(t, engine, low.ID, matching.StatusNew, 0)
+}
+
+// TestDynamicBand tests that orders cannot trade through the band around the last trade
+func TestDynamicBand(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{DynamicBps: 100})
+	trade(t, engine, 10000)
+
+	// Only the side an order would trade through is bounded
+	_, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Buy, 10200, 1))
+	checkRejected(t, err, matching.RejectPriceBand)
+	_, err = engine.SubmitOrder(newUserLimit(engine, "user3", matching.Sell, 9800, 1))
+	checkRejected(t, err, matching.RejectPriceBand)
+	if _, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Buy, 9000, 1)); err != nil {
+		t.Errorf("SubmitOrder() for a passive bid error = %v", err)
+	}
+
+	// A market order stops at the band and its remainder is cancelled for it
+	placeLimit(engine, matching.Sell, 10050, 5)
+	placeLimit(engine, matching.Sell, 10200, 5)
+	market := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.MarketOrder, matching.Buy, 0, 8)
+	if trades := engine.PlaceOrder(market); len(trades) != 1 || trades[0].Size != 5 {
+		t.Fatalf("Market order trades %+v, want 5 at 10050", trades)
+	}
+	checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, 5)
+	checkCancelReason(t, engine, market.ID, matching.CancelPriceBand)
+
+	// Orders collecting for an auction are not bounded by the last trade
+	startAuction(t, engine)
+	if _, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Buy, 10200, 1)); err != nil {
+		t.Errorf("SubmitOrder() during an auction error = %v", err)
+	}
+}
+
+// TestTriggeredStopOutsideBand tests that a stop-limit priced beyond the band when it triggers is
+// cancelled instead of trading
+func TestTriggeredStopOutsideBand(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{DynamicBps: 100})
+	trade(t, engine, 10000)
+
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopLimitOrder, matching.Buy, 10300, 5)
+	stop.StopPrice = 10050
+	if _, err := engine.SubmitOrder(stop); err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+	placeLimit(engine, matching.Sell, 10300, 5)
+	trade(t, engine, 10050)
+
+	checkOrderStatus(t, engine, stop.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, stop.ID, matching.CancelPriceBand)
+}
+
+// TestVolatilityHalt tests that a fast move halts the symbol, that the halt stops a stop cascade,
+// and that the symbol reopens through a reopening auction
+func TestVolatilityHalt(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{
+		StaticBps:     2000,
+		HaltMoveBps:   500,
+		HaltWindow:    time.Minute,
+		HaltDuration:  time.Minute,
+		ReopenAuction: time.Minute,
+	})
+	trade(t, engine, 10000)
+
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopMarketOrder, matching.Buy, 0, 1)
+	stop.StopPrice = 10500
+	engine.PlaceOrder(stop)
+	placeLimit(engine, matching.Sell, 10700, 5)
+
+	trade(t, engine, 10600)
+	checkPhase(t, engine, matching.PhaseHalted)
+	checkOrderStatus(t, engine, stop.ID, matching.StatusNew, 0)
+	if triggers := engine.GetTriggerBookForSymbol(matching.DefaultSymbol); triggers.Len() != 1 {
+		t.Errorf("Trigger book holds %d stops, want 1", triggers.Len())
+	}
+	_, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Buy, 10600, 1))
+	checkRejected(t, err, matching.RejectPhase)
+
+	info, _ := engine.GetPriceBands(matching.DefaultSymbol)
+	if info.ReopenAt.IsZero() {
+		t.Fatal("Volatility halt has no reopen time")
+	}
+
+	// Nothing moves before the halt is due
+	engine.ReopenHalted(info.ReopenAt.Add(-time.Second))
+	checkPhase(t, engine, matching.PhaseHalted)
+
+	engine.ReopenHalted(info.ReopenAt)
+	checkPhase(t, engine, matching.PhaseReopeningAuction)
+	placeLimit(engine, matching.Buy, 10700, 2)
+
+	info, _ = engine.GetPriceBands(matching.DefaultSymbol)
+	trades := engine.ReopenHalted(info.ReopenAt)
+	checkPhase(t, engine, matching.PhaseContinuous)
+	if len(trades) == 0 || trades[0].Price != 10700 {
+		t.Fatalf("Reopening uncross trades %+v, want trades at 10700", trades)
+	}
+	if info, _ := engine.GetPriceBands(matching.DefaultSymbol); info.Reference != 10700 || !info.ReopenAt.IsZero() {
+		t.Errorf("Price bands after reopening %+v, want reference 10700 and no reopen time", info)
+	}
+}
+
+// TestHaltWindow tests that moves spread over more than the window do not halt the symbol
+func TestHaltWindow(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{HaltMoveBps: 500, HaltWindow: time.Nanosecond})
+	trade(t, engine, 10000)
+	trade(t, engine, 10600)
+	checkPhase(t, engine, matching.PhaseContinuous)
+}
+
+// TestManualHaltWaits tests that a halt set by hand is not reopened by ReopenHalted
+func TestManualHaltWaits(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{HaltMoveBps: 500, HaltWindow: time.Minute, HaltDuration: time.Minute})
+	setPhase(t, engine, matching.PhaseHalted)
+	engine.ReopenHalted(time.Now().Add(time.Hour))
+	checkPhase(t, engine, matching.PhaseHalted)
+}
+
+// TestBandsRecovered tests that the reference price and a volatility halt survive a snapshot and
+// journal replay
+func TestBandsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	bands := matching.PriceBands{StaticBps: 2000, HaltMoveBps: 500, HaltWindow: time.Minute, HaltDuration: time.Minute}
+	engine := newBandedEngine(t, dir, bands)
+	trade(t, engine, 10000)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	trade(t, engine, 10600)
+	want, _ := engine.GetPriceBands(matching.DefaultSymbol)
+	engine.Close()
+
+	recovered := newBandedEngine(t, dir, bands)
+	checkPhase(t, recovered, matching.PhaseHalted)
+	got, _ := recovered.GetPriceBands(matching.DefaultSymbol)
+	if got.Reference != want.Reference || !got.ReopenAt.Equal(want.ReopenAt) {
+		t.Errorf("Recovered price bands %+v, want %+v", got, want)
+	}
+}
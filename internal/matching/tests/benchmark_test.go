@@ -21,7 +21,7 @@ func BenchmarkOrderCreation(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+		matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 	}
 
 	// KPI: Orders created per second
@@ -31,7 +31,7 @@ func BenchmarkOrderCreation(b *testing.B) {
 
 // BenchmarkOrderValidation benchmarks order validation speed
 func BenchmarkOrderValidation(b *testing.B) {
-	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 	b.ReportAllocs()
 	b.ResetTimer()
 
@@ -48,7 +48,7 @@ func BenchmarkAddBidOrder(b *testing.B) {
 	ob := matching.NewOrderBook()
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0+float64(i%100)*0.01, 10)
+		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 10000+matching.Price(i%100), 10)
 	}
 
 	b.ReportAllocs()
@@ -67,7 +67,7 @@ func BenchmarkAddAskOrder(b *testing.B) {
 	ob := matching.NewOrderBook()
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 101.0+float64(i%100)*0.01, 10)
+		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 10100+matching.Price(i%100), 10)
 	}
 
 	b.ReportAllocs()
@@ -87,7 +87,7 @@ func BenchmarkGetBestBid(b *testing.B) {
 
 	// Pre-populate with orders at different prices
 	for i := 0; i < 100; i++ {
-		price := 100.0 - float64(i)*0.01
+		price := 10000 - matching.Price(i)
 		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, price, 10))
 	}
 
@@ -108,7 +108,7 @@ func BenchmarkGetBestAsk(b *testing.B) {
 
 	// Pre-populate with orders at different prices
 	for i := 0; i < 100; i++ {
-		price := 101.0 + float64(i)*0.01
+		price := 10100 + matching.Price(i)
 		ob.AddAskOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, price, 10))
 	}
 
@@ -129,7 +129,7 @@ func BenchmarkSearchById(b *testing.B) {
 
 	// Pre-populate orderbook
 	for i := 0; i < 1000; i++ {
-		price := 100.0 + float64(i%100)*0.01
+		price := 10000 + matching.Price(i%100)
 		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, price, 10))
 	}
 
@@ -149,7 +149,7 @@ func BenchmarkDeleteBidOrder(b *testing.B) {
 	// Pre-create orders
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+		orders[i] = matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 	}
 
 	b.ReportAllocs()
@@ -169,7 +169,7 @@ func BenchmarkDeleteBidOrder(b *testing.B) {
 func BenchmarkMarketOrderExecution(b *testing.B) {
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+		orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	}
 
 	b.ReportAllocs()
@@ -179,7 +179,7 @@ func BenchmarkMarketOrderExecution(b *testing.B) {
 		engine := matching.NewEngine()
 		// Add liquidity
 		for j := 0; j < 10; j++ {
-			engine.PlaceOrder(matching.NewOrder(uint64(j), "user_test", matching.LimitOrder, matching.Sell, 101.0+float64(j)*0.01, 10))
+			engine.PlaceOrder(matching.NewOrder(uint64(j), "user_test", matching.LimitOrder, matching.Sell, 10100+matching.Price(j), 10))
 		}
 		// Execute market order
 		engine.PlaceOrder(orders[i])
@@ -193,7 +193,7 @@ func BenchmarkMarketOrderExecution(b *testing.B) {
 func BenchmarkLimitOrderExecution(b *testing.B) {
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Buy, 101.0, 10)
+		orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Buy, 101, 10)
 	}
 
 	b.ReportAllocs()
@@ -202,7 +202,7 @@ func BenchmarkLimitOrderExecution(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		engine := matching.NewEngine()
 		// Add liquidity
-		engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+		engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 		// Execute limit order
 		engine.PlaceOrder(orders[i])
 	}
@@ -218,7 +218,7 @@ func BenchmarkCancelOrder(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		engine := matching.NewEngine()
-		order := matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+		order := matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 		engine.PlaceOrder(order)
 		engine.CancelOrder(uint64(i))
 	}
@@ -247,14 +247,19 @@ func BenchmarkOrderBookDepth_10000(b *testing.B) {
 	benchmarkOrderBookDepth(b, 10000)
 }
 
+// BenchmarkOrderBookDepth_100000 benchmarks with 100000 price levels
+func BenchmarkOrderBookDepth_100000(b *testing.B) {
+	benchmarkOrderBookDepth(b, 100000)
+}
+
 // benchmarkOrderBookDepth is a helper for depth benchmarks
 func benchmarkOrderBookDepth(b *testing.B, depth int) {
 	engine := matching.NewEngine()
 
 	// Pre-populate orderbook with depth price levels
 	for i := 0; i < depth; i++ {
-		bidPrice := 100.0 - float64(i)*0.01
-		askPrice := 101.0 + float64(i)*0.01
+		bidPrice := 10000 - matching.Price(i)
+		askPrice := 10100 + matching.Price(i)
 		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, bidPrice, 10))
 		engine.PlaceOrder(matching.NewOrder(uint64(i+depth), "user_test", matching.LimitOrder, matching.Sell, askPrice, 10))
 	}
@@ -263,9 +268,9 @@ func benchmarkOrderBookDepth(b *testing.B, depth int) {
 	for i := 0; i < b.N; i++ {
 		// Alternate between market buys and sells
 		if i%2 == 0 {
-			orders[i] = matching.NewOrder(uint64(i+depth*2), "user_test", matching.MarketOrder, matching.Buy, 0.0, 5)
+			orders[i] = matching.NewOrder(uint64(i+depth*2), "user_test", matching.MarketOrder, matching.Buy, 0, 5)
 		} else {
-			orders[i] = matching.NewOrder(uint64(i+depth*2), "user_test", matching.MarketOrder, matching.Sell, 0.0, 5)
+			orders[i] = matching.NewOrder(uint64(i+depth*2), "user_test", matching.MarketOrder, matching.Sell, 0, 5)
 		}
 	}
 
@@ -279,7 +284,7 @@ func benchmarkOrderBookDepth(b *testing.B, depth int) {
 	ordersPerSec := float64(b.N) / b.Elapsed().Seconds()
 	b.ReportMetric(ordersPerSec, "orders/sec")
 	avgLatency := b.Elapsed().Nanoseconds() / int64(b.N)
-	b.ReportMetric(float64(avgLatency)/1000.0, "µs/op")
+	b.ReportMetric(float64(avgLatency)/1000, "µs/op")
 }
 
 // BenchmarkHighFrequencyTrading simulates HFT scenario
@@ -288,8 +293,8 @@ func BenchmarkHighFrequencyTrading(b *testing.B) {
 
 	// Initialize book with liquidity
 	for i := 0; i < 50; i++ {
-		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 99.0+float64(i)*0.01, 100))
-		engine.PlaceOrder(matching.NewOrder(uint64(i+50), "user_test", matching.LimitOrder, matching.Sell, 101.0+float64(i)*0.01, 100))
+		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 9900+matching.Price(i), 100))
+		engine.PlaceOrder(matching.NewOrder(uint64(i+50), "user_test", matching.LimitOrder, matching.Sell, 10100+matching.Price(i), 100))
 	}
 
 	rand.Seed(time.Now().UnixNano())
@@ -297,11 +302,11 @@ func BenchmarkHighFrequencyTrading(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		orderType := matching.LimitOrder
 		side := matching.Buy
-		price := 100.0
+		price := matching.Price(100)
 
 		if rand.Float64() > 0.5 {
 			side = matching.Sell
-			price = 101.0
+			price = 101
 		}
 
 		orders[i] = matching.NewOrder(uint64(i+1000), "user_test", orderType, side, price, 10)
@@ -317,7 +322,7 @@ func BenchmarkHighFrequencyTrading(b *testing.B) {
 	ordersPerSec := float64(b.N) / b.Elapsed().Seconds()
 	b.ReportMetric(ordersPerSec, "orders/sec")
 	avgLatency := b.Elapsed().Nanoseconds() / int64(b.N)
-	b.ReportMetric(float64(avgLatency)/1000.0, "µs/op")
+	b.ReportMetric(float64(avgLatency)/1000, "µs/op")
 }
 
 // BenchmarkMixedOperations benchmarks realistic mix of operations
@@ -326,8 +331,8 @@ func BenchmarkMixedOperations(b *testing.B) {
 
 	// Initialize with some liquidity
 	for i := 0; i < 20; i++ {
-		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 99.0+float64(i)*0.01, 50))
-		engine.PlaceOrder(matching.NewOrder(uint64(i+20), "user_test", matching.LimitOrder, matching.Sell, 101.0+float64(i)*0.01, 50))
+		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 9900+matching.Price(i), 50))
+		engine.PlaceOrder(matching.NewOrder(uint64(i+20), "user_test", matching.LimitOrder, matching.Sell, 10100+matching.Price(i), 50))
 	}
 
 	rand.Seed(time.Now().UnixNano())
@@ -338,10 +343,10 @@ func BenchmarkMixedOperations(b *testing.B) {
 		switch {
 		case r < 0.4: // 40% limit orders
 			side := matching.Buy
-			price := 99.5
+			price := matching.Price(9950)
 			if rand.Float64() > 0.5 {
 				side = matching.Sell
-				price = 101.5
+				price = 10150
 			}
 			order := matching.NewOrder(uint64(i+1000), "user_test", matching.LimitOrder, side, price, 10)
 			operations[i] = func() { engine.PlaceOrder(order) }
@@ -351,7 +356,7 @@ func BenchmarkMixedOperations(b *testing.B) {
 			if rand.Float64() > 0.5 {
 				side = matching.Sell
 			}
-			order := matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, side, 0.0, 10)
+			order := matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, side, 0, 10)
 			operations[i] = func() { engine.PlaceOrder(order) }
 
 		default: // 30% cancellations
@@ -370,7 +375,7 @@ func BenchmarkMixedOperations(b *testing.B) {
 	opsPerSec := float64(b.N) / b.Elapsed().Seconds()
 	b.ReportMetric(opsPerSec, "ops/sec")
 	avgLatency := b.Elapsed().Nanoseconds() / int64(b.N)
-	b.ReportMetric(float64(avgLatency)/1000.0, "µs/op")
+	b.ReportMetric(float64(avgLatency)/1000, "µs/op")
 }
 
 // BenchmarkWorstCaseSearch benchmarks search in worst case (order at end)
@@ -379,7 +384,7 @@ func BenchmarkWorstCaseSearch(b *testing.B) {
 
 	// Add many orders at same price
 	for i := 0; i < 1000; i++ {
-		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
+		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10))
 	}
 
 	b.ReportAllocs()
@@ -400,7 +405,7 @@ func BenchmarkBestCaseSearch(b *testing.B) {
 
 	// Add many orders at different prices
 	for i := 0; i < 1000; i++ {
-		price := 100.0 + float64(i)*0.01
+		price := 10000 + matching.Price(i)
 		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, price, 10))
 	}
 
@@ -425,10 +430,10 @@ func BenchmarkFullExecution(b *testing.B) {
 		engine := matching.NewEngine()
 
 		// Add liquidity
-		engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 100))
+		engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 100))
 
 		// Execute market order
-		engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.MarketOrder, matching.Buy, 0.0, 50))
+		engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.MarketOrder, matching.Buy, 0, 50))
 
 		// Cancel remaining
 		engine.CancelOrder(1)
@@ -444,13 +449,13 @@ func BenchmarkLargeOrderPartialFill(b *testing.B) {
 
 	// Add many small orders
 	for i := 0; i < 100; i++ {
-		price := 101.0 + float64(i)*0.01
+		price := 10100 + matching.Price(i)
 		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, price, 10))
 	}
 
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, matching.Buy, 0.0, 500)
+		orders[i] = matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, matching.Buy, 0, 500)
 	}
 
 	b.ReportAllocs()
@@ -460,7 +465,7 @@ func BenchmarkLargeOrderPartialFill(b *testing.B) {
 		engine.PlaceOrder(orders[i])
 		// Replenish liquidity
 		for j := 0; j < 100; j++ {
-			price := 101.0 + float64(j)*0.01
+			price := 10100 + matching.Price(j)
 			engine.PlaceOrder(matching.NewOrder(uint64(i*100+j+10000), "user_test", matching.LimitOrder, matching.Sell, price, 10))
 		}
 	}
@@ -475,12 +480,12 @@ func BenchmarkPriceTimePriority(b *testing.B) {
 
 	// Add many orders at same price
 	for i := 0; i < 100; i++ {
-		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 	}
 
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
-		orders[i] = matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+		orders[i] = matching.NewOrder(uint64(i+1000), "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	}
 
 	b.ReportAllocs()
@@ -489,7 +494,7 @@ func BenchmarkPriceTimePriority(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		engine.PlaceOrder(orders[i])
 		// Add replacement order
-		engine.PlaceOrder(matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+		engine.PlaceOrder(matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 	}
 
 	ordersPerSec := float64(b.N) / b.Elapsed().Seconds()
@@ -505,8 +510,8 @@ func BenchmarkMemoryUsage(b *testing.B) {
 
 		// Simulate realistic orderbook
 		for j := 0; j < 1000; j++ {
-			bidPrice := 100.0 - float64(j)*0.01
-			askPrice := 101.0 + float64(j)*0.01
+			bidPrice := 10000 - matching.Price(j)
+			askPrice := 10100 + matching.Price(j)
 			engine.PlaceOrder(matching.NewOrder(uint64(j*2), "user_test", matching.LimitOrder, matching.Buy, bidPrice, 100))
 			engine.PlaceOrder(matching.NewOrder(uint64(j*2+1), "user_test", matching.LimitOrder, matching.Sell, askPrice, 100))
 		}
@@ -521,17 +526,17 @@ func BenchmarkThroughputStressTest(b *testing.B) {
 
 	// Pre-populate with deep liquidity
 	for i := 0; i < 500; i++ {
-		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 95.0+float64(i)*0.01, 1000))
-		engine.PlaceOrder(matching.NewOrder(uint64(i+500), "user_test", matching.LimitOrder, matching.Sell, 105.0+float64(i)*0.01, 1000))
+		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 9500+matching.Price(i), 1000))
+		engine.PlaceOrder(matching.NewOrder(uint64(i+500), "user_test", matching.LimitOrder, matching.Sell, 10500+matching.Price(i), 1000))
 	}
 
 	rand.Seed(time.Now().UnixNano())
 	orders := make([]*matching.Order, b.N)
 	for i := 0; i < b.N; i++ {
 		if rand.Float64() > 0.5 {
-			orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+			orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 		} else {
-			orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Sell, 101.0, 10)
+			orders[i] = matching.NewOrder(uint64(i+10000), "user_test", matching.LimitOrder, matching.Sell, 101, 10)
 		}
 	}
 
@@ -545,13 +550,13 @@ func BenchmarkThroughputStressTest(b *testing.B) {
 	throughput := float64(b.N) / b.Elapsed().Seconds()
 	b.ReportMetric(throughput, "orders/sec")
 	avgLatency := b.Elapsed().Nanoseconds() / int64(b.N)
-	b.ReportMetric(float64(avgLatency)/1000.0, "µs/op")
+	b.ReportMetric(float64(avgLatency)/1000, "µs/op")
 
 	// Print summary
 	b.Logf("\n=== Throughput Stress Test Summary ===")
 	b.Logf("Total Orders: %d", b.N)
 	b.Logf("Throughput: %.2f orders/sec", throughput)
-	b.Logf("Avg Latency: %.2f µs/op", float64(avgLatency)/1000.0)
+	b.Logf("Avg Latency: %.2f µs/op", float64(avgLatency)/1000)
 }
 
 // PrintBenchmarkSummary prints a summary of key performance indicators
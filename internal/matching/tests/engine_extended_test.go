@@ -1,7 +1,9 @@
 package matching
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/PxPatel/trading-system/internal/matching"
 )
@@ -32,7 +34,7 @@ func TestTrackOrder(t *testing.T) {
 	engine := matching.NewEngine()
 	defer engine.Close()
 
-	order := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10)
 
 	// Track the order
 	engine.TrackOrder(order)
@@ -53,7 +55,7 @@ func TestUntrackOrder(t *testing.T) {
 	engine := matching.NewEngine()
 	defer engine.Close()
 
-	order := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10)
 	engine.TrackOrder(order)
 
 	// Verify tracked
@@ -77,7 +79,7 @@ func TestGetAllOrders(t *testing.T) {
 
 	// Track multiple orders
 	for i := uint64(1); i <= 5; i++ {
-		order := matching.NewOrder(i, "user1", matching.LimitOrder, matching.Buy, 100.0, 10)
+		order := matching.NewOrder(i, "user1", matching.LimitOrder, matching.Buy, 100, 10)
 		engine.TrackOrder(order)
 	}
 
@@ -94,10 +96,10 @@ func TestGetOrdersByUser(t *testing.T) {
 	defer engine.Close()
 
 	// Track orders for different users
-	engine.TrackOrder(matching.NewOrder(1, "alice", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.TrackOrder(matching.NewOrder(2, "bob", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.TrackOrder(matching.NewOrder(3, "alice", matching.LimitOrder, matching.Sell, 101.0, 10))
-	engine.TrackOrder(matching.NewOrder(4, "charlie", matching.LimitOrder, matching.Buy, 99.0, 10))
+	engine.TrackOrder(matching.NewOrder(1, "alice", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.TrackOrder(matching.NewOrder(2, "bob", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.TrackOrder(matching.NewOrder(3, "alice", matching.LimitOrder, matching.Sell, 101, 10))
+	engine.TrackOrder(matching.NewOrder(4, "charlie", matching.LimitOrder, matching.Buy, 99, 10))
 
 	// Get Alice's orders
 	aliceOrders := engine.GetOrdersByUser("alice")
@@ -119,9 +121,9 @@ func TestGetOrdersBySide(t *testing.T) {
 	defer engine.Close()
 
 	// Track mixed orders
-	engine.TrackOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.TrackOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101.0, 10))
-	engine.TrackOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 99.0, 10))
+	engine.TrackOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.TrackOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101, 10))
+	engine.TrackOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 99, 10))
 
 	// Get buy orders
 	buyOrders := engine.GetOrdersBySide(matching.Buy)
@@ -146,7 +148,7 @@ func TestAddTradeToHistory(t *testing.T) {
 		trade := &matching.Trade{
 			BuyOrderID:  uint64(i),
 			SellOrderID: uint64(i + 100),
-			Price:       100.0,
+			Price:       100,
 			Size:        10,
 		}
 		engine.AddTradeToHistory(trade)
@@ -178,7 +180,7 @@ func TestTradeHistoryLimit(t *testing.T) {
 		trade := &matching.Trade{
 			BuyOrderID:  uint64(i),
 			SellOrderID: uint64(i + 100),
-			Price:       100.0,
+			Price:       100,
 			Size:        10,
 		}
 		engine.AddTradeToHistory(trade)
@@ -202,7 +204,7 @@ func TestPlaceOrderTracking(t *testing.T) {
 	defer engine.Close()
 
 	// Place limit order (should be tracked)
-	limitOrder := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99.0, 10)
+	limitOrder := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99, 10)
 	engine.PlaceOrder(limitOrder)
 
 	// Verify tracked
@@ -211,7 +213,7 @@ func TestPlaceOrderTracking(t *testing.T) {
 	}
 
 	// Place another limit order for matching
-	sellOrder := matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 99.0, 10)
+	sellOrder := matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 99, 10)
 	engine.PlaceOrder(sellOrder)
 
 	// Both should be untracked after full fill
@@ -229,7 +231,7 @@ func TestMarketOrderNotTracked(t *testing.T) {
 	defer engine.Close()
 
 	// Add liquidity
-	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 100.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 100, 10))
 
 	// Place market order (should be fully filled and untracked)
 	marketOrder := matching.NewOrder(2, "user2", matching.MarketOrder, matching.Buy, 0, 10)
@@ -251,10 +253,10 @@ func TestPartialFillTracking(t *testing.T) {
 	defer engine.Close()
 
 	// Add small liquidity
-	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 100.0, 5))
+	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 100, 5))
 
 	// Place larger limit order
-	bigOrder := matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100.0, 20)
+	bigOrder := matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100, 20)
 	trades := engine.PlaceOrder(bigOrder)
 
 	if len(trades) != 1 {
@@ -279,8 +281,8 @@ func TestGetOrderBookAccess(t *testing.T) {
 	defer engine.Close()
 
 	// Place some orders
-	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101, 10))
 
 	// Access orderbook
 	ob := engine.GetOrderBook()
@@ -290,12 +292,280 @@ func TestGetOrderBookAccess(t *testing.T) {
 
 	// Verify content
 	bidPrice, bidOrders := ob.GetBestBid()
-	if bidPrice != 99.0 || len(bidOrders) != 1 {
-		t.Errorf("Expected best bid at 99.0 with 1 order, got price=%f, count=%d", bidPrice, len(bidOrders))
+	if bidPrice != 99 || len(bidOrders) != 1 {
+		t.Errorf("Expected best bid at 99 with 1 order, got price=%d, count=%d", bidPrice, len(bidOrders))
 	}
 
 	askPrice, askOrders := ob.GetBestAsk()
-	if askPrice != 101.0 || len(askOrders) != 1 {
-		t.Errorf("Expected best ask at 101.0 with 1 order, got price=%f, count=%d", askPrice, len(askOrders))
+	if askPrice != 101 || len(askOrders) != 1 {
+		t.Errorf("Expected best ask at 101 with 1 order, got price=%d, count=%d", askPrice, len(askOrders))
+	}
+}
+
+// newMultiSymbolEngine creates an engine with books for the given symbols
+func newMultiSymbolEngine(t *testing.T, symbols ...string) *matching.Engine {
+	return matching.NewEngineWithConfig(&matching.EngineConfig{
+		TradeHistorySize: 100,
+		TradeLogPath:     filepath.Join(t.TempDir(), "trades.log"),
+		Symbols:          symbols,
+	})
+}
+
+// TestMultiSymbolBooksAreIsolated tests that orders only match within their own symbol
+func TestMultiSymbolBooksAreIsolated(t *testing.T) {
+	engine := newMultiSymbolEngine(t, "AAA", "BBB")
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrderWithSymbol(1, "user1", "AAA", matching.LimitOrder, matching.Sell, 100, 10))
+
+	// Crossing buy on another symbol must not match
+	trades := engine.PlaceOrder(matching.NewOrderWithSymbol(2, "user2", "BBB", matching.LimitOrder, matching.Buy, 105, 10))
+	if len(trades) != 0 {
+		t.Fatalf("Expected no trades across symbols, got %d", len(trades))
+	}
+
+	if _, bids := engine.GetOrderBookForSymbol("BBB").GetBestBid(); len(bids) != 1 {
+		t.Errorf("Expected BBB bid to rest, got %d orders", len(bids))
+	}
+
+	// Same-symbol buy matches
+	trades = engine.PlaceOrder(matching.NewOrderWithSymbol(3, "user2", "AAA", matching.LimitOrder, matching.Buy, 100, 10))
+	if len(trades) != 1 {
+		t.Fatalf("Expected 1 trade, got %d", len(trades))
+	}
+	if trades[0].Symbol != "AAA" {
+		t.Errorf("Expected trade symbol AAA, got %s", trades[0].Symbol)
+	}
+
+	if got := engine.GetRecentTradesBySymbol("AAA", 10); len(got) != 1 {
+		t.Errorf("Expected 1 AAA trade in history, got %d", len(got))
+	}
+	if got := engine.GetRecentTradesBySymbol("BBB", 10); len(got) != 0 {
+		t.Errorf("Expected 0 BBB trades in history, got %d", len(got))
+	}
+}
+
+// TestUnknownSymbolRejected tests that orders for unregistered symbols are dropped
+func TestUnknownSymbolRejected(t *testing.T) {
+	engine := newMultiSymbolEngine(t, "AAA")
+	defer engine.Close()
+
+	trades := engine.PlaceOrder(matching.NewOrderWithSymbol(1, "user1", "ZZZ", matching.LimitOrder, matching.Buy, 100, 10))
+	if trades != nil {
+		t.Errorf("Expected nil trades for unknown symbol, got %d", len(trades))
+	}
+	if engine.GetOrder(1) != nil {
+		t.Error("Order for unknown symbol should not be tracked")
+	}
+	if engine.HasSymbol("ZZZ") {
+		t.Error("ZZZ should not be registered")
+	}
+}
+
+// TestCancelOrderRoutesBySymbol tests cancellation on a non-default symbol
+func TestCancelOrderRoutesBySymbol(t *testing.T) {
+	engine := newMultiSymbolEngine(t, "AAA", "BBB")
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrderWithSymbol(1, "user1", "BBB", matching.LimitOrder, matching.Buy, 50, 10))
+
+	if !engine.CancelOrder(1) {
+		t.Fatal("Cancel should succeed")
+	}
+	if len(engine.GetOrderBookForSymbol("BBB").GetAllBids()) != 0 {
+		t.Error("BBB book should be empty after cancel")
+	}
+
+	expected := []string{"AAA", "BBB"}
+	symbols := engine.GetSymbols()
+	if len(symbols) != len(expected) || symbols[0] != expected[0] || symbols[1] != expected[1] {
+		t.Errorf("Expected symbols %v, got %v", expected, symbols)
+	}
+}
+
+// TestStopMarketTriggeredByTrade tests that a trade at the stop price fires a resting stop
+func TestStopMarketTriggeredByTrade(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrder(1, "maker", matching.LimitOrder, matching.Sell, 100, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "maker", matching.LimitOrder, matching.Sell, 101, 10))
+
+	stop := matching.NewOrder(3, "stopper", matching.StopMarketOrder, matching.Buy, 0, 4)
+	stop.StopPrice = 100
+	if trades := engine.PlaceOrder(stop); trades != nil {
+		t.Fatal("Stop should rest before any trade")
+	}
+
+	// Trade at 100 fires the stop, which then lifts the 101 offer
+	trades := engine.PlaceOrder(matching.NewOrder(4, "taker", matching.MarketOrder, matching.Buy, 0, 5))
+	if len(trades) != 2 {
+		t.Fatalf("Expected 2 trades (taker + triggered stop), got %d", len(trades))
+	}
+	if trades[1].BuyOrderID != 3 || trades[1].Price != 101 || trades[1].Size != 4 {
+		t.Errorf("Unexpected stop trade: %+v", trades[1])
+	}
+
+	if engine.GetTriggerBookForSymbol(matching.DefaultSymbol).Len() != 0 {
+		t.Error("Trigger book should be empty after firing")
+	}
+	if last, ok := engine.GetLastTradePrice(matching.DefaultSymbol); !ok || last != 101 {
+		t.Errorf("Expected last trade price 101, got %d", last)
+	}
+}
+
+// TestStopCascade tests that fills from a fired stop can trigger further stops
+func TestStopCascade(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrder(1, "maker", matching.LimitOrder, matching.Buy, 100, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "maker", matching.LimitOrder, matching.Buy, 98, 5))
+	engine.PlaceOrder(matching.NewOrder(3, "maker", matching.LimitOrder, matching.Buy, 96, 5))
+
+	// First stop fires at 100, trades at 98, which fires the second stop
+	stop1 := matching.NewOrder(10, "stopper", matching.StopMarketOrder, matching.Sell, 0, 5)
+	stop1.StopPrice = 100
+	stop2 := matching.NewOrder(11, "stopper", matching.StopLimitOrder, matching.Sell, 95, 5)
+	stop2.StopPrice = 98
+	engine.PlaceOrder(stop1)
+	engine.PlaceOrder(stop2)
+
+	trades := engine.PlaceOrder(matching.NewOrder(20, "taker", matching.MarketOrder, matching.Sell, 0, 5))
+	if len(trades) != 3 {
+		t.Fatalf("Expected 3 trades from cascade, got %d", len(trades))
+	}
+
+	expectedSellers := []uint64{20, 10, 11}
+	expectedPrices := []matching.Price{100, 98, 96}
+	for i := range trades {
+		if trades[i].SellOrderID != expectedSellers[i] || trades[i].Price != expectedPrices[i] {
+			t.Errorf("Trade %d: expected seller %d at %d, got seller %d at %d",
+				i, expectedSellers[i], expectedPrices[i], trades[i].SellOrderID, trades[i].Price)
+		}
+	}
+}
+
+// TestCancelRestingStop tests that resting stops can be cancelled
+func TestCancelRestingStop(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	stop := matching.NewOrder(1, "stopper", matching.StopMarketOrder, matching.Sell, 0, 5)
+	stop.StopPrice = 90
+	engine.PlaceOrder(stop)
+
+	if !engine.CancelOrder(1) {
+		t.Fatal("Cancel of resting stop should succeed")
+	}
+	if engine.GetOrder(1) != nil {
+		t.Error("Cancelled stop should be untracked")
+	}
+	if engine.GetTriggerBookForSymbol(matching.DefaultSymbol).Len() != 0 {
+		t.Error("Trigger book should be empty")
+	}
+}
+
+// TestImmediateOrCancelDoesNotRest tests that an IOC remainder is cancelled
+func TestImmediateOrCancelDoesNotRest(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrder(1, "maker", matching.LimitOrder, matching.Sell, 100, 5))
+
+	ioc := matching.NewOrder(2, "taker", matching.LimitOrder, matching.Buy, 100, 8)
+	ioc.TimeInForce = matching.ImmediateOrCancel
+	trades := engine.PlaceOrder(ioc)
+
+	if len(trades) != 1 || trades[0].Size != 5 {
+		t.Fatalf("Expected one fill of 5, got %+v", trades)
+	}
+	if len(engine.GetOrderBook().GetAllBids()) != 0 {
+		t.Error("IOC remainder should not rest on the book")
+	}
+	if engine.GetOrder(2) != nil {
+		t.Error("IOC order should be untracked")
+	}
+}
+
+// TestFillOrKill tests that FOK orders fill completely or not at all
+func TestFillOrKill(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrder(1, "maker", matching.LimitOrder, matching.Sell, 100, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "maker", matching.LimitOrder, matching.Sell, 102, 5))
+
+	// Only 5 available at or below 101
+	fok := matching.NewOrder(3, "taker", matching.LimitOrder, matching.Buy, 101, 8)
+	fok.TimeInForce = matching.FillOrKill
+	if trades := engine.PlaceOrder(fok); len(trades) != 0 {
+		t.Fatalf("FOK without enough depth should not trade, got %d trades", len(trades))
+	}
+	if _, asks := engine.GetOrderBook().GetBestAsk(); asks[0].Size != 5 {
+		t.Error("Killed FOK must not consume liquidity")
+	}
+
+	// Market FOK can use the whole book
+	fokMarket := matching.NewOrder(4, "taker", matching.MarketOrder, matching.Buy, 0, 10)
+	fokMarket.TimeInForce = matching.FillOrKill
+	if trades := engine.PlaceOrder(fokMarket); len(trades) != 2 {
+		t.Fatalf("Expected market FOK to fill across 2 levels, got %d trades", len(trades))
+	}
+}
+
+// TestGoodTillDateExpiry tests expiring GTD orders
+func TestGoodTillDateExpiry(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	now := time.Now()
+
+	gtd := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99, 10)
+	gtd.TimeInForce = matching.GoodTillDate
+	gtd.ExpireTime = now.Add(time.Hour)
+	engine.PlaceOrder(gtd)
+
+	gtc := matching.NewOrder(2, "user1", matching.LimitOrder, matching.Buy, 98, 10)
+	engine.PlaceOrder(gtc)
+
+	if expired := engine.ExpireOrders(now); len(expired) != 0 {
+		t.Fatalf("Nothing should expire yet, got %d", len(expired))
+	}
+
+	expired := engine.ExpireOrders(now.Add(2 * time.Hour))
+	if len(expired) != 1 || expired[0].ID != 1 {
+		t.Fatalf("Expected order 1 to expire, got %+v", expired)
+	}
+	if engine.GetOrder(1) != nil || engine.GetOrder(2) == nil {
+		t.Error("Only the GTD order should be removed")
+	}
+
+	// Already expired on arrival
+	stale := matching.NewOrder(3, "user1", matching.LimitOrder, matching.Buy, 97, 10)
+	stale.TimeInForce = matching.GoodTillDate
+	stale.ExpireTime = now.Add(-time.Minute)
+	engine.PlaceOrder(stale)
+	if engine.GetOrder(3) != nil {
+		t.Error("Expired GTD order should be rejected")
+	}
+}
+
+// TestSweepDayOrders tests that day orders are cancelled at session end
+func TestSweepDayOrders(t *testing.T) {
+	engine := newMultiSymbolEngine(t, matching.DefaultSymbol)
+	defer engine.Close()
+
+	day := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 101, 10)
+	day.TimeInForce = matching.Day
+	engine.PlaceOrder(day)
+	engine.PlaceOrder(matching.NewOrder(2, "user1", matching.LimitOrder, matching.Sell, 102, 10))
+
+	swept := engine.SweepDayOrders()
+	if len(swept) != 1 || swept[0].ID != 1 {
+		t.Fatalf("Expected order 1 to be swept, got %+v", swept)
+	}
+	if asks := engine.GetOrderBook().GetAllAsks(); len(asks) != 1 || asks[0] != 102 {
+		t.Errorf("Expected only the GTC ask at 102 to remain, got %v", asks)
 	}
 }
@@ -1,6 +1,7 @@
 package matching
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/PxPatel/trading-system/internal/matching"
@@ -20,24 +21,24 @@ func TestPlaceMarketOrderBuy(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add some ask orders (liquidity to buy against)
-	ask1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10)
-	ask2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102.0, 20)
-	ask3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103.0, 15)
+	ask1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10)
+	ask2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 20)
+	ask3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103, 15)
 
 	engine.PlaceOrder(ask1)
 	engine.PlaceOrder(ask2)
 	engine.PlaceOrder(ask3)
 
 	// Place market buy order that fully fills against best ask
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 {
 		t.Errorf("Expected 1 trade, got %d", len(trades))
 	}
 
-	if trades[0].Price != 101.0 {
-		t.Errorf("Expected trade price 101.0, got %f", trades[0].Price)
+	if trades[0].Price != 101 {
+		t.Errorf("Expected trade price 101, got %d", trades[0].Price)
 	}
 
 	if trades[0].Size != 10 {
@@ -54,24 +55,24 @@ func TestPlaceMarketOrderSell(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add some bid orders (liquidity to sell against)
-	bid1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
-	bid2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 99.0, 20)
-	bid3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 98.0, 15)
+	bid1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
+	bid2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 99, 20)
+	bid3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 98, 15)
 
 	engine.PlaceOrder(bid1)
 	engine.PlaceOrder(bid2)
 	engine.PlaceOrder(bid3)
 
 	// Place market sell order
-	marketSell := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Sell, 0.0, 10)
+	marketSell := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Sell, 0, 10)
 	trades := engine.PlaceOrder(marketSell)
 
 	if len(trades) != 1 {
 		t.Errorf("Expected 1 trade, got %d", len(trades))
 	}
 
-	if trades[0].Price != 100.0 {
-		t.Errorf("Expected trade price 100.0 (best bid), got %f", trades[0].Price)
+	if trades[0].Price != 100 {
+		t.Errorf("Expected trade price 100 (best bid), got %d", trades[0].Price)
 	}
 
 	if trades[0].Size != 10 {
@@ -88,12 +89,12 @@ func TestMarketOrderPartialFill(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add smaller ask orders
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103.0, 8))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103, 8))
 
 	// Place market buy that requires multiple fills
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 20)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 20)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should create 3 trades: 5 @ 101, 10 @ 102, 5 @ 103
@@ -102,18 +103,18 @@ func TestMarketOrderPartialFill(t *testing.T) {
 	}
 
 	// Verify first trade
-	if trades[0].Price != 101.0 || trades[0].Size != 5 {
-		t.Errorf("Trade 0: expected 5@101.0, got %d@%f", trades[0].Size, trades[0].Price)
+	if trades[0].Price != 101 || trades[0].Size != 5 {
+		t.Errorf("Trade 0: expected 5@101, got %d@%d", trades[0].Size, trades[0].Price)
 	}
 
 	// Verify second trade
-	if trades[1].Price != 102.0 || trades[1].Size != 10 {
-		t.Errorf("Trade 1: expected 10@102.0, got %d@%f", trades[1].Size, trades[1].Price)
+	if trades[1].Price != 102 || trades[1].Size != 10 {
+		t.Errorf("Trade 1: expected 10@102, got %d@%d", trades[1].Size, trades[1].Price)
 	}
 
 	// Verify third trade
-	if trades[2].Price != 103.0 || trades[2].Size != 5 {
-		t.Errorf("Trade 2: expected 5@103.0, got %d@%f", trades[2].Size, trades[2].Price)
+	if trades[2].Price != 103 || trades[2].Size != 5 {
+		t.Errorf("Trade 2: expected 5@103, got %d@%d", trades[2].Size, trades[2].Price)
 	}
 
 	// Verify total size
@@ -128,7 +129,7 @@ func TestMarketOrderNoLiquidity(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place market buy with no asks in book
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should create no trades
@@ -142,11 +143,11 @@ func TestMarketOrderInsufficientLiquidity(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add limited liquidity
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102.0, 8))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 8))
 
 	// Place market buy larger than available liquidity
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 20)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 20)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should only fill what's available: 5 + 8 = 13
@@ -169,18 +170,18 @@ func TestPlaceLimitOrderBuyImmediate(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add ask order
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 	// Place limit buy at or above best ask
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101.0, 10)
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101, 10)
 	trades := engine.PlaceOrder(limitBuy)
 
 	if len(trades) != 1 {
 		t.Errorf("Expected 1 trade, got %d", len(trades))
 	}
 
-	if trades[0].Price != 101.0 || trades[0].Size != 10 {
-		t.Errorf("Expected 10@101.0, got %d@%f", trades[0].Size, trades[0].Price)
+	if trades[0].Price != 101 || trades[0].Size != 10 {
+		t.Errorf("Expected 10@101, got %d@%d", trades[0].Size, trades[0].Price)
 	}
 }
 
@@ -189,18 +190,18 @@ func TestPlaceLimitOrderSellImmediate(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add bid order
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
 
 	// Place limit sell at or below best bid
-	limitSell := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Sell, 100.0, 10)
+	limitSell := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Sell, 100, 10)
 	trades := engine.PlaceOrder(limitSell)
 
 	if len(trades) != 1 {
 		t.Errorf("Expected 1 trade, got %d", len(trades))
 	}
 
-	if trades[0].Price != 100.0 || trades[0].Size != 10 {
-		t.Errorf("Expected 10@100.0, got %d@%f", trades[0].Size, trades[0].Price)
+	if trades[0].Price != 100 || trades[0].Size != 10 {
+		t.Errorf("Expected 10@100, got %d@%d", trades[0].Size, trades[0].Price)
 	}
 }
 
@@ -209,7 +210,7 @@ func TestPlaceLimitOrderAddToBook(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place limit buy below any asks
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99.0, 10)
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99, 10)
 	trades := engine.PlaceOrder(limitBuy)
 
 	// Should create no trades
@@ -218,7 +219,7 @@ func TestPlaceLimitOrderAddToBook(t *testing.T) {
 	}
 
 	// Place limit sell above any bids (should also be added to book)
-	limitSell := matching.NewOrder(101, "user_test", matching.LimitOrder, matching.Sell, 102.0, 15)
+	limitSell := matching.NewOrder(101, "user_test", matching.LimitOrder, matching.Sell, 102, 15)
 	trades = engine.PlaceOrder(limitSell)
 
 	if len(trades) != 0 {
@@ -227,15 +228,15 @@ func TestPlaceLimitOrderAddToBook(t *testing.T) {
 
 	// Now place matching orders
 	// Market buy should match against the sell we added
-	marketBuy := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades = engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 {
 		t.Errorf("Expected 1 trade from market buy, got %d", len(trades))
 	}
 
-	if trades[0].Price != 102.0 || trades[0].Size != 10 {
-		t.Errorf("Expected 10@102.0, got %d@%f", trades[0].Size, trades[0].Price)
+	if trades[0].Price != 102 || trades[0].Size != 10 {
+		t.Errorf("Expected 10@102, got %d@%d", trades[0].Size, trades[0].Price)
 	}
 }
 
@@ -244,10 +245,10 @@ func TestPlaceLimitOrderPartialFillAndRest(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add smaller ask order
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
 
 	// Place limit buy that partially matches
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101.0, 15)
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101, 15)
 	trades := engine.PlaceOrder(limitBuy)
 
 	// Should create 1 trade for 5 units
@@ -261,7 +262,7 @@ func TestPlaceLimitOrderPartialFillAndRest(t *testing.T) {
 
 	// Remaining 10 units should be added to book
 	// Place market sell to verify
-	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0.0, 8)
+	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0, 8)
 	trades = engine.PlaceOrder(marketSell)
 
 	if len(trades) != 1 {
@@ -278,7 +279,7 @@ func TestCancelOrder(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place limit order
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99.0, 10)
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99, 10)
 	engine.PlaceOrder(limitBuy)
 
 	// Cancel the order
@@ -295,7 +296,7 @@ func TestCancelOrder(t *testing.T) {
 
 	// Verify order is no longer in book
 	// Place market sell that would match if order still existed
-	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0.0, 5)
+	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0, 5)
 	trades := engine.PlaceOrder(marketSell)
 
 	if len(trades) != 0 {
@@ -308,11 +309,11 @@ func TestCancelOrderViaOrderType(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place limit order
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99.0, 10)
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 99, 10)
 	engine.PlaceOrder(limitBuy)
 
 	// Cancel using CancelOrder type
-	cancelOrder := matching.NewOrder(100, "user_test", matching.CancelOrder, matching.Buy, 0.0, 0)
+	cancelOrder := matching.NewOrder(100, "user_test", matching.CancelOrder, matching.Buy, 0, 0)
 	trades := engine.PlaceOrder(cancelOrder)
 
 	// Cancel orders don't produce trades
@@ -336,20 +337,20 @@ func TestPricePriority(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add asks at different prices
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 103.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 103, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
 
 	// Market buy should match with best (lowest) ask first
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 {
 		t.Fatalf("Expected 1 trade, got %d", len(trades))
 	}
 
-	if trades[0].Price != 101.0 {
-		t.Errorf("Expected to match best ask 101.0, got %f", trades[0].Price)
+	if trades[0].Price != 101 {
+		t.Errorf("Expected to match best ask 101, got %d", trades[0].Price)
 	}
 
 	if trades[0].SellOrderID != 2 {
@@ -362,12 +363,12 @@ func TestTimePriority(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add multiple asks at same price
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
 
 	// Market buy should match in time priority (FIFO)
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 5)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 5)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 {
@@ -385,12 +386,12 @@ func TestMultipleTrades(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add multiple asks at different prices
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102.0, 15))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103.0, 20))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 15))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103, 20))
 
 	// Large market buy
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 40)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 40)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should create 3 trades
@@ -400,17 +401,17 @@ func TestMultipleTrades(t *testing.T) {
 
 	// Verify trade details
 	expectedTrades := []struct {
-		price float64
+		price matching.Price
 		size  int
 	}{
-		{101.0, 10},
-		{102.0, 15},
-		{103.0, 15}, // Only 15 of the 20 available
+		{101, 10},
+		{102, 15},
+		{103, 15}, // Only 15 of the 20 available
 	}
 
 	for i, expected := range expectedTrades {
 		if trades[i].Price != expected.price {
-			t.Errorf("Trade %d: expected price %f, got %f", i, expected.price, trades[i].Price)
+			t.Errorf("Trade %d: expected price %d, got %d", i, expected.price, trades[i].Price)
 		}
 		if trades[i].Size != expected.size {
 			t.Errorf("Trade %d: expected size %d, got %d", i, expected.size, trades[i].Size)
@@ -422,20 +423,20 @@ func TestMultipleTrades(t *testing.T) {
 func TestLimitOrderPriceImprovement(t *testing.T) {
 	engine := matching.NewEngine()
 
-	// Add ask at 101.0
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	// Add ask at 101
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
-	// Place limit buy willing to pay up to 105.0
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 105.0, 10)
+	// Place limit buy willing to pay up to 105
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 105, 10)
 	trades := engine.PlaceOrder(limitBuy)
 
 	if len(trades) != 1 {
 		t.Fatalf("Expected 1 trade, got %d", len(trades))
 	}
 
-	// Should execute at resting order price (101.0), not limit price (105.0)
-	if trades[0].Price != 101.0 {
-		t.Errorf("Expected price improvement to 101.0, got %f", trades[0].Price)
+	// Should execute at resting order price (101), not limit price (105)
+	if trades[0].Price != 101 {
+		t.Errorf("Expected price improvement to 101, got %d", trades[0].Price)
 	}
 }
 
@@ -443,14 +444,14 @@ func TestLimitOrderPriceImprovement(t *testing.T) {
 func TestAggressiveLimitOrders(t *testing.T) {
 	engine := matching.NewEngine()
 
-	// Create spread: bids at 99-100, asks at 102-103
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 99.0, 10))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
-	engine.PlaceOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Sell, 103.0, 10))
+	// Create spread: bids at 99.00-100.00, asks at 102.00-103.00
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 10000, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 9900, 10))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 10200, 10))
+	engine.PlaceOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Sell, 10300, 10))
 
-	// Aggressive limit buy crosses the spread
-	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 102.5, 15)
+	// Aggressive limit buy at 102.50 crosses the spread
+	limitBuy := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 10250, 15)
 	trades := engine.PlaceOrder(limitBuy)
 
 	// Should match only one ask
@@ -458,9 +459,9 @@ func TestAggressiveLimitOrders(t *testing.T) {
 		t.Errorf("Expected 1 trades, got %d", len(trades))
 	}
 
-	// First trade at 102.0 (best ask)
-	if trades[0].Price != 102.0 || trades[0].Size != 10 {
-		t.Errorf("Trade 0: expected 10@102.0, got %d@%f", trades[0].Size, trades[0].Price)
+	// First trade at 102.00 (best ask)
+	if trades[0].Price != 10200 || trades[0].Size != 10 {
+		t.Errorf("Trade 0: expected 10@102, got %d@%d", trades[0].Size, trades[0].Price)
 	}
 }
 
@@ -471,11 +472,11 @@ func TestAggressiveLimitOrders(t *testing.T) {
 
 // 	// Place bid and ask with same ID prefix (simulating same participant)
 // 	// Note: The engine doesn't prevent self-matching - that's typically handled at a higher level
-// 	engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101.0, 10))
-// 	engine.PlaceOrder(matching.NewOrder(101, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+// 	engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101, 10))
+// 	engine.PlaceOrder(matching.NewOrder(101, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 // 	// These should match
-// 	trades := engine.PlaceOrder(matching.NewOrder(102, "user_test", matching.MarketOrder, matching.Sell, 0.0, 5))
+// 	trades := engine.PlaceOrder(matching.NewOrder(102, "user_test", matching.MarketOrder, matching.Sell, 0, 5))
 
 // 	if len(trades) == 0 {
 // 		t.Error("Expected orders to match (engine doesn't prevent self-matching)")
@@ -488,17 +489,17 @@ func TestFullBookExecution(t *testing.T) {
 
 	// Build a realistic order book
 	// Bids: 100(10), 99(20), 98(30)
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 99.0, 20))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 98.0, 30))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 99, 20))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 98, 30))
 
 	// Asks: 101(15), 102(25), 103(35)
-	engine.PlaceOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101.0, 15))
-	engine.PlaceOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 102.0, 25))
-	engine.PlaceOrder(matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 103.0, 35))
+	engine.PlaceOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101, 15))
+	engine.PlaceOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 102, 25))
+	engine.PlaceOrder(matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 103, 35))
 
 	// Large market buy: should sweep through all asks
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 70)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 70)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should match all asks: 15 + 25 + 30 = 70
@@ -516,7 +517,7 @@ func TestFullBookExecution(t *testing.T) {
 	}
 
 	// Now place large market sell: should sweep through all bids
-	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0.0, 60)
+	marketSell := matching.NewOrder(200, "user_test", matching.MarketOrder, matching.Sell, 0, 60)
 	trades = engine.PlaceOrder(marketSell)
 
 	// Should match all bids: 10 + 20 + 30 = 60
@@ -539,11 +540,11 @@ func TestOrderSizeReduction(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add ask order
-	ask := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 20)
+	ask := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 20)
 	engine.PlaceOrder(ask)
 
 	// Partially fill with market buy
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 8)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 8)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 || trades[0].Size != 8 {
@@ -551,7 +552,7 @@ func TestOrderSizeReduction(t *testing.T) {
 	}
 
 	// Place another market buy to verify remaining size
-	marketBuy2 := matching.NewOrder(101, "user_test", matching.MarketOrder, matching.Buy, 0.0, 12)
+	marketBuy2 := matching.NewOrder(101, "user_test", matching.MarketOrder, matching.Buy, 0, 12)
 	trades2 := engine.PlaceOrder(marketBuy2)
 
 	if len(trades2) != 1 || trades2[0].Size != 12 {
@@ -565,7 +566,7 @@ func TestEmptyEngineOperations(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Market order with no liquidity
-	marketBuy := matching.NewOrder(1, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(1, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 0 {
@@ -573,7 +574,7 @@ func TestEmptyEngineOperations(t *testing.T) {
 	}
 
 	// Limit order should be added
-	limitBuy := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+	limitBuy := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 	trades = engine.PlaceOrder(limitBuy)
 
 	if len(trades) != 0 {
@@ -592,7 +593,7 @@ func TestNoActionOrderType(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place NoActionOrder
-	noAction := matching.NewOrder(1, "user_test", matching.NoActionOrder, matching.Buy, 100.0, 10)
+	noAction := matching.NewOrder(1, "user_test", matching.NoActionOrder, matching.Buy, 100, 10)
 	trades := engine.PlaceOrder(noAction)
 
 	// Should return nil (default case in switch)
@@ -606,10 +607,10 @@ func TestZeroSizeOrder(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add ask order
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 	// Market buy with zero size
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 0)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 0)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should produce no trades
@@ -624,12 +625,12 @@ func TestLargeOrderExecution(t *testing.T) {
 
 	// Add many small asks
 	for i := 0; i < 100; i++ {
-		price := 101.0 + float64(i)*0.01
+		price := 10100 + matching.Price(i)
 		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, price, 10))
 	}
 
 	// Large market buy
-	marketBuy := matching.NewOrder(10000, "user_test", matching.MarketOrder, matching.Buy, 0.0, 1000)
+	marketBuy := matching.NewOrder(10000, "user_test", matching.MarketOrder, matching.Buy, 0, 1000)
 	trades := engine.PlaceOrder(marketBuy)
 
 	// Should create 100 trades
@@ -653,10 +654,10 @@ func TestTradeTimestamps(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add ask
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 	// Execute market buy
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 10)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 10)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 1 {
@@ -674,29 +675,29 @@ func TestSequentialTrades(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Place initial orders
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 	// Execute multiple trades in sequence
 	for i := 0; i < 10; i++ {
 		if i%2 == 0 {
 			// Market sell
-			sell := matching.NewOrder(uint64(100+i), "user_test", matching.MarketOrder, matching.Sell, 0.0, 5)
+			sell := matching.NewOrder(uint64(100+i), "user_test", matching.MarketOrder, matching.Sell, 0, 5)
 			trades := engine.PlaceOrder(sell)
 			if len(trades) > 1 {
 				t.Errorf("Trade %d: expected at most 1 trade, got %d", i, len(trades))
 			}
 			// Add new bid
-			engine.PlaceOrder(matching.NewOrder(uint64(10+i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 5))
+			engine.PlaceOrder(matching.NewOrder(uint64(10+i), "user_test", matching.LimitOrder, matching.Buy, 100, 5))
 		} else {
 			// Market buy
-			buy := matching.NewOrder(uint64(100+i), "user_test", matching.MarketOrder, matching.Buy, 0.0, 5)
+			buy := matching.NewOrder(uint64(100+i), "user_test", matching.MarketOrder, matching.Buy, 0, 5)
 			trades := engine.PlaceOrder(buy)
 			if len(trades) > 1 {
 				t.Errorf("Trade %d: expected at most 1 trade, got %d", i, len(trades))
 			}
 			// Add new ask
-			engine.PlaceOrder(matching.NewOrder(uint64(10+i), "user_test", matching.LimitOrder, matching.Sell, 101.0, 5))
+			engine.PlaceOrder(matching.NewOrder(uint64(10+i), "user_test", matching.LimitOrder, matching.Sell, 101, 5))
 		}
 	}
 }
@@ -706,12 +707,12 @@ func TestEdgeCaseExactFill(t *testing.T) {
 	engine := matching.NewEngine()
 
 	// Add asks with specific sizes
-	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
-	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102.0, 20))
-	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103.0, 30))
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 20))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103, 30))
 
 	// Market buy that exactly fills first two levels
-	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0.0, 30)
+	marketBuy := matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 30)
 	trades := engine.PlaceOrder(marketBuy)
 
 	if len(trades) != 2 {
@@ -719,7 +720,7 @@ func TestEdgeCaseExactFill(t *testing.T) {
 	}
 
 	// Verify the third ask is still available
-	marketBuy2 := matching.NewOrder(101, "user_test", matching.MarketOrder, matching.Buy, 0.0, 30)
+	marketBuy2 := matching.NewOrder(101, "user_test", matching.MarketOrder, matching.Buy, 0, 30)
 	trades2 := engine.PlaceOrder(marketBuy2)
 
 	if len(trades2) != 1 {
@@ -731,43 +732,52 @@ func TestEdgeCaseExactFill(t *testing.T) {
 	}
 }
 
-// TestStopOrderTypes tests that stop orders don't execute (not implemented yet)
+// TestStopOrderTypes tests that stop orders rest until the last trade price reaches the stop
 func TestStopOrderTypes(t *testing.T) {
 	engine := matching.NewEngine()
 
-	// Place stop market order (not implemented, should return nil)
-	stopMarket := matching.NewOrder(1, "user_test", matching.StopMarketOrder, matching.Buy, 0.0, 10)
+	// Place stop market order (no trades yet, should rest)
+	stopMarket := matching.NewOrder(1, "user_test", matching.StopMarketOrder, matching.Buy, 0, 10)
+	stopMarket.StopPrice = 105
 	trades := engine.PlaceOrder(stopMarket)
 
 	if trades != nil {
-		t.Error("Stop orders not implemented, should return nil")
+		t.Error("Untriggered stop orders should not trade")
 	}
 
 	// Place stop limit order
-	stopLimit := matching.NewOrder(2, "user_test", matching.StopLimitOrder, matching.Buy, 100.0, 10)
+	stopLimit := matching.NewOrder(2, "user_test", matching.StopLimitOrder, matching.Buy, 100, 10)
+	stopLimit.StopPrice = 110
 	trades = engine.PlaceOrder(stopLimit)
 
 	if trades != nil {
-		t.Error("Stop orders not implemented, should return nil")
+		t.Error("Untriggered stop orders should not trade")
+	}
+
+	// Stops are held outside the order book
+	if len(engine.GetOrderBook().GetAllBids()) != 0 {
+		t.Error("Stop orders should not appear in the order book")
+	}
+	if engine.GetTriggerBookForSymbol(matching.DefaultSymbol).Len() != 2 {
+		t.Error("Stop orders should rest in the trigger book")
 	}
 }
 
-// TestConcurrentOrders tests basic concurrent order placement
+// TestConcurrentOrders tests concurrent order placement through the sequencer
 func TestConcurrentOrders(t *testing.T) {
 	engine := matching.NewEngine()
 
-	// Note: This is a basic test. For production, proper synchronization would be needed
 	done := make(chan bool, 100)
 
 	// Add initial liquidity
 	for i := 0; i < 50; i++ {
-		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 101.0+float64(i)*0.01, 10))
+		engine.PlaceOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, 10100+matching.Price(i), 10))
 	}
 
 	// Concurrent market buys
 	for i := 0; i < 100; i++ {
 		go func(id uint64) {
-			marketBuy := matching.NewOrder(id+1000, "user_test", matching.MarketOrder, matching.Buy, 0.0, 1)
+			marketBuy := matching.NewOrder(id+1000, "user_test", matching.MarketOrder, matching.Buy, 0, 1)
 			engine.PlaceOrder(marketBuy)
 			done <- true
 		}(uint64(i))
@@ -777,4 +787,57 @@ func TestConcurrentOrders(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		<-done
 	}
+
+	// Every buy filled exactly once against the 500 offered
+	if remaining := engine.GetOrderBook().GetAskQuantityAtOrBelow(20000); remaining != 400 {
+		t.Errorf("Expected 400 remaining ask quantity, got %d", remaining)
+	}
+	if trades := engine.GetRecentTrades(1000); len(trades) != 100 {
+		t.Errorf("Expected 100 trades, got %d", len(trades))
+	}
+}
+
+// TestBookSnapshot tests level aggregation and depth limits of book snapshots
+func TestBookSnapshot(t *testing.T) {
+	engine := matching.NewEngine()
+
+	engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 9900, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 9900, 5))
+	engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 9800, 7))
+	engine.PlaceOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Sell, 10100, 3))
+
+	snapshot, ok := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	if !ok {
+		t.Fatal("Expected snapshot for default symbol")
+	}
+	expectedBids := []matching.BookLevel{{Price: 9900, Quantity: 15, OrderCount: 2}, {Price: 9800, Quantity: 7, OrderCount: 1}}
+	if !reflect.DeepEqual(snapshot.Bids, expectedBids) {
+		t.Errorf("Expected bids %v, got %v", expectedBids, snapshot.Bids)
+	}
+	if len(snapshot.Asks) != 1 || snapshot.Asks[0].Quantity != 3 {
+		t.Errorf("Expected one ask level of 3, got %v", snapshot.Asks)
+	}
+
+	top, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 1)
+	if len(top.Bids) != 1 || top.Bids[0].Price != 9900 {
+		t.Errorf("Expected only the best bid level, got %v", top.Bids)
+	}
+
+	if _, ok := engine.GetBookSnapshot("UNKNOWN", 0); ok {
+		t.Error("Expected no snapshot for unregistered symbol")
+	}
+}
+
+// TestOrdersIgnoredAfterClose tests that a closed engine no longer accepts orders
+func TestOrdersIgnoredAfterClose(t *testing.T) {
+	engine := matching.NewEngine()
+	engine.Close()
+
+	trades := engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 9900, 10))
+	if trades != nil {
+		t.Errorf("Expected no trades, got %v", trades)
+	}
+	if engine.GetOrder(1) != nil {
+		t.Error("Order should not be tracked after close")
+	}
 }
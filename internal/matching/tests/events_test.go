@@ -0,0 +1,144 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func collectEvents(engine *matching.Engine) *[][]matching.Event {
+	var batches [][]matching.Event
+	engine.Subscribe(func(events []matching.Event) {
+		batches = append(batches, events)
+	})
+	return &batches
+}
+
+func eventsOfType(events []matching.Event, eventType matching.EventType) []matching.Event {
+	var matched []matching.Event
+	for _, event := range events {
+		if event.Type == eventType {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// TestEventsForRestingOrder tests the order and book events of an order that rests
+func TestEventsForRestingOrder(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	batches := collectEvents(engine)
+
+	id := placeLimit(engine, matching.Buy, 9900, 10)
+
+	if len(*batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(*batches))
+	}
+	events := (*batches)[0]
+
+	orders := eventsOfType(events, matching.OrderEvent)
+	if len(orders) != 1 || orders[0].Order.ID != id || orders[0].Order.Status != matching.StatusNew {
+		t.Errorf("Unexpected order events %+v", orders)
+	}
+
+	books := eventsOfType(events, matching.BookEvent)
+	if len(books) != 1 {
+		t.Fatalf("Expected 1 book event, got %d", len(books))
+	}
+	update := books[0].Book
+	want := []matching.BookLevel{{Price: 9900, Quantity: 10, OrderCount: 1}}
+	if update.Seq != 1 || !reflect.DeepEqual(update.Bids, want) || len(update.Asks) != 0 {
+		t.Errorf("Unexpected book update %+v", update)
+	}
+	if update.BestBid == nil || update.BestBid.Price != 9900 || update.BestAsk != nil {
+		t.Errorf("Unexpected top of book %+v / %+v", update.BestBid, update.BestAsk)
+	}
+}
+
+// TestEventsForMatch tests trades, final order states and removed levels from a sweep
+func TestEventsForMatch(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	first := placeLimit(engine, matching.Sell, 10000, 5)
+	second := placeLimit(engine, matching.Sell, 10100, 5)
+
+	batches := collectEvents(engine)
+	buy := placeLimit(engine, matching.Buy, 10100, 7)
+	events := (*batches)[0]
+
+	trades := eventsOfType(events, matching.TradeEvent)
+	if len(trades) != 2 || trades[0].Trade.Price != 10000 || trades[1].Trade.Price != 10100 {
+		t.Errorf("Unexpected trade events %+v", trades)
+	}
+
+	// Each order is reported once, in its state after the command
+	statuses := make(map[uint64]matching.OrderStatus)
+	for _, event := range eventsOfType(events, matching.OrderEvent) {
+		if _, seen := statuses[event.Order.ID]; seen {
+			t.Errorf("Order %d reported twice", event.Order.ID)
+		}
+		statuses[event.Order.ID] = event.Order.Status
+	}
+	want := map[uint64]matching.OrderStatus{
+		buy:    matching.StatusFilled,
+		first:  matching.StatusFilled,
+		second: matching.StatusPartiallyFilled,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Order statuses %v, want %v", statuses, want)
+	}
+
+	books := eventsOfType(events, matching.BookEvent)
+	if len(books) != 1 {
+		t.Fatalf("Expected 1 book event, got %d", len(books))
+	}
+	wantAsks := []matching.BookLevel{
+		{Price: 10000, Quantity: 0, OrderCount: 0},
+		{Price: 10100, Quantity: 3, OrderCount: 1},
+	}
+	if !reflect.DeepEqual(books[0].Book.Asks, wantAsks) {
+		t.Errorf("Ask updates %+v, want %+v", books[0].Book.Asks, wantAsks)
+	}
+	if books[0].Book.Seq != 3 {
+		t.Errorf("Book sequence %d, want 3", books[0].Book.Seq)
+	}
+}
+
+// TestEventsSequenceMatchesSnapshot tests that book updates continue from a snapshot's sequence
+func TestEventsSequenceMatchesSnapshot(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeLimit(engine, matching.Buy, 9900, 10)
+	placeLimit(engine, matching.Sell, 10100, 10)
+
+	snapshot, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	batches := collectEvents(engine)
+
+	id := placeLimit(engine, matching.Buy, 9800, 5)
+	engine.CancelOrder(id)
+	engine.CancelOrder(id) // Not found, so no book change
+
+	var seqs []uint64
+	for _, events := range *batches {
+		for _, event := range eventsOfType(events, matching.BookEvent) {
+			seqs = append(seqs, event.Book.Seq)
+		}
+	}
+	if want := []uint64{snapshot.Seq + 1, snapshot.Seq + 2}; !reflect.DeepEqual(seqs, want) {
+		t.Errorf("Book sequences %v, want %v", seqs, want)
+	}
+}
+
+// TestEventsUnsubscribe tests that an unsubscribed handler receives nothing further
+func TestEventsUnsubscribe(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+
+	calls := 0
+	unsubscribe := engine.Subscribe(func(events []matching.Event) { calls++ })
+	placeLimit(engine, matching.Buy, 9900, 10)
+	unsubscribe()
+	placeLimit(engine, matching.Buy, 9900, 10)
+
+	if calls != 1 {
+		t.Errorf("Handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,238 @@
+package matching
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func newUserLimit(engine *matching.Engine, userID string, side matching.SideType, price matching.Price, size int) *matching.Order {
+	return matching.NewOrder(engine.GenerateOrderID(), userID, matching.LimitOrder, side, price, size)
+}
+
+func checkRejected(t *testing.T, err error, reason matching.RejectReason) {
+	t.Helper()
+	var rejectErr *matching.RejectError
+	if !errors.As(err, &rejectErr) || rejectErr.Reason != reason {
+		t.Errorf("Error = %v, want rejection for %v", err, reason)
+	}
+}
+
+// TestPostOnlyRejectsCrossing tests that a crossing post-only order is rejected without trading
+func TestPostOnlyRejectsCrossing(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	ask := placeLimit(engine, matching.Sell, 10100, 10)
+
+	order := newUserLimit(engine, "user2", matching.Buy, 10100, 5)
+	order.PostOnly = matching.PostOnlyReject
+	trades, err := engine.SubmitOrder(order)
+	if len(trades) != 0 {
+		t.Errorf("Post-only order produced %d trades", len(trades))
+	}
+	checkRejected(t, err, matching.RejectPostOnly)
+
+	rejected := checkOrderStatus(t, engine, order.ID, matching.StatusRejected, 0)
+	if rejected.RejectReason != matching.RejectPostOnly {
+		t.Errorf("Reject reason %v, want %v", rejected.RejectReason, matching.RejectPostOnly)
+	}
+	checkOrderStatus(t, engine, ask, matching.StatusNew, 0)
+
+	// Behind the best ask it rests as usual
+	passive := newUserLimit(engine, "user2", matching.Buy, 10000, 5)
+	passive.PostOnly = matching.PostOnlyReject
+	if _, err := engine.SubmitOrder(passive); err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+	if bestBid, _ := engine.GetOrderBook().GetBestBid(); bestBid != 10000 {
+		t.Errorf("Best bid %d, want 10000", bestBid)
+	}
+}
+
+// TestPostOnlySlide tests that a crossing slide order rests one tick behind the opposite best
+func TestPostOnlySlide(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeLimit(engine, matching.Sell, 10100, 10)
+
+	order := newUserLimit(engine, "user2", matching.Buy, 10300, 5)
+	order.PostOnly = matching.PostOnlySlide
+	trades, err := engine.SubmitOrder(order)
+	if err != nil || len(trades) != 0 {
+		t.Fatalf("SubmitOrder() = %d trades, %v, want none", len(trades), err)
+	}
+
+	resting := engine.GetOrder(order.ID)
+	if resting.Price != 10099 || resting.Status != matching.StatusNew {
+		t.Errorf("Slid order is %v at %d, want new at 10099", resting.Status, resting.Price)
+	}
+	if got := bidQueue(engine, 10099); len(got) != 1 || got[0] != order.ID {
+		t.Errorf("Queue at 10099 %v, want [%d]", got, order.ID)
+	}
+}
+
+// TestPostOnlyAmendCrossing tests that a post-only order cannot be amended through the spread
+func TestPostOnlyAmendCrossing(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeLimit(engine, matching.Sell, 10100, 10)
+
+	order := newUserLimit(engine, "user2", matching.Buy, 10000, 5)
+	order.PostOnly = matching.PostOnlyReject
+	engine.PlaceOrder(order)
+
+	_, err := engine.AmendOrder(order.ID, 10100, 0)
+	checkRejected(t, err, matching.RejectPostOnly)
+	if resting := engine.GetOrder(order.ID); resting.Price != 10000 || resting.Status != matching.StatusNew {
+		t.Errorf("Rejected amend left the order %v at %d, want new at 10000", resting.Status, resting.Price)
+	}
+}
+
+// TestReduceOnlyWithoutPosition tests that a reduce-only order with nothing to reduce is rejected
+func TestReduceOnlyWithoutPosition(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 10)
+
+	order := newUserLimit(engine, "user2", matching.Sell, 10000, 5)
+	order.ReduceOnly = true
+	_, err := engine.SubmitOrder(order)
+	checkRejected(t, err, matching.RejectReduceOnly)
+	checkOrderStatus(t, engine, order.ID, matching.StatusRejected, 0)
+}
+
+// TestReduceOnlyClippedToPosition tests that a reduce-only order never flips the position
+func TestReduceOnlyClippedToPosition(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 6))
+	engine.PlaceOrder(newUserLimit(engine, "user1", matching.Buy, 10000, 6))
+	if position := engine.GetPosition("user1", matching.DefaultSymbol); position != 6 {
+		t.Fatalf("Position %d, want 6", position)
+	}
+
+	engine.PlaceOrder(newUserLimit(engine, "user3", matching.Buy, 9900, 20))
+
+	order := newUserLimit(engine, "user1", matching.Sell, 9900, 10)
+	order.ReduceOnly = true
+	trades, err := engine.SubmitOrder(order)
+	if err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+	if len(trades) != 1 || trades[0].Size != 6 {
+		t.Errorf("Unexpected trades %+v", trades)
+	}
+	if order.OriginalSize != 6 {
+		t.Errorf("Original size %d, want the clipped 6", order.OriginalSize)
+	}
+	checkOrderStatus(t, engine, order.ID, matching.StatusFilled, 6)
+	if position := engine.GetPosition("user1", matching.DefaultSymbol); position != 0 {
+		t.Errorf("Position %d, want 0", position)
+	}
+}
+
+// TestReduceOnlyCancelledWhenFlat tests that a resting reduce-only order is capped at the
+// position left and cancelled once there is nothing to reduce
+func TestReduceOnlyCancelledWhenFlat(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 10))
+	engine.PlaceOrder(newUserLimit(engine, "user1", matching.Buy, 10000, 10))
+
+	reduce := newUserLimit(engine, "user1", matching.Sell, 10200, 10)
+	reduce.ReduceOnly = true
+	engine.PlaceOrder(reduce)
+
+	// The user flattens part of the position elsewhere, leaving 4 to reduce
+	engine.PlaceOrder(newUserLimit(engine, "user3", matching.Buy, 10100, 6))
+	engine.PlaceOrder(newUserLimit(engine, "user1", matching.Sell, 10100, 6))
+
+	trades := engine.PlaceOrder(newUserLimit(engine, "user3", matching.Buy, 10200, 10))
+	if len(trades) != 1 || trades[0].SellOrderID != reduce.ID || trades[0].Size != 4 {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+	order := checkOrderStatus(t, engine, reduce.ID, matching.StatusCancelled, 4)
+	if order.Size != 6 {
+		t.Errorf("Cancelled reduce-only order has %d unfilled, want 6", order.Size)
+	}
+	if position := engine.GetPosition("user1", matching.DefaultSymbol); position != 0 {
+		t.Errorf("Position %d, want 0", position)
+	}
+	if bestBid, _ := engine.GetOrderBook().GetBestBid(); bestBid != 10200 {
+		t.Errorf("Aggressor remainder not resting, best bid %d", bestBid)
+	}
+}
+
+// TestMinQuantity tests that an order is rejected unless its minimum can execute on arrival
+func TestMinQuantity(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10000, 3)
+	placeLimit(engine, matching.Sell, 10100, 3)
+
+	order := newUserLimit(engine, "user2", matching.Buy, 10000, 10)
+	order.MinQuantity = 5
+	_, err := engine.SubmitOrder(order)
+	checkRejected(t, err, matching.RejectMinQuantity)
+	checkOrderStatus(t, engine, order.ID, matching.StatusRejected, 0)
+
+	order = newUserLimit(engine, "user2", matching.Buy, 10100, 10)
+	order.MinQuantity = 5
+	trades, err := engine.SubmitOrder(order)
+	if err != nil || len(trades) != 2 {
+		t.Fatalf("SubmitOrder() = %d trades, %v, want 2 trades", len(trades), err)
+	}
+	checkOrderStatus(t, engine, order.ID, matching.StatusPartiallyFilled, 6)
+}
+
+// TestExecutionInstructionValidation tests the order types each instruction is allowed on
+func TestExecutionInstructionValidation(t *testing.T) {
+	ioc := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 10000, 10)
+	ioc.TimeInForce = matching.ImmediateOrCancel
+	ioc.PostOnly = matching.PostOnlyReject
+
+	market := matching.NewOrder(2, "user1", matching.MarketOrder, matching.Buy, 0, 10)
+	market.PostOnly = matching.PostOnlySlide
+
+	postMin := matching.NewOrder(3, "user1", matching.LimitOrder, matching.Buy, 10000, 10)
+	postMin.PostOnly = matching.PostOnlyReject
+	postMin.MinQuantity = 2
+
+	overMin := matching.NewOrder(4, "user1", matching.LimitOrder, matching.Buy, 10000, 10)
+	overMin.MinQuantity = 11
+
+	stopMin := matching.NewOrder(5, "user1", matching.StopMarketOrder, matching.Buy, 0, 10)
+	stopMin.StopPrice = 10100
+	stopMin.MinQuantity = 2
+
+	for _, order := range []*matching.Order{ioc, market, postMin, overMin, stopMin} {
+		if order.IsValid() {
+			t.Errorf("Order %d should be invalid", order.ID)
+		}
+	}
+
+	reduceMarket := matching.NewOrder(6, "user1", matching.MarketOrder, matching.Sell, 0, 10)
+	reduceMarket.ReduceOnly = true
+	reduceMarket.MinQuantity = 10
+	if !reduceMarket.IsValid() {
+		t.Error("Reduce-only market order with a minimum quantity should be valid")
+	}
+}
+
+// TestPositionsRecovered tests that positions survive a snapshot and journal replay
+func TestPositionsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 10))
+	engine.PlaceOrder(newUserLimit(engine, "user1", matching.Buy, 10000, 4))
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	engine.PlaceOrder(newUserLimit(engine, "user1", matching.Buy, 10000, 3))
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+
+	if position := recovered.GetPosition("user1", matching.DefaultSymbol); position != 7 {
+		t.Errorf("Recovered position %d, want 7", position)
+	}
+	if position := recovered.GetPosition("user2", matching.DefaultSymbol); position != -7 {
+		t.Errorf("Recovered position %d, want -7", position)
+	}
+}
@@ -0,0 +1,152 @@
+package matching
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+var testFeeSchedule = matching.FeeSchedule{
+	Tiers: []matching.FeeTier{
+		{Name: "standard", MinVolume: 0, MakerBps: -1, TakerBps: 5},
+		{Name: "pro", MinVolume: 5, MakerBps: 0, TakerBps: 2},
+		{Name: "market_maker", MinVolume: 1000, MakerBps: -2, TakerBps: 2},
+	},
+	UserTiers: map[string]string{"user3": "market_maker"},
+}
+
+func newFeeEngine(t *testing.T, dir string) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+		cfg.SnapshotDir = filepath.Join(dir, "snapshots")
+		cfg.SnapshotRetain = 2
+		cfg.Fees = map[string]matching.FeeSchedule{matching.DefaultSymbol: testFeeSchedule}
+	})
+}
+
+func checkFees(t *testing.T, trades []*matching.Trade, maker, taker matching.Price) {
+	t.Helper()
+	if len(trades) != 1 || trades[0].MakerFee != maker || trades[0].TakerFee != taker {
+		t.Errorf("Trades %+v, want maker fee %d and taker fee %d", trades, maker, taker)
+	}
+}
+
+// TestFeesMakerTaker tests that the resting order pays the maker rate, rebates included, and the
+// incoming order the taker rate, rounded in the exchange's favour
+func TestFeesMakerTaker(t *testing.T) {
+	engine := newFeeEngine(t, t.TempDir())
+
+	// Notional 10050: the taker pays 5.025 rounded up, the maker earns 1.005 rounded down
+	placeLimit(engine, matching.Sell, 10050, 1)
+	checkFees(t, engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10050, 1)), -1, 6)
+	if recent := engine.GetRecentTrades(1); recent[0].MakerFee != -1 || recent[0].TakerFee != 6 {
+		t.Errorf("Recent trade %+v, want the fees charged", recent[0])
+	}
+}
+
+// TestFeeTiersByVolume tests that users move up a tier once their 30-day volume reaches it, from
+// the trade after the one that reached it
+func TestFeeTiersByVolume(t *testing.T) {
+	engine := newFeeEngine(t, t.TempDir())
+
+	placeLimit(engine, matching.Sell, 10000, 10)
+	checkFees(t, engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 5)), -5, 25)
+	checkFees(t, engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 5)), 0, 10)
+
+	want := matching.FeeInfo{UserID: "user2", Symbol: matching.DefaultSymbol, Tier: testFeeSchedule.Tiers[1], Volume: 10}
+	if info, _ := engine.GetFeeInfo("user2", matching.DefaultSymbol); info != want {
+		t.Errorf("GetFeeInfo() = %+v, want %+v", info, want)
+	}
+	if info, _ := engine.GetFeeInfo("user4", matching.DefaultSymbol); info.Tier.Name != "standard" || info.Volume != 0 {
+		t.Errorf("GetFeeInfo() for a new user = %+v, want standard without volume", info)
+	}
+	if _, ok := engine.GetFeeInfo("user2", "NOPE"); ok {
+		t.Error("GetFeeInfo() should fail for an unknown symbol")
+	}
+}
+
+// TestFeeTierAssigned tests that an assigned tier applies whatever the user's volume
+func TestFeeTierAssigned(t *testing.T) {
+	engine := newFeeEngine(t, t.TempDir())
+
+	engine.PlaceOrder(newUserLimit(engine, "user3", matching.Sell, 10000, 1))
+	checkFees(t, engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 1)), -2, 5)
+
+	if info, _ := engine.GetFeeInfo("user3", matching.DefaultSymbol); !info.Assigned || info.Tier.Name != "market_maker" || info.Volume != 1 {
+		t.Errorf("GetFeeInfo() = %+v, want the assigned market_maker tier", info)
+	}
+}
+
+// TestAuctionFees tests that the later order of an uncross trade pays the taker rate
+func TestAuctionFees(t *testing.T) {
+	engine := newFeeEngine(t, t.TempDir())
+	startAuction(t, engine)
+
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 1))
+	placeLimit(engine, matching.Sell, 10000, 1)
+	_, trades, err := engine.Uncross(matching.DefaultSymbol)
+	if err != nil {
+		t.Fatalf("Uncross() error = %v", err)
+	}
+	checkFees(t, trades, -1, 5)
+	if trades[0].TakerUserID != "user1" {
+		t.Errorf("Taker %s, want the later order's user1", trades[0].TakerUserID)
+	}
+}
+
+// TestFeesRecovered tests that volumes survive a snapshot and journal replay, so recovered trades
+// keep their fees and later trades pay the tier reached
+func TestFeesRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newFeeEngine(t, dir)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 3))
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 2))
+
+	want := engine.GetRecentTrades(0)
+	engine.Close()
+
+	recovered := newFeeEngine(t, dir)
+	got := recovered.GetRecentTrades(0)
+	if len(got) != len(want) {
+		t.Fatalf("Recovered %d trades, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].MakerFee != want[i].MakerFee || got[i].TakerFee != want[i].TakerFee {
+			t.Errorf("Recovered trade %+v, want %+v", got[i], want[i])
+		}
+	}
+	if info, _ := recovered.GetFeeInfo("user2", matching.DefaultSymbol); info.Volume != 5 || info.Tier.Name != "pro" {
+		t.Errorf("Recovered fee info %+v, want the pro tier at volume 5", info)
+	}
+	checkFees(t, recovered.PlaceOrder(newUserLimit(recovered, "user2", matching.Buy, 10000, 1)), 0, 2)
+}
+
+// TestFeeScheduleValidation tests the rules a fee schedule must follow
+func TestFeeScheduleValidation(t *testing.T) {
+	invalid := map[string]matching.FeeSchedule{
+		"no tiers":          {},
+		"first above 0":     {Tiers: []matching.FeeTier{{Name: "a", MinVolume: 10}}},
+		"falling volume":    {Tiers: []matching.FeeTier{{Name: "a"}, {Name: "b", MinVolume: 10}, {Name: "c", MinVolume: 5}}},
+		"duplicate name":    {Tiers: []matching.FeeTier{{Name: "a"}, {Name: "a", MinVolume: 10}}},
+		"net rebate":        {Tiers: []matching.FeeTier{{Name: "a", MakerBps: -3, TakerBps: 2}}},
+		"taker rebate":      {Tiers: []matching.FeeTier{{Name: "a", MakerBps: 3, TakerBps: -1}}},
+		"unknown user tier": {Tiers: []matching.FeeTier{{Name: "a"}}, UserTiers: map[string]string{"user1": "b"}},
+	}
+	for name, schedule := range invalid {
+		if schedule.Validate() == nil {
+			t.Errorf("Schedule with %s should be invalid", name)
+		}
+	}
+	if err := testFeeSchedule.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	free := matching.FeeSchedule{Tiers: []matching.FeeTier{{Name: "free"}}}
+	if err := free.Validate(); err != nil {
+		t.Errorf("Validate() of a single free tier error = %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package matching
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// newGroupOrder builds a user3 order for a group
+func newGroupOrder(engine *matching.Engine, orderType matching.OrderType, side matching.SideType, price matching.Price, stopPrice matching.Price, size int) *matching.Order {
+	order := matching.NewOrder(engine.GenerateOrderID(), "user3", orderType, side, price, size)
+	order.StopPrice = stopPrice
+	return order
+}
+
+// submitBracket submits a buy entry at 10000 with a take-profit at 10500 and a stop-loss at 9500
+func submitBracket(t *testing.T, engine *matching.Engine, size int) (entry, takeProfit, stopLoss *matching.Order) {
+	t.Helper()
+	entry = newGroupOrder(engine, matching.LimitOrder, matching.Buy, 10000, 0, size)
+	takeProfit = newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10500, 0, size)
+	stopLoss = newGroupOrder(engine, matching.StopMarketOrder, matching.Sell, 0, 9500, size)
+	if _, err := engine.SubmitGroup(matching.GroupBracket, []*matching.Order{entry, takeProfit, stopLoss}); err != nil {
+		t.Fatalf("SubmitGroup() error = %v", err)
+	}
+	return entry, takeProfit, stopLoss
+}
+
+func checkRemaining(t *testing.T, engine *matching.Engine, id uint64, size int) {
+	t.Helper()
+	if order := engine.GetOrder(id); order == nil || order.Size != size || order.OriginalSize != order.FilledSize+size {
+		t.Errorf("Order %d is %+v, want %d remaining", id, order, size)
+	}
+}
+
+// TestOCOFillCancelsOther tests that a partial fill on one leg shrinks the other, and that the
+// leg filling completely cancels it
+func TestOCOFillCancelsOther(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	trade(t, engine, 10000)
+
+	takeProfit := newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10200, 0, 5)
+	stopLoss := newGroupOrder(engine, matching.StopMarketOrder, matching.Sell, 0, 9800, 5)
+	if _, err := engine.SubmitGroup(matching.GroupOCO, []*matching.Order{takeProfit, stopLoss}); err != nil {
+		t.Fatalf("SubmitGroup() error = %v", err)
+	}
+	if takeProfit.GroupID != takeProfit.ID || stopLoss.GroupID != takeProfit.ID {
+		t.Errorf("Group IDs %d and %d, want %d", takeProfit.GroupID, stopLoss.GroupID, takeProfit.ID)
+	}
+
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10200, 2))
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusPartiallyFilled, 2)
+	checkRemaining(t, engine, stopLoss.ID, 3)
+
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10200, 5))
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusFilled, 5)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, stopLoss.ID, matching.CancelGroup)
+	if stops := engine.GetTriggerBookForSymbol(matching.DefaultSymbol).GetSellStops(); len(stops) != 0 {
+		t.Errorf("Sell stops %v, want none", stops)
+	}
+}
+
+// TestOCOCancelCancelsOther tests that cancelling one leg cancels the other
+func TestOCOCancelCancelsOther(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	buy := newGroupOrder(engine, matching.LimitOrder, matching.Buy, 9900, 0, 3)
+	sell := newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10100, 0, 3)
+	engine.SubmitGroup(matching.GroupOCO, []*matching.Order{buy, sell})
+
+	engine.CancelOrder(sell.ID)
+	checkOrderStatus(t, engine, buy.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, buy.ID, matching.CancelGroup)
+	if bids := engine.GetOrderBook().GetBidOrders(); len(bids) != 0 {
+		t.Errorf("Bids %v, want none", bids)
+	}
+}
+
+// TestBracketActivatesExits tests that exits are held until the entry fills, then follow its fills
+// and each other's
+func TestBracketActivatesExits(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	trade(t, engine, 10000)
+	entry, takeProfit, stopLoss := submitBracket(t, engine, 10)
+
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusPending, 0)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusPending, 0)
+	if asks := engine.GetOrderBook().GetAskOrders(); len(asks) != 0 {
+		t.Errorf("Asks %v, want the take-profit held", asks)
+	}
+
+	placeLimit(engine, matching.Sell, 10000, 4)
+	checkOrderStatus(t, engine, entry.ID, matching.StatusPartiallyFilled, 4)
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusNew, 0)
+	checkRemaining(t, engine, takeProfit.ID, 4)
+	checkRemaining(t, engine, stopLoss.ID, 4)
+	if stops := engine.GetTriggerBookForSymbol(matching.DefaultSymbol).GetSellStops(); len(stops) != 1 {
+		t.Errorf("Sell stops %v, want the stop-loss", stops)
+	}
+
+	placeLimit(engine, matching.Sell, 10000, 6)
+	checkRemaining(t, engine, takeProfit.ID, 10)
+	checkRemaining(t, engine, stopLoss.ID, 10)
+
+	// Exits share the position, and cannot fill beyond it between them
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10500, 3))
+	checkRemaining(t, engine, takeProfit.ID, 7)
+	checkRemaining(t, engine, stopLoss.ID, 7)
+
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10500, 9))
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusFilled, 10)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, stopLoss.ID, matching.CancelGroup)
+}
+
+// TestBracketEntryEnds tests that an entry ending unfilled cancels its exits, and that exits
+// closing the position cancel a working entry
+func TestBracketEntryEnds(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	entry, takeProfit, stopLoss := submitBracket(t, engine, 5)
+	engine.CancelOrder(entry.ID)
+	for _, id := range []uint64{takeProfit.ID, stopLoss.ID} {
+		checkOrderStatus(t, engine, id, matching.StatusCancelled, 0)
+		checkCancelReason(t, engine, id, matching.CancelGroup)
+	}
+
+	entry, takeProfit, stopLoss = submitBracket(t, engine, 5)
+	placeLimit(engine, matching.Sell, 10000, 2)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10500, 2))
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusFilled, 2)
+	checkOrderStatus(t, engine, entry.ID, matching.StatusCancelled, 2)
+	checkCancelReason(t, engine, entry.ID, matching.CancelGroup)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusCancelled, 0)
+}
+
+// TestBracketExitWaitsForPhase tests that exits filled into a phase that does not accept them
+// stay held until trading resumes
+func TestBracketExitWaitsForPhase(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	if _, err := engine.SetPhase(matching.DefaultSymbol, matching.PhaseClosingAuction); err != nil {
+		t.Fatalf("SetPhase() error = %v", err)
+	}
+	placeLimit(engine, matching.Sell, 10000, 5)
+	entry, takeProfit, stopLoss := submitBracket(t, engine, 5)
+
+	engine.SetPhase(matching.DefaultSymbol, matching.PhaseClosed)
+	checkOrderStatus(t, engine, entry.ID, matching.StatusFilled, 5)
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusPending, 0)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusPending, 0)
+
+	engine.SetPhase(matching.DefaultSymbol, matching.PhaseContinuous)
+	checkRemaining(t, engine, takeProfit.ID, 5)
+	checkOrderStatus(t, engine, takeProfit.ID, matching.StatusNew, 0)
+	checkOrderStatus(t, engine, stopLoss.ID, matching.StatusNew, 0)
+}
+
+// TestGroupValidation tests malformed groups and amends of grouped orders
+func TestGroupValidation(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	limit := func(side matching.SideType, size int) *matching.Order {
+		return newGroupOrder(engine, matching.LimitOrder, side, 10000, 0, size)
+	}
+	market := newGroupOrder(engine, matching.MarketOrder, matching.Sell, 0, 0, 1)
+	other := limit(matching.Sell, 1)
+	other.UserID = "user4"
+	tests := []struct {
+		name   string
+		kind   matching.GroupType
+		orders []*matching.Order
+	}{
+		{"no type", matching.GroupNone, []*matching.Order{limit(matching.Buy, 1), limit(matching.Sell, 1)}},
+		{"one leg", matching.GroupOCO, []*matching.Order{limit(matching.Buy, 1)}},
+		{"unequal legs", matching.GroupOCO, []*matching.Order{limit(matching.Buy, 1), limit(matching.Sell, 2)}},
+		{"market leg", matching.GroupOCO, []*matching.Order{limit(matching.Buy, 1), market}},
+		{"two users", matching.GroupOCO, []*matching.Order{limit(matching.Buy, 1), other}},
+		{"exit on entry side", matching.GroupBracket, []*matching.Order{limit(matching.Buy, 1), limit(matching.Buy, 1)}},
+		{"no exits", matching.GroupBracket, []*matching.Order{limit(matching.Buy, 1)}},
+	}
+	for _, tt := range tests {
+		if _, err := engine.SubmitGroup(tt.kind, tt.orders); !errors.Is(err, matching.ErrInvalidGroup) {
+			t.Errorf("%s: SubmitGroup() error = %v, want %v", tt.name, err, matching.ErrInvalidGroup)
+		}
+	}
+	if orders := engine.GetAllOrders(); len(orders) != 0 {
+		t.Errorf("Invalid groups left orders %v", orders)
+	}
+
+	_, takeProfit, _ := submitBracket(t, engine, 5)
+	if _, err := engine.AmendOrder(takeProfit.ID, 0, 3); !errors.Is(err, matching.ErrAmendGroup) {
+		t.Errorf("AmendOrder() error = %v, want %v", err, matching.ErrAmendGroup)
+	}
+	if _, err := engine.AmendOrder(takeProfit.ID, 10600, 0); err != nil {
+		t.Errorf("AmendOrder() error = %v", err)
+	}
+	if order := checkOrderStatus(t, engine, takeProfit.ID, matching.StatusPending, 0); order.Price != 10600 {
+		t.Errorf("Held exit price %d, want 10600", order.Price)
+	}
+}
+
+// TestGroupsRecovered tests that working groups survive a snapshot and journal replay
+func TestGroupsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	trade(t, engine, 10000)
+	entry, takeProfit, stopLoss := submitBracket(t, engine, 6)
+	placeLimit(engine, matching.Sell, 10000, 2)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	placeLimit(engine, matching.Sell, 10000, 1)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10500, 1))
+	first := newGroupOrder(engine, matching.LimitOrder, matching.Buy, 9000, 0, 4)
+	second := newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10400, 0, 4)
+	engine.SubmitGroup(matching.GroupOCO, []*matching.Order{first, second})
+
+	want := captureEngineState(engine)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+	if got := captureEngineState(recovered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered state %+v, want %+v", got, want)
+	}
+	checkRemaining(t, recovered, takeProfit.ID, 2)
+	checkRemaining(t, recovered, stopLoss.ID, 2)
+
+	// The recovered groups are still linked
+	recovered.PlaceOrder(newUserLimit(recovered, "user2", matching.Buy, 10400, 4))
+	if order := recovered.GetOrder(first.ID); order != nil {
+		t.Errorf("Order %d is %+v, want it cancelled with its pair", first.ID, order)
+	}
+	placeLimit(recovered, matching.Sell, 10000, 3)
+	if order := recovered.GetOrder(entry.ID); order != nil {
+		t.Errorf("Entry is %+v, want it filled", order)
+	}
+	checkRemaining(t, recovered, stopLoss.ID, 5)
+}
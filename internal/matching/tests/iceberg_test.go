@@ -0,0 +1,129 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func placeIceberg(engine *matching.Engine, side matching.SideType, price matching.Price, size int, display int) uint64 {
+	order := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, side, price, size)
+	order.DisplaySize = display
+	engine.PlaceOrder(order)
+	return order.ID
+}
+
+func askQueue(engine *matching.Engine, price matching.Price) []uint64 {
+	var ids []uint64
+	for _, order := range engine.GetOrderBook().GetAsksAtPrice(price) {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
+
+// TestIcebergDisplaysSlice tests that only the visible slice counts in depth and top of book
+func TestIcebergDisplaysSlice(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeIceberg(engine, matching.Sell, 10100, 100, 10)
+	placeLimit(engine, matching.Sell, 10100, 5)
+
+	snapshot, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	want := []matching.BookLevel{{Price: 10100, Quantity: 15, OrderCount: 2}}
+	if !reflect.DeepEqual(snapshot.Asks, want) {
+		t.Errorf("Asks %+v, want %+v", snapshot.Asks, want)
+	}
+	if level := engine.GetOrderBook().GetBestAskLevel(); level.DisplayedSize() != 15 || level.TotalSize() != 105 {
+		t.Errorf("Best ask shows %d of %d, want 15 of 105", level.DisplayedSize(), level.TotalSize())
+	}
+}
+
+// TestIcebergRefreshLosesPriority tests that a refreshed slice joins the back of the queue
+func TestIcebergRefreshLosesPriority(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	iceberg := placeIceberg(engine, matching.Sell, 10100, 30, 10)
+	other := placeLimit(engine, matching.Sell, 10100, 5)
+
+	// Takes the whole visible slice, then the next order in the queue
+	trades := engine.PlaceOrder(matching.NewOrder(engine.GenerateOrderID(), "user2", matching.LimitOrder, matching.Buy, 10100, 12))
+	if len(trades) != 2 || trades[0].SellOrderID != iceberg || trades[0].Size != 10 ||
+		trades[1].SellOrderID != other || trades[1].Size != 2 {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+
+	if got := askQueue(engine, 10100); !reflect.DeepEqual(got, []uint64{other, iceberg}) {
+		t.Errorf("Queue %v, want %v", got, []uint64{other, iceberg})
+	}
+	order := checkOrderStatus(t, engine, iceberg, matching.StatusPartiallyFilled, 10)
+	if order.Size != 20 || order.VisibleSize != 10 {
+		t.Errorf("Iceberg has %d remaining with %d visible, want 20 with 10", order.Size, order.VisibleSize)
+	}
+}
+
+// TestIcebergSweepThroughReserve tests that one aggressor can trade through several refreshes
+func TestIcebergSweepThroughReserve(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	iceberg := placeIceberg(engine, matching.Sell, 10100, 25, 10)
+
+	trades := engine.PlaceOrder(matching.NewOrder(engine.GenerateOrderID(), "user2", matching.MarketOrder, matching.Buy, 0, 25))
+
+	var sizes []int
+	for _, trade := range trades {
+		sizes = append(sizes, trade.Size)
+	}
+	if want := []int{10, 10, 5}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("Trade sizes %v, want %v", sizes, want)
+	}
+
+	order := checkOrderStatus(t, engine, iceberg, matching.StatusFilled, 25)
+	if order.AvgFillPrice() != 10100 {
+		t.Errorf("Average fill price %d, want 10100", order.AvgFillPrice())
+	}
+	if engine.GetOrderBook().GetBestAskLevel() != nil {
+		t.Error("Filled iceberg still rests in the book")
+	}
+}
+
+// TestIcebergHiddenLiquidityFillOrKill tests that fill-or-kill counts hidden reserves
+func TestIcebergHiddenLiquidityFillOrKill(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeIceberg(engine, matching.Sell, 10100, 50, 5)
+
+	fok := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.LimitOrder, matching.Buy, 10100, 40)
+	fok.TimeInForce = matching.FillOrKill
+	if trades := engine.PlaceOrder(fok); len(trades) != 8 {
+		t.Errorf("Fill-or-kill produced %d trades, want 8", len(trades))
+	}
+}
+
+// TestIcebergRestsWithSlice tests that an aggressive iceberg's remainder rests showing one slice
+func TestIcebergRestsWithSlice(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	placeLimit(engine, matching.Sell, 10000, 4)
+
+	id := placeIceberg(engine, matching.Buy, 10000, 50, 10)
+
+	order := engine.GetOrder(id)
+	if order.FilledSize != 4 || order.Size != 46 || order.VisibleSize != 10 {
+		t.Errorf("Iceberg filled %d with %d left and %d visible, want 4, 46 and 10", order.FilledSize, order.Size, order.VisibleSize)
+	}
+	if level := engine.GetOrderBook().GetLevel(matching.Buy, 10000); level.Quantity != 10 {
+		t.Errorf("Displayed bid %d, want 10", level.Quantity)
+	}
+}
+
+// TestIcebergValidation tests that only limit orders may carry a display size
+func TestIcebergValidation(t *testing.T) {
+	limit := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 10000, 10)
+	limit.DisplaySize = 2
+	if !limit.IsValid() {
+		t.Error("Limit iceberg should be valid")
+	}
+
+	market := matching.NewOrder(2, "user1", matching.MarketOrder, matching.Buy, 0, 10)
+	market.DisplaySize = 2
+	if market.IsValid() {
+		t.Error("Market iceberg should be invalid")
+	}
+}
@@ -0,0 +1,130 @@
+package matching
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// TestParsePrice tests exact decimal parsing onto the tick grid
+func TestParsePrice(t *testing.T) {
+	inst, err := matching.NewInstrument("ABCD", 2, "0.05")
+	if err != nil {
+		t.Fatalf("NewInstrument() error = %v", err)
+	}
+	if inst.TickSize != 5 {
+		t.Fatalf("Expected tick size 5, got %d", inst.TickSize)
+	}
+
+	tests := []struct {
+		text    string
+		want    matching.Price
+		wantErr error
+	}{
+		{"100", 10000, nil},
+		{"100.25", 10025, nil},
+		{"0.3", 30, nil},
+		{"0.300", 30, nil},
+		{".05", 5, nil},
+		{"100.03", 0, matching.ErrOffTick},
+		{"100.251", 0, matching.ErrOffTick},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := inst.ParsePrice(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePrice(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePrice(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	for _, text := range []string{"", "abc", "1.2.3", "1e5"} {
+		if _, err := inst.ParsePrice(text); err == nil {
+			t.Errorf("ParsePrice(%q) should fail", text)
+		}
+	}
+}
+
+// TestFormatPrice tests rendering prices at the instrument's precision
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		precision int
+		price     matching.Price
+		want      string
+	}{
+		{2, 10025, "100.25"},
+		{2, 5, "0.05"},
+		{2, 0, "0.00"},
+		{2, -150, "-1.50"},
+		{0, 42, "42"},
+		{4, 12345, "1.2345"},
+	}
+
+	for _, tt := range tests {
+		inst := matching.Instrument{Symbol: "ABCD", Precision: tt.precision, TickSize: 1}
+		if got := inst.FormatPrice(tt.price); got != tt.want {
+			t.Errorf("FormatPrice(%d) at precision %d = %q, want %q", tt.price, tt.precision, got, tt.want)
+		}
+	}
+}
+
+// TestParseAmount tests that amounts are parsed at the precision without the tick check
+func TestParseAmount(t *testing.T) {
+	inst, err := matching.NewInstrument("ABCD", 2, "0.03")
+	if err != nil {
+		t.Fatalf("NewInstrument() error = %v", err)
+	}
+
+	if got, err := inst.ParseAmount("1000"); err != nil || got != 100000 {
+		t.Errorf("ParseAmount(1000) = %d, %v, want 100000", got, err)
+	}
+	if got, err := inst.ParseAmount("10.01"); err != nil || got != 1001 {
+		t.Errorf("ParseAmount(10.01) = %d, %v, want 1001", got, err)
+	}
+	if _, err := inst.ParseAmount("10.001"); err == nil {
+		t.Error("Amount finer than the precision should be rejected")
+	}
+	if _, err := inst.ParsePrice("1000.01"); !errors.Is(err, matching.ErrOffTick) {
+		t.Errorf("ParsePrice(1000.01) error = %v, want ErrOffTick", err)
+	}
+}
+
+// TestNewInstrumentValidation tests rejection of unusable tick sizes and precisions
+func TestNewInstrumentValidation(t *testing.T) {
+	if _, err := matching.NewInstrument("ABCD", 2, "0.001"); err == nil {
+		t.Error("Tick size finer than the precision should be rejected")
+	}
+	if _, err := matching.NewInstrument("ABCD", 2, "0"); err == nil {
+		t.Error("Zero tick size should be rejected")
+	}
+	if _, err := matching.NewInstrument("ABCD", -1, "1"); err == nil {
+		t.Error("Negative precision should be rejected")
+	}
+}
+
+// TestEngineRejectsOffTickOrders tests that the engine ignores orders off the tick grid
+func TestEngineRejectsOffTickOrders(t *testing.T) {
+	inst, _ := matching.NewInstrument("ABCD", 2, "0.05")
+	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
+		TradeHistorySize: 10,
+		TradeLogPath:     t.TempDir() + "/trades.log",
+		Instruments:      []matching.Instrument{inst},
+	})
+	defer engine.Close()
+
+	engine.PlaceOrder(matching.NewOrderWithSymbol(1, "user1", "ABCD", matching.LimitOrder, matching.Sell, 10003, 10))
+	engine.PlaceOrder(matching.NewOrderWithSymbol(2, "user1", "ABCD", matching.LimitOrder, matching.Sell, 10005, 10))
+
+	book := engine.GetOrderBookForSymbol("ABCD")
+	if asks := book.GetAllAsks(); len(asks) != 1 || asks[0] != 10005 {
+		t.Errorf("Expected only the on-tick ask at 10005, got %v", asks)
+	}
+	if engine.GetOrder(1) != nil {
+		t.Error("Off-tick order should not be tracked")
+	}
+}
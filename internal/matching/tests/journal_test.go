@@ -0,0 +1,227 @@
+package matching
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// newTestEngine opens an engine on the default symbol that journals to dir, after tweak adjusts
+// its config, and closes it when the test ends
+func newTestEngine(t *testing.T, dir string, tweak func(cfg *matching.EngineConfig)) *matching.Engine {
+	t.Helper()
+	cfg := &matching.EngineConfig{
+		TradeHistorySize: 100,
+		TradeLogPath:     filepath.Join(dir, "trades.log"),
+		Symbols:          []string{matching.DefaultSymbol},
+		JournalPath:      filepath.Join(dir, "journal.log"),
+		JournalSync:      matching.SyncNever,
+	}
+	if tweak != nil {
+		tweak(cfg)
+	}
+	engine, err := matching.OpenEngine(cfg)
+	if err != nil {
+		t.Fatalf("OpenEngine() error = %v", err)
+	}
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+func newJournaledEngine(t *testing.T, dir string) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, dir, func(cfg *matching.EngineConfig) { cfg.JournalSync = matching.SyncAlways })
+}
+
+func sortedOrders(engine *matching.Engine) []*matching.Order {
+	orders := engine.GetAllOrders()
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders
+}
+
+// TestJournalRecoveryRebuildsState tests that replaying the journal restores books, tracker and IDs
+func TestJournalRecoveryRebuildsState(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+
+	place := func(side matching.SideType, orderType matching.OrderType, price matching.Price, size int) uint64 {
+		order := matching.NewOrder(engine.GenerateOrderID(), "user1", orderType, side, price, size)
+		if orderType == matching.StopMarketOrder {
+			order.StopPrice = price
+			order.Price = 0
+		}
+		if _, err := engine.SubmitOrder(order); err != nil {
+			t.Fatalf("SubmitOrder() error = %v", err)
+		}
+		return order.ID
+	}
+
+	place(matching.Sell, matching.LimitOrder, 10100, 10)
+	place(matching.Sell, matching.LimitOrder, 10200, 5)
+	cancelled := place(matching.Sell, matching.LimitOrder, 10300, 7)
+	place(matching.Buy, matching.LimitOrder, 9900, 8)
+	place(matching.Buy, matching.MarketOrder, 0, 4)              // Partially fills the 10100 ask
+	place(matching.Sell, matching.StopMarketOrder, 9000, 3)      // Rests in the trigger book
+	lastID := place(matching.Buy, matching.LimitOrder, 10100, 6) // Takes the rest of 10100
+	if ok, err := engine.SubmitCancel(cancelled); !ok || err != nil {
+		t.Fatalf("SubmitCancel() = %v, %v", ok, err)
+	}
+
+	wantBook, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	wantOrders := sortedOrders(engine)
+	wantTrades := engine.GetRecentTrades(100)
+	engine.Close()
+
+	recovered := newJournaledEngine(t, dir)
+	defer recovered.Close()
+
+	gotBook, _ := recovered.GetBookSnapshot(matching.DefaultSymbol, 0)
+	if !reflect.DeepEqual(gotBook, wantBook) {
+		t.Errorf("Recovered book %+v, want %+v", gotBook, wantBook)
+	}
+
+	gotOrders := sortedOrders(recovered)
+	if len(gotOrders) != len(wantOrders) {
+		t.Fatalf("Recovered %d orders, want %d", len(gotOrders), len(wantOrders))
+	}
+	for i := range wantOrders {
+		if !gotOrders[i].TimeStamp.Equal(wantOrders[i].TimeStamp) {
+			t.Errorf("Order %d timestamp %v, want %v", wantOrders[i].ID, gotOrders[i].TimeStamp, wantOrders[i].TimeStamp)
+		}
+		gotOrders[i].TimeStamp, wantOrders[i].TimeStamp = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(gotOrders[i], wantOrders[i]) {
+			t.Errorf("Recovered order %+v, want %+v", gotOrders[i], wantOrders[i])
+		}
+	}
+
+	if gotTrades := recovered.GetRecentTrades(100); len(gotTrades) != len(wantTrades) {
+		t.Errorf("Recovered %d trades, want %d", len(gotTrades), len(wantTrades))
+	}
+	if id := recovered.GenerateOrderID(); id <= lastID {
+		t.Errorf("Order ID %d reissued after recovery", id)
+	}
+}
+
+// TestJournalTruncatesTornTail tests that a partial last entry is dropped and appends continue
+func TestJournalTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+	engine.PlaceOrder(matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 9900, 10))
+	engine.Close()
+
+	// Simulate a crash mid-write
+	file, err := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"seq":2,"type":"place","ord`)
+	file.Close()
+
+	engine = newJournaledEngine(t, dir)
+	if orders := engine.GetAllOrders(); len(orders) != 1 {
+		t.Fatalf("Expected 1 recovered order, got %d", len(orders))
+	}
+	engine.PlaceOrder(matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 9800, 10))
+	engine.Close()
+
+	engine = newJournaledEngine(t, dir)
+	defer engine.Close()
+	if orders := engine.GetAllOrders(); len(orders) != 2 {
+		t.Errorf("Expected 2 recovered orders after appending past a torn tail, got %d", len(orders))
+	}
+}
+
+// TestJournalSkipsRejectedOrders tests that only orders the engine accepts are journaled
+func TestJournalSkipsRejectedOrders(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+	engine.PlaceOrder(matching.NewOrderWithSymbol(1, "user1", "UNKNOWN", matching.LimitOrder, matching.Buy, 9900, 10))
+	engine.CancelOrder(42)
+	engine.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "journal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected empty journal, got %q", data)
+	}
+}
+
+// TestSubmitAfterClose tests that a closed engine reports ErrEngineClosed
+func TestSubmitAfterClose(t *testing.T) {
+	engine := newJournaledEngine(t, t.TempDir())
+	engine.Close()
+
+	if _, err := engine.SubmitOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 9900, 10)); !errors.Is(err, matching.ErrEngineClosed) {
+		t.Errorf("SubmitOrder() error = %v, want ErrEngineClosed", err)
+	}
+	if _, err := engine.SubmitCancel(1); !errors.Is(err, matching.ErrEngineClosed) {
+		t.Errorf("SubmitCancel() error = %v, want ErrEngineClosed", err)
+	}
+}
+
+// TestCloseWhileSubmitting tests that Close waits for the sequencer, so orders accepted while it
+// runs are journaled and later ones report ErrEngineClosed. Run with -race.
+func TestCloseWhileSubmitting(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+
+	accepted := make(chan uint64, 400)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				order := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 9900, 1)
+				if _, err := engine.SubmitOrder(order); err != nil {
+					if !errors.Is(err, matching.ErrEngineClosed) {
+						t.Errorf("SubmitOrder() error = %v, want nil or ErrEngineClosed", err)
+					}
+					return
+				}
+				accepted <- order.ID
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	engine.Close()
+	wg.Wait()
+	close(accepted)
+
+	recovered := newJournaledEngine(t, dir)
+	for id := range accepted {
+		if recovered.GetOrder(id) == nil {
+			t.Errorf("Accepted order %d not recovered", id)
+		}
+	}
+}
+
+// TestParseSyncPolicy tests journal sync policy names
+func TestParseSyncPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    matching.SyncPolicy
+		wantErr bool
+	}{
+		{"always", matching.SyncAlways, false},
+		{"interval", matching.SyncInterval, false},
+		{"never", matching.SyncNever, false},
+		{"sometimes", matching.SyncAlways, true},
+	}
+
+	for _, tt := range tests {
+		got, err := matching.ParseSyncPolicy(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSyncPolicy(%q) = %v, %v", tt.value, got, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package matching
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func newRetainingEngine(t *testing.T, retention time.Duration) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, t.TempDir(), func(cfg *matching.EngineConfig) { cfg.OrderRetention = retention })
+}
+
+func checkOrderStatus(t *testing.T, engine *matching.Engine, id uint64, status matching.OrderStatus, filled int) *matching.Order {
+	t.Helper()
+	order := engine.GetOrder(id)
+	if order == nil {
+		t.Fatalf("Order %d not found", id)
+	}
+	if order.Status != status || order.FilledSize != filled {
+		t.Errorf("Order %d is %v with %d filled, want %v with %d filled", id, order.Status, order.FilledSize, status, filled)
+	}
+	return order
+}
+
+// TestOrderLifecycleFills tests the new, partially filled and filled transitions
+func TestOrderLifecycleFills(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	sell := placeLimit(engine, matching.Sell, 10100, 10)
+	checkOrderStatus(t, engine, sell, matching.StatusNew, 0)
+
+	buy := placeLimit(engine, matching.Buy, 10100, 4)
+	resting := checkOrderStatus(t, engine, sell, matching.StatusPartiallyFilled, 4)
+	if resting.Size != 6 || resting.OriginalSize != 10 {
+		t.Errorf("Resting order has size %d of %d, want 6 of 10", resting.Size, resting.OriginalSize)
+	}
+
+	// The incoming order filled in full and stays queryable
+	filled := checkOrderStatus(t, engine, buy, matching.StatusFilled, 4)
+	if filled.AvgFillPrice() != 10100 || filled.DoneTime.IsZero() {
+		t.Errorf("Filled order has average %d and done time %v", filled.AvgFillPrice(), filled.DoneTime)
+	}
+
+	placeLimit(engine, matching.Buy, 10100, 6)
+	checkOrderStatus(t, engine, sell, matching.StatusFilled, 10)
+	if level := engine.GetOrderBook().GetBestAskLevel(); level != nil {
+		t.Error("Filled order still rests in the book")
+	}
+}
+
+// TestOrderLifecycleAverageAcrossLevels tests the average price of a sweep across several levels
+func TestOrderLifecycleAverageAcrossLevels(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	placeLimit(engine, matching.Sell, 10000, 5)
+	placeLimit(engine, matching.Sell, 10300, 10)
+
+	market := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.MarketOrder, matching.Buy, 0, 10)
+	engine.PlaceOrder(market)
+
+	order := checkOrderStatus(t, engine, market.ID, matching.StatusFilled, 10)
+	if got := order.AvgFillPrice(); got != 10150 {
+		t.Errorf("AvgFillPrice() = %d, want 10150", got)
+	}
+}
+
+// TestOrderLifecycleTerminalStatuses tests how orders that do not fill end
+func TestOrderLifecycleTerminalStatuses(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10100, 3)
+
+	// IOC remainder is cancelled after a partial fill
+	ioc := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 10100, 5)
+	ioc.TimeInForce = matching.ImmediateOrCancel
+	engine.PlaceOrder(ioc)
+	checkOrderStatus(t, engine, ioc.ID, matching.StatusCancelled, 3)
+
+	// FOK that cannot fill is cancelled untouched
+	placeLimit(engine, matching.Sell, 10100, 2)
+	fok := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 10100, 5)
+	fok.TimeInForce = matching.FillOrKill
+	engine.PlaceOrder(fok)
+	checkOrderStatus(t, engine, fok.ID, matching.StatusCancelled, 0)
+
+	// Market order with no liquidity left
+	placeLimit(engine, matching.Buy, 10100, 2)
+	market := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.MarketOrder, matching.Buy, 0, 5)
+	engine.PlaceOrder(market)
+	checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, 0)
+
+	// Explicit cancel
+	resting := placeLimit(engine, matching.Buy, 9900, 5)
+	engine.CancelOrder(resting)
+	checkOrderStatus(t, engine, resting, matching.StatusCancelled, 0)
+	if engine.CancelOrder(resting) {
+		t.Error("Cancelling a terminal order succeeded")
+	}
+
+	// Rejected for an unknown symbol
+	rejected := matching.NewOrderWithSymbol(engine.GenerateOrderID(), "user1", "UNKNOWN", matching.LimitOrder, matching.Buy, 9900, 5)
+	engine.PlaceOrder(rejected)
+	checkOrderStatus(t, engine, rejected.ID, matching.StatusRejected, 0)
+
+	// Expired good-till-date order
+	gtd := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, matching.Buy, 9800, 5)
+	gtd.TimeInForce = matching.GoodTillDate
+	gtd.ExpireTime = time.Now().Add(time.Minute)
+	engine.PlaceOrder(gtd)
+	engine.ExpireOrders(gtd.ExpireTime)
+	checkOrderStatus(t, engine, gtd.ID, matching.StatusExpired, 0)
+}
+
+// TestOrderRetention tests that terminal orders are dropped once the retention window passes
+func TestOrderRetention(t *testing.T) {
+	engine := newRetainingEngine(t, 20*time.Millisecond)
+
+	id := placeLimit(engine, matching.Buy, 9900, 5)
+	engine.CancelOrder(id)
+	if engine.GetOrder(id) == nil {
+		t.Fatal("Cancelled order not retained")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	engine.ExpireOrders(time.Now())
+	if engine.GetOrder(id) != nil {
+		t.Error("Cancelled order retained past the retention window")
+	}
+}
+
+// TestOrderRetentionDisabled tests that terminal orders are forgotten immediately without retention
+func TestOrderRetentionDisabled(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+
+	id := placeLimit(engine, matching.Buy, 9900, 5)
+	engine.CancelOrder(id)
+	if engine.GetOrder(id) != nil {
+		t.Error("Cancelled order retained with retention disabled")
+	}
+	if orders := engine.GetAllOrders(); len(orders) != 0 {
+		t.Errorf("Expected no orders, got %d", len(orders))
+	}
+}
@@ -1,6 +1,7 @@
 package matching
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -60,16 +61,16 @@ func TestNewOrder(t *testing.T) {
 		userId    string
 		orderType matching.OrderType
 		side      matching.SideType
-		price     float64
+		price     matching.Price
 		quantity  int
 	}{
-		{"ValidLimitBuy", 1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10},
-		{"ValidLimitSell", 2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 20},
-		{"ValidMarketBuy", 3, "user_test", matching.MarketOrder, matching.Buy, 0.0, 15},
-		{"ValidMarketSell", 4, "user_test", matching.MarketOrder, matching.Sell, 0.0, 25},
-		{"LargeQuantity", 5, "user_test", matching.LimitOrder, matching.Buy, 99.5, 1000000},
-		{"SmallPrice", 6, "user_test", matching.LimitOrder, matching.Sell, 0.01, 100},
-		{"HighPrice", 7, "user_test", matching.LimitOrder, matching.Buy, 999999.99, 1},
+		{"ValidLimitBuy", 1, "user_test", matching.LimitOrder, matching.Buy, 100, 10},
+		{"ValidLimitSell", 2, "user_test", matching.LimitOrder, matching.Sell, 101, 20},
+		{"ValidMarketBuy", 3, "user_test", matching.MarketOrder, matching.Buy, 0, 15},
+		{"ValidMarketSell", 4, "user_test", matching.MarketOrder, matching.Sell, 0, 25},
+		{"LargeQuantity", 5, "user_test", matching.LimitOrder, matching.Buy, 9950, 1000000},
+		{"SmallPrice", 6, "user_test", matching.LimitOrder, matching.Sell, 1, 100},
+		{"HighPrice", 7, "user_test", matching.LimitOrder, matching.Buy, 99999999, 1},
 	}
 
 	for _, tt := range tests {
@@ -88,7 +89,7 @@ func TestNewOrder(t *testing.T) {
 				t.Errorf("Expected Side %d, got %d", tt.side, order.Side)
 			}
 			if order.Price != tt.price {
-				t.Errorf("Expected Price %f, got %f", tt.price, order.Price)
+				t.Errorf("Expected Price %d, got %d", tt.price, order.Price)
 			}
 			if order.Size != tt.quantity {
 				t.Errorf("Expected Size %d, got %d", tt.quantity, order.Size)
@@ -117,7 +118,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        1,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Buy,
-				Price:     100.0,
+				Price:     100,
 				Size:      10,
 			},
 			wantValid: true,
@@ -128,7 +129,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        2,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Sell,
-				Price:     101.0,
+				Price:     101,
 				Size:      20,
 			},
 			wantValid: true,
@@ -139,7 +140,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        3,
 				OrderType: matching.MarketOrder,
 				Side:      matching.Buy,
-				Price:     0.0,
+				Price:     0,
 				Size:      15,
 			},
 			wantValid: true,
@@ -150,7 +151,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        4,
 				OrderType: matching.MarketOrder,
 				Side:      matching.Sell,
-				Price:     0.0,
+				Price:     0,
 				Size:      25,
 			},
 			wantValid: true,
@@ -161,7 +162,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        5,
 				OrderType: matching.NoActionOrder,
 				Side:      matching.Buy,
-				Price:     100.0,
+				Price:     100,
 				Size:      10,
 			},
 			wantValid: false,
@@ -172,7 +173,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        6,
 				OrderType: matching.LimitOrder,
 				Side:      matching.NoActionSide,
-				Price:     100.0,
+				Price:     100,
 				Size:      10,
 			},
 			wantValid: false,
@@ -183,7 +184,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        7,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Buy,
-				Price:     100.0,
+				Price:     100,
 				Size:      0,
 			},
 			wantValid: false,
@@ -194,7 +195,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        8,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Buy,
-				Price:     100.0,
+				Price:     100,
 				Size:      -10,
 			},
 			wantValid: false,
@@ -205,7 +206,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        9,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Buy,
-				Price:     0.0,
+				Price:     0,
 				Size:      10,
 			},
 			wantValid: false,
@@ -216,7 +217,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        10,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Sell,
-				Price:     -50.0,
+				Price:     -50,
 				Size:      10,
 			},
 			wantValid: false,
@@ -227,7 +228,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        11,
 				OrderType: matching.StopMarketOrder,
 				Side:      matching.Buy,
-				StopPrice: 105.0,
+				StopPrice: 105,
 				Size:      10,
 			},
 			wantValid: true,
@@ -238,8 +239,8 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        12,
 				OrderType: matching.StopLimitOrder,
 				Side:      matching.Sell,
-				Price:     100.0,
-				StopPrice: 95.0,
+				Price:     100,
+				StopPrice: 95,
 				Size:      10,
 			},
 			wantValid: true,
@@ -260,7 +261,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        14,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Buy,
-				Price:     0.0001,
+				Price:     1,
 				Size:      10,
 			},
 			wantValid: true,
@@ -271,7 +272,7 @@ func TestOrderIsValid(t *testing.T) {
 				ID:        15,
 				OrderType: matching.LimitOrder,
 				Side:      matching.Sell,
-				Price:     999999999.99,
+				Price:     99999999999,
 				Size:      1,
 			},
 			wantValid: true,
@@ -316,7 +317,7 @@ func TestOrderSetSize(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, tt.initialSize)
+			order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, tt.initialSize)
 			order.SetSize(tt.newSize)
 
 			if order.Size != tt.expectedSize {
@@ -328,7 +329,7 @@ func TestOrderSetSize(t *testing.T) {
 
 // TestOrderImmutability tests that order fields can be accessed
 func TestOrderFieldAccess(t *testing.T) {
-	order := matching.NewOrder(12345, "user_test", matching.LimitOrder, matching.Buy, 99.99, 100)
+	order := matching.NewOrder(12345, "user_test", matching.LimitOrder, matching.Buy, 9999, 100)
 
 	// Test all fields are accessible
 	if order.ID != 12345 {
@@ -343,8 +344,8 @@ func TestOrderFieldAccess(t *testing.T) {
 	if order.Side != matching.Buy {
 		t.Errorf("Expected Side Buy, got %d", order.Side)
 	}
-	if order.Price != 99.99 {
-		t.Errorf("Expected Price 99.99, got %f", order.Price)
+	if order.Price != 9999 {
+		t.Errorf("Expected Price 9999, got %d", order.Price)
 	}
 	if order.Size != 100 {
 		t.Errorf("Expected Size 100, got %d", order.Size)
@@ -356,19 +357,19 @@ func TestOrderFieldAccess(t *testing.T) {
 
 // TestOrderModification tests that orders can be modified after creation
 func TestOrderModification(t *testing.T) {
-	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 50)
+	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 50)
 
 	// Modify fields
-	order.Price = 105.0
-	order.StopPrice = 95.0
+	order.Price = 105
+	order.StopPrice = 95
 	order.Side = matching.Sell
 	order.OrderType = matching.StopLimitOrder
 
-	if order.Price != 105.0 {
-		t.Errorf("Expected modified Price 105.0, got %f", order.Price)
+	if order.Price != 105 {
+		t.Errorf("Expected modified Price 105, got %d", order.Price)
 	}
-	if order.StopPrice != 95.0 {
-		t.Errorf("Expected StopPrice 95.0, got %f", order.StopPrice)
+	if order.StopPrice != 95 {
+		t.Errorf("Expected StopPrice 95, got %d", order.StopPrice)
 	}
 	if order.Side != matching.Sell {
 		t.Errorf("Expected modified Side Sell, got %d", order.Side)
@@ -385,7 +386,7 @@ func TestOrderConcurrentCreation(t *testing.T) {
 
 	for i := 0; i < numOrders; i++ {
 		go func(id uint64) {
-			order := matching.NewOrder(id, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+			order := matching.NewOrder(id, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 			if order.ID != id {
 				t.Errorf("Expected ID %d, got %d", id, order.ID)
 			}
@@ -404,10 +405,10 @@ func TestOrderTimestampAccuracy(t *testing.T) {
 	before := time.Now()
 	time.Sleep(1 * time.Millisecond) // Small delay to ensure timestamp is different
 
-	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 
 	time.Sleep(1 * time.Millisecond)
-	order2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10)
+	order2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10)
 
 	time.Sleep(1 * time.Millisecond)
 	after := time.Now()
@@ -426,7 +427,7 @@ func TestOrderTimestampAccuracy(t *testing.T) {
 
 // TestOrderWithZeroID tests that orders can have zero ID
 func TestOrderWithZeroID(t *testing.T) {
-	order := matching.NewOrder(0, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order := matching.NewOrder(0, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 
 	if order.ID != 0 {
 		t.Errorf("Expected ID 0, got %d", order.ID)
@@ -440,20 +441,20 @@ func TestOrderWithZeroID(t *testing.T) {
 func TestOrderEdgeCasePrices(t *testing.T) {
 	tests := []struct {
 		name  string
-		price float64
+		price matching.Price
 		valid bool
 	}{
-		{"MaxFloat64", 1.7976931348623157e+308, true},
-		{"VerySmallPositive", 1e-10, true},
-		{"ExactlyOne", 1.0, true},
-		{"LargeRoundNumber", 1000000.0, true},
+		{"MaxPrice", math.MaxInt64, true},
+		{"SmallestUnit", 1, true},
+		{"ExactlyOne", 1, true},
+		{"LargeRoundNumber", 1000000, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, tt.price, 10)
 			if order.IsValid() != tt.valid {
-				t.Errorf("Expected IsValid() = %v for price %f", tt.valid, tt.price)
+				t.Errorf("Expected IsValid() = %v for price %d", tt.valid, tt.price)
 			}
 		})
 	}
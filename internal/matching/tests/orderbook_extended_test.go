@@ -11,9 +11,9 @@ func TestGetAllBids(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add bids at different prices
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99.0, 5))
-	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 101.0, 8))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99, 5))
+	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 101, 8))
 
 	prices := ob.GetAllBids()
 
@@ -23,7 +23,7 @@ func TestGetAllBids(t *testing.T) {
 	}
 
 	// Should be sorted descending (highest first)
-	if prices[0] != 101.0 || prices[1] != 100.0 || prices[2] != 99.0 {
+	if prices[0] != 101 || prices[1] != 100 || prices[2] != 99 {
 		t.Errorf("Bids not sorted correctly: %v", prices)
 	}
 }
@@ -33,9 +33,9 @@ func TestGetAllAsks(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add asks at different prices
-	ob.AddAskOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 102.0, 10))
-	ob.AddAskOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101.0, 5))
-	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 103.0, 8))
+	ob.AddAskOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 102, 10))
+	ob.AddAskOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101, 5))
+	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 103, 8))
 
 	prices := ob.GetAllAsks()
 
@@ -45,7 +45,7 @@ func TestGetAllAsks(t *testing.T) {
 	}
 
 	// Should be sorted ascending (lowest first)
-	if prices[0] != 101.0 || prices[1] != 102.0 || prices[2] != 103.0 {
+	if prices[0] != 101 || prices[1] != 102 || prices[2] != 103 {
 		t.Errorf("Asks not sorted correctly: %v", prices)
 	}
 }
@@ -55,14 +55,14 @@ func TestGetBidsAtPrice(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add multiple bids at same price
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100.0, 5))
-	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 99.0, 8))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100, 5))
+	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 99, 8))
 
-	// Get bids at 100.0
-	orders := ob.GetBidsAtPrice(100.0)
+	// Get bids at 100
+	orders := ob.GetBidsAtPrice(100)
 	if len(orders) != 2 {
-		t.Errorf("Expected 2 orders at 100.0, got %d", len(orders))
+		t.Errorf("Expected 2 orders at 100, got %d", len(orders))
 	}
 
 	// Verify FIFO order
@@ -76,14 +76,14 @@ func TestGetAsksAtPrice(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add multiple asks at same price
-	ob.AddAskOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 101.0, 10))
-	ob.AddAskOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101.0, 5))
-	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 102.0, 8))
+	ob.AddAskOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 101, 10))
+	ob.AddAskOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Sell, 101, 5))
+	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 102, 8))
 
-	// Get asks at 101.0
-	orders := ob.GetAsksAtPrice(101.0)
+	// Get asks at 101
+	orders := ob.GetAsksAtPrice(101)
 	if len(orders) != 2 {
-		t.Errorf("Expected 2 orders at 101.0, got %d", len(orders))
+		t.Errorf("Expected 2 orders at 101, got %d", len(orders))
 	}
 
 	// Verify FIFO order
@@ -112,10 +112,10 @@ func TestEmptyOrderBookPriceLevels(t *testing.T) {
 func TestGetPriceAtNonExistentLevel(t *testing.T) {
 	ob := matching.NewOrderBook()
 
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
 
 	// Query non-existent price
-	orders := ob.GetBidsAtPrice(99.0)
+	orders := ob.GetBidsAtPrice(99)
 	if orders != nil {
 		t.Error("Expected nil for non-existent price level")
 	}
@@ -127,7 +127,7 @@ func TestLargePriceLevelCount(t *testing.T) {
 
 	// Add 1000 different price levels
 	for i := 0; i < 1000; i++ {
-		price := 100.0 + float64(i)*0.01
+		price := 10000 + matching.Price(i)
 		ob.AddBidOrder(matching.NewOrder(uint64(i), "user", matching.LimitOrder, matching.Buy, price, 10))
 	}
 
@@ -151,10 +151,10 @@ func TestMixedPriceLevels(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add mixed orders
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 98.0, 5))
-	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 101.0, 8))
-	ob.AddAskOrder(matching.NewOrder(4, "user4", matching.LimitOrder, matching.Sell, 102.0, 12))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 99, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 98, 5))
+	ob.AddAskOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 101, 8))
+	ob.AddAskOrder(matching.NewOrder(4, "user4", matching.LimitOrder, matching.Sell, 102, 12))
 
 	bidPrices := ob.GetAllBids()
 	askPrices := ob.GetAllAsks()
@@ -168,12 +168,12 @@ func TestMixedPriceLevels(t *testing.T) {
 	}
 
 	// Verify best prices are at expected positions
-	if bidPrices[0] != 99.0 {
-		t.Errorf("Expected best bid 99.0, got %f", bidPrices[0])
+	if bidPrices[0] != 99 {
+		t.Errorf("Expected best bid 99, got %d", bidPrices[0])
 	}
 
-	if askPrices[0] != 101.0 {
-		t.Errorf("Expected best ask 101.0, got %f", askPrices[0])
+	if askPrices[0] != 101 {
+		t.Errorf("Expected best ask 101, got %d", askPrices[0])
 	}
 }
 
@@ -182,8 +182,8 @@ func TestPriceLevelAfterDeletion(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add orders
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99.0, 5))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99, 5))
 
 	// Delete one price level completely
 	ob.DeleteBidOrder(1)
@@ -193,8 +193,8 @@ func TestPriceLevelAfterDeletion(t *testing.T) {
 		t.Errorf("Expected 1 price level after deletion, got %d", len(prices))
 	}
 
-	if prices[0] != 99.0 {
-		t.Errorf("Expected remaining price 99.0, got %f", prices[0])
+	if prices[0] != 99 {
+		t.Errorf("Expected remaining price 99, got %d", prices[0])
 	}
 }
 
@@ -204,10 +204,10 @@ func TestMultipleOrdersAtSamePriceLevel(t *testing.T) {
 
 	// Add 5 orders at same price
 	for i := uint64(1); i <= 5; i++ {
-		ob.AddBidOrder(matching.NewOrder(i, "user", matching.LimitOrder, matching.Buy, 100.0, 10))
+		ob.AddBidOrder(matching.NewOrder(i, "user", matching.LimitOrder, matching.Buy, 100, 10))
 	}
 
-	orders := ob.GetBidsAtPrice(100.0)
+	orders := ob.GetBidsAtPrice(100)
 	if len(orders) != 5 {
 		t.Errorf("Expected 5 orders at price level, got %d", len(orders))
 	}
@@ -226,11 +226,11 @@ func TestPriceLevelQuantityAggregation(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add multiple orders at same price with different sizes
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100.0, 20))
-	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 100.0, 15))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100, 20))
+	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 100, 15))
 
-	orders := ob.GetBidsAtPrice(100.0)
+	orders := ob.GetBidsAtPrice(100)
 
 	// Calculate total quantity
 	totalQty := 0
@@ -248,9 +248,9 @@ func TestPriceLevelStability(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add initial orders
-	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99.0, 10))
-	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 98.0, 10))
+	ob.AddBidOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 99, 10))
+	ob.AddBidOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Buy, 98, 10))
 
 	// Get initial state
 	initialPrices := ob.GetAllBids()
@@ -269,3 +269,71 @@ func TestPriceLevelStability(t *testing.T) {
 		}
 	}
 }
+
+// TestPriceLevelsSortedAfterRandomInserts tests ordering when levels arrive out of order
+func TestPriceLevelsSortedAfterRandomInserts(t *testing.T) {
+	ob := matching.NewOrderBook()
+
+	// Interleave prices so levels are not inserted in sorted order
+	for i := 0; i < 500; i++ {
+		price := 10000 + matching.Price((i*37)%500)
+		ob.AddBidOrder(matching.NewOrder(uint64(i), "user", matching.LimitOrder, matching.Buy, price, 10))
+		ob.AddAskOrder(matching.NewOrder(uint64(i+500), "user", matching.LimitOrder, matching.Sell, price+1000, 10))
+	}
+
+	bids := ob.GetAllBids()
+	asks := ob.GetAllAsks()
+	if len(bids) != 500 || len(asks) != 500 {
+		t.Fatalf("Expected 500 levels per side, got %d bids and %d asks", len(bids), len(asks))
+	}
+	for i := 0; i < len(bids)-1; i++ {
+		if bids[i] <= bids[i+1] {
+			t.Fatalf("Bids not strictly descending at %d: %v, %v", i, bids[i], bids[i+1])
+		}
+		if asks[i] >= asks[i+1] {
+			t.Fatalf("Asks not strictly ascending at %d: %v, %v", i, asks[i], asks[i+1])
+		}
+	}
+
+	bestBid, _ := ob.GetBestBid()
+	bestAsk, _ := ob.GetBestAsk()
+	if bestBid != bids[0] || bestAsk != asks[0] {
+		t.Errorf("Best prices %v/%v do not match level heads %v/%v", bestBid, bestAsk, bids[0], asks[0])
+	}
+}
+
+// TestOrderIndexAfterDeletions tests that the order index stays consistent with the levels
+func TestOrderIndexAfterDeletions(t *testing.T) {
+	ob := matching.NewOrderBook()
+
+	for i := uint64(1); i <= 10; i++ {
+		ob.AddBidOrder(matching.NewOrder(i, "user", matching.LimitOrder, matching.Buy, 100+matching.Price(i%3), 10))
+	}
+
+	// Delete from the middle of a level, then a whole level
+	if !ob.DeleteBidOrder(4) {
+		t.Fatal("Expected order 4 to be deleted")
+	}
+	if !ob.DeleteBidBlock(102) {
+		t.Fatal("Expected level 102 to be deleted")
+	}
+
+	if ob.SearchById(4) != nil {
+		t.Error("Deleted order still found by ID")
+	}
+	if ob.SearchById(2) != nil || ob.SearchById(5) != nil {
+		t.Error("Orders from deleted level still found by ID")
+	}
+	if ob.DeleteAskOrder(1) {
+		t.Error("Bid order should not be deletable from the ask side")
+	}
+	if ob.OrderCount() != 6 {
+		t.Errorf("Expected 6 indexed orders, got %d", ob.OrderCount())
+	}
+
+	// FIFO is preserved around the removed order
+	orders := ob.GetBidsAtPrice(101)
+	if len(orders) != 3 || orders[0].ID != 1 || orders[1].ID != 7 || orders[2].ID != 10 {
+		t.Errorf("Unexpected queue at 101 after deletion: %v", orders)
+	}
+}
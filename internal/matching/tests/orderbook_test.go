@@ -1,7 +1,6 @@
 package matching
 
 import (
-	"math"
 	"testing"
 
 	"github.com/PxPatel/trading-system/internal/matching"
@@ -17,13 +16,13 @@ func TestNewOrderBook(t *testing.T) {
 
 	// Verify empty book
 	bidPrice, bidOrders := ob.GetBestBid()
-	if bidPrice != 0.0 || bidOrders != nil {
-		t.Errorf("Expected empty bids, got price=%f, orders=%v", bidPrice, bidOrders)
+	if bidPrice != 0 || bidOrders != nil {
+		t.Errorf("Expected empty bids, got price=%d, orders=%v", bidPrice, bidOrders)
 	}
 
 	askPrice, askOrders := ob.GetBestAsk()
-	if askPrice != 0.0 || askOrders != nil {
-		t.Errorf("Expected empty asks, got price=%f, orders=%v", askPrice, askOrders)
+	if askPrice != 0 || askOrders != nil {
+		t.Errorf("Expected empty asks, got price=%d, orders=%v", askPrice, askOrders)
 	}
 }
 
@@ -35,10 +34,10 @@ func TestAddBidOrder(t *testing.T) {
 		name  string
 		order *matching.Order
 	}{
-		{"SingleBid", matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)},
-		{"HigherBid", matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101.0, 20)},
-		{"LowerBid", matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 99.0, 15)},
-		{"SamePriceBid", matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Buy, 100.0, 5)},
+		{"SingleBid", matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)},
+		{"HigherBid", matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101, 20)},
+		{"LowerBid", matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 99, 15)},
+		{"SamePriceBid", matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Buy, 100, 5)},
 	}
 
 	for _, tt := range tests {
@@ -68,10 +67,10 @@ func TestAddAskOrder(t *testing.T) {
 		name  string
 		order *matching.Order
 	}{
-		{"SingleAsk", matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10)},
-		{"LowerAsk", matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101.0, 20)},
-		{"HigherAsk", matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103.0, 15)},
-		{"SamePriceAsk", matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 102.0, 5)},
+		{"SingleAsk", matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10)},
+		{"LowerAsk", matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101, 20)},
+		{"HigherAsk", matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103, 15)},
+		{"SamePriceAsk", matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 102, 5)},
 	}
 
 	for _, tt := range tests {
@@ -99,20 +98,20 @@ func TestGetBestBid(t *testing.T) {
 
 	// Empty book
 	price, orders := ob.GetBestBid()
-	if price != 0.0 || orders != nil {
+	if price != 0 || orders != nil {
 		t.Error("Expected empty best bid for empty book")
 	}
 
 	// Add bids at different prices
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101.0, 20))
-	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 99.0, 15))
-	ob.AddBidOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Buy, 101.0, 5)) // Same as highest
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101, 20))
+	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 99, 15))
+	ob.AddBidOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Buy, 101, 5)) // Same as highest
 
-	// Best bid should be 101.0
+	// Best bid should be 101
 	price, orders = ob.GetBestBid()
-	if price != 101.0 {
-		t.Errorf("Expected best bid price 101.0, got %f", price)
+	if price != 101 {
+		t.Errorf("Expected best bid price 101, got %d", price)
 	}
 	if len(orders) != 2 {
 		t.Errorf("Expected 2 orders at best bid, got %d", len(orders))
@@ -125,20 +124,20 @@ func TestGetBestAsk(t *testing.T) {
 
 	// Empty book
 	price, orders := ob.GetBestAsk()
-	if price != 0.0 || orders != nil {
+	if price != 0 || orders != nil {
 		t.Error("Expected empty best ask for empty book")
 	}
 
 	// Add asks at different prices
-	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
-	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101.0, 20))
-	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103.0, 15))
-	ob.AddAskOrder(matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 101.0, 5)) // Same as lowest
+	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
+	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 101, 20))
+	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103, 15))
+	ob.AddAskOrder(matching.NewOrder(13, "user_test", matching.LimitOrder, matching.Sell, 101, 5)) // Same as lowest
 
-	// Best ask should be 101.0
+	// Best ask should be 101
 	price, orders = ob.GetBestAsk()
-	if price != 101.0 {
-		t.Errorf("Expected best ask price 101.0, got %f", price)
+	if price != 101 {
+		t.Errorf("Expected best ask price 101, got %d", price)
 	}
 	if len(orders) != 2 {
 		t.Errorf("Expected 2 orders at best ask, got %d", len(orders))
@@ -156,10 +155,10 @@ func TestSearchById(t *testing.T) {
 	}
 
 	// Add some orders
-	bid1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
-	bid2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101.0, 20)
-	ask1 := matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10)
-	ask2 := matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 103.0, 15)
+	bid1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
+	bid2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 101, 20)
+	ask1 := matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10)
+	ask2 := matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 103, 15)
 
 	ob.AddBidOrder(bid1)
 	ob.AddBidOrder(bid2)
@@ -201,29 +200,29 @@ func TestGetOrdersByPrice(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Test on empty book
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 	if plo.Bids != nil || plo.Asks != nil {
 		t.Error("Expected nil orders for non-existent price level")
 	}
 
-	// Add orders at price 100.0
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100.0, 20))
-	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 100.0, 15))
+	// Add orders at price 100
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100, 20))
+	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 100, 15))
 
-	// Test retrieving orders at 100.0
-	plo = ob.GetOrdersByPrice(100.0)
+	// Test retrieving orders at 100
+	plo = ob.GetOrdersByPrice(100)
 	if len(plo.Bids) != 2 {
-		t.Errorf("Expected 2 bid orders at 100.0, got %d", len(plo.Bids))
+		t.Errorf("Expected 2 bid orders at 100, got %d", len(plo.Bids))
 	}
 	if len(plo.Asks) != 1 {
-		t.Errorf("Expected 1 ask order at 100.0, got %d", len(plo.Asks))
+		t.Errorf("Expected 1 ask order at 100, got %d", len(plo.Asks))
 	}
 
 	// Test retrieving orders at non-existent price
-	plo = ob.GetOrdersByPrice(200.0)
+	plo = ob.GetOrdersByPrice(200)
 	if plo.Bids != nil || plo.Asks != nil {
-		t.Error("Expected nil orders for non-existent price level 200.0")
+		t.Error("Expected nil orders for non-existent price level 200")
 	}
 }
 
@@ -238,9 +237,9 @@ func TestDeleteBidOrder(t *testing.T) {
 	}
 
 	// Add orders
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100.0, 20))
-	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 101.0, 15))
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100, 20))
+	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 101, 15))
 
 	// Delete order 1
 	success = ob.DeleteBidOrder(1)
@@ -255,14 +254,14 @@ func TestDeleteBidOrder(t *testing.T) {
 	}
 
 	// Verify other orders at same price still exist
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 	if len(plo.Bids) != 1 {
-		t.Errorf("Expected 1 bid remaining at 100.0, got %d", len(plo.Bids))
+		t.Errorf("Expected 1 bid remaining at 100, got %d", len(plo.Bids))
 	}
 
 	// Delete last order at a price level
 	ob.DeleteBidOrder(2)
-	plo = ob.GetOrdersByPrice(100.0)
+	plo = ob.GetOrdersByPrice(100)
 	if plo.Bids != nil {
 		t.Error("Expected price level to be removed when all orders deleted")
 	}
@@ -279,9 +278,9 @@ func TestDeleteAskOrder(t *testing.T) {
 	}
 
 	// Add orders
-	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
-	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 102.0, 20))
-	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103.0, 15))
+	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
+	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 102, 20))
+	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 103, 15))
 
 	// Delete order 10
 	success = ob.DeleteAskOrder(10)
@@ -296,14 +295,14 @@ func TestDeleteAskOrder(t *testing.T) {
 	}
 
 	// Verify other orders at same price still exist
-	plo := ob.GetOrdersByPrice(102.0)
+	plo := ob.GetOrdersByPrice(102)
 	if len(plo.Asks) != 1 {
-		t.Errorf("Expected 1 ask remaining at 102.0, got %d", len(plo.Asks))
+		t.Errorf("Expected 1 ask remaining at 102, got %d", len(plo.Asks))
 	}
 
 	// Delete last order at a price level
 	ob.DeleteAskOrder(11)
-	plo = ob.GetOrdersByPrice(102.0)
+	plo = ob.GetOrdersByPrice(102)
 	if plo.Asks != nil {
 		t.Error("Expected price level to be removed when all orders deleted")
 	}
@@ -314,8 +313,8 @@ func TestDeleteOrderById(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add both bid and ask orders
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
 
 	// Delete bid order
 	success := ob.DeleteOrderById(1)
@@ -347,24 +346,24 @@ func TestDeleteBidBlock(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Try to delete non-existent block
-	success := ob.DeleteBidBlock(100.0)
+	success := ob.DeleteBidBlock(100)
 	if success {
 		t.Error("Expected false when deleting non-existent block")
 	}
 
 	// Add multiple orders at same price
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100.0, 20))
-	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 100.0, 15))
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddBidOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100, 20))
+	ob.AddBidOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 100, 15))
 
 	// Delete entire block
-	success = ob.DeleteBidBlock(100.0)
+	success = ob.DeleteBidBlock(100)
 	if !success {
 		t.Error("Expected true when deleting existing block")
 	}
 
 	// Verify all orders at that price are gone
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 	if plo.Bids != nil {
 		t.Error("Expected price level to be completely removed")
 	}
@@ -380,24 +379,24 @@ func TestDeleteAskBlock(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Try to delete non-existent block
-	success := ob.DeleteAskBlock(102.0)
+	success := ob.DeleteAskBlock(102)
 	if success {
 		t.Error("Expected false when deleting non-existent block")
 	}
 
 	// Add multiple orders at same price
-	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102.0, 10))
-	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 102.0, 20))
-	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 102.0, 15))
+	ob.AddAskOrder(matching.NewOrder(10, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
+	ob.AddAskOrder(matching.NewOrder(11, "user_test", matching.LimitOrder, matching.Sell, 102, 20))
+	ob.AddAskOrder(matching.NewOrder(12, "user_test", matching.LimitOrder, matching.Sell, 102, 15))
 
 	// Delete entire block
-	success = ob.DeleteAskBlock(102.0)
+	success = ob.DeleteAskBlock(102)
 	if !success {
 		t.Error("Expected true when deleting existing block")
 	}
 
 	// Verify all orders at that price are gone
-	plo := ob.GetOrdersByPrice(102.0)
+	plo := ob.GetOrdersByPrice(102)
 	if plo.Asks != nil {
 		t.Error("Expected price level to be completely removed")
 	}
@@ -413,16 +412,16 @@ func TestPriceTimePriority(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add orders at same price in sequence
-	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
-	order2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100.0, 20)
-	order3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 100.0, 15)
+	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
+	order2 := matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Buy, 100, 20)
+	order3 := matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Buy, 100, 15)
 
 	ob.AddBidOrder(order1)
 	ob.AddBidOrder(order2)
 	ob.AddBidOrder(order3)
 
 	// Get orders at price level
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 
 	// Verify order sequence (FIFO at price level)
 	if len(plo.Bids) != 3 {
@@ -441,28 +440,28 @@ func TestLargeOrderBook(t *testing.T) {
 
 	// Add 1000 bid orders at different prices
 	for i := 0; i < 1000; i++ {
-		price := 100.0 + float64(i)*0.01
+		price := 10000 + matching.Price(i)
 		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, price, 10))
 	}
 
 	// Add 1000 ask orders at different prices
 	for i := 1000; i < 2000; i++ {
-		price := 110.0 + float64(i-1000)*0.01
+		price := 11000 + matching.Price(i-1000)
 		ob.AddAskOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Sell, price, 10))
 	}
 
 	// Test best bid (should be highest)
 	bidPrice, _ := ob.GetBestBid()
-	expectedBidPrice := 100.0 + 999*0.01
-	if math.Abs(bidPrice-expectedBidPrice) > 0.001 {
-		t.Errorf("Expected best bid ~%f, got %f", expectedBidPrice, bidPrice)
+	expectedBidPrice := matching.Price(10000 + 999)
+	if bidPrice != expectedBidPrice {
+		t.Errorf("Expected best bid %d, got %d", expectedBidPrice, bidPrice)
 	}
 
 	// Test best ask (should be lowest)
 	askPrice, _ := ob.GetBestAsk()
-	expectedAskPrice := 110.0
-	if math.Abs(askPrice-expectedAskPrice) > 0.001 {
-		t.Errorf("Expected best ask ~%f, got %f", expectedAskPrice, askPrice)
+	expectedAskPrice := matching.Price(11000)
+	if askPrice != expectedAskPrice {
+		t.Errorf("Expected best ask %d, got %d", expectedAskPrice, askPrice)
 	}
 
 	// Test search for order in middle
@@ -489,12 +488,12 @@ func TestMultipleOrdersAtSamePrice(t *testing.T) {
 
 	// Add 10 orders at same price
 	for i := 0; i < 10; i++ {
-		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
+		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10))
 	}
 
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 	if len(plo.Bids) != 10 {
-		t.Errorf("Expected 10 orders at price 100.0, got %d", len(plo.Bids))
+		t.Errorf("Expected 10 orders at price 100, got %d", len(plo.Bids))
 	}
 
 	// Delete middle order
@@ -503,7 +502,7 @@ func TestMultipleOrdersAtSamePrice(t *testing.T) {
 		t.Error("Should successfully delete order 5")
 	}
 
-	plo = ob.GetOrdersByPrice(100.0)
+	plo = ob.GetOrdersByPrice(100)
 	if len(plo.Bids) != 9 {
 		t.Errorf("Expected 9 orders after deletion, got %d", len(plo.Bids))
 	}
@@ -520,15 +519,15 @@ func TestMultipleOrdersAtSamePrice(t *testing.T) {
 func TestEdgeCasePrices(t *testing.T) {
 	ob := matching.NewOrderBook()
 
-	// Test very small price
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 0.0001, 10))
+	// Test smallest price unit
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 1, 10))
 	found := ob.SearchById(1)
-	if found == nil || found.Price != 0.0001 {
+	if found == nil || found.Price != 1 {
 		t.Error("Should handle very small prices")
 	}
 
 	// Test very large price
-	largePrice := 999999999.99
+	largePrice := matching.Price(99999999999)
 	ob.AddAskOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, largePrice, 10))
 	found = ob.SearchById(2)
 	if found == nil || found.Price != largePrice {
@@ -541,8 +540,8 @@ func TestCrossedBook(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Create crossed book (this is allowed at orderbook level, engine should handle)
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 102.0, 10))
-	ob.AddAskOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 100.0, 10))
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 102, 10))
+	ob.AddAskOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 100, 10))
 
 	bidPrice, _ := ob.GetBestBid()
 	askPrice, _ := ob.GetBestAsk()
@@ -578,25 +577,25 @@ func TestEmptyBookOperations(t *testing.T) {
 		t.Error("DeleteAskOrder should return false for empty book")
 	}
 
-	if ob.DeleteBidBlock(100.0) {
+	if ob.DeleteBidBlock(100) {
 		t.Error("DeleteBidBlock should return false for empty book")
 	}
 
-	if ob.DeleteAskBlock(100.0) {
+	if ob.DeleteAskBlock(100) {
 		t.Error("DeleteAskBlock should return false for empty book")
 	}
 
 	bidPrice, bidOrders := ob.GetBestBid()
-	if bidPrice != 0.0 || bidOrders != nil {
-		t.Error("GetBestBid should return 0.0 and nil for empty book")
+	if bidPrice != 0 || bidOrders != nil {
+		t.Error("GetBestBid should return 0 and nil for empty book")
 	}
 
 	askPrice, askOrders := ob.GetBestAsk()
-	if askPrice != 0.0 || askOrders != nil {
-		t.Error("GetBestAsk should return 0.0 and nil for empty book")
+	if askPrice != 0 || askOrders != nil {
+		t.Error("GetBestAsk should return 0 and nil for empty book")
 	}
 
-	plo := ob.GetOrdersByPrice(100.0)
+	plo := ob.GetOrdersByPrice(100)
 	if plo.Bids != nil || plo.Asks != nil {
 		t.Error("GetOrdersByPrice should return nil for empty book")
 	}
@@ -607,8 +606,8 @@ func TestDuplicateOrderIDs(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add two orders with same ID at different prices
-	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
-	order2 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 101.0, 20)
+	order1 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
+	order2 := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 101, 20)
 
 	ob.AddBidOrder(order1)
 	ob.AddBidOrder(order2)
@@ -620,8 +619,8 @@ func TestDuplicateOrderIDs(t *testing.T) {
 	}
 
 	// Both orders should exist in book at their respective prices
-	plo100 := ob.GetOrdersByPrice(100.0)
-	plo101 := ob.GetOrdersByPrice(101.0)
+	plo100 := ob.GetOrdersByPrice(100)
+	plo101 := ob.GetOrdersByPrice(101)
 
 	if len(plo100.Bids) != 1 || len(plo101.Bids) != 1 {
 		t.Error("Both orders should exist at their respective prices")
@@ -632,7 +631,7 @@ func TestDuplicateOrderIDs(t *testing.T) {
 func TestModifyOrderInBook(t *testing.T) {
 	ob := matching.NewOrderBook()
 
-	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10)
+	order := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10)
 	ob.AddBidOrder(order)
 
 	// Modify the order directly
@@ -655,7 +654,7 @@ func TestConcurrentAccess(t *testing.T) {
 
 	// Add initial orders
 	for i := 0; i < 100; i++ {
-		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
+		ob.AddBidOrder(matching.NewOrder(uint64(i), "user_test", matching.LimitOrder, matching.Buy, 100, 10))
 	}
 
 	// Note: This is a basic test. For production, proper synchronization would be needed
@@ -680,16 +679,16 @@ func TestSpreadCalculation(t *testing.T) {
 	ob := matching.NewOrderBook()
 
 	// Add orders
-	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100.0, 10))
-	ob.AddAskOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101.0, 10))
+	ob.AddBidOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 100, 10))
+	ob.AddAskOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
 
 	bidPrice, _ := ob.GetBestBid()
 	askPrice, _ := ob.GetBestAsk()
 
 	spread := askPrice - bidPrice
-	expectedSpread := 1.0
+	expectedSpread := matching.Price(1)
 
-	if math.Abs(spread-expectedSpread) > 0.001 {
-		t.Errorf("Expected spread %f, got %f", expectedSpread, spread)
+	if spread != expectedSpread {
+		t.Errorf("Expected spread %d, got %d", expectedSpread, spread)
 	}
 }
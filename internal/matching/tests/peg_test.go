@@ -0,0 +1,247 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// placePeg submits a user3 pegged order for size
+func placePeg(t *testing.T, engine *matching.Engine, side matching.SideType, peg matching.PegType, offset matching.Price, size int) *matching.Order {
+	t.Helper()
+	order := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.LimitOrder, side, 0, size)
+	order.Peg = peg
+	order.PegOffset = offset
+	if _, err := engine.SubmitOrder(order); err != nil {
+		t.Fatalf("SubmitOrder() for a %v peg error = %v", peg, err)
+	}
+	return order
+}
+
+func checkPegPrice(t *testing.T, engine *matching.Engine, id uint64, want matching.Price) {
+	t.Helper()
+	if order := engine.GetOrder(id); order == nil || order.Price != want {
+		t.Errorf("Pegged order %d is %+v, want price %d", id, order, want)
+	}
+}
+
+// TestPegReferences tests the price each peg type rests at, with offsets and on both sides
+func TestPegReferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		side   matching.SideType
+		peg    matching.PegType
+		offset matching.Price
+		want   matching.Price
+	}{
+		{"primary buy", matching.Buy, matching.PegPrimary, 0, 10000},
+		{"primary buy below", matching.Buy, matching.PegPrimary, -20, 9980},
+		{"primary sell", matching.Sell, matching.PegPrimary, 10, 10115},
+		{"market buy", matching.Buy, matching.PegMarket, -30, 10075},
+		{"market sell", matching.Sell, matching.PegMarket, 40, 10040},
+		{"midpoint buy", matching.Buy, matching.PegMidpoint, 0, 10052},
+		{"midpoint sell", matching.Sell, matching.PegMidpoint, 0, 10053},
+		{"midpoint sell above", matching.Sell, matching.PegMidpoint, 5, 10058},
+		{"market buy kept passive", matching.Buy, matching.PegMarket, 0, 10104},
+		{"market sell kept passive", matching.Sell, matching.PegMarket, 0, 10001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRetainingEngine(t, time.Hour)
+			placeLimit(engine, matching.Buy, 10000, 5)
+			placeLimit(engine, matching.Sell, 10105, 5)
+
+			order := placePeg(t, engine, tt.side, tt.peg, tt.offset, 1)
+			checkOrderStatus(t, engine, order.ID, matching.StatusNew, 0)
+			checkPegPrice(t, engine, order.ID, tt.want)
+		})
+	}
+}
+
+// TestPegFollowsBook tests that a peg is repriced as its reference moves, joining the back of
+// the queue at each new price
+func TestPegFollowsBook(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	placeLimit(engine, matching.Sell, 10100, 5)
+	peg := placePeg(t, engine, matching.Buy, matching.PegPrimary, 0, 3)
+
+	better := placeLimit(engine, matching.Buy, 10010, 5)
+	checkPegPrice(t, engine, peg.ID, 10010)
+	if got := bidQueue(engine, 10010); !reflect.DeepEqual(got, []uint64{better, peg.ID}) {
+		t.Errorf("Queue at 10010 is %v, want %v", got, []uint64{better, peg.ID})
+	}
+	if got := bidQueue(engine, 10000); len(got) != 1 {
+		t.Errorf("Queue at 10000 is %v, want only the original bid", got)
+	}
+
+	engine.CancelOrder(better)
+	checkPegPrice(t, engine, peg.ID, 10000)
+
+	// A sell reaching the peg fills it like any resting order
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 8)); len(trades) != 2 {
+		t.Fatalf("Sell produced %d trades, want 2", len(trades))
+	}
+	checkOrderStatus(t, engine, peg.ID, matching.StatusFilled, 3)
+}
+
+// TestPegKeepsPriority tests that a peg whose price does not change keeps its place in the queue
+func TestPegKeepsPriority(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	peg := placePeg(t, engine, matching.Buy, matching.PegPrimary, -10, 1)
+	behind := placeLimit(engine, matching.Buy, 9990, 1)
+
+	placeLimit(engine, matching.Sell, 10100, 5)
+	placeLimit(engine, matching.Buy, 9980, 1)
+	if got := bidQueue(engine, 9990); !reflect.DeepEqual(got, []uint64{peg.ID, behind}) {
+		t.Errorf("Queue at 9990 is %v, want %v", got, []uint64{peg.ID, behind})
+	}
+}
+
+// TestPegCap tests that a peg does not follow its reference beyond its cap
+func TestPegCap(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	peg := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.LimitOrder, matching.Buy, 0, 1)
+	peg.Peg = matching.PegPrimary
+	peg.PegCap = 10005
+	engine.PlaceOrder(peg)
+
+	placeLimit(engine, matching.Buy, 10020, 5)
+	checkPegPrice(t, engine, peg.ID, 10005)
+}
+
+// TestPegsIgnoreEachOther tests that pegs follow only orders that are not pegged
+func TestPegsIgnoreEachOther(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	above := placePeg(t, engine, matching.Buy, matching.PegPrimary, 10, 1)
+	level := placePeg(t, engine, matching.Buy, matching.PegPrimary, 0, 1)
+
+	checkPegPrice(t, engine, above.ID, 10010)
+	checkPegPrice(t, engine, level.ID, 10000)
+}
+
+// TestPegReference tests that a peg needs a reference to enter, and keeps its price when the
+// reference leaves
+func TestPegReference(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	midpoint := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.LimitOrder, matching.Buy, 0, 1)
+	midpoint.Peg = matching.PegMidpoint
+	_, err := engine.SubmitOrder(midpoint)
+	checkRejected(t, err, matching.RejectPegReference)
+	checkOrderStatus(t, engine, midpoint.ID, matching.StatusRejected, 0)
+
+	bid := placeLimit(engine, matching.Buy, 10000, 5)
+	peg := placePeg(t, engine, matching.Buy, matching.PegPrimary, 0, 1)
+	engine.CancelOrder(bid)
+	checkPegPrice(t, engine, peg.ID, 10000)
+	checkOrderStatus(t, engine, peg.ID, matching.StatusNew, 0)
+}
+
+// TestPegAmend tests that a peg's quantity can be amended but not its price
+func TestPegAmend(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	peg := placePeg(t, engine, matching.Buy, matching.PegPrimary, 0, 2)
+
+	if _, err := engine.AmendOrder(peg.ID, 9990, 0); err != matching.ErrAmendPrice {
+		t.Errorf("AmendOrder() price error = %v, want %v", err, matching.ErrAmendPrice)
+	}
+	if _, err := engine.AmendOrder(peg.ID, 0, 4); err != nil {
+		t.Fatalf("AmendOrder() quantity error = %v", err)
+	}
+	placeLimit(engine, matching.Buy, 10010, 5)
+	checkPegPrice(t, engine, peg.ID, 10010)
+}
+
+// TestPegBookEvents tests that a repriced peg moves its quantity between levels in book updates
+// and depth snapshots
+func TestPegBookEvents(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	placePeg(t, engine, matching.Buy, matching.PegPrimary, 0, 3)
+	batches := collectEvents(engine)
+
+	placeLimit(engine, matching.Buy, 10010, 5)
+	books := eventsOfType((*batches)[0], matching.BookEvent)
+	if len(books) != 1 {
+		t.Fatalf("Got %d book events, want 1", len(books))
+	}
+	want := []matching.BookLevel{{Price: 10010, Quantity: 8, OrderCount: 2}, {Price: 10000, Quantity: 5, OrderCount: 1}}
+	if got := books[0].Book.Bids; !reflect.DeepEqual(got, want) {
+		t.Errorf("Book update bids %+v, want %+v", got, want)
+	}
+
+	snapshot, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	if !reflect.DeepEqual(snapshot.Bids, want) {
+		t.Errorf("Snapshot bids %+v, want %+v", snapshot.Bids, want)
+	}
+}
+
+// TestPegAuction tests that pegs cannot enter an auction
+func TestPegAuction(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	startAuction(t, engine)
+
+	peg := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.LimitOrder, matching.Buy, 0, 1)
+	peg.Peg = matching.PegPrimary
+	_, err := engine.SubmitOrder(peg)
+	checkRejected(t, err, matching.RejectAuction)
+}
+
+// TestPegsRecovered tests that pegs keep following the book after a snapshot and journal replay
+func TestPegsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	placeLimit(engine, matching.Buy, 10000, 5)
+	placeLimit(engine, matching.Sell, 10100, 5)
+	early := placePeg(t, engine, matching.Buy, matching.PegMidpoint, 0, 1)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	late := placePeg(t, engine, matching.Sell, matching.PegPrimary, 0, 1)
+	placeLimit(engine, matching.Sell, 10080, 5)
+
+	want := captureEngineState(engine)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+	if got := captureEngineState(recovered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered state %+v, want %+v", got, want)
+	}
+
+	placeLimit(recovered, matching.Sell, 10060, 5)
+	checkPegPrice(t, recovered, early.ID, 10030)
+	checkPegPrice(t, recovered, late.ID, 10060)
+}
+
+// TestPegValidation tests that only limit orders that can rest may be pegged
+func TestPegValidation(t *testing.T) {
+	ioc := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 0, 1)
+	ioc.Peg = matching.PegPrimary
+	ioc.TimeInForce = matching.ImmediateOrCancel
+
+	market := matching.NewOrder(2, "user1", matching.MarketOrder, matching.Buy, 0, 1)
+	market.Peg = matching.PegMarket
+
+	for _, order := range []*matching.Order{ioc, market} {
+		if order.IsValid() {
+			t.Errorf("Order %d should be invalid", order.ID)
+		}
+	}
+
+	midpoint := matching.NewOrder(3, "user1", matching.LimitOrder, matching.Sell, 0, 1)
+	midpoint.Peg = matching.PegMidpoint
+	midpoint.PegOffset = -5
+	if !midpoint.IsValid() {
+		t.Error("Midpoint peg without a price should be valid")
+	}
+}
@@ -0,0 +1,229 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+var testPolicies = []struct {
+	name   string
+	policy matching.MatchingPolicy
+}{
+	{"FIFO", matching.FIFO{}},
+	{"ProRata", matching.ProRata{MinAllocation: 2}},
+	{"ProRataTopOrder", matching.ProRata{MinAllocation: 2, TopOrder: true}},
+	{"ProRataLMM", matching.ProRata{LeadMarketMakers: []string{"mm"}, LMMPercent: 40}},
+}
+
+func newPolicyEngine(t *testing.T, policy matching.MatchingPolicy) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, t.TempDir(), func(cfg *matching.EngineConfig) {
+		cfg.MatchingPolicies = map[string]matching.MatchingPolicy{matching.DefaultSymbol: policy}
+	})
+}
+
+// sizeByPrice sums trade sizes per price, which no policy may change
+func sizeByPrice(trades []*matching.Trade) map[matching.Price]int {
+	sizes := make(map[matching.Price]int)
+	for _, trade := range trades {
+		sizes[trade.Price] += trade.Size
+	}
+	return sizes
+}
+
+// TestPoliciesOnEngineScenarios tests that every policy keeps the price priority results of the
+// engine test scenarios, which only differ in how a level's quantity is shared
+func TestPoliciesOnEngineScenarios(t *testing.T) {
+	for _, tp := range testPolicies {
+		t.Run(tp.name, func(t *testing.T) {
+			t.Run("PricePriority", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 103, 10))
+				engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+				engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 102, 10))
+
+				trades := engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 10))
+				if len(trades) != 1 || trades[0].Price != 101 || trades[0].SellOrderID != 2 {
+					t.Errorf("Unexpected trades %+v", trades)
+				}
+			})
+
+			t.Run("MultipleLevels", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+				engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 102, 15))
+				engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 103, 20))
+
+				trades := engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 40))
+				want := map[matching.Price]int{101: 10, 102: 15, 103: 15}
+				if got := sizeByPrice(trades); !reflect.DeepEqual(got, want) {
+					t.Errorf("Traded %v, want %v", got, want)
+				}
+			})
+
+			t.Run("AggressiveLimitRests", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Buy, 10000, 10))
+				engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 10200, 10))
+				engine.PlaceOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Sell, 10300, 10))
+
+				trades := engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 10250, 15))
+				if len(trades) != 1 || trades[0].Price != 10200 || trades[0].Size != 10 {
+					t.Errorf("Unexpected trades %+v", trades)
+				}
+				if level := engine.GetOrderBook().GetLevel(matching.Buy, 10250); level.Quantity != 5 {
+					t.Errorf("Resting remainder %d, want 5", level.Quantity)
+				}
+			})
+
+			t.Run("SharedLevelSweep", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+				engine.PlaceOrder(matching.NewOrder(2, "mm", matching.LimitOrder, matching.Sell, 101, 30))
+				engine.PlaceOrder(matching.NewOrder(3, "user_test", matching.LimitOrder, matching.Sell, 101, 60))
+				engine.PlaceOrder(matching.NewOrder(4, "user_test", matching.LimitOrder, matching.Sell, 102, 20))
+
+				trades := engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 110))
+				want := map[matching.Price]int{101: 100, 102: 10}
+				if got := sizeByPrice(trades); !reflect.DeepEqual(got, want) {
+					t.Errorf("Traded %v, want %v", got, want)
+				}
+				if level := engine.GetOrderBook().GetLevel(matching.Sell, 102); level.Quantity != 10 {
+					t.Errorf("Remaining ask %d, want 10", level.Quantity)
+				}
+			})
+
+			t.Run("FillOrKill", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				engine.PlaceOrder(matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+				engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 5))
+
+				fok := matching.NewOrder(100, "user_test", matching.LimitOrder, matching.Buy, 101, 20)
+				fok.TimeInForce = matching.FillOrKill
+				if trades := engine.PlaceOrder(fok); len(trades) != 0 {
+					t.Errorf("Fill-or-kill produced %d trades", len(trades))
+				}
+				if level := engine.GetOrderBook().GetLevel(matching.Sell, 101); level.Quantity != 15 {
+					t.Errorf("Ask quantity %d, want 15", level.Quantity)
+				}
+			})
+
+			t.Run("IcebergReserve", func(t *testing.T) {
+				engine := newPolicyEngine(t, tp.policy)
+				iceberg := matching.NewOrder(1, "user_test", matching.LimitOrder, matching.Sell, 101, 50)
+				iceberg.DisplaySize = 5
+				engine.PlaceOrder(iceberg)
+				engine.PlaceOrder(matching.NewOrder(2, "user_test", matching.LimitOrder, matching.Sell, 101, 10))
+
+				trades := engine.PlaceOrder(matching.NewOrder(100, "user_test", matching.MarketOrder, matching.Buy, 0, 40))
+				if got := sizeByPrice(trades); got[101] != 40 {
+					t.Errorf("Traded %d, want 40", got[101])
+				}
+				if level := engine.GetOrderBook().GetBestAskLevel(); level.TotalSize() != 20 {
+					t.Errorf("Ask left %d, want 20", level.TotalSize())
+				}
+			})
+		})
+	}
+}
+
+// TestProRataAllocate tests allocation with rounding, minimum allocation, top order and lead market makers
+func TestProRataAllocate(t *testing.T) {
+	orders := []*matching.Order{
+		matching.NewOrder(1, "user1", matching.LimitOrder, matching.Sell, 101, 60),
+		matching.NewOrder(2, "mm", matching.LimitOrder, matching.Sell, 101, 30),
+		matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 101, 10),
+	}
+	sizes := []int{60, 30, 10}
+
+	tests := []struct {
+		name     string
+		policy   matching.MatchingPolicy
+		quantity int
+		want     []int
+	}{
+		{"FIFO", matching.FIFO{}, 70, []int{60, 0, 0}},
+		{"Proportional", matching.ProRata{}, 50, []int{30, 15, 5}},
+		{"RoundingLeftoverInTimePriority", matching.ProRata{}, 7, []int{5, 2, 0}},
+		{"MinimumAllocation", matching.ProRata{MinAllocation: 3}, 20, []int{14, 6, 0}},
+		{"QuantityAboveLevel", matching.ProRata{}, 500, []int{60, 30, 10}},
+		{"TopOrder", matching.ProRata{TopOrder: true}, 70, []int{60, 8, 2}},
+		{"LeadMarketMaker", matching.ProRata{LeadMarketMakers: []string{"mm"}, LMMPercent: 40}, 50, []int{24, 23, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allocate(orders, sizes, tt.quantity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate(%d) = %v, want %v", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestProRataEngineShares tests that a level is shared by size and the rounding leftover goes to the oldest order
+func TestProRataEngineShares(t *testing.T) {
+	engine := newPolicyEngine(t, matching.ProRata{})
+	engine.PlaceOrder(matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 100, 10))
+	engine.PlaceOrder(matching.NewOrder(2, "user2", matching.LimitOrder, matching.Buy, 100, 20))
+
+	trades := engine.PlaceOrder(matching.NewOrder(3, "user3", matching.LimitOrder, matching.Sell, 100, 10))
+	var fills []int
+	for _, trade := range trades {
+		fills = append(fills, trade.Size)
+	}
+	if want := []int{4, 6}; !reflect.DeepEqual(fills, want) {
+		t.Errorf("Fills %v, want %v", fills, want)
+	}
+	if ids := bidQueue(engine, 100); !reflect.DeepEqual(ids, []uint64{1, 2}) {
+		t.Errorf("Queue %v, want [1 2]", ids)
+	}
+}
+
+// TestNewMatchingPolicy tests building policies from their configured names
+func TestNewMatchingPolicy(t *testing.T) {
+	params := matching.ProRata{MinAllocation: 2, LeadMarketMakers: []string{"mm"}, LMMPercent: 40}
+
+	policy, err := matching.NewMatchingPolicy("pro_rata_top_order", params)
+	if err != nil || !reflect.DeepEqual(policy, matching.ProRata{MinAllocation: 2, TopOrder: true}) {
+		t.Errorf("NewMatchingPolicy() = %+v, %v", policy, err)
+	}
+	if _, err := matching.NewMatchingPolicy("pro_rata_lmm", matching.ProRata{}); err == nil {
+		t.Error("Lead market maker policy without market makers should fail")
+	}
+	if _, err := matching.NewMatchingPolicy("random", params); err == nil {
+		t.Error("Unknown policy should fail")
+	}
+}
+
+// TestProRataReplay tests that pro-rata allocations replay to the same book
+func TestProRataReplay(t *testing.T) {
+	dir := t.TempDir()
+	open := func() *matching.Engine {
+		return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+			cfg.JournalSync = matching.SyncAlways
+			cfg.MatchingPolicies = map[string]matching.MatchingPolicy{matching.DefaultSymbol: matching.ProRata{MinAllocation: 2}}
+		})
+	}
+
+	engine := open()
+	for _, size := range []int{7, 13, 3} {
+		placeLimit(engine, matching.Sell, 10100, size)
+	}
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10100, 11))
+	want := sortedOrders(engine)
+	engine.Close()
+
+	recovered := open()
+	defer recovered.Close()
+	got := sortedOrders(recovered)
+	if len(got) != len(want) {
+		t.Fatalf("Recovered %d orders, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Size != want[i].Size {
+			t.Errorf("Recovered order %d with %d left, want %d with %d", got[i].ID, got[i].Size, want[i].ID, want[i].Size)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package matching
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// newProtectedMarket builds a user2 market order for size
+func newProtectedMarket(engine *matching.Engine, side matching.SideType, size int) *matching.Order {
+	return matching.NewOrder(engine.GenerateOrderID(), "user2", matching.MarketOrder, side, 0, size)
+}
+
+// TestProtectionPrice tests that a market order stops at its worst acceptable price and its
+// remainder is cancelled for it
+func TestProtectionPrice(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10000, 5)
+	placeLimit(engine, matching.Sell, 10010, 5)
+	placeLimit(engine, matching.Sell, 10020, 5)
+
+	market := newProtectedMarket(engine, matching.Buy, 15)
+	market.ProtectionPrice = 10010
+	if trades := engine.PlaceOrder(market); len(trades) != 2 {
+		t.Fatalf("Market order produced %d trades, want 2", len(trades))
+	}
+	order := checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, 10)
+	if order.Size != 5 {
+		t.Errorf("Cancelled remainder is %d, want 5", order.Size)
+	}
+	checkCancelReason(t, engine, market.ID, matching.CancelProtection)
+}
+
+// TestMaxSlippage tests slippage bounds in ticks and basis points from the opposite best, and
+// that the tightest bound wins
+func TestMaxSlippage(t *testing.T) {
+	tests := []struct {
+		name       string
+		protect    func(order *matching.Order)
+		wantFilled int
+	}{
+		{"ticks", func(o *matching.Order) { o.MaxSlippageTicks = 10 }, 10},
+		{"bps", func(o *matching.Order) { o.MaxSlippageBps = 20 }, 15},
+		{"tightest", func(o *matching.Order) { o.MaxSlippageBps = 20; o.ProtectionPrice = 9995 }, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRetainingEngine(t, time.Hour)
+			placeLimit(engine, matching.Buy, 10000, 5)
+			placeLimit(engine, matching.Buy, 9990, 5)
+			placeLimit(engine, matching.Buy, 9980, 5)
+			placeLimit(engine, matching.Buy, 9970, 5)
+
+			market := newProtectedMarket(engine, matching.Sell, 20)
+			tt.protect(market)
+			engine.PlaceOrder(market)
+			checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, tt.wantFilled)
+			checkCancelReason(t, engine, market.ID, matching.CancelProtection)
+		})
+	}
+}
+
+// TestProtectionFilled tests that an order filled inside its protection is not cancelled
+func TestProtectionFilled(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10000, 5)
+
+	market := newProtectedMarket(engine, matching.Buy, 5)
+	market.MaxSlippageTicks = 1
+	engine.PlaceOrder(market)
+	checkOrderStatus(t, engine, market.ID, matching.StatusFilled, 5)
+	checkCancelReason(t, engine, market.ID, matching.CancelNone)
+}
+
+// TestMarketRemainderNoLiquidity tests that a market order's remainder left when the book runs
+// out is cancelled for lack of liquidity
+func TestMarketRemainderNoLiquidity(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10000, 5)
+
+	market := newProtectedMarket(engine, matching.Buy, 8)
+	market.ProtectionPrice = 10100
+	engine.PlaceOrder(market)
+	checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, 5)
+	checkCancelReason(t, engine, market.ID, matching.CancelNoLiquidity)
+}
+
+// TestProtectionMinQuantity tests that liquidity beyond the protection does not count towards a
+// minimum quantity or a fill-or-kill
+func TestProtectionMinQuantity(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Sell, 10000, 5)
+	placeLimit(engine, matching.Sell, 10050, 5)
+
+	minQty := newProtectedMarket(engine, matching.Buy, 10)
+	minQty.MinQuantity = 8
+	minQty.MaxSlippageTicks = 10
+	_, err := engine.SubmitOrder(minQty)
+	checkRejected(t, err, matching.RejectMinQuantity)
+
+	fok := newProtectedMarket(engine, matching.Buy, 10)
+	fok.TimeInForce = matching.FillOrKill
+	fok.ProtectionPrice = 10000
+	if trades := engine.PlaceOrder(fok); len(trades) != 0 {
+		t.Errorf("Fill-or-kill produced %d trades, want none", len(trades))
+	}
+	checkOrderStatus(t, engine, fok.ID, matching.StatusCancelled, 0)
+}
+
+// TestProtectionInsideBand tests that the tighter of the price band and the protection decides
+// the cancel reason
+func TestProtectionInsideBand(t *testing.T) {
+	engine := newBandedEngine(t, t.TempDir(), matching.PriceBands{DynamicBps: 100})
+	trade(t, engine, 10000)
+	placeLimit(engine, matching.Sell, 10050, 5)
+	placeLimit(engine, matching.Sell, 10080, 5)
+	placeLimit(engine, matching.Sell, 10200, 5)
+
+	loose := newProtectedMarket(engine, matching.Buy, 15)
+	loose.ProtectionPrice = 10150
+	engine.PlaceOrder(loose)
+	checkOrderStatus(t, engine, loose.ID, matching.StatusCancelled, 10)
+	checkCancelReason(t, engine, loose.ID, matching.CancelPriceBand)
+
+	placeLimit(engine, matching.Sell, 10050, 5)
+	placeLimit(engine, matching.Sell, 10080, 5)
+	tight := newProtectedMarket(engine, matching.Buy, 15)
+	tight.ProtectionPrice = 10060
+	engine.PlaceOrder(tight)
+	checkOrderStatus(t, engine, tight.ID, matching.StatusCancelled, 5)
+	checkCancelReason(t, engine, tight.ID, matching.CancelProtection)
+}
+
+// TestProtectedStopMarket tests that a triggered stop-market order measures its slippage from
+// the book as it triggers
+func TestProtectedStopMarket(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopMarketOrder, matching.Buy, 0, 10)
+	stop.StopPrice = 10000
+	stop.MaxSlippageTicks = 20
+	if _, err := engine.SubmitOrder(stop); err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+
+	placeLimit(engine, matching.Sell, 10010, 5)
+	placeLimit(engine, matching.Sell, 10040, 5)
+	placeLimit(engine, matching.Sell, 10000, 1)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 1))
+
+	checkOrderStatus(t, engine, stop.ID, matching.StatusCancelled, 5)
+	checkCancelReason(t, engine, stop.ID, matching.CancelProtection)
+}
+
+// TestProtectionValidation tests that protection is only valid on market and stop-market orders
+func TestProtectionValidation(t *testing.T) {
+	limit := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 10000, 1)
+	limit.MaxSlippageTicks = 5
+
+	negative := matching.NewOrder(2, "user1", matching.MarketOrder, matching.Buy, 0, 1)
+	negative.MaxSlippageBps = -1
+
+	for _, order := range []*matching.Order{limit, negative} {
+		if order.IsValid() {
+			t.Errorf("Order %d should be invalid", order.ID)
+		}
+	}
+
+	stop := matching.NewOrder(3, "user1", matching.StopMarketOrder, matching.Sell, 0, 1)
+	stop.StopPrice = 9900
+	stop.ProtectionPrice = 9800
+	if !stop.IsValid() {
+		t.Error("Stop-market order with a protection price should be valid")
+	}
+}
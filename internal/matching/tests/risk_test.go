@@ -0,0 +1,161 @@
+package matching
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func newRiskEngine(t *testing.T, dir string, rules matching.RiskRules) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+		cfg.OrderRetention = time.Hour
+		cfg.Risk = map[string]matching.RiskRules{matching.DefaultSymbol: rules}
+	})
+}
+
+// checkRiskRejected submits an order and checks that it is rejected for a risk limit
+func checkRiskRejected(t *testing.T, engine *matching.Engine, order *matching.Order, reason matching.RejectReason) {
+	t.Helper()
+	trades, err := engine.SubmitOrder(order)
+	checkRejected(t, err, reason)
+	if len(trades) != 0 {
+		t.Errorf("Rejected order traded %+v", trades)
+	}
+	if got := checkOrderStatus(t, engine, order.ID, matching.StatusRejected, 0); got.RejectReason != reason {
+		t.Errorf("Reject reason %v, want %v", got.RejectReason, reason)
+	}
+}
+
+// TestRiskOrderLimits tests the quantity and notional limits of one order, with market orders
+// valued at the touch and stop-market orders at their stop price
+func TestRiskOrderLimits(t *testing.T) {
+	engine := newRiskEngine(t, t.TempDir(), matching.RiskRules{
+		Default: matching.RiskLimits{MaxOrderQuantity: 10, MaxOrderNotional: 60000},
+	})
+
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Buy, 1000, 11), matching.RejectMaxOrderQuantity)
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Buy, 10100, 6), matching.RejectMaxOrderNotional)
+	if _, err := engine.SubmitOrder(newUserLimit(engine, "user2", matching.Buy, 10000, 6)); err != nil {
+		t.Errorf("SubmitOrder() at the notional limit error = %v", err)
+	}
+
+	placeLimit(engine, matching.Sell, 12500, 1)
+	market := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.MarketOrder, matching.Buy, 0, 5)
+	checkRiskRejected(t, engine, market, matching.RejectMaxOrderNotional)
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.StopMarketOrder, matching.Buy, 0, 5)
+	stop.StopPrice = 13000
+	checkRiskRejected(t, engine, stop, matching.RejectMaxOrderNotional)
+}
+
+// TestRiskPriceDeviation tests that limit orders may not be priced too far through the touch,
+// falling back to the order's own side once the other side is empty
+func TestRiskPriceDeviation(t *testing.T) {
+	engine := newRiskEngine(t, t.TempDir(), matching.RiskRules{
+		Default: matching.RiskLimits{MaxPriceDeviationBps: 100},
+	})
+
+	first := newUserLimit(engine, "user2", matching.Buy, 20000, 1)
+	if _, err := engine.SubmitOrder(first); err != nil {
+		t.Errorf("SubmitOrder() into an empty book error = %v", err)
+	}
+	engine.CancelOrder(first.ID)
+
+	placeLimit(engine, matching.Sell, 10000, 1)
+	placeLimit(engine, matching.Buy, 9900, 1)
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Buy, 10101, 1), matching.RejectPriceDeviation)
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Sell, 9800, 1), matching.RejectPriceDeviation)
+
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10100, 1)); len(trades) != 1 {
+		t.Errorf("Buy 100 bps through the ask traded %+v, want one trade", trades)
+	}
+	// With no asks left a buy is checked against the best bid
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Buy, 10000, 1), matching.RejectPriceDeviation)
+}
+
+// TestRiskOpenLimits tests the open order and per-side open notional limits, and that a user's
+// own limits replace the defaults
+func TestRiskOpenLimits(t *testing.T) {
+	engine := newRiskEngine(t, t.TempDir(), matching.RiskRules{
+		Default: matching.RiskLimits{MaxOpenOrders: 2},
+		Users:   map[string]matching.RiskLimits{"user3": {MaxOpenNotional: 30000}},
+	})
+
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 9000, 1))
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 9000, 1))
+	checkRiskRejected(t, engine, newUserLimit(engine, "user2", matching.Sell, 11000, 1), matching.RejectMaxOpenOrders)
+
+	engine.PlaceOrder(newUserLimit(engine, "user3", matching.Buy, 10000, 2))
+	checkRiskRejected(t, engine, newUserLimit(engine, "user3", matching.Buy, 9000, 2), matching.RejectMaxOpenNotional)
+	for i := 0; i < 2; i++ {
+		if _, err := engine.SubmitOrder(newUserLimit(engine, "user3", matching.Sell, 11000, 1)); err != nil {
+			t.Errorf("SubmitOrder() on the other side error = %v", err)
+		}
+	}
+
+	if limits := engine.GetRiskLimits("user3", matching.DefaultSymbol); limits != (matching.RiskLimits{MaxOpenNotional: 30000}) {
+		t.Errorf("GetRiskLimits() = %+v, want user3's own limits", limits)
+	}
+}
+
+// TestRiskAmend tests that amends adding exposure are checked, without counting the order again
+// towards the open orders, and that reductions are not
+func TestRiskAmend(t *testing.T) {
+	engine := newRiskEngine(t, t.TempDir(), matching.RiskRules{
+		Default: matching.RiskLimits{MaxOrderQuantity: 10, MaxOpenOrders: 1, MaxOpenNotional: 50000},
+	})
+
+	bid := newUserLimit(engine, "user2", matching.Buy, 10000, 4)
+	engine.PlaceOrder(bid)
+
+	_, err := engine.AmendOrder(bid.ID, 0, 6)
+	checkRejected(t, err, matching.RejectMaxOpenNotional)
+	_, err = engine.AmendOrder(bid.ID, 0, 11)
+	checkRejected(t, err, matching.RejectMaxOrderQuantity)
+	checkRemaining(t, engine, bid.ID, 4)
+
+	if _, err := engine.AmendOrder(bid.ID, 12000, 4); err != nil {
+		t.Errorf("AmendOrder() within the limits error = %v", err)
+	}
+	if _, err := engine.AmendOrder(bid.ID, 0, 3); err != nil {
+		t.Errorf("AmendOrder() reducing the order error = %v", err)
+	}
+}
+
+// TestRiskGroups tests that a group counts all its orders towards the open orders and is
+// rejected as a whole
+func TestRiskGroups(t *testing.T) {
+	engine := newRiskEngine(t, t.TempDir(), matching.RiskRules{
+		Default: matching.RiskLimits{MaxOpenOrders: 2},
+	})
+
+	entry := newGroupOrder(engine, matching.LimitOrder, matching.Buy, 10000, 0, 1)
+	takeProfit := newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10500, 0, 1)
+	stopLoss := newGroupOrder(engine, matching.StopMarketOrder, matching.Sell, 0, 9500, 1)
+	_, err := engine.SubmitGroup(matching.GroupBracket, []*matching.Order{entry, takeProfit, stopLoss})
+	checkRejected(t, err, matching.RejectMaxOpenOrders)
+	for _, order := range []*matching.Order{entry, takeProfit, stopLoss} {
+		checkOrderStatus(t, engine, order.ID, matching.StatusRejected, 0)
+	}
+
+	sell := newGroupOrder(engine, matching.LimitOrder, matching.Sell, 10500, 0, 1)
+	stop := newGroupOrder(engine, matching.StopMarketOrder, matching.Sell, 0, 9500, 1)
+	if _, err := engine.SubmitGroup(matching.GroupOCO, []*matching.Order{sell, stop}); err != nil {
+		t.Errorf("SubmitGroup() within the limits error = %v", err)
+	}
+}
+
+// TestRiskNotReplayed tests that recovery keeps orders accepted under limits that have since
+// been tightened
+func TestRiskNotReplayed(t *testing.T) {
+	dir := t.TempDir()
+	engine := newRiskEngine(t, dir, matching.RiskRules{Default: matching.RiskLimits{MaxOrderQuantity: 10}})
+	bid := newUserLimit(engine, "user2", matching.Buy, 10000, 8)
+	engine.PlaceOrder(bid)
+	engine.Close()
+
+	recovered := newRiskEngine(t, dir, matching.RiskRules{Default: matching.RiskLimits{MaxOrderQuantity: 5}})
+	checkRemaining(t, recovered, bid.ID, 8)
+	checkRiskRejected(t, recovered, newUserLimit(recovered, "user2", matching.Buy, 10000, 8), matching.RejectMaxOrderQuantity)
+}
@@ -0,0 +1,180 @@
+package matching
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func placeSelfTrade(engine *matching.Engine, side matching.SideType, price matching.Price, size int, mode matching.SelfTradeMode) (*matching.Order, []*matching.Trade) {
+	order := newUserLimit(engine, "user1", side, price, size)
+	order.SelfTradePrevention = mode
+	return order, engine.PlaceOrder(order)
+}
+
+func checkCancelReason(t *testing.T, engine *matching.Engine, id uint64, reason matching.CancelReason) {
+	t.Helper()
+	if order := engine.GetOrder(id); order == nil || order.CancelReason != reason {
+		t.Errorf("Order %d cancel reason %+v, want %v", id, order, reason)
+	}
+}
+
+// TestSelfTradeModes tests each mode against a resting order of the same user behind another user's order
+func TestSelfTradeModes(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          matching.SelfTradeMode
+		restingStatus matching.OrderStatus
+		restingSize   int
+		incoming      matching.OrderStatus
+		filled        int
+		remaining     int
+		cancelled     func(incoming, resting uint64) []uint64
+	}{
+		{"CancelNewest", matching.SelfTradeCancelNewest, matching.StatusNew, 4, matching.StatusCancelled, 3, 7,
+			func(incoming, resting uint64) []uint64 { return []uint64{incoming} }},
+		{"CancelOldest", matching.SelfTradeCancelOldest, matching.StatusCancelled, 0, matching.StatusPartiallyFilled, 3, 7,
+			func(incoming, resting uint64) []uint64 { return []uint64{resting} }},
+		{"CancelBoth", matching.SelfTradeCancelBoth, matching.StatusCancelled, 0, matching.StatusCancelled, 3, 7,
+			func(incoming, resting uint64) []uint64 { return []uint64{resting, incoming} }},
+		{"Decrement", matching.SelfTradeDecrement, matching.StatusCancelled, 0, matching.StatusPartiallyFilled, 3, 3,
+			func(incoming, resting uint64) []uint64 { return []uint64{resting} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRetainingEngine(t, time.Hour)
+			engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 3))
+			resting, _ := placeSelfTrade(engine, matching.Sell, 10000, 4, matching.SelfTradeAllow)
+
+			incoming, trades := placeSelfTrade(engine, matching.Buy, 10000, 10, tt.mode)
+			if len(trades) != 1 || trades[0].Size != 3 || trades[0].SellOrderID == resting.ID {
+				t.Fatalf("Unexpected trades %+v", trades)
+			}
+
+			checkOrderStatus(t, engine, resting.ID, tt.restingStatus, 0)
+			checkOrderStatus(t, engine, incoming.ID, tt.incoming, tt.filled)
+			if order := engine.GetOrder(incoming.ID); order.Size != tt.remaining {
+				t.Errorf("Incoming order has %d remaining, want %d", order.Size, tt.remaining)
+			}
+			if want := tt.cancelled(incoming.ID, resting.ID); !reflect.DeepEqual(incoming.SelfTradeCancelled, want) {
+				t.Errorf("Self-trade cancelled %v, want %v", incoming.SelfTradeCancelled, want)
+			}
+			for _, id := range incoming.SelfTradeCancelled {
+				checkCancelReason(t, engine, id, matching.CancelSelfTrade)
+			}
+			if level := engine.GetOrderBook().GetLevel(matching.Sell, 10000); level.Quantity != tt.restingSize {
+				t.Errorf("Ask quantity %d, want %d", level.Quantity, tt.restingSize)
+			}
+		})
+	}
+}
+
+// TestSelfTradeDecrementResting tests that a smaller incoming order shrinks the resting order in place
+func TestSelfTradeDecrementResting(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	resting, _ := placeSelfTrade(engine, matching.Sell, 10000, 10, matching.SelfTradeAllow)
+	behind := newUserLimit(engine, "user2", matching.Sell, 10000, 5)
+	engine.PlaceOrder(behind)
+
+	incoming, trades := placeSelfTrade(engine, matching.Buy, 10000, 4, matching.SelfTradeDecrement)
+	if len(trades) != 0 {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+
+	checkOrderStatus(t, engine, incoming.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, incoming.ID, matching.CancelSelfTrade)
+	if order := engine.GetOrder(resting.ID); order.Size != 6 || order.Status != matching.StatusNew {
+		t.Errorf("Resting order is %v with %d left, want new with 6", order.Status, order.Size)
+	}
+	if got := askQueue(engine, 10000); !reflect.DeepEqual(got, []uint64{resting.ID, behind.ID}) {
+		t.Errorf("Queue %v, want %v", got, []uint64{resting.ID, behind.ID})
+	}
+}
+
+// TestSelfTradeMarketOrder tests that a market order stopped by self-trade prevention is not
+// finished twice
+func TestSelfTradeMarketOrder(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeSelfTrade(engine, matching.Sell, 10000, 5, matching.SelfTradeAllow)
+
+	market := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.MarketOrder, matching.Buy, 0, 5)
+	market.SelfTradePrevention = matching.SelfTradeCancelNewest
+	if trades := engine.PlaceOrder(market); len(trades) != 0 {
+		t.Fatalf("Unexpected trades %+v", trades)
+	}
+	checkOrderStatus(t, engine, market.ID, matching.StatusCancelled, 0)
+	checkCancelReason(t, engine, market.ID, matching.CancelSelfTrade)
+}
+
+// TestSelfTradeEngineDefault tests that orders without a mode take the engine's, and may opt out
+func TestSelfTradeEngineDefault(t *testing.T) {
+	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
+		TradeHistorySize:    100,
+		TradeLogPath:        filepath.Join(t.TempDir(), "trades.log"),
+		Symbols:             []string{matching.DefaultSymbol},
+		SelfTradePrevention: matching.SelfTradeCancelNewest,
+	})
+	defer engine.Close()
+
+	placeLimit(engine, matching.Sell, 10000, 10)
+	defaulted := newUserLimit(engine, "user1", matching.Buy, 10000, 4)
+	if trades := engine.PlaceOrder(defaulted); len(trades) != 0 {
+		t.Errorf("Defaulted order traded with its own user: %+v", trades)
+	}
+	if defaulted.SelfTradePrevention != matching.SelfTradeCancelNewest {
+		t.Errorf("Order mode %v, want %v", defaulted.SelfTradePrevention, matching.SelfTradeCancelNewest)
+	}
+
+	if _, trades := placeSelfTrade(engine, matching.Buy, 10000, 4, matching.SelfTradeAllow); len(trades) != 1 {
+		t.Errorf("Order allowing self-trades produced %d trades, want 1", len(trades))
+	}
+}
+
+// TestSelfTradeFillOrKill tests that fill-or-kill does not count liquidity behind the user's own order
+func TestSelfTradeFillOrKill(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 5))
+	placeSelfTrade(engine, matching.Sell, 10000, 5, matching.SelfTradeAllow)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10100, 5))
+
+	fok := newUserLimit(engine, "user1", matching.Buy, 10100, 10)
+	fok.TimeInForce = matching.FillOrKill
+	fok.SelfTradePrevention = matching.SelfTradeCancelNewest
+	if trades := engine.PlaceOrder(fok); len(trades) != 0 {
+		t.Errorf("Fill-or-kill behind its own order produced %d trades", len(trades))
+	}
+
+	// Cancelling the resting side instead skips the user's own order
+	fok = newUserLimit(engine, "user1", matching.Buy, 10100, 10)
+	fok.TimeInForce = matching.FillOrKill
+	fok.SelfTradePrevention = matching.SelfTradeCancelOldest
+	if trades := engine.PlaceOrder(fok); len(trades) != 2 {
+		t.Errorf("Fill-or-kill cancelling its own order produced %d trades, want 2", len(trades))
+	}
+}
+
+// TestSelfTradeReplay tests that self-trade prevention replays to the same book
+func TestSelfTradeReplay(t *testing.T) {
+	dir := t.TempDir()
+	engine := newJournaledEngine(t, dir)
+	resting, _ := placeSelfTrade(engine, matching.Sell, 10000, 10, matching.SelfTradeAllow)
+	placeSelfTrade(engine, matching.Buy, 10000, 4, matching.SelfTradeDecrement)
+	engine.PlaceOrder(newUserLimit(engine, "user2", matching.Sell, 10000, 3))
+	placeSelfTrade(engine, matching.Buy, 10000, 8, matching.SelfTradeCancelOldest)
+	wantBook, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	engine.Close()
+
+	recovered := newJournaledEngine(t, dir)
+	defer recovered.Close()
+
+	if gotBook, _ := recovered.GetBookSnapshot(matching.DefaultSymbol, 0); !reflect.DeepEqual(gotBook, wantBook) {
+		t.Errorf("Recovered book %+v, want %+v", gotBook, wantBook)
+	}
+	if recovered.GetOrder(resting.ID) != nil {
+		t.Error("Resting order cancelled by self-trade prevention is working after replay")
+	}
+}
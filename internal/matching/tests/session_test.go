@@ -0,0 +1,297 @@
+package matching
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func setPhase(t *testing.T, engine *matching.Engine, phase matching.TradingPhase) []*matching.Trade {
+	t.Helper()
+	trades, err := engine.SetPhase(matching.DefaultSymbol, phase)
+	if err != nil {
+		t.Fatalf("SetPhase(%v) error = %v", phase, err)
+	}
+	return trades
+}
+
+func checkPhase(t *testing.T, engine *matching.Engine, want matching.TradingPhase) {
+	t.Helper()
+	if phase, _ := engine.GetPhase(matching.DefaultSymbol); phase != want {
+		t.Errorf("Phase %v, want %v", phase, want)
+	}
+}
+
+// TestPhaseOrderTypes tests which order types each phase accepts
+func TestPhaseOrderTypes(t *testing.T) {
+	tests := []struct {
+		phase   matching.TradingPhase
+		allowed []matching.OrderType
+	}{
+		{matching.PhasePreOpen, []matching.OrderType{matching.LimitOrder, matching.StopMarketOrder}},
+		{matching.PhaseOpeningAuction, []matching.OrderType{matching.LimitOrder}},
+		{matching.PhaseContinuous, []matching.OrderType{matching.MarketOrder, matching.LimitOrder, matching.StopMarketOrder}},
+		{matching.PhaseClosingAuction, []matching.OrderType{matching.LimitOrder}},
+		{matching.PhaseHalted, nil},
+		{matching.PhaseReopeningAuction, []matching.OrderType{matching.LimitOrder, matching.StopMarketOrder}},
+		{matching.PhaseClosed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phase.String(), func(t *testing.T) {
+			engine := newRetainingEngine(t, time.Hour)
+			setPhase(t, engine, tt.phase)
+
+			for _, orderType := range []matching.OrderType{matching.MarketOrder, matching.LimitOrder, matching.StopMarketOrder} {
+				order := matching.NewOrder(engine.GenerateOrderID(), "user1", orderType, matching.Buy, 10000, 10)
+				order.StopPrice = 10100
+				_, err := engine.SubmitOrder(order)
+
+				var rejectErr *matching.RejectError
+				rejected := errors.As(err, &rejectErr) && rejectErr.Reason == matching.RejectPhase
+				if want := !tt.phase.AllowsOrderType(orderType); rejected != want {
+					t.Errorf("Order type %d rejected = %v, want %v (error %v)", orderType, rejected, want, err)
+				}
+			}
+			if got := tt.phase.OrderTypes(); len(got) < len(tt.allowed) {
+				t.Errorf("OrderTypes() = %v, want at least %v", got, tt.allowed)
+			}
+			for _, orderType := range tt.allowed {
+				if !tt.phase.AllowsOrderType(orderType) {
+					t.Errorf("Order type %d not allowed", orderType)
+				}
+			}
+		})
+	}
+}
+
+// TestPhaseCancelsAndAmends tests that cancels and amends follow the phase's rules
+func TestPhaseCancelsAndAmends(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	id := placeLimit(engine, matching.Buy, 10000, 10)
+
+	setPhase(t, engine, matching.PhaseOpeningAuction)
+	if _, err := engine.SubmitCancel(id); !errors.Is(err, matching.ErrCancelNotAllowed) {
+		t.Errorf("SubmitCancel() in the opening auction error = %v", err)
+	}
+	_, err := engine.AmendOrder(id, 0, 5)
+	checkRejected(t, err, matching.RejectPhase)
+	checkOrderStatus(t, engine, id, matching.StatusNew, 0)
+
+	// A halt keeps cancels but refuses new terms
+	setPhase(t, engine, matching.PhaseHalted)
+	_, err = engine.AmendOrder(id, 10100, 0)
+	checkRejected(t, err, matching.RejectPhase)
+	if cancelled, err := engine.SubmitCancel(id); !cancelled || err != nil {
+		t.Errorf("SubmitCancel() while halted = %v, %v", cancelled, err)
+	}
+}
+
+// TestPhaseTradingDay tests the auctions run by a day's phases
+func TestPhaseTradingDay(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	setPhase(t, engine, matching.PhasePreOpen)
+	if !engine.InAuction(matching.DefaultSymbol) {
+		t.Fatal("Pre-open did not start an auction")
+	}
+	placeLimit(engine, matching.Sell, 10000, 10)
+	placeLimit(engine, matching.Buy, 10100, 6)
+
+	// The opening auction keeps collecting, and Uncross cannot end it early
+	setPhase(t, engine, matching.PhaseOpeningAuction)
+	if _, _, err := engine.Uncross(matching.DefaultSymbol); !errors.Is(err, matching.ErrNotContinuous) {
+		t.Errorf("Uncross() during the opening auction error = %v", err)
+	}
+
+	trades := setPhase(t, engine, matching.PhaseContinuous)
+	if len(trades) != 1 || trades[0].Price != 10000 || trades[0].Size != 6 {
+		t.Fatalf("Opening uncross trades %+v, want 6 at 10000", trades)
+	}
+	if engine.InAuction(matching.DefaultSymbol) {
+		t.Error("Auction still running in continuous trading")
+	}
+
+	// Stops triggered by the closing uncross wait, since trading does not continue
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user2", matching.StopMarketOrder, matching.Sell, 0, 2)
+	stop.StopPrice = 9900
+	engine.PlaceOrder(stop)
+	placeLimit(engine, matching.Buy, 9900, 10)
+
+	setPhase(t, engine, matching.PhaseClosingAuction)
+	placeLimit(engine, matching.Sell, 9900, 5)
+	trades = setPhase(t, engine, matching.PhaseClosed)
+	if len(trades) != 1 || trades[0].Price != 9900 {
+		t.Fatalf("Closing uncross trades %+v, want one at 9900", trades)
+	}
+	checkOrderStatus(t, engine, stop.ID, matching.StatusNew, 0)
+
+	if err := engine.StartAuction(matching.DefaultSymbol); !errors.Is(err, matching.ErrNotContinuous) {
+		t.Errorf("StartAuction() while closed error = %v", err)
+	}
+}
+
+// TestPhaseHaltFreezesAuction tests that a halt keeps an auction's orders until trading resumes
+func TestPhaseHaltFreezesAuction(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	setPhase(t, engine, matching.PhasePreOpen)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	placeLimit(engine, matching.Buy, 10000, 10)
+
+	if trades := setPhase(t, engine, matching.PhaseHalted); len(trades) != 0 {
+		t.Errorf("Halt produced trades %+v", trades)
+	}
+	if !engine.InAuction(matching.DefaultSymbol) {
+		t.Error("Halt ended the auction")
+	}
+	if trades := setPhase(t, engine, matching.PhaseContinuous); len(trades) != 1 {
+		t.Errorf("Resuming produced %d trades, want 1", len(trades))
+	}
+}
+
+// TestPhaseErrors tests phase changes that do not apply
+func TestPhaseErrors(t *testing.T) {
+	engine := newRetainingEngine(t, 0)
+
+	if _, err := engine.SetPhase("UNKNOWN", matching.PhaseHalted); !errors.Is(err, matching.ErrUnknownSymbol) {
+		t.Errorf("SetPhase() for an unknown symbol error = %v", err)
+	}
+	if _, err := engine.SetPhase(matching.DefaultSymbol, matching.TradingPhase(99)); err == nil {
+		t.Error("SetPhase() accepted an unknown phase")
+	}
+	if _, err := matching.ParseTradingPhase("lunch"); err == nil {
+		t.Error("ParseTradingPhase() accepted an unknown name")
+	}
+	if phase, err := matching.ParseTradingPhase("closing_auction"); err != nil || phase != matching.PhaseClosingAuction {
+		t.Errorf("ParseTradingPhase() = %v, %v", phase, err)
+	}
+	checkPhase(t, engine, matching.PhaseContinuous)
+}
+
+// TestSchedulePhaseAt tests the phase a schedule sets through trading days, other days and holidays
+func TestSchedulePhaseAt(t *testing.T) {
+	location := time.FixedZone("EST", -5*60*60)
+	schedule := &matching.Schedule{
+		Phases: []matching.ScheduledPhase{
+			{Start: 4 * time.Hour, Phase: matching.PhasePreOpen},
+			{Start: 9*time.Hour + 25*time.Minute, Phase: matching.PhaseOpeningAuction},
+			{Start: 9*time.Hour + 30*time.Minute, Phase: matching.PhaseContinuous},
+			{Start: 15*time.Hour + 50*time.Minute, Phase: matching.PhaseClosingAuction},
+			{Start: 16 * time.Hour, Phase: matching.PhaseClosed},
+		},
+		Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Holidays: []time.Time{time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)},
+		Location: location,
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 12, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		time time.Time
+		want matching.TradingPhase
+	}{
+		{at(22, 3, 59), matching.PhaseClosed},
+		{at(22, 4, 0), matching.PhasePreOpen},
+		{at(22, 9, 29), matching.PhaseOpeningAuction},
+		{at(22, 12, 0), matching.PhaseContinuous},
+		{at(22, 15, 55), matching.PhaseClosingAuction},
+		{at(22, 16, 0), matching.PhaseClosed},
+		{at(22, 17, 0).UTC(), matching.PhaseClosed}, // Evaluated in the schedule's time zone
+		{at(22, 14, 30).UTC(), matching.PhaseContinuous},
+		{at(25, 12, 0), matching.PhaseClosed}, // Holiday
+		{at(27, 12, 0), matching.PhaseClosed}, // Saturday
+	}
+	for _, tt := range tests {
+		if got := schedule.PhaseAt(tt.time); got != tt.want {
+			t.Errorf("PhaseAt(%v) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+
+	changes := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{at(22, 3, 0), at(22, 4, 0)},
+		{at(22, 4, 0), at(22, 9, 25)},
+		{at(22, 16, 0), at(23, 0, 0)},
+		{at(31, 18, 0), time.Date(2026, 1, 1, 0, 0, 0, 0, location)},
+	}
+	for _, tt := range changes {
+		if got := schedule.NextChange(tt.after); !got.Equal(tt.want) {
+			t.Errorf("NextChange(%v) = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+}
+
+// TestScheduleOnStartup tests that an engine opens in the scheduled phase, except for halted
+// symbols
+func TestScheduleOnStartup(t *testing.T) {
+	dir := t.TempDir()
+	open := func(schedule *matching.Schedule) *matching.Engine {
+		return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+			cfg.Symbols = []string{matching.DefaultSymbol, "ACME"}
+			cfg.Schedule = schedule
+		})
+	}
+
+	// Pre-open all day, every day
+	engine := open(&matching.Schedule{Phases: []matching.ScheduledPhase{{Phase: matching.PhasePreOpen}}})
+	checkPhase(t, engine, matching.PhasePreOpen)
+	if !engine.InAuction("ACME") {
+		t.Error("Scheduled pre-open did not start an auction")
+	}
+	setPhase(t, engine, matching.PhaseHalted)
+	engine.Close()
+
+	// A holiday today closes everything, halted symbols included
+	today := time.Now()
+	engine = open(&matching.Schedule{
+		Phases:   []matching.ScheduledPhase{{Phase: matching.PhaseContinuous}},
+		Holidays: []time.Time{today},
+	})
+	checkPhase(t, engine, matching.PhaseClosed)
+	engine.Close()
+
+	// Otherwise a recovered halt outlasts the schedule
+	engine = open(nil)
+	setPhase(t, engine, matching.PhaseHalted)
+	engine.Close()
+	engine = open(&matching.Schedule{Phases: []matching.ScheduledPhase{{Phase: matching.PhaseContinuous}}})
+	defer engine.Close()
+	checkPhase(t, engine, matching.PhaseHalted)
+	if phase, _ := engine.GetPhase("ACME"); phase != matching.PhaseContinuous {
+		t.Errorf("ACME phase %v, want continuous", phase)
+	}
+}
+
+// TestPhaseRecovered tests that phases survive a snapshot and journal replay
+func TestPhaseRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	setPhase(t, engine, matching.PhasePreOpen)
+	placeLimit(engine, matching.Sell, 10000, 10)
+	placeLimit(engine, matching.Buy, 10000, 4)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	setPhase(t, engine, matching.PhaseOpeningAuction)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	checkPhase(t, recovered, matching.PhaseOpeningAuction)
+	if trades := setPhase(t, recovered, matching.PhaseContinuous); len(trades) != 1 {
+		t.Fatalf("Opening uncross after recovery produced %d trades, want 1", len(trades))
+	}
+	recovered.Close()
+
+	replayed := newSnapshottingEngine(t, dir)
+	defer replayed.Close()
+	checkPhase(t, replayed, matching.PhaseContinuous)
+	if replayed.InAuction(matching.DefaultSymbol) {
+		t.Error("Auction still running after replaying the open")
+	}
+}
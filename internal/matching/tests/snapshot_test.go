@@ -0,0 +1,142 @@
+package matching
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+func newSnapshottingEngine(t *testing.T, dir string) *matching.Engine {
+	t.Helper()
+	return newTestEngine(t, dir, func(cfg *matching.EngineConfig) {
+		cfg.SnapshotDir = filepath.Join(dir, "snapshots")
+		cfg.SnapshotRetain = 2
+	})
+}
+
+type engineState struct {
+	book   matching.BookSnapshot
+	orders []matching.Order
+	trades int
+}
+
+func captureEngineState(engine *matching.Engine) engineState {
+	book, _ := engine.GetBookSnapshot(matching.DefaultSymbol, 0)
+	state := engineState{book: book, trades: len(engine.GetRecentTrades(100))}
+	for _, order := range sortedOrders(engine) {
+		order.TimeStamp = order.TimeStamp.UTC().Round(0)
+		state.orders = append(state.orders, *order)
+	}
+	return state
+}
+
+func placeLimit(engine *matching.Engine, side matching.SideType, price matching.Price, size int) uint64 {
+	order := matching.NewOrder(engine.GenerateOrderID(), "user1", matching.LimitOrder, side, price, size)
+	engine.PlaceOrder(order)
+	return order.ID
+}
+
+// TestSnapshotRecoveryReplaysTail tests recovery from a snapshot plus the journal entries after it
+func TestSnapshotRecoveryReplaysTail(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+
+	placeLimit(engine, matching.Sell, 10100, 10)
+	placeLimit(engine, matching.Sell, 10100, 5)
+	placeLimit(engine, matching.Buy, 10100, 3) // Trades before the snapshot
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	cancelled := placeLimit(engine, matching.Buy, 9900, 4)
+	placeLimit(engine, matching.Buy, 10100, 9) // Trades after the snapshot
+	engine.CancelOrder(cancelled)
+
+	want := captureEngineState(engine)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+
+	if got := captureEngineState(recovered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered state %+v, want %+v", got, want)
+	}
+}
+
+// TestSnapshotCorruptFallsBack tests that a truncated snapshot is skipped for an older one
+func TestSnapshotCorruptFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+
+	placeLimit(engine, matching.Sell, 10100, 10)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	placeLimit(engine, matching.Buy, 10100, 4)
+	newest, err := engine.WriteSnapshot()
+	if err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	placeLimit(engine, matching.Buy, 9900, 2)
+
+	want := captureEngineState(engine)
+	engine.Close()
+
+	// Cut the newest snapshot short
+	data, _ := os.ReadFile(newest)
+	if err := os.WriteFile(newest, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+
+	if got := captureEngineState(recovered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered state %+v, want %+v", got, want)
+	}
+}
+
+// TestSnapshotRetention tests that only the newest snapshots are kept
+func TestSnapshotRetention(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	defer engine.Close()
+
+	for i := 0; i < 4; i++ {
+		placeLimit(engine, matching.Buy, 9900, 1)
+		if _, err := engine.WriteSnapshot(); err != nil {
+			t.Fatalf("WriteSnapshot() error = %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "snapshots", "*.snap"))
+	if len(files) != 2 {
+		t.Errorf("Expected 2 retained snapshots, got %v", files)
+	}
+}
+
+// TestSnapshotAheadOfJournal tests that recovery refuses a journal shorter than the snapshot
+func TestSnapshotAheadOfJournal(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	placeLimit(engine, matching.Buy, 9900, 1)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	engine.Close()
+
+	os.Remove(filepath.Join(dir, "journal.log"))
+
+	_, err := matching.OpenEngine(&matching.EngineConfig{
+		TradeHistorySize: 100,
+		TradeLogPath:     filepath.Join(dir, "trades.log"),
+		JournalPath:      filepath.Join(dir, "journal.log"),
+		SnapshotDir:      filepath.Join(dir, "snapshots"),
+	})
+	if err == nil {
+		t.Error("Expected an error when the journal ends before the snapshot")
+	}
+}
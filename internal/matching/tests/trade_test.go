@@ -0,0 +1,143 @@
+package matching
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// tradeParties is what identifies a trade for reconciliation
+type tradeParties struct {
+	ID           uint64
+	Aggressor    matching.SideType
+	Maker, Taker uint64
+	MakerUser    string
+	TakerUser    string
+}
+
+func partiesOf(trades []*matching.Trade) []tradeParties {
+	parties := make([]tradeParties, len(trades))
+	for i, trade := range trades {
+		parties[i] = tradeParties{
+			trade.ID, trade.AggressorSide, trade.MakerOrderID, trade.TakerOrderID, trade.MakerUserID, trade.TakerUserID,
+		}
+	}
+	return parties
+}
+
+// TestTradeParties tests that trades carry increasing IDs, the aggressor side and the resting
+// order as maker
+func TestTradeParties(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	ask := placeLimit(engine, matching.Sell, 10000, 5)
+	bid := placeLimit(engine, matching.Buy, 9900, 5)
+	buy := newUserLimit(engine, "user2", matching.Buy, 10000, 3)
+	sell := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.MarketOrder, matching.Sell, 0, 2)
+
+	var trades []*matching.Trade
+	for _, order := range []*matching.Order{buy, sell} {
+		trades = append(trades, engine.PlaceOrder(order)...)
+	}
+	want := []tradeParties{
+		{1, matching.Buy, ask, buy.ID, "user1", "user2"},
+		{2, matching.Sell, bid, sell.ID, "user1", "user3"},
+	}
+	if got := partiesOf(trades); !reflect.DeepEqual(got, want) {
+		t.Errorf("Trades %+v, want %+v", got, want)
+	}
+	if trades[0].BuyOrderID != buy.ID || trades[0].SellOrderID != ask || trades[0].Symbol != matching.DefaultSymbol {
+		t.Errorf("Trade %+v, want buy %d against sell %d", trades[0], buy.ID, ask)
+	}
+}
+
+// TestAuctionTradeParties tests that an uncross trade has no aggressor and treats the later order
+// as taker
+func TestAuctionTradeParties(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	startAuction(t, engine)
+
+	ask := placeLimit(engine, matching.Sell, 10000, 5)
+	bid := newUserLimit(engine, "user2", matching.Buy, 10100, 5)
+	engine.PlaceOrder(bid)
+	_, trades, err := engine.Uncross(matching.DefaultSymbol)
+	if err != nil {
+		t.Fatalf("Uncross() error = %v", err)
+	}
+	want := []tradeParties{{1, matching.NoActionSide, ask, bid.ID, "user1", "user2"}}
+	if got := partiesOf(trades); !reflect.DeepEqual(got, want) {
+		t.Errorf("Trades %+v, want %+v", got, want)
+	}
+}
+
+// TestTradeIDsRecovered tests that trade IDs survive a snapshot and journal replay and carry on
+// from the last one issued
+func TestTradeIDsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	trade(t, engine, 10000)
+	trade(t, engine, 10100)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	trade(t, engine, 10200)
+
+	want := partiesOf(engine.GetRecentTrades(0))
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+	if got := partiesOf(recovered.GetRecentTrades(0)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered trades %+v, want %+v", got, want)
+	}
+	placeLimit(recovered, matching.Sell, 10300, 1)
+	trades := recovered.PlaceOrder(newUserLimit(recovered, "user2", matching.Buy, 10300, 1))
+	if len(trades) != 1 || trades[0].ID != 4 {
+		t.Errorf("Trade after recovery %+v, want ID 4", trades)
+	}
+}
+
+// TestTradeLogRecord tests the keys of a trade in the persisted log
+func TestTradeLogRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trades.log")
+	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
+		TradeHistorySize: 10,
+		TradeLogPath:     path,
+		Symbols:          []string{matching.DefaultSymbol},
+	})
+	trade(t, engine, 10000)
+	engine.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("Trade log is empty")
+	}
+	var record map[string]any
+	if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for key, want := range map[string]any{
+		"trade_id":       1.0,
+		"symbol":         matching.DefaultSymbol,
+		"price":          10000.0,
+		"quantity":       1.0,
+		"aggressor_side": float64(matching.Buy),
+		"maker_user_id":  "user1",
+		"taker_user_id":  "user2",
+	} {
+		if record[key] != want {
+			t.Errorf("Trade log %s = %v, want %v", key, record[key], want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// newTrailingStop builds a user3 trailing stop-market order for size
+func newTrailingStop(engine *matching.Engine, side matching.SideType, size int) *matching.Order {
+	return matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopMarketOrder, side, 0, size)
+}
+
+func checkStopPrice(t *testing.T, engine *matching.Engine, id uint64, want matching.Price) {
+	t.Helper()
+	if order := engine.GetOrder(id); order == nil || order.StopPrice != want {
+		t.Errorf("Trailing stop %d is %+v, want stop price %d", id, order, want)
+	}
+}
+
+// TestTrailingStopFollowsMarket tests that a sell stop's trigger rises with the market, holds as
+// it falls, and fires once the market falls back to it
+func TestTrailingStopFollowsMarket(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	placeLimit(engine, matching.Buy, 9000, 5)
+	trade(t, engine, 10000)
+
+	stop := newTrailingStop(engine, matching.Sell, 2)
+	stop.TrailAmount = 100
+	if _, err := engine.SubmitOrder(stop); err != nil {
+		t.Fatalf("SubmitOrder() error = %v", err)
+	}
+	checkStopPrice(t, engine, stop.ID, 9900)
+
+	trade(t, engine, 10200)
+	checkStopPrice(t, engine, stop.ID, 10100)
+	trade(t, engine, 10150)
+	checkStopPrice(t, engine, stop.ID, 10100)
+	checkOrderStatus(t, engine, stop.ID, matching.StatusNew, 0)
+
+	// The trade that reaches the trigger fires the stop into the bid
+	placeLimit(engine, matching.Sell, 10100, 1)
+	if trades := engine.PlaceOrder(newUserLimit(engine, "user2", matching.Buy, 10100, 1)); len(trades) != 2 {
+		t.Fatalf("Trade at 10100 produced %d trades, want 2", len(trades))
+	}
+	checkOrderStatus(t, engine, stop.ID, matching.StatusFilled, 2)
+}
+
+// TestTrailingStopBps tests a buy stop trailing in basis points, rounded to the tick away from the
+// market
+func TestTrailingStopBps(t *testing.T) {
+	inst, _ := matching.NewInstrument(matching.DefaultSymbol, 2, "0.05")
+	engine := matching.NewEngineWithConfig(&matching.EngineConfig{
+		TradeHistorySize: 10,
+		TradeLogPath:     t.TempDir() + "/trades.log",
+		Instruments:      []matching.Instrument{inst},
+	})
+	defer engine.Close()
+	trade(t, engine, 10000)
+
+	stop := newTrailingStop(engine, matching.Buy, 1)
+	stop.TrailBps = 125
+	engine.PlaceOrder(stop)
+	checkStopPrice(t, engine, stop.ID, 10125)
+
+	trade(t, engine, 9800)
+	checkStopPrice(t, engine, stop.ID, 9925)
+	trade(t, engine, 9900)
+	checkStopPrice(t, engine, stop.ID, 9925)
+}
+
+// TestTrailingStopLimit tests that a trailing stop-limit's limit price keeps its distance from the
+// trigger
+func TestTrailingStopLimit(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	trade(t, engine, 10000)
+
+	stop := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopLimitOrder, matching.Sell, 9880, 1)
+	stop.StopPrice = 9900
+	stop.TrailAmount = 150
+	engine.PlaceOrder(stop)
+	checkStopPrice(t, engine, stop.ID, 9900)
+
+	trade(t, engine, 10100)
+	checkStopPrice(t, engine, stop.ID, 9950)
+	if order := engine.GetOrder(stop.ID); order.Price != 9930 {
+		t.Errorf("Limit price is %d, want 9930", order.Price)
+	}
+}
+
+// TestTrailingStopOrder tests that a moved trigger joins the back of the stops at its new price
+func TestTrailingStopOrder(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+	trade(t, engine, 10000)
+
+	fixed := matching.NewOrder(engine.GenerateOrderID(), "user3", matching.StopMarketOrder, matching.Sell, 0, 1)
+	fixed.StopPrice = 9950
+	engine.PlaceOrder(fixed)
+	stop := newTrailingStop(engine, matching.Sell, 1)
+	stop.TrailAmount = 150
+	engine.PlaceOrder(stop)
+
+	trade(t, engine, 10100)
+	checkStopPrice(t, engine, stop.ID, 9950)
+	var got []uint64
+	for _, order := range engine.GetTriggerBookForSymbol(matching.DefaultSymbol).GetSellStops() {
+		got = append(got, order.ID)
+	}
+	if want := []uint64{fixed.ID, stop.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sell stops %v, want %v", got, want)
+	}
+}
+
+// TestTrailingStopReference tests that a trailing stop without a stop price needs a trade to trail
+func TestTrailingStopReference(t *testing.T) {
+	engine := newRetainingEngine(t, time.Hour)
+
+	stop := newTrailingStop(engine, matching.Sell, 1)
+	stop.TrailAmount = 100
+	_, err := engine.SubmitOrder(stop)
+	checkRejected(t, err, matching.RejectTrailReference)
+	checkOrderStatus(t, engine, stop.ID, matching.StatusRejected, 0)
+}
+
+// TestTrailingStopsRecovered tests that moved triggers survive a snapshot and journal replay
+func TestTrailingStopsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	engine := newSnapshottingEngine(t, dir)
+	trade(t, engine, 10000)
+	early := newTrailingStop(engine, matching.Sell, 1)
+	early.TrailAmount = 100
+	engine.PlaceOrder(early)
+	trade(t, engine, 10100)
+	if _, err := engine.WriteSnapshot(); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	late := newTrailingStop(engine, matching.Buy, 1)
+	late.TrailBps = 100
+	engine.PlaceOrder(late)
+	trade(t, engine, 10150)
+	trade(t, engine, 10080)
+
+	want := captureEngineState(engine)
+	engine.Close()
+
+	recovered := newSnapshottingEngine(t, dir)
+	defer recovered.Close()
+	if got := captureEngineState(recovered); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recovered state %+v, want %+v", got, want)
+	}
+	checkStopPrice(t, recovered, early.ID, 10050)
+	checkStopPrice(t, recovered, late.ID, 10180)
+}
+
+// TestTrailingStopValidation tests that only stop orders may trail, by a single distance
+func TestTrailingStopValidation(t *testing.T) {
+	limit := matching.NewOrder(1, "user1", matching.LimitOrder, matching.Buy, 10000, 1)
+	limit.TrailAmount = 10
+
+	both := matching.NewOrder(2, "user1", matching.StopMarketOrder, matching.Sell, 0, 1)
+	both.TrailAmount = 10
+	both.TrailBps = 50
+
+	stopLimit := matching.NewOrder(3, "user1", matching.StopLimitOrder, matching.Sell, 9900, 1)
+	stopLimit.TrailAmount = 10
+
+	for _, order := range []*matching.Order{limit, both, stopLimit} {
+		if order.IsValid() {
+			t.Errorf("Order %d should be invalid", order.ID)
+		}
+	}
+
+	stop := matching.NewOrder(4, "user1", matching.StopMarketOrder, matching.Sell, 0, 1)
+	stop.TrailBps = 50
+	if !stop.IsValid() {
+		t.Error("Trailing stop-market without a stop price should be valid")
+	}
+}
@@ -0,0 +1,91 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/PxPatel/trading-system/internal/matching"
+)
+
+// newStopOrder creates a stop market order with the given stop price
+func newStopOrder(id uint64, side matching.SideType, stopPrice matching.Price) *matching.Order {
+	order := matching.NewOrder(id, "user_test", matching.StopMarketOrder, side, 0, 10)
+	order.StopPrice = stopPrice
+	return order
+}
+
+// TestIsStopTriggered tests trigger conditions for both sides
+func TestIsStopTriggered(t *testing.T) {
+	tests := []struct {
+		name      string
+		side      matching.SideType
+		stopPrice matching.Price
+		lastPrice matching.Price
+		want      bool
+	}{
+		{"BuyBelowStop", matching.Buy, 105, 104, false},
+		{"BuyAtStop", matching.Buy, 105, 105, true},
+		{"BuyThroughStop", matching.Buy, 105, 106, true},
+		{"SellAboveStop", matching.Sell, 95, 96, false},
+		{"SellAtStop", matching.Sell, 95, 95, true},
+		{"SellThroughStop", matching.Sell, 95, 94, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newStopOrder(1, tt.side, tt.stopPrice)
+			if got := matching.IsStopTriggered(order, tt.lastPrice); got != tt.want {
+				t.Errorf("IsStopTriggered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTriggerBookPopOrder tests that triggered stops come out closest-first, then by arrival
+func TestTriggerBookPopOrder(t *testing.T) {
+	tb := matching.NewTriggerBook()
+
+	tb.AddStopOrder(newStopOrder(1, matching.Buy, 103))
+	tb.AddStopOrder(newStopOrder(2, matching.Buy, 101))
+	tb.AddStopOrder(newStopOrder(3, matching.Buy, 101))
+	tb.AddStopOrder(newStopOrder(4, matching.Buy, 110))
+	tb.AddStopOrder(newStopOrder(5, matching.Sell, 90))
+
+	if tb.Len() != 5 {
+		t.Fatalf("Expected 5 stops, got %d", tb.Len())
+	}
+
+	triggered := tb.PopTriggered(105)
+	expected := []uint64{2, 3, 1}
+	if len(triggered) != len(expected) {
+		t.Fatalf("Expected %d triggered stops, got %d", len(expected), len(triggered))
+	}
+	for i, id := range expected {
+		if triggered[i].ID != id {
+			t.Errorf("Position %d: expected order %d, got %d", i, id, triggered[i].ID)
+		}
+	}
+
+	if tb.Len() != 2 {
+		t.Errorf("Expected 2 stops remaining, got %d", tb.Len())
+	}
+}
+
+// TestTriggerBookDelete tests removing resting stops
+func TestTriggerBookDelete(t *testing.T) {
+	tb := matching.NewTriggerBook()
+	tb.AddStopOrder(newStopOrder(1, matching.Buy, 105))
+	tb.AddStopOrder(newStopOrder(2, matching.Sell, 95))
+
+	if !tb.DeleteStopOrder(2) {
+		t.Error("Delete of existing sell stop should succeed")
+	}
+	if tb.DeleteStopOrder(2) {
+		t.Error("Second delete should fail")
+	}
+	if tb.SearchById(1) == nil {
+		t.Error("Buy stop should still be found")
+	}
+	if len(tb.GetSellStops()) != 0 {
+		t.Error("Sell stops should be empty")
+	}
+}
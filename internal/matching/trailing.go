@@ -0,0 +1,87 @@
+package matching
+
+/*
+A trailing stop is a stop order whose stop price follows the market at a fixed distance, either
+TrailAmount or TrailBps basis points of the price it trails. A sell stop trails below the market
+and a buy stop above it, and the trigger only ever moves towards the market: a sell stop's rises
+as prices rise and holds as they fall, a buy stop's falls as prices fall and holds as they rise.
+Once the last trade price reaches the trigger the stop fires as its market or limit order, like
+any other stop.
+
+A trailing stop-market may arrive without a stop price; its trigger then starts at its distance
+from the last trade price, and it is rejected with RejectTrailReference before the symbol has
+traded. A trailing stop-limit needs a stop price, and its limit price keeps the distance it was
+entered at from the trigger as the trigger moves.
+
+Triggers move after every command's trades, from the highest trade for sell stops and the
+lowest for buy stops, before those trades are checked against the triggers. A trigger in basis
+points is rounded to the tick away from the market. A stop whose trigger moves joins the back of
+the stops at its new stop price, as an amended stop does. Trailing follows deterministically from
+each command's trades, so it is not journaled.
+*/
+
+// trailingStopPrice returns the stop price a trailing stop would have at a trade price, or false
+// if that leaves no valid price
+func (e *Engine) trailingStopPrice(order *Order, price Price) (Price, bool) {
+	inst, _ := e.GetInstrument(order.Symbol)
+	distance := order.TrailAmount
+	if order.TrailBps > 0 {
+		distance = price * Price(order.TrailBps) / 10000
+	}
+
+	if order.Side == Buy {
+		stopPrice := price + distance
+		if remainder := stopPrice % inst.TickSize; remainder != 0 {
+			stopPrice += inst.TickSize - remainder
+		}
+		return stopPrice, true
+	}
+	stopPrice := price - distance
+	stopPrice -= stopPrice % inst.TickSize
+	return stopPrice, stopPrice > 0
+}
+
+// trailStops moves the triggers of a symbol's trailing stops after trades
+func (e *Engine) trailStops(triggers *TriggerBook, trades []*Trade) {
+	high, low := trades[0].Price, trades[0].Price
+	for _, trade := range trades[1:] {
+		high, low = max(high, trade.Price), min(low, trade.Price)
+	}
+
+	type move struct {
+		stop      *Order
+		stopPrice Price
+	}
+	var moves []move
+	for _, stop := range triggers.GetBuyStops() {
+		if !stop.IsTrailing() {
+			continue
+		}
+		if stopPrice, _ := e.trailingStopPrice(stop, low); stopPrice < stop.StopPrice {
+			moves = append(moves, move{stop, stopPrice})
+		}
+	}
+	for _, stop := range triggers.GetSellStops() {
+		if !stop.IsTrailing() {
+			continue
+		}
+		if stopPrice, ok := e.trailingStopPrice(stop, high); ok && stopPrice > stop.StopPrice {
+			moves = append(moves, move{stop, stopPrice})
+		}
+	}
+
+	for _, m := range moves {
+		// A stop-limit's limit price moves with its trigger, unless that would leave no price
+		if m.stop.OrderType == StopLimitOrder {
+			price := m.stop.Price + m.stopPrice - m.stop.StopPrice
+			if price <= 0 {
+				continue
+			}
+			m.stop.Price = price
+		}
+		triggers.DeleteStopOrder(m.stop.ID)
+		m.stop.StopPrice = m.stopPrice
+		triggers.AddStopOrder(m.stop)
+		e.markOrder(m.stop)
+	}
+}
@@ -0,0 +1,133 @@
+package matching
+
+import "sort"
+
+/*
+Stop orders do not belong in the OrderBook: they are invisible to the market and never match
+until the last trade price reaches their stop price. Each symbol keeps a TriggerBook beside its
+OrderBook.
+
+Buy stops trigger when the price rises to or through StopPrice, so they are kept sorted by
+StopPrice ascending. Sell stops trigger when the price falls to or through StopPrice, so they are
+kept sorted descending. Either way the stops closest to the market sit at the front, and equal
+stop prices keep arrival order.
+*/
+
+type TriggerBook struct {
+	buyStops  []*Order // Ascending stop price, then arrival
+	sellStops []*Order // Descending stop price, then arrival
+}
+
+func NewTriggerBook() *TriggerBook {
+	return &TriggerBook{
+		buyStops:  make([]*Order, 0),
+		sellStops: make([]*Order, 0),
+	}
+}
+
+// IsStopTriggered reports whether a stop order fires at the given last trade price
+func IsStopTriggered(order *Order, lastPrice Price) bool {
+	if order.Side == Buy {
+		return lastPrice >= order.StopPrice
+	}
+	return lastPrice <= order.StopPrice
+}
+
+// AddStopOrder inserts a stop order behind any stops with the same stop price
+func (tb *TriggerBook) AddStopOrder(order *Order) bool {
+	if order.Side == Buy {
+		i := sort.Search(len(tb.buyStops), func(i int) bool {
+			return tb.buyStops[i].StopPrice > order.StopPrice
+		})
+		tb.buyStops = insertOrderAt(tb.buyStops, i, order)
+		return true
+	}
+
+	if order.Side == Sell {
+		i := sort.Search(len(tb.sellStops), func(i int) bool {
+			return tb.sellStops[i].StopPrice < order.StopPrice
+		})
+		tb.sellStops = insertOrderAt(tb.sellStops, i, order)
+		return true
+	}
+
+	return false
+}
+
+func insertOrderAt(orders []*Order, index int, order *Order) []*Order {
+	orders = append(orders, nil)
+	copy(orders[index+1:], orders[index:])
+	orders[index] = order
+	return orders
+}
+
+// DeleteStopOrder removes a stop order by ID
+func (tb *TriggerBook) DeleteStopOrder(orderId uint64) bool {
+	var ok bool
+	if tb.buyStops, ok = removeOrderById(tb.buyStops, orderId); ok {
+		return true
+	}
+	tb.sellStops, ok = removeOrderById(tb.sellStops, orderId)
+	return ok
+}
+
+func removeOrderById(orders []*Order, orderId uint64) ([]*Order, bool) {
+	for i, order := range orders {
+		if order.ID == orderId {
+			return append(orders[:i], orders[i+1:]...), true
+		}
+	}
+	return orders, false
+}
+
+// SearchById finds a resting stop order by ID
+func (tb *TriggerBook) SearchById(orderId uint64) *Order {
+	for _, order := range tb.buyStops {
+		if order.ID == orderId {
+			return order
+		}
+	}
+	for _, order := range tb.sellStops {
+		if order.ID == orderId {
+			return order
+		}
+	}
+	return nil
+}
+
+// PopTriggered removes and returns every stop that fires at the last trade price.
+// Buy stops come first, each side in the order the price would have reached them.
+func (tb *TriggerBook) PopTriggered(lastPrice Price) []*Order {
+	var triggered []*Order
+
+	n := 0
+	for n < len(tb.buyStops) && IsStopTriggered(tb.buyStops[n], lastPrice) {
+		n++
+	}
+	triggered = append(triggered, tb.buyStops[:n]...)
+	tb.buyStops = tb.buyStops[n:]
+
+	n = 0
+	for n < len(tb.sellStops) && IsStopTriggered(tb.sellStops[n], lastPrice) {
+		n++
+	}
+	triggered = append(triggered, tb.sellStops[:n]...)
+	tb.sellStops = tb.sellStops[n:]
+
+	return triggered
+}
+
+// GetBuyStops returns resting buy stops, closest to the market first
+func (tb *TriggerBook) GetBuyStops() []*Order {
+	return tb.buyStops
+}
+
+// GetSellStops returns resting sell stops, closest to the market first
+func (tb *TriggerBook) GetSellStops() []*Order {
+	return tb.sellStops
+}
+
+// Len returns the number of resting stop orders
+func (tb *TriggerBook) Len() int {
+	return len(tb.buyStops) + len(tb.sellStops)
+}